@@ -1,12 +1,12 @@
 package controllers
 
 import (
-	"context"
 	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	svc "cloudminds.com/harix/cc-server/services"
@@ -14,16 +14,14 @@ import (
 
 // GetManyAdmins as name suggests
 func (s *CCServer) GetManyAdmins(c *gin.Context) {
-	cursor, err := svc.GetManyAdmins()
+	cursor, err := s.Stores.Admins.GetManyAdmins(c.Request.Context())
 	if err != nil {
 		log.Printf("Error while getting all admins - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	admins := []svc.Admin{}
-	if err = cursor.All(context.TODO(), &admins); err != nil {
+	if err = cursor.All(c.Request.Context(), &admins); err != nil {
 		panic(err)
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -36,17 +34,14 @@ func (s *CCServer) GetManyAdmins(c *gin.Context) {
 // GetManyAdminsByInstID as name suggests
 func (s *CCServer) GetManyAdminsByInstID(c *gin.Context) {
 	instID := c.DefaultQuery("instID", "000000000000000000000000")
-	cursor, err := svc.GetManyAdminsByInstID(instID)
+	cursor, err := s.Stores.Admins.GetManyAdminsByInstID(c.Request.Context(), instID)
 	if err != nil {
 		log.Printf("Error while getting all admins under the institution - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	admins := []svc.Admin{}
-	if err = cursor.All(context.TODO(), &admins); err != nil {
+	if err = cursor.All(c.Request.Context(), &admins); err != nil {
 		panic(err)
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -61,7 +56,7 @@ func (s *CCServer) GetAdminByFrasUsername(c *gin.Context) {
 	var queryParams svc.GetAdminParams
 	queryParams.FrasUsername = c.DefaultQuery("frasUsername", "000000000000000000000000")
 	admin := svc.Admin{}
-	err := svc.GetAdminByFrasUsername(queryParams.FrasUsername).Decode(&admin)
+	err := s.Stores.Admins.GetAdminByFrasUsername(c.Request.Context(), queryParams.FrasUsername).Decode(&admin)
 	if err != nil {
 		// When no Doc found, create a new Admin in DB
 		if err == mongo.ErrNoDocuments {
@@ -72,10 +67,7 @@ func (s *CCServer) GetAdminByFrasUsername(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while Getting Admin by Fras Username - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -90,12 +82,12 @@ func (s *CCServer) GetAdminByFrasUsername(c *gin.Context) {
 func (s *CCServer) AdminLogin(c *gin.Context) {
 	var aLoginForm svc.AdminLoginForm
 	c.BindJSON(&aLoginForm)
-	log.Printf("Login Info - %v\n", aLoginForm)
+	log.Printf("Login Info - %v\n", aLoginForm.FrasUsername)
 
 	// Get Admin
 	adminToLogin := svc.Admin{}
 	aLoginForm.FrasUsername = strings.ToLower(strings.TrimSpace(aLoginForm.FrasUsername))
-	err := svc.GetAdminByFrasUsername(aLoginForm.FrasUsername).Decode(&adminToLogin)
+	err := s.Stores.Admins.GetAdminByFrasUsername(c.Request.Context(), aLoginForm.FrasUsername).Decode(&adminToLogin)
 	if err != nil {
 		// When no Doc found, create a new Admin in DB
 		if err == mongo.ErrNoDocuments {
@@ -106,30 +98,46 @@ func (s *CCServer) AdminLogin(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while getting Admin By Fras Username from DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
 	// Compare Password
-	if s.Config.RequireAdminPswd && (aLoginForm.Password != adminToLogin.Password) {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Wrong Password, Login Failed",
-			"success": false,
-		})
-		return
+	if s.Config.RequireAdminPswd {
+		match, needsRehash := svc.VerifyAdminPassword(adminToLogin, aLoginForm.Password)
+		if !match {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Wrong Password, Login Failed",
+				"success": false,
+			})
+			return
+		}
+		// Migrate plaintext (or outdated) Password to a fresh hash
+		if needsRehash {
+			if _, err := s.Stores.Admins.UpdateAdminPassword(c.Request.Context(), adminToLogin.ID.Hex(), aLoginForm.Password); err != nil {
+				log.Printf("Error while rehashing Admin Password - %v\n", err)
+			}
+		}
 	}
 
 	// Update Admin
 	admin := svc.Admin{}
-	err = svc.UpdateAdminLoginTime(adminToLogin.ID.Hex(), &admin)
+	err = s.Stores.Admins.UpdateAdminLoginTime(c.Request.Context(), adminToLogin.ID.Hex(), &admin)
 	if err != nil {
 		log.Printf("Error while loggin in admin - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
+		return
+	}
+
+	// Issue Session Token
+	token, err := s.SignSessionToken(SessionClaims{
+		Subject: admin.ID.Hex(),
+		Role:    SessionRole(admin.GetRole()),
+		InstID:  admin.InstID,
+	})
+	if err != nil {
+		log.Printf("Error while signing session token for admin - %v\n", err)
+		respondServerError(c, err)
 		return
 	}
 
@@ -137,7 +145,7 @@ func (s *CCServer) AdminLogin(c *gin.Context) {
 		"message": "Login Succeed",
 		"success": true,
 		"data":    admin,
-		"token":   s.Config.DebugTokenL.Admin,
+		"token":   token,
 	})
 	return
 }
@@ -146,10 +154,16 @@ func (s *CCServer) AdminLogin(c *gin.Context) {
 func (s *CCServer) RegisterAdmin(c *gin.Context) {
 	var adminForm svc.AdminRegForm
 	c.BindJSON(&adminForm)
+	if len(adminForm.Role) > 0 && !svc.IsValidAdminRole(adminForm.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid Admin Role",
+		})
+		return
+	}
 
 	// Check if institution exists
 	inst := svc.Institution{}
-	err := svc.GetInstByID(adminForm.InstID).Decode(&inst)
+	err := s.Stores.Insts.GetInstByID(c.Request.Context(), adminForm.InstID).Decode(&inst)
 
 	if err != nil {
 		// When no institution found, return failed
@@ -160,34 +174,28 @@ func (s *CCServer) RegisterAdmin(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while finding institution - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
-	// Check if admin with same FRAS username exists (by validator)
-	count, err := svc.CountAdminByFrasUsername(adminForm.FrasUsername)
-	if err != nil {
-		log.Printf("Error while counting Admin by FrasUsername - %v\n", err)
-	}
-	if count > 0 {
+	// Create Admin in DB; the unique index on FRAS usernames rejects one that has been used
+	adminForm.FrasUsername = strings.ToLower(strings.TrimSpace(adminForm.FrasUsername))
+	res, err := s.Stores.Admins.CreateAdmin(c.Request.Context(), adminForm)
+	if svc.IsDuplicateKeyErr(err) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"message": "Username has been used! Try another one",
 		})
 		return
 	}
-
-	// Create Admin in DB
-	adminForm.FrasUsername = strings.ToLower(strings.TrimSpace(adminForm.FrasUsername))
-	_, err = svc.CreateAdmin(adminForm)
 	if err != nil {
 		log.Printf("Error while inserting new Admin into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	adminID := res.InsertedID.(primitive.ObjectID).Hex()
+	after := svc.Admin{}
+	s.Stores.Admins.GetAdminByID(c.Request.Context(), adminID).Decode(&after)
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetAdmin, adminID, after.InstID, nil, after)
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Admin registered Successfully",
 	})
@@ -198,15 +206,27 @@ func (s *CCServer) RegisterAdmin(c *gin.Context) {
 func (s *CCServer) UpdateAdminByID(c *gin.Context) {
 	var adminForm svc.AdminEditForm
 	c.BindJSON(&adminForm)
+	if len(adminForm.Role) > 0 && !svc.IsValidAdminRole(adminForm.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid Admin Role",
+		})
+		return
+	}
 
 	adminForm.FrasUsername = strings.ToLower(strings.TrimSpace(adminForm.FrasUsername))
 	idToUpdate := c.Param("id")
-	res, err := svc.UpdateAdminByID(adminForm, idToUpdate)
+	before := svc.Admin{}
+	s.Stores.Admins.GetAdminByID(c.Request.Context(), idToUpdate).Decode(&before)
+	res, err := s.Stores.Admins.UpdateAdminByID(c.Request.Context(), adminForm, idToUpdate)
+	if svc.IsDuplicateKeyErr(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Username has been used! Try another one",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error while updating Admin in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	if res.ModifiedCount == 0 {
@@ -215,6 +235,9 @@ func (s *CCServer) UpdateAdminByID(c *gin.Context) {
 		})
 		return
 	}
+	after := svc.Admin{}
+	s.Stores.Admins.GetAdminByID(c.Request.Context(), idToUpdate).Decode(&after)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetAdmin, idToUpdate, after.InstID, before, after)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Admin updated Successfully",
 	})
@@ -226,12 +249,12 @@ func (s *CCServer) DeleteAdminByID(c *gin.Context) {
 	// TODO: Set CC Records to "expired"
 	idToDelete := c.Param("id")
 
-	res, err := svc.DeleteAdminByID(idToDelete)
+	before := svc.Admin{}
+	s.Stores.Admins.GetAdminByID(c.Request.Context(), idToDelete).Decode(&before)
+	res, err := s.Stores.Admins.DeleteAdminByID(c.Request.Context(), idToDelete)
 	if err != nil {
 		log.Printf("Error while deleting Admin from DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -241,6 +264,7 @@ func (s *CCServer) DeleteAdminByID(c *gin.Context) {
 		})
 		return
 	}
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetAdmin, idToDelete, before.InstID, before, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Admin Deleted Successfully",
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetManyAuditEntries - query the Audit Log; Admins only see entries of their own Institution
+func (s *CCServer) GetManyAuditEntries(c *gin.Context) {
+	params := svc.GetAuditParams{
+		InstID:     c.Query("instID"),
+		ActorID:    c.Query("actorID"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		TargetID:   c.Query("targetID"),
+	}
+	for key, t := range map[string]*time.Time{"startDate": &params.StartDate, "endDate": &params.EndDate} {
+		param, ok := c.GetQuery(key)
+		if !ok {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Bad Audit Query Parameters",
+			})
+			return
+		}
+		*t = parsed
+	}
+	if claims := getSessionClaims(c); claims != nil && claims.Role != SessionRoleSuperAdmin {
+		params.InstID = claims.InstID
+	}
+
+	cursor, err := s.Stores.Audits.GetManyAuditEntries(c.Request.Context(), &params)
+	if err != nil {
+		log.Printf("Error while getting Audit Entries - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	entries := []svc.AuditEntry{}
+	if err = cursor.All(c.Request.Context(), &entries); err != nil {
+		log.Printf("Error while decoding Audit Entries - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All Audit Entries",
+		"data":    entries,
+	})
+	return
+}
+
+// recordAudit - append a mutation to the Audit Log; pass nil "before" for creations, and nil "after" for deletions.
+// Failing to record is logged only, since the mutation has already been made
+func (s *CCServer) recordAudit(c *gin.Context, action svc.AuditAction, targetType svc.AuditTargetType,
+	targetID string, instID string, before interface{}, after interface{}) {
+	changes, err := svc.DiffAuditFields(before, after)
+	if err != nil {
+		log.Printf("Error while diffing %v %v for Audit - %v\n", targetType, targetID, err)
+	}
+
+	entry := svc.AuditEntry{
+		InstID:     instID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+		ClientIP:   c.ClientIP(),
+	}
+	if claims := getSessionClaims(c); claims != nil {
+		entry.ActorID = claims.Subject
+		entry.ActorRole = string(claims.Role)
+	}
+	// The mutation is done by now, so its entry is recorded even if the client has hung up
+	ctx, cancel := s.backgroundDBContext()
+	defer cancel()
+	if _, err := s.Stores.Audits.CreateAuditEntry(ctx, entry); err != nil {
+		log.Printf("Error while recording Audit Entry - %v\n", err)
+	}
+}
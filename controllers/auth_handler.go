@@ -1,15 +1,25 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 	"strings"
 
+	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// TokenAuthKey - gin context key holding the verified *SessionClaims
 var TokenAuthKey = "TokenAuth"
 
-func (s *CCServer) tokenAuth(expectedToken string) gin.HandlerFunc {
+// DeviceAuthKey - gin context key holding the verified *svc.Device
+var DeviceAuthKey = "DeviceAuth"
+
+// DeviceKeyHeader - header carrying the API Key of a Gatekeeper
+var DeviceKeyHeader = "X-Device-Key"
+
+func (s *CCServer) tokenAuth(allowedRoles ...SessionRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeaderFields := strings.Fields(c.GetHeader("Authorization"))
 		if len(authHeaderFields) != 2 {
@@ -17,11 +27,125 @@ func (s *CCServer) tokenAuth(expectedToken string) gin.HandlerFunc {
 			return
 		}
 		token := authHeaderFields[1]
-		// log.Printf("token - %v\n", token)
-		if token != expectedToken {
+		claims, err := s.verifySessionToken(token)
+		if err != nil {
+			log.Printf("tokenAuth - rejected token: %v\n", err)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if !isRoleAllowed(claims.Role, allowedRoles) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Set(TokenAuthKey, claims)
+	}
+}
+
+// requirePermission - reject Admins whose role grants none of the given Permissions
+func (s *CCServer) requirePermission(permissions ...svc.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := getSessionClaims(c)
+		if claims == nil {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
+		for _, permission := range permissions {
+			if hasPermission(claims, permission) {
+				return
+			}
+		}
+		log.Printf("requirePermission - %v with role %v lacks %v\n", claims.Subject, claims.Role, permissions)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"message": "Permission denied",
+		})
+	}
+}
+
+// hasPermission - Permission granted to the role in the session token
+func hasPermission(claims *SessionClaims, permission svc.Permission) bool {
+	return svc.RoleHasPermission(svc.AdminRole(claims.Role), permission)
+}
+
+// deviceAuth - reject Scans posted by unregistered or disabled Gatekeepers.
+// The API Key is read from the "X-Device-Key" header, or the "device_key" form field
+func (s *CCServer) deviceAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.PostForm("device_id")
+		apiKey := c.GetHeader(DeviceKeyHeader)
+		if len(apiKey) == 0 {
+			apiKey = c.PostForm("device_key")
+		}
+
+		device := svc.Device{}
+		err := s.Stores.Devices.GetDeviceByDeviceID(c.Request.Context(), deviceID).Decode(&device)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				log.Printf("deviceAuth - Device %v is not registered\n", deviceID)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"message": "Device is not registered",
+				})
+				return
+			}
+			log.Printf("Error while getting Device by DeviceID - %v\n", err)
+			respondServerError(c, err)
+			return
+		}
+		if !device.MatchesAPIKey(apiKey) {
+			log.Printf("deviceAuth - rejected API Key of Device %v\n", deviceID)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "Device API Key is not valid",
+			})
+			return
+		}
+		if !device.Enabled {
+			log.Printf("deviceAuth - Device %v is disabled\n", deviceID)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "Device is disabled",
+			})
+			return
+		}
+
+		if _, err := s.Stores.Devices.UpdateDeviceLastSeen(c.Request.Context(), device.ID); err != nil {
+			log.Printf("Error while updating Device Last Seen Time - %v\n", err)
+		}
+		c.Set(DeviceAuthKey, &device)
+	}
+}
 
+// getScanDevice - Device put on the context by "deviceAuth"
+func getScanDevice(c *gin.Context) *svc.Device {
+	device, ok := c.Get(DeviceAuthKey)
+	if !ok {
+		return nil
+	}
+	return device.(*svc.Device)
+}
+
+// getSessionClaims - claims put on the context by "tokenAuth"; nil on routes without auth
+func getSessionClaims(c *gin.Context) *SessionClaims {
+	claims, ok := c.Get(TokenAuthKey)
+	if !ok {
+		return nil
+	}
+	return claims.(*SessionClaims)
+}
+
+// getSessionSubject - the ID of whoever the session token was signed for; empty without one
+func getSessionSubject(c *gin.Context) string {
+	if claims := getSessionClaims(c); claims != nil {
+		return claims.Subject
+	}
+	return ""
+}
+
+func isRoleAllowed(role SessionRole, allowedRoles []SessionRole) bool {
+	for _, allowed := range allowedRoles {
+		if role == allowed {
+			return true
+		}
 	}
+	return false
 }
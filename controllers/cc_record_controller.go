@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -19,7 +19,7 @@ import (
 // var requireCheckOutTemperature = false
 // var serverAddr = "http://192.168.86.101:8000"
 
-//GetManyCCRecords - as is
+// GetManyCCRecords - as is
 func (s *CCServer) GetManyCCRecords(c *gin.Context) {
 	// TODO - handle error when parsing time
 	var queryParams svc.GetCCRecordParams
@@ -33,11 +33,21 @@ func (s *CCServer) GetManyCCRecords(c *gin.Context) {
 		})
 		return
 	}
+	queryParams.List, err = s.extractListParams(c)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
 	log.Printf("cc-event query params - %v\n", queryParams)
 
+	// Admins only allowed to see failed screenings (e.g. Nurses)
+	if claims := getSessionClaims(c); claims != nil && !hasPermission(claims, svc.PermissionViewCCRecords) {
+		queryParams.Status = int(svc.CCrFailed)
+	}
+
 	// Get Institution
 	inst := svc.Institution{}
-	err = svc.GetInstByID(queryParams.InstID).Decode(&inst)
+	err = s.Stores.Insts.GetInstByID(c.Request.Context(), queryParams.InstID).Decode(&inst)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			log.Printf("Institution does not exist, need to create a new one")
@@ -47,35 +57,41 @@ func (s *CCServer) GetManyCCRecords(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while getting institution by ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
+	// Temperatures are given & rendered in the Institution's unit
+	unit := s.instTemperatureUnit(inst)
+	if queryParams.TemperatureThrd != 0 {
+		queryParams.TemperatureThrd = svc.ToCanonicalTemperature(queryParams.TemperatureThrd, unit)
+	}
+
 	// Get CCRecords
-	cursor, err := svc.GetManyCCRecords(&queryParams, inst.MemberType)
+	cursor, err := s.Stores.CCRecords.GetManyCCRecords(c.Request.Context(), &queryParams, inst.MemberType)
 
 	if err != nil {
 		log.Printf("Error while getting all CCEvents - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondListError(c, err)
 		return
 	}
 
-	// Iterate through the returned cursor
 	ccRecords := []svc.CCRecord{}
-	for cursor.Next(context.TODO()) {
-		var ccRecord svc.CCRecord
-		cursor.Decode(&ccRecord)
-		ccRecords = append(ccRecords, ccRecord)
+	nextCursor, err := svc.DecodeList(c.Request.Context(), cursor, &ccRecords)
+	if err != nil {
+		log.Printf("Error while decoding CCEvents - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	for i := range ccRecords {
+		ccRecords[i] = ccRecords[i].InUnit(unit)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "All CCEvents",
-		"data":    ccRecords,
+		"message":          "All CCEvents",
+		"data":             ccRecords,
+		"next_cursor":      nextCursor,
+		"temperature_unit": unit,
 	})
 
 	return
@@ -86,12 +102,12 @@ func (s *CCServer) DeleteCCRecordByID(c *gin.Context) {
 	// TODO: Set CC Records to "expired"
 	idToDelete := c.Param("id")
 
-	res, err := svc.DeleteCCRecordByID(idToDelete)
+	before := svc.CCRecord{}
+	s.Stores.CCRecords.GetCCRecordByID(c.Request.Context(), idToDelete).Decode(&before)
+	res, err := s.Stores.CCRecords.DeleteCCRecordByID(c.Request.Context(), idToDelete)
 	if err != nil {
 		log.Printf("Error while deleting CCRecord from DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -101,6 +117,7 @@ func (s *CCServer) DeleteCCRecordByID(c *gin.Context) {
 		})
 		return
 	}
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetCCRecord, idToDelete, before.InstID, before, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "CCRecord Deleted Successfully",
@@ -108,11 +125,11 @@ func (s *CCServer) DeleteCCRecordByID(c *gin.Context) {
 	return
 }
 
-func getAndUpdateCCRecordWithEvent(c *gin.Context,
+func (s *CCServer) getAndUpdateCCRecordWithEvent(c *gin.Context,
 	params svc.GetCCRecordParams, newEventData svc.NewEventData) bool {
 
 	ccRecord := svc.CCRecord{}
-	err := svc.GetCCRecord(&params).Decode(&ccRecord)
+	err := s.Stores.CCRecords.GetCCRecord(c.Request.Context(), &params).Decode(&ccRecord)
 	if err != nil {
 		log.Printf("CCRecord with requested WardID and Status not Exist - %v\n", err)
 		c.JSON(http.StatusMethodNotAllowed, gin.H{
@@ -121,68 +138,127 @@ func getAndUpdateCCRecordWithEvent(c *gin.Context,
 		return false
 	}
 
-	_, err = svc.UpdateCCRecordWithEvent(ccRecord, newEventData)
+	_, err = s.Stores.CCRecords.UpdateCCRecordWithEvent(c.Request.Context(), ccRecord, newEventData)
+	if err == svc.ErrCCRecordConflict {
+		// Another scan updated the Record first; the scan is not applied, and may be made again
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "CCRecord was updated by another scan, try again",
+		})
+		return false
+	}
+	if ok := checkTransition(c, err); !ok {
+		return false
+	}
 	if err != nil {
 		log.Printf("Error when updating CCRecord with Event - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Somthing went wrong",
+		respondServerError(c, err)
+		return false
+	}
+	if scanEvent := getScanEvent(c); scanEvent != nil {
+		scanEvent.AddApplied(ccRecord.ID.Hex(), ccRecord.Version+1, newEventData)
+	}
+	s.recordScreeningFailure(ccRecord, newEventData)
+	return true
+}
+
+// instWorkflow - get the Workflow the CCRecords of an Institution go through
+func (s *CCServer) instWorkflow(c *gin.Context, instID string) (svc.CCWorkflow, bool) {
+	inst, ok := s.getInst(c, instID)
+	if !ok {
+		return svc.CCWorkflow{}, false
+	}
+	return getInstWorkflow(c, inst)
+}
+
+// getInst - get the Institution of a Record or Scan; a missing one is answered with 403
+func (s *CCServer) getInst(c *gin.Context, instID string) (svc.Institution, bool) {
+	inst := svc.Institution{}
+	err := s.Stores.Insts.GetInstByID(c.Request.Context(), instID).Decode(&inst)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			log.Printf("Institution not found - %v\n", instID)
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Institution not found",
+			})
+			return svc.Institution{}, false
+		}
+		log.Printf("Error while getting institution by ID - %v\n", err)
+		respondServerError(c, err)
+		return svc.Institution{}, false
+	}
+	return inst, true
+}
+
+// getInstWorkflow - get the Workflow of "inst"; an unknown Workflow type is a misconfigured Institution
+func getInstWorkflow(c *gin.Context, inst svc.Institution) (svc.CCWorkflow, bool) {
+	workflow, err := svc.GetCCWorkflow(inst.WorkflowType)
+	if err != nil {
+		log.Printf("Error while getting Workflow of Institution %v - %v\n", inst.ID.Hex(), err)
+		respondServerError(c, err)
+		return svc.CCWorkflow{}, false
+	}
+	return workflow, true
+}
+
+// checkTransition - reject scans the Workflow does not allow with 405, as for Records not in the Status
+// the scan needs
+func checkTransition(c *gin.Context, err error) bool {
+	var tErr *svc.InvalidTransitionError
+	if errors.As(err, &tErr) {
+		log.Printf("Scan rejected - %v\n", tErr)
+		c.JSON(http.StatusMethodNotAllowed, gin.H{
+			"message": "Scan is not allowed for the CCRecord's Status",
 		})
 		return false
 	}
 	return true
 }
 
-func getOrCreateCCRecordGW(c *gin.Context, ccParams *svc.GetCCRecordParams) (*svc.CCRecord, bool) {
+func (s *CCServer) getOrCreateCCRecordGW(c *gin.Context, ccParams *svc.GetCCRecordParams) (*svc.CCRecord, bool) {
 	ccRecord := svc.CCRecord{}
-	err := svc.GetCCRecord(ccParams).Decode(&ccRecord)
+	err := s.Stores.CCRecords.GetCCRecord(c.Request.Context(), ccParams).Decode(&ccRecord)
 	if err == nil {
 		return &ccRecord, true
 	}
 	// When no CCRecord Found, Create a New One and return
 	if err == mongo.ErrNoDocuments {
-		if ok := createAndGetCCRecordGWByWardID(c, ccParams.WardID, &ccRecord); !ok {
+		if ok := s.createAndGetCCRecordGWByWardID(c, ccParams.WardID, &ccRecord); !ok {
 			return nil, false
 		}
 		return &ccRecord, true
 	}
 	log.Printf("Error while finding CCRecords - %v\n", err)
-	c.JSON(http.StatusInternalServerError, gin.H{
-		"message": "Something went wrong",
-	})
+	respondServerError(c, err)
 	return nil, false
 }
 
-func getOrCreateCCRecordMember(c *gin.Context, ccParams *svc.GetCCRecordParams) (*svc.CCRecord, bool) {
+func (s *CCServer) getOrCreateCCRecordMember(c *gin.Context, ccParams *svc.GetCCRecordParams) (*svc.CCRecord, bool) {
 	ccRecord := svc.CCRecord{}
-	err := svc.GetCCRecord(ccParams).Decode(&ccRecord)
+	err := s.Stores.CCRecords.GetCCRecord(c.Request.Context(), ccParams).Decode(&ccRecord)
 	if err == nil {
 		return &ccRecord, true
 	}
 	// When no CCRecord Found, Create a New One and return
 	if err == mongo.ErrNoDocuments {
-		if ok := createAndGetCCRecordMByMemberID(c, ccParams.MemberTagID, &ccRecord); !ok {
+		if ok := s.createAndGetCCRecordMByMemberID(c, ccParams.MemberTagID, &ccRecord); !ok {
 			return nil, false
 		}
 		return &ccRecord, true
 	}
 	log.Printf("Error while finding CCRecords - %v\n", err)
-	c.JSON(http.StatusInternalServerError, gin.H{
-		"message": "Something went wrong",
-	})
+	respondServerError(c, err)
 	return nil, false
 
 }
 
-func createAndGetCCRecordGWByWardID(c *gin.Context, wardID string, newCCRecord *svc.CCRecord) bool {
+func (s *CCServer) createAndGetCCRecordGWByWardID(c *gin.Context, wardID string, newCCRecord *svc.CCRecord) bool {
 	// Get Family By WardID
 	familyToProcess := svc.Family{}
-	err := svc.GetFamilyByWardID(wardID).Decode(&familyToProcess)
+	err := s.Stores.Families.GetFamilyByWardID(c.Request.Context(), wardID).Decode(&familyToProcess)
 	log.Printf("Decorded familyToProcess is - %v\n", familyToProcess)
 	if err != nil {
 		log.Printf("Error while Getting Family by WardID into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
 	// Get Ward And Create CCRecord
@@ -196,72 +272,60 @@ func createAndGetCCRecordGWByWardID(c *gin.Context, wardID string, newCCRecord *
 		Ward: wardToProcess,
 	}
 
-	res, err := svc.CreateCCRecord(familyToProcess.InstID, initData)
+	res, err := s.Stores.CCRecords.CreateCCRecord(c.Request.Context(), familyToProcess.InstID, initData)
 	if err != nil {
 		log.Printf("Error while inserting new CCRecord into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
 
-	err = svc.GetCCRecordByID(res.InsertedID.(primitive.ObjectID).Hex()).Decode(newCCRecord)
+	err = s.Stores.CCRecords.GetCCRecordByID(c.Request.Context(), res.InsertedID.(primitive.ObjectID).Hex()).Decode(newCCRecord)
 
 	if err != nil {
 		log.Printf("Error while Getting new CCRecord By ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
 	return true
 }
 
-func createAndGetCCRecordMByMemberID(c *gin.Context, memberID string, newCCRecord *svc.CCRecord) bool {
+func (s *CCServer) createAndGetCCRecordMByMemberID(c *gin.Context, memberID string, newCCRecord *svc.CCRecord) bool {
 	// Get Member
 	memberToProcess := svc.Member{}
-	err := svc.GetMemberByID(memberID).Decode(&memberToProcess)
+	err := s.Stores.Members.GetMemberByID(c.Request.Context(), memberID).Decode(&memberToProcess)
 	if err != nil {
 		log.Printf("Error while Getting Member by ID From DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
 
 	initData := svc.CreateCCRecordData{
 		Member: &memberToProcess,
 	}
-	res, err := svc.CreateCCRecord(memberToProcess.InstID, initData)
+	res, err := s.Stores.CCRecords.CreateCCRecord(c.Request.Context(), memberToProcess.InstID, initData)
 	if err != nil {
 		log.Printf("Error while inserting new CCRecord into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
-	err = svc.GetCCRecordByID(res.InsertedID.(primitive.ObjectID).Hex()).Decode(newCCRecord)
+	err = s.Stores.CCRecords.GetCCRecordByID(c.Request.Context(), res.InsertedID.(primitive.ObjectID).Hex()).Decode(newCCRecord)
 	if err != nil {
 		log.Printf("Error while Getting new CCRecord By ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
 	return true
 }
 
-func createCCRecordTByTag(c *gin.Context, tag svc.Tag) bool {
+func (s *CCServer) createCCRecordTByTag(c *gin.Context, tag svc.Tag) bool {
 	initData := svc.CreateCCRecordData{
 		Tag: &tag,
 	}
 	log.Printf("createCCRecordTByTag")
-	_, err := svc.CreateCCRecord(tag.InstID, initData)
+	_, err := s.Stores.CCRecords.CreateCCRecord(c.Request.Context(), tag.InstID, initData)
 	if err != nil {
 		log.Printf("Error while inserting new CCRecord into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
 	return true
@@ -272,6 +336,9 @@ func extractCCRecordParams(c *gin.Context, params *svc.GetCCRecordParams) error
 
 	params.InstID = c.DefaultQuery("instID", "000000000000000000000000")
 	params.WardID = c.DefaultQuery("wardID", "")
+	params.Group = c.Query("group")
+	params.NamePrefix = c.Query("name")
+	params.IncludeExpired = c.Query("expired") == "true"
 
 	param, ok := c.GetQuery("startDate")
 	if ok {
@@ -323,3 +390,29 @@ func extractCCRecordParams(c *gin.Context, params *svc.GetCCRecordParams) error
 	}
 	return nil
 }
+
+// expireCCRecords - expire the CC Records of a deleted Ward, Member, Tag or Institution.
+// Failing to is logged only, since the deletion has already been made
+func (s *CCServer) expireCCRecords(c *gin.Context, params svc.MarkCCRecordAsExpiredParams) {
+	_, err := s.Stores.CCRecords.MarkCCRecordAsExpired(c.Request.Context(), params)
+	if err != nil {
+		log.Printf("Error while marking CCRecord as Expired - %v\n", err)
+	}
+}
+
+// unexpireCCRecords - undo "expireCCRecords" for a restored Ward, Member, Tag or Institution
+func (s *CCServer) unexpireCCRecords(c *gin.Context, params svc.MarkCCRecordAsExpiredParams) {
+	_, err := s.Stores.CCRecords.RestoreExpiredCCRecords(c.Request.Context(), params)
+	if err != nil {
+		log.Printf("Error while restoring expired CCRecords - %v\n", err)
+	}
+}
+
+// instMemberType - Member Type of an Institution; Standard when it cannot be found
+func (s *CCServer) instMemberType(c *gin.Context, instID string) svc.MemberType {
+	inst := svc.Institution{MemberType: svc.MemberTypeStandard}
+	if err := s.Stores.Insts.GetInstByID(c.Request.Context(), instID).Decode(&inst); err != nil {
+		log.Printf("Error while getting institution by ID - %v\n", err)
+	}
+	return inst.MemberType
+}
@@ -20,7 +20,7 @@ func (s *CCServer) GetOrCreateManyCCRecords(c *gin.Context) {
 
 	// Get Institution
 	inst := svc.Institution{}
-	err := svc.GetInstByID(CCRecordsForm.InstID).Decode(&inst)
+	err := s.Stores.Insts.GetInstByID(c.Request.Context(), CCRecordsForm.InstID).Decode(&inst)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			log.Printf("Institution not found, Getting CCRecords Failed!")
@@ -30,18 +30,16 @@ func (s *CCServer) GetOrCreateManyCCRecords(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while getting institution by ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
-	var excludeStatusList []int
-	if inst.WorkflowType == svc.WorkflowTypeCC {
-		excludeStatusList = []int{int(svc.CCrCheckOutComplete), int(svc.CCrFailed)}
-	} else if inst.WorkflowType == svc.WorkflowTypeCheckIn {
-		excludeStatusList = []int{int(svc.CCrCheckInComplete), int(svc.CCrFailed)}
+	workflow, ok := getInstWorkflow(c, inst)
+	if !ok {
+		return
 	}
+	excludeStatusList := workflow.FinalStatuses()
+	unit := s.instTemperatureUnit(inst)
 
 	// Case 2 - Member
 	if CCRecordsForm.MemberID != nil {
@@ -51,19 +49,22 @@ func (s *CCServer) GetOrCreateManyCCRecords(c *gin.Context) {
 			Status:            -1, // set Status to "-1" to disable status filter
 			ExcludeStatusList: excludeStatusList,
 		}
-		ccRecord, ok := getOrCreateCCRecordMember(c, &getParams)
+		ccRecord, exclusion, ok := s.getSyncCCRecord(c, inst.ID.Hex(), memberID, &getParams, s.getOrCreateCCRecordMember)
 		if !ok {
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{
-			"message": "CCEvent",
-			"data":    ccRecord,
+			"message":          "CCEvent",
+			"data":             ccRecord.InUnit(unit),
+			"temperature_unit": unit,
+			"exclusions":       appendExclusion([]svc.Exclusion{}, exclusion),
 		})
 		return
 	}
 	// Case 1 - Gaurdian-Ward
 	if CCRecordsForm.WardIDList != nil {
 		var ccRecords []svc.CCRecord
+		exclusions := []svc.Exclusion{}
 		for _, wardID := range *CCRecordsForm.WardIDList {
 
 			getParams := svc.GetCCRecordParams{
@@ -71,16 +72,19 @@ func (s *CCServer) GetOrCreateManyCCRecords(c *gin.Context) {
 				Status:            -1, // set Status to "-1" to disable status filter
 				ExcludeStatusList: excludeStatusList,
 			}
-			ccRecord, ok := getOrCreateCCRecordGW(c, &getParams)
+			ccRecord, exclusion, ok := s.getSyncCCRecord(c, inst.ID.Hex(), wardID, &getParams, s.getOrCreateCCRecordGW)
 			if !ok {
 				return
 			}
 			// // Append the obtained CC Record to List
-			ccRecords = append(ccRecords, *ccRecord)
+			ccRecords = append(ccRecords, ccRecord.InUnit(unit))
+			exclusions = appendExclusion(exclusions, exclusion)
 		}
 		c.JSON(http.StatusOK, gin.H{
-			"message": "All CCEvents",
-			"data":    ccRecords,
+			"message":          "All CCEvents",
+			"data":             ccRecords,
+			"temperature_unit": unit,
+			"exclusions":       exclusions,
 		})
 		return
 	}
@@ -89,30 +93,119 @@ func (s *CCServer) GetOrCreateManyCCRecords(c *gin.Context) {
 	})
 }
 
+// getSyncCCRecord - get or create the open Record of a Ward or Member. Those excluded from Check-Ins get no new
+// Record, and are given their latest one, e.g. the Record they failed, along with the Exclusion
+func (s *CCServer) getSyncCCRecord(c *gin.Context, instID string, subjectID string, getParams *svc.GetCCRecordParams,
+	getOrCreate func(*gin.Context, *svc.GetCCRecordParams) (*svc.CCRecord, bool)) (*svc.CCRecord, *svc.Exclusion, bool) {
+	exclusion, _, ok := s.getActiveExclusion(c, instID, subjectID)
+	if !ok {
+		return nil, nil, false
+	}
+	if exclusion == nil {
+		ccRecord, ok := getOrCreate(c, getParams)
+		return ccRecord, nil, ok
+	}
+
+	latestParams := *getParams
+	latestParams.ExcludeStatusList = nil
+	latestParams.GetLatest = true
+	ccRecord := svc.CCRecord{}
+	err := s.Stores.CCRecords.GetCCRecord(c.Request.Context(), &latestParams).Decode(&ccRecord)
+	if err == mongo.ErrNoDocuments {
+		log.Printf("No CCRecord is made for excluded %v\n", subjectID)
+		c.JSON(http.StatusForbidden, gin.H{
+			"message":     "Excluded after a failed screening, no CCRecord is made",
+			"reason_code": "excluded",
+			"exclusion":   exclusion,
+		})
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Printf("Error while finding CCRecords - %v\n", err)
+		respondServerError(c, err)
+		return nil, nil, false
+	}
+	return &ccRecord, exclusion, true
+}
+
+// appendExclusion - as is; nil ones are left out
+func appendExclusion(exclusions []svc.Exclusion, exclusion *svc.Exclusion) []svc.Exclusion {
+	if exclusion == nil {
+		return exclusions
+	}
+	return append(exclusions, *exclusion)
+}
+
 // HandleCheckoutScheduleEvent - process "Schedule Checkout" request from MobileApp
 func (s *CCServer) HandleCheckoutScheduleEvent(c *gin.Context) {
 	var sPostingForm svc.SchedulePostingForm
 	c.BindJSON(&sPostingForm)
 
 	log.Printf("Schedule Form is - %v\n", sPostingForm)
+	scheduledTime := time.Unix(int64(sPostingForm.TimeStamp), 0)
+	scanEvent := startScanEvent(c, svc.ScanEvent{
+		Source:      svc.ScanEventSourceSchedule,
+		ScheduledAt: &scheduledTime,
+	})
+	defer s.recordScanEvent(c, scanEvent)
+	recordNotFound := gin.H{
+		"message": "CCRecord with requested WardID and Status not Exist",
+	}
 	for _, wardID := range sPostingForm.WardIDs {
+		instID, err := s.wardInstID(c.Request.Context(), wardID)
+		if err == mongo.ErrNoDocuments {
+			log.Printf("Ward to schedule not Exist - %v\n", wardID)
+			c.JSON(http.StatusMethodNotAllowed, recordNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error while getting Institution of Ward - %v\n", err)
+			respondServerError(c, err)
+			return
+		}
+		// Only Workflows with a "schedule" stage let Guardians schedule check-outs, of Records in the Status it
+		// is made from
+		workflow, ok := s.instWorkflow(c, instID)
+		if !ok {
+			return
+		}
+		from, err := workflow.From(svc.MemberTypeGuardian, svc.CCStageSchedule)
+		if ok := checkTransition(c, err); !ok {
+			return
+		}
+
 		// Get CCRecord
 		params := svc.GetCCRecordParams{
 			WardID: wardID,
-			Status: int(svc.CCrCheckInComplete),
+			Status: int(from),
 		}
 		ccRecord := svc.CCRecord{}
-		err := svc.GetCCRecord(&params).Decode(&ccRecord)
+		err = s.Stores.CCRecords.GetCCRecord(c.Request.Context(), &params).Decode(&ccRecord)
 		if err != nil {
 			log.Printf("CCRecord with requested WardID and Status not Exist - %v\n", err)
-			c.JSON(http.StatusMethodNotAllowed, gin.H{
-				"message": "CCRecord with requested WardID and Status not Exist",
-			})
+			c.JSON(http.StatusMethodNotAllowed, recordNotFound)
 			return
 		}
 		// Update CCRecord with Scheduled Time
-		scheduledTime := time.Unix(int64(sPostingForm.TimeStamp), 0)
-		svc.UpdateCCRecordScheduleTime(ccRecord.ID.Hex(), scheduledTime)
+		_, err = s.Stores.CCRecords.UpdateCCRecordScheduleTime(c.Request.Context(), ccRecord, scheduledTime)
+		if err == svc.ErrCCRecordConflict {
+			// A scan updated the Record since it was read; the schedule is not applied, and may be made again
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "CCRecord was updated by a scan, try again",
+			})
+			return
+		}
+		if err != nil {
+			log.Printf("Error while updating Scheduled Time of CCRecord - %v\n", err)
+			respondServerError(c, err)
+			return
+		}
+		scanEvent.InstID = ccRecord.InstID
+		scanEvent.AddApplied(ccRecord.ID.Hex(), ccRecord.Version+1, svc.NewEventData{
+			Stage:      string(svc.CCStageSchedule),
+			Workflow:   workflow.Type,
+			MemberType: svc.MemberTypeGuardian,
+		})
 
 	}
 
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"log"
+	"os"
 	"path/filepath"
+	"time"
 
 	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/spf13/viper"
@@ -24,22 +26,66 @@ type SMSConfig struct {
 	FromPhoneNum string `json:"from_phone_num" mapstructure:"from_phone_num"`
 }
 
-type DebugTokenList struct {
-	SuperAdmin string `json:"super_admin" mapstructure:"super_admin"`
-	Admin      string `json:"admin" mapstructure:"admin"`
-	Mobile     string `json:"mobile" mapstructure:"mobile"`
+// AuthConfig - for signing & verifying session tokens
+type AuthConfig struct {
+	SigningKey    string `json:"signing_key" mapstructure:"signing_key"`
+	TokenTTLHours int    `json:"token_ttl_hours" mapstructure:"token_ttl_hours"`
+}
+
+// RegCodeConfig - for issuing & verifying RegCodes used to activate Members
+type RegCodeConfig struct {
+	TTLHours          int `json:"ttl_hours" mapstructure:"ttl_hours"`
+	MaxFailedAttempts int `json:"max_failed_attempts" mapstructure:"max_failed_attempts"`
+	LockoutMinutes    int `json:"lockout_minutes" mapstructure:"lockout_minutes"`
+}
+
+// TTL - as is
+func (r RegCodeConfig) TTL() time.Duration {
+	return time.Duration(r.TTLHours) * time.Hour
+}
+
+// Lockout - as is
+func (r RegCodeConfig) Lockout() time.Duration {
+	return time.Duration(r.LockoutMinutes) * time.Minute
+}
+
+// ScanConfig - for verifying QR scan payloads posted by Gatekeepers
+type ScanConfig struct {
+	AllowUnsignedPayloads  bool `json:"allow_unsigned_payloads" mapstructure:"allow_unsigned_payloads"`
+	FreshnessWindowSeconds int  `json:"freshness_window_seconds" mapstructure:"freshness_window_seconds"`
+}
+
+// DBConfig - for bounding the time spent on DB calls, and upgrading the schema
+type DBConfig struct {
+	TimeoutSeconds   int  `json:"timeout_seconds" mapstructure:"timeout_seconds"`
+	MigrateOnStartup bool `json:"migrate_on_startup" mapstructure:"migrate_on_startup"`
+}
+
+// Timeout - as is
+func (d DBConfig) Timeout() time.Duration {
+	return time.Duration(d.TimeoutSeconds) * time.Second
+}
+
+// PageConfig - for paging list endpoints; lists without a limit get DefaultLimit, or MaxLimit when it is 0
+type PageConfig struct {
+	DefaultLimit int64 `json:"default_limit" mapstructure:"default_limit"`
+	MaxLimit     int64 `json:"max_limit" mapstructure:"max_limit"`
 }
 
 // Config - top-level configuration structure
 type Config struct {
-	MongoServerURI      string         `json:"mongo_server_uri" mapstructure:"mongo_server_uri"`
-	ServerAddr          string         `json:"server_address" mapstructure:"server_address"`
-	RequireCheckOutTemp bool           `json:"require_check_out_temperature" mapstructure:"require_check_out_temperature"`
-	RequireAdminPswd    bool           `json:"require_admin_password" mapstructure:"require_admin_password"`
-	TempThrd            float32        `json:"temperature_threshold" mapstructure:"temperature_threshold"`
-	DebugTokenL         DebugTokenList `json:"debug_token_list" mapstructure:"debug_token_list"`
-	EmailConf           EmailConfig    `json:"email_config" mapstructure:"email_config"`
-	SMSConf             SMSConfig      `json:"sms_config" mapstructure:"sms_config"`
+	MongoServerURI      string        `json:"mongo_server_uri" mapstructure:"mongo_server_uri"`
+	ServerAddr          string        `json:"server_address" mapstructure:"server_address"`
+	RequireCheckOutTemp bool          `json:"require_check_out_temperature" mapstructure:"require_check_out_temperature"`
+	RequireAdminPswd    bool          `json:"require_admin_password" mapstructure:"require_admin_password"`
+	TempThrd            float32       `json:"temperature_threshold" mapstructure:"temperature_threshold"`
+	AuthConf            AuthConfig    `json:"auth_config" mapstructure:"auth_config"`
+	RegCodeConf         RegCodeConfig `json:"reg_code_config" mapstructure:"reg_code_config"`
+	ScanConf            ScanConfig    `json:"scan_config" mapstructure:"scan_config"`
+	DBConf              DBConfig      `json:"db_config" mapstructure:"db_config"`
+	PageConf            PageConfig    `json:"page_config" mapstructure:"page_config"`
+	EmailConf           EmailConfig   `json:"email_config" mapstructure:"email_config"`
+	SMSConf             SMSConfig     `json:"sms_config" mapstructure:"sms_config"`
 }
 
 // var defaulEmailConfig = EmailConfig{
@@ -57,6 +103,26 @@ type Config struct {
 
 var defaultConfig = Config{
 	RequireCheckOutTemp: false,
+	AuthConf: AuthConfig{
+		TokenTTLHours: 24,
+	},
+	RegCodeConf: RegCodeConfig{
+		TTLHours:          72,
+		MaxFailedAttempts: 5,
+		LockoutMinutes:    15,
+	},
+	ScanConf: ScanConfig{
+		AllowUnsignedPayloads:  false,
+		FreshnessWindowSeconds: 120,
+	},
+	DBConf: DBConfig{
+		TimeoutSeconds:   10,
+		MigrateOnStartup: true,
+	},
+	PageConf: PageConfig{
+		DefaultLimit: 100,
+		MaxLimit:     500,
+	},
 }
 
 // InitConfig - loading global configurations from json file
@@ -81,14 +147,24 @@ func (s *CCServer) InitConfig(appName string, isTest bool) {
 	}
 	// log.Printf("config initialized - %v\n", config)
 
+	// Signing Key should be kept out of the config files in deployment
+	if signingKey := os.Getenv("CC_AUTH_SIGNING_KEY"); len(signingKey) > 0 {
+		config.AuthConf.SigningKey = signingKey
+	}
+	if len(config.AuthConf.SigningKey) == 0 {
+		log.Println("auth_config.signing_key is empty, all session tokens will be rejected")
+	}
+
 	s.Config = config
-	log.Printf("config initiated, tokens: %v\n", s.Config.DebugTokenL)
+	log.Println("config initiated")
 }
 
 // ReloadConfigFromDB - reload hot-reloadable configs from DB
 func (s *CCServer) ReloadConfigFromDB() {
+	ctx, cancel := s.backgroundDBContext()
+	defer cancel()
 	var reloadedConfig svc.Config
-	err := svc.GetConfigByName("default").Decode(&reloadedConfig)
+	err := s.Stores.Configs.GetConfigByName(ctx, "default").Decode(&reloadedConfig)
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
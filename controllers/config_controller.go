@@ -1,17 +1,18 @@
 package controllers
 
 import (
-	"context"
 	"log"
 	"net/http"
 
 	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // RunReloadConfig - as is
 func (s *CCServer) RunReloadConfig(c *gin.Context) {
 	s.ReloadConfigFromDB()
+	s.recordAudit(c, svc.AuditActionReload, svc.AuditTargetConfig, "", "", nil, nil)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Config Reload is Successful",
 	})
@@ -19,16 +20,14 @@ func (s *CCServer) RunReloadConfig(c *gin.Context) {
 
 // GetManyConfigs - as is
 func (s *CCServer) GetManyConfigs(c *gin.Context) {
-	cursor, err := svc.GetManyConfigs()
+	cursor, err := s.Stores.Configs.GetManyConfigs(c.Request.Context())
 	if err != nil {
 		log.Printf("Error while getting all admins - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	configs := []svc.Config{}
-	if err = cursor.All(context.TODO(), &configs); err != nil {
+	if err = cursor.All(c.Request.Context(), &configs); err != nil {
 		panic(err)
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -44,7 +43,7 @@ func (s *CCServer) CreateConfig(c *gin.Context) {
 	c.BindJSON(&configForm)
 
 	// Check if Config exists
-	count, err := svc.CountConfigByName(configForm.Name)
+	count, err := s.Stores.Configs.CountConfigByName(c.Request.Context(), configForm.Name)
 	if err != nil {
 		log.Printf("Error while counting Config by Name - %v\n", err)
 	}
@@ -56,14 +55,16 @@ func (s *CCServer) CreateConfig(c *gin.Context) {
 	}
 
 	// Create Config in DB
-	_, err = svc.CreateConfig(configForm)
+	res, err := s.Stores.Configs.CreateConfig(c.Request.Context(), configForm)
 	if err != nil {
 		log.Printf("Error while creating new Config in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	configID := res.InsertedID.(primitive.ObjectID).Hex()
+	after := svc.Config{}
+	s.Stores.Configs.GetConfigByID(c.Request.Context(), configID).Decode(&after)
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetConfig, configID, "", nil, after)
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Config created Successfully",
 	})
@@ -76,12 +77,12 @@ func (s *CCServer) UpdateConfigByID(c *gin.Context) {
 	c.BindJSON(&cEditForm)
 
 	idToUpdate := c.Param("id")
-	res, err := svc.UpdateConfigByID(cEditForm, idToUpdate)
+	before := svc.Config{}
+	s.Stores.Configs.GetConfigByID(c.Request.Context(), idToUpdate).Decode(&before)
+	res, err := s.Stores.Configs.UpdateConfigByID(c.Request.Context(), cEditForm, idToUpdate)
 	if err != nil {
 		log.Printf("Error while updating Config in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	if res.ModifiedCount == 0 {
@@ -90,6 +91,9 @@ func (s *CCServer) UpdateConfigByID(c *gin.Context) {
 		})
 		return
 	}
+	after := svc.Config{}
+	s.Stores.Configs.GetConfigByID(c.Request.Context(), idToUpdate).Decode(&after)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetConfig, idToUpdate, "", before, after)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Config updated Successfully",
 	})
@@ -11,8 +11,46 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-// Connect - finding a running MongoDB Instance
+// schemaTimeout - bounds Migrations & index builds, which may take a while on large collections
+const schemaTimeout = 10 * time.Minute
+
+// Connect - finding a running MongoDB Instance, upgrading its schema & ensuring its indexes
 func (s *CCServer) Connect() {
+	db := s.connectDB()
+	s.Stores = svc.NewMongoStores(db)
+
+	// Schema Migrations, before indexes, as they may change indexed fields
+	if s.Config.DBConf.MigrateOnStartup {
+		migrateCtx, migrateCancel := context.WithTimeout(context.Background(), schemaTimeout)
+		defer migrateCancel()
+		results, err := svc.MigrateUp(migrateCtx, db, false)
+		for _, res := range results {
+			log.Printf("Migration applied - %v\n", res)
+		}
+		if err != nil {
+			log.Fatal("Couldn't migrate the database", err)
+		}
+	}
+
+	// Ensure Indexes
+	indexCtx, indexCancel := context.WithTimeout(context.Background(), schemaTimeout)
+	defer indexCancel()
+	report, err := svc.EnsureIndexes(indexCtx, db)
+	if err != nil {
+		log.Fatal("Couldn't ensure database indexes", err)
+	}
+	for _, name := range report.Created {
+		log.Printf("Index created - %v\n", name)
+	}
+	for _, drift := range report.Drifts {
+		log.Printf("Index drift - %v\n", drift)
+	}
+
+	return
+}
+
+// connectDB - as is
+func (s *CCServer) connectDB() *mongo.Database {
 
 	// DB Config
 	clientOptions := options.Client().ApplyURI(s.Config.MongoServerURI)
@@ -40,16 +78,5 @@ func (s *CCServer) Connect() {
 		log.Println("Connected!")
 	}
 
-	db := client.Database("go_mongo")
-	svc.FamilyCollection(db)
-	svc.CCRecordCollection(db)
-	svc.InstCollection(db)
-	svc.AdminCollection(db)
-	svc.RegCodeCollection(db)
-	svc.SurveyCollection(db)
-	svc.ConfigCollection(db)
-	svc.MemberCollection(db)
-	svc.TagCollection(db)
-
-	return
+	return client.Database("go_mongo")
 }
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// dbDeadline - bound the request's context by the DB timeout; handlers pass "c.Request.Context()"
+// to every Store call, so that a hung DB or a client hanging up does not keep them waiting
+func (s *CCServer) dbDeadline() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), s.Config.DBConf.Timeout())
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// backgroundDBContext - for DB work not tied to a request, or that must not be given up with it
+func (s *CCServer) backgroundDBContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.Config.DBConf.Timeout())
+}
+
+// respondServerError - 504 when the DB did not answer in time, 503 when it could not be reached,
+// and 500 for anything else
+func respondServerError(c *gin.Context, err error) {
+	switch {
+	case svc.IsTimeoutErr(err):
+		c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
+			"message": "Database did not respond in time, please try again",
+		})
+	case svc.IsUnavailableErr(err):
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"message": "Database is unavailable, please try again later",
+		})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"message": "Something went wrong",
+		})
+	}
+}
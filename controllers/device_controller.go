@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetManyDevices - as is
+func (s *CCServer) GetManyDevices(c *gin.Context) {
+	var queryParams svc.GetDeviceParams
+	queryParams.InstID = c.DefaultQuery("instID", "000000000000000000000000")
+
+	cursor, err := s.Stores.Devices.GetManyDevices(c.Request.Context(), &queryParams)
+	if err != nil {
+		log.Printf("Error while getting all Devices - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+
+	// Iterate through the returned cursor
+	devices := []svc.Device{}
+	for cursor.Next(c.Request.Context()) {
+		var device svc.Device
+		cursor.Decode(&device)
+		devices = append(devices, device)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All Devices",
+		"data":    devices,
+	})
+	return
+}
+
+// CreateDevice - register a Gatekeeper; the API Key is returned only once
+func (s *CCServer) CreateDevice(c *gin.Context) {
+	var dRegForm svc.DeviceRegForm
+	c.BindJSON(&dRegForm)
+
+	// Validation
+	err := s.Validator.v.Struct(dRegForm)
+	if err != nil {
+		var badInput bool = false
+		for _, e := range err.(validator.ValidationErrors) {
+			badInput = true
+			log.Println(e)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprint(e.Translate(*s.Validator.trans)),
+			})
+		}
+		if badInput {
+			return
+		}
+	}
+
+	// Get Institution
+	var inst svc.Institution
+	err = s.Stores.Insts.GetInstByID(c.Request.Context(), dRegForm.InstID).Decode(&inst)
+	if err != nil {
+		// When no institution found, return failed
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Institution does not exist, Device Registration failed",
+			})
+			return
+		}
+		log.Printf("Error while finding institution - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+
+	// Check if the DeviceID exists, in any Institution
+	count, err := s.Stores.Devices.CountDeviceByDeviceID(c.Request.Context(), dRegForm.DeviceID)
+	if err != nil {
+		log.Printf("Error while counting Device by DeviceID - %v\n", err)
+	}
+	if count > 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Device has been registered!",
+		})
+		return
+	}
+
+	// Create Device
+	res, apiKey, err := s.Stores.Devices.CreateDevice(c.Request.Context(), dRegForm)
+	if err != nil {
+		log.Printf("Error while inserting new Device into DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	deviceID := res.InsertedID.(primitive.ObjectID).Hex()
+	after := svc.Device{}
+	s.Stores.Devices.GetDeviceByID(c.Request.Context(), deviceID).Decode(&after)
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetDevice, deviceID, after.InstID, nil, after)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Device registered Successfully",
+		"data": gin.H{
+			"_id":     deviceID,
+			"api_key": apiKey,
+		},
+	})
+	return
+}
+
+// UpdateDeviceByID - as is
+func (s *CCServer) UpdateDeviceByID(c *gin.Context) {
+	var dForm svc.DeviceEditForm
+	c.BindJSON(&dForm)
+
+	// Validation
+	if err := s.Validator.v.Struct(dForm); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			log.Println(e)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprint(e.Translate(*s.Validator.trans)),
+			})
+			return
+		}
+	}
+
+	idToUpdate := c.Param("id")
+	before := svc.Device{}
+	s.Stores.Devices.GetDeviceByID(c.Request.Context(), idToUpdate).Decode(&before)
+	res, err := s.Stores.Devices.UpdateDeviceByID(c.Request.Context(), dForm, idToUpdate)
+	if err != nil {
+		log.Printf("Error while updating Device in DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "ID To Update Device Not Found",
+		})
+		return
+	}
+
+	after := svc.Device{}
+	s.Stores.Devices.GetDeviceByID(c.Request.Context(), idToUpdate).Decode(&after)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetDevice, idToUpdate, after.InstID, before, after)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device updated Successfully",
+	})
+	return
+}
+
+// RotateDeviceAPIKey - issue a new API Key; the old one stops working immediately
+func (s *CCServer) RotateDeviceAPIKey(c *gin.Context) {
+	idToUpdate := c.Param("id")
+	before := svc.Device{}
+	s.Stores.Devices.GetDeviceByID(c.Request.Context(), idToUpdate).Decode(&before)
+	res, apiKey, err := s.Stores.Devices.RotateDeviceAPIKey(c.Request.Context(), idToUpdate)
+	if err != nil {
+		log.Printf("Error while rotating Device API Key in DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "ID To Update Device Not Found",
+		})
+		return
+	}
+
+	after := svc.Device{}
+	s.Stores.Devices.GetDeviceByID(c.Request.Context(), idToUpdate).Decode(&after)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetDevice, idToUpdate, after.InstID, before, after)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device API Key rotated Successfully",
+		"data": gin.H{
+			"_id":     idToUpdate,
+			"api_key": apiKey,
+		},
+	})
+	return
+}
+
+// DeleteDeviceByID - as is
+func (s *CCServer) DeleteDeviceByID(c *gin.Context) {
+	idToDelete := c.Param("id")
+
+	before := svc.Device{}
+	s.Stores.Devices.GetDeviceByID(c.Request.Context(), idToDelete).Decode(&before)
+	res, err := s.Stores.Devices.DeleteDeviceByID(c.Request.Context(), idToDelete)
+	if err != nil {
+		log.Printf("Error while deleting Device from DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "ID To Delete Device Not Found",
+		})
+		return
+	}
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetDevice, idToDelete, before.InstID, before, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device Deleted Successfully",
+	})
+	return
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// getActiveExclusion - the active Exclusion of a Ward, Member or Tag, and the status of its Screening Case;
+// nil when there is none. The case is consulted first: subjects whose case was cleared are not held excluded
+func (s *CCServer) getActiveExclusion(c *gin.Context, instID string, subjectID string) (*svc.Exclusion, svc.ScreeningCaseStatus, bool) {
+	exclusion := svc.Exclusion{}
+	err := s.Stores.Exclusions.GetActiveExclusion(c.Request.Context(), instID, subjectID, time.Now()).Decode(&exclusion)
+	if err == mongo.ErrNoDocuments {
+		return nil, "", true
+	}
+	if err != nil {
+		log.Printf("Error while getting Exclusion of %v - %v\n", subjectID, err)
+		respondServerError(c, err)
+		return nil, "", false
+	}
+	screeningCase, ok := s.getScreeningCaseOf(c, exclusion)
+	if !ok {
+		return nil, "", false
+	}
+	if screeningCase == nil {
+		return &exclusion, "", true
+	}
+	if screeningCase.Status == svc.ScreeningCaseCleared {
+		return nil, screeningCase.Status, true
+	}
+	return &exclusion, screeningCase.Status, true
+}
+
+// refuseExcluded - deny Check-In Scans of which any subject is excluded; those are answered as failed, with the
+// status of the Screening Case, and not applied
+func (s *CCServer) refuseExcluded(c *gin.Context, instID string, subjectIDs []string) bool {
+	for _, subjectID := range subjectIDs {
+		exclusion, caseStatus, ok := s.getActiveExclusion(c, instID, subjectID)
+		if !ok {
+			return false
+		}
+		if exclusion == nil {
+			continue
+		}
+		log.Printf("Scan denied - %v is excluded until %v\n", subjectID, exclusion.ExpiresAt)
+		if scanEvent := getScanEvent(c); scanEvent != nil {
+			scanEvent.Stage = string(svc.CCStageCheckIn)
+			scanEvent.Decision = svc.ScanDecisionExcluded
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success":     false,
+			"message":     "Scan denied - excluded after a failed screening",
+			"reason_code": "excluded",
+			"data":        s.Config.ServerAddr + surveyBaseAddr + "failed-page.html",
+			"stage":       svc.CCStageCheckIn,
+			"expires_at":  exclusion.ExpiresAt,
+			"case_status": caseStatus,
+		})
+		return false
+	}
+	return true
+}
+
+// GetManyExclusions - a page of the Exclusions of the Institution, latest first unless sorted otherwise; with
+// "active=true", only those in effect. Admins only see those of their own Institution
+func (s *CCServer) GetManyExclusions(c *gin.Context) {
+	active, _ := strconv.ParseBool(c.Query("active"))
+	params := svc.GetExclusionParams{
+		InstID:    c.Query("instID"),
+		SubjectID: c.Query("subjectID"),
+		Active:    active,
+		Now:       time.Now(),
+	}
+	if claims := getSessionClaims(c); claims != nil && claims.Role != SessionRoleSuperAdmin {
+		params.InstID = claims.InstID
+	}
+	var err error
+	if params.List, err = s.extractListParams(c); err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	cursor, err := s.Stores.Exclusions.GetManyExclusions(c.Request.Context(), &params)
+	if err != nil {
+		log.Printf("Error while getting Exclusions - %v\n", err)
+		respondListError(c, err)
+		return
+	}
+	exclusions := []svc.Exclusion{}
+	nextCursor, err := svc.DecodeList(c.Request.Context(), cursor, &exclusions)
+	if err != nil {
+		log.Printf("Error while decoding Exclusions - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "All Exclusions",
+		"data":        exclusions,
+		"next_cursor": nextCursor,
+	})
+	return
+}
+
+// ClearExclusionByID - end an Exclusion, letting its subject check in again
+func (s *CCServer) ClearExclusionByID(c *gin.Context) {
+	idToClear := c.Param("id")
+	before := svc.Exclusion{}
+	s.Stores.Exclusions.GetExclusionByID(c.Request.Context(), idToClear).Decode(&before)
+	res, err := s.Stores.Exclusions.ClearExclusion(c.Request.Context(), idToClear, getSessionSubject(c), time.Now())
+	if err != nil {
+		log.Printf("Error while clearing Exclusion in DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "ID To Clear Exclusion Not Found, or already cleared",
+		})
+		return
+	}
+
+	after := svc.Exclusion{}
+	s.Stores.Exclusions.GetExclusionByID(c.Request.Context(), idToClear).Decode(&after)
+	s.recordAudit(c, svc.AuditActionClear, svc.AuditTargetExclusion, idToClear, before.InstID, before, after)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Exclusion cleared Successfully",
+	})
+	return
+}
@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"bytes"
-	"context"
 	"encoding/csv"
 	"log"
 	"math"
@@ -35,21 +34,25 @@ func (s *CCServer) ExportManyCCRecords(c *gin.Context) {
 
 	// Get Institution
 	inst := svc.Institution{}
-	err = svc.GetInstByID(queryParams.InstID).Decode(&inst)
+	err = s.Stores.Insts.GetInstByID(c.Request.Context(), queryParams.InstID).Decode(&inst)
 
-	cursor, err := svc.GetManyCCRecords(&queryParams, inst.MemberType)
+	// Temperatures are given & rendered in the Institution's unit
+	unit := s.instTemperatureUnit(inst)
+	if queryParams.TemperatureThrd != 0 {
+		queryParams.TemperatureThrd = svc.ToCanonicalTemperature(queryParams.TemperatureThrd, unit)
+	}
+
+	cursor, err := s.Stores.CCRecords.GetManyCCRecords(c.Request.Context(), &queryParams, inst.MemberType)
 
 	if err != nil {
 		log.Printf("Error while getting all CCEvents - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
 	// Iterate through the returned cursor
 	ccRecords := []svc.CCRecord{}
-	for cursor.Next(context.TODO()) {
+	for cursor.Next(c.Request.Context()) {
 		var ccRecord svc.CCRecord
 		cursor.Decode(&ccRecord)
 		ccRecords = append(ccRecords, ccRecord)
@@ -59,7 +62,7 @@ func (s *CCServer) ExportManyCCRecords(c *gin.Context) {
 	b := &bytes.Buffer{}
 	w := csv.NewWriter(b)
 
-	firstLine := []string{"Member Name", "Group", "Temperature", "Phone #", "Drop Off At"}
+	firstLine := []string{"Member Name", "Group", "Temperature (°" + string(unit) + ")", "Phone #", "Drop Off At"}
 	// firstLine := []string{"Ward Name", "Group", "Guardian Name", "Temperature", "Phone #", "Drop Off At", "Scheduled Pickup At", "Actual Pickup At"}
 	if err := w.Write(firstLine); err != nil {
 		log.Printf("eoor writing record to csv - %v\n", err)
@@ -89,7 +92,7 @@ func (s *CCServer) ExportManyCCRecords(c *gin.Context) {
 		record = append(record, recordName)
 		record = append(record, recordGroup)
 		// record = append(record, ccRecord.CheckInEvent.GuardianInfo.Name)
-		record = append(record, strconv.FormatFloat(float64(ccRecord.Temperature), 'f', 1, 64))
+		record = append(record, strconv.FormatFloat(float64(svc.FromCanonicalTemperature(ccRecord.Temperature, unit)), 'f', 1, 64))
 		record = append(record, recordPhoneNum)
 		record = append(record, recordTime.In(time.FixedZone("BROWSER", int(offsetHours)*60*60)).Format("01/02/2006 03:04:05PM"))
 		// record = append(record, ccRecord.CheckOutScheduledAt.In(time.Now().Location()).Format("01/02/2006 03:04:05PM"))
@@ -115,24 +118,22 @@ func (s *CCServer) ExportManyMembers(c *gin.Context) {
 	instID := c.DefaultQuery("instID", "000000000000000000000000")
 	// Get Institution
 	inst := svc.Institution{}
-	err := svc.GetInstByID(instID).Decode(&inst)
+	err := s.Stores.Insts.GetInstByID(c.Request.Context(), instID).Decode(&inst)
 
 	params := svc.GetMemberParams{
 		InstID: instID,
 	}
 
-	cursor, err := svc.GetManyMembers(&params)
+	cursor, err := s.Stores.Members.GetManyMembers(c.Request.Context(), &params)
 
 	if err != nil {
 		log.Printf("Error while getting all CCEvents - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
 	members := []svc.Member{}
-	for cursor.Next(context.TODO()) {
+	for cursor.Next(c.Request.Context()) {
 		var member svc.Member
 		cursor.Decode(&member)
 		members = append(members, member)
@@ -174,7 +175,7 @@ func (s *CCServer) ExportManyMembers(c *gin.Context) {
 func (s *CCServer) ExportManyFamilies(c *gin.Context) {
 
 	var families *[]svc.Family
-	if families = getFamilies(c); families == nil {
+	if families = s.getFamilies(c); families == nil {
 		log.Println("Error when getting Failies")
 		return
 	}
@@ -212,7 +213,7 @@ func (s *CCServer) ExportManyFamilies(c *gin.Context) {
 //ExportManyWards - as is
 func (s *CCServer) ExportManyWards(c *gin.Context) {
 	var families *[]svc.Family
-	if families = getFamilies(c); families == nil {
+	if families = s.getFamilies(c); families == nil {
 		log.Println("Error when getting Failies")
 		return
 	}
@@ -259,17 +260,15 @@ func (s *CCServer) ExportManySurveys(c *gin.Context) {
 	offsetHourRaw := c.DefaultQuery("hourOffset", "-8")
 	offsetHours, _ := strconv.ParseInt(offsetHourRaw, 10, 0)
 
-	cursor, err := svc.GetManySurveys(&queryParams)
+	cursor, err := s.Stores.Surveys.GetManySurveys(c.Request.Context(), &queryParams)
 
 	if err != nil {
 		log.Printf("Error while getting all Surveys - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	surveys := []svc.Survey{}
-	if err = cursor.All(context.TODO(), &surveys); err != nil {
+	if err = cursor.All(c.Request.Context(), &surveys); err != nil {
 		panic(err)
 	}
 	if len(surveys) == 0 {
@@ -295,7 +294,7 @@ func (s *CCServer) ExportManySurveys(c *gin.Context) {
 	for _, survey := range surveys {
 		var record []string
 		member := svc.Member{}
-		err := svc.GetMemberByID(survey.MemberID).Decode(&member)
+		err := s.Stores.Members.GetMemberByID(c.Request.Context(), survey.MemberID).Decode(&member)
 		if err != nil {
 			record = append(record, []string{"", "", ""}...)
 		} else {
@@ -324,7 +323,7 @@ func (s *CCServer) ExportManySurveys(c *gin.Context) {
 	c.Data(http.StatusOK, "text/csv", b.Bytes())
 }
 
-func getFamilies(c *gin.Context) *[]svc.Family {
+func (s *CCServer) getFamilies(c *gin.Context) *[]svc.Family {
 	var queryParams svc.GetFamilyParams
 	err := extractFamilyParams(c, &queryParams)
 
@@ -338,17 +337,14 @@ func getFamilies(c *gin.Context) *[]svc.Family {
 	}
 	log.Printf("families query params - %v\n", queryParams)
 
-	cursor, err := svc.GetManyFamilies(&queryParams)
+	cursor, err := s.Stores.Families.GetManyFamilies(c.Request.Context(), &queryParams)
 	if err != nil {
 		log.Printf("Error while getting all families - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return nil
 	}
 	families := []svc.Family{}
-	if err = cursor.All(context.TODO(), &families); err != nil {
+	if err = cursor.All(c.Request.Context(), &families); err != nil {
 		panic(err)
 	}
 	return &families
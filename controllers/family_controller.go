@@ -31,27 +31,36 @@ type FamilyWithMembersResponse struct {
 func (s *CCServer) GetManyFamilies(c *gin.Context) {
 	var queryParams svc.GetFamilyParams
 	queryParams.InstID = c.DefaultQuery("instID", "000000000000000000000000")
+	queryParams.Group = c.Query("group")
+	queryParams.NamePrefix = c.Query("name")
+	err := extractDateRange(c, &queryParams.StartDate, &queryParams.EndDate)
+	if err == nil {
+		queryParams.List, err = s.extractListParams(c)
+	}
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
 
-	cursor, err := svc.GetManyFamilies(&queryParams)
+	cursor, err := s.Stores.Families.GetManyFamilies(c.Request.Context(), &queryParams)
 
 	if err != nil {
 		log.Printf("Error while getting all families - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondListError(c, err)
 		return
 	}
-	// Iterate through the returned cursor
 	families := []svc.Family{}
-	for cursor.Next(context.TODO()) {
-		var family svc.Family
-		cursor.Decode(&family)
-		families = append(families, family)
+	nextCursor, err := svc.DecodeList(c.Request.Context(), cursor, &families)
+	if err != nil {
+		log.Printf("Error while decoding families - %v\n", err)
+		respondServerError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "All Families",
-		"data":    families,
+		"message":     "All Families",
+		"data":        families,
+		"next_cursor": nextCursor,
 	})
 
 	return
@@ -63,7 +72,7 @@ func (s *CCServer) GetFamilyWithMembersByID(c *gin.Context) {
 
 	// Get Family By ID
 	family := svc.Family{}
-	err := svc.GetFamilyByID(id).Decode(&family)
+	err := s.Stores.Families.GetFamilyByID(c.Request.Context(), id).Decode(&family)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			c.JSON(http.StatusForbidden, gin.H{
@@ -72,9 +81,7 @@ func (s *CCServer) GetFamilyWithMembersByID(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while Getting Family by ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -82,10 +89,10 @@ func (s *CCServer) GetFamilyWithMembersByID(c *gin.Context) {
 	params := svc.GetMemberParams{
 		FamilyID: family.ID.Hex(),
 	}
-	cursor, err := svc.GetManyMembers(&params)
+	cursor, err := s.Stores.Members.GetManyMembers(c.Request.Context(), &params)
 
 	members := []svc.Member{}
-	if err = cursor.All(context.TODO(), &members); err != nil {
+	if err = cursor.All(c.Request.Context(), &members); err != nil {
 		panic(err)
 	}
 
@@ -120,7 +127,7 @@ func (s *CCServer) GetFamilyWithMembers(c *gin.Context) {
 	}
 	// Get Family By WardID
 	family := svc.Family{}
-	err := svc.GetFamilyByWardID(wardID).Decode(&family)
+	err := s.Stores.Families.GetFamilyByWardID(c.Request.Context(), wardID).Decode(&family)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			c.JSON(http.StatusForbidden, gin.H{
@@ -129,9 +136,7 @@ func (s *CCServer) GetFamilyWithMembers(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while Getting Family by wardID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -139,10 +144,10 @@ func (s *CCServer) GetFamilyWithMembers(c *gin.Context) {
 	params := svc.GetMemberParams{
 		FamilyID: family.ID.Hex(),
 	}
-	cursor, err := svc.GetManyMembers(&params)
+	cursor, err := s.Stores.Members.GetManyMembers(c.Request.Context(), &params)
 
 	members := []svc.Member{}
-	if err = cursor.All(context.TODO(), &members); err != nil {
+	if err = cursor.All(c.Request.Context(), &members); err != nil {
 		panic(err)
 	}
 
@@ -200,20 +205,6 @@ func (s *CCServer) CreateFamily(c *gin.Context) {
 		}
 	}
 
-	// Check if the phone number exists
-	for _, mInFamilyRegForm := range fRegForm.Members {
-		count, err := svc.CountMembersByPhoneNum(mInFamilyRegForm.PhoneNum)
-		if err != nil {
-			log.Printf("Error while finding Member by PhoneNum - %v\n", err)
-		}
-		if count > 0 {
-			c.JSON(http.StatusForbidden, gin.H{
-				"message": "Phone # has been used! Try another one",
-			})
-			return
-		}
-	}
-
 	// // Preparing for Creating Family
 	// wardsToCreate := []svc.Ward{}
 	// vehiclesToCreate := []svc.Vehicle{}
@@ -228,7 +219,7 @@ func (s *CCServer) CreateFamily(c *gin.Context) {
 
 	// // Create Family, but without family info
 	// cMemberInFamilyRegForm := fRegForm.Members[0]
-	// fRes, err := svc.CreateFamily(fRegForm, cMemberInFamilyRegForm, wardsToCreate, vehiclesToCreate)
+	// fRes, err := s.Stores.Families.CreateFamily(c.Request.Context(), fRegForm, cMemberInFamilyRegForm, wardsToCreate, vehiclesToCreate)
 	// if err != nil {
 	// 	log.Printf("Error while inserting new Family into DB - %v\n", err)
 	// 	c.JSON(http.StatusInternalServerError, gin.H{
@@ -237,53 +228,64 @@ func (s *CCServer) CreateFamily(c *gin.Context) {
 	// 	return
 	// }
 
-	insertedFamilyID, ok := handleCreateFamily(c, &fRegForm)
-	if !ok {
-		return
-	}
-
-	// Create Members with FamilyID
-	// insertedFamilyID := fRes.InsertedID.(primitive.ObjectID).Hex()
-	var contactMemberID string
-	for index, mInFamilyRegForm := range fRegForm.Members {
-		fInfo := svc.FamilyInfo{
-			ID:       insertedFamilyID,
-			Relation: mInFamilyRegForm.Relation,
-		}
-		mRegForm := svc.MemberRegForm{
-			InstID:     fRegForm.InstID,
-			FamilyInfo: &fInfo,
-			PhoneNum:   mInFamilyRegForm.PhoneNum,
-			Email:      mInFamilyRegForm.Email,
-			FirstName:  mInFamilyRegForm.FirstName,
-			LastName:   mInFamilyRegForm.LastName,
+	// Create the Family & its Members all or nothing; a Phone # already used fails the insert of its
+	// Member on the unique index, undoing what was created before it
+	var insertedFamilyID string
+	var memberIDs []string
+	err = s.Stores.Tx.RunInTx(c.Request.Context(), func(ctx context.Context, tx *svc.Tx) error {
+		memberIDs = nil
+		var err error
+		insertedFamilyID, err = s.createFamily(ctx, tx, &fRegForm)
+		if err != nil {
+			return err
 		}
-		insertedMemberID, ok := handleCreateMember(c, &mRegForm)
-		if !ok {
-			return
+
+		// Create Members with FamilyID
+		for _, mInFamilyRegForm := range fRegForm.Members {
+			fInfo := svc.FamilyInfo{
+				ID:       insertedFamilyID,
+				Relation: mInFamilyRegForm.Relation,
+			}
+			mRegForm := svc.MemberRegForm{
+				InstID:     fRegForm.InstID,
+				FamilyInfo: &fInfo,
+				PhoneNum:   mInFamilyRegForm.PhoneNum,
+				Email:      mInFamilyRegForm.Email,
+				FirstName:  mInFamilyRegForm.FirstName,
+				LastName:   mInFamilyRegForm.LastName,
+			}
+			insertedMemberID, err := s.createMember(ctx, tx, mRegForm)
+			if err != nil {
+				return err
+			}
+			memberIDs = append(memberIDs, insertedMemberID)
 		}
+
+		// Set Contact Member ID to Family
+		_, err = s.Stores.Families.SetFamilyContactMemberID(ctx, insertedFamilyID, memberIDs[0])
 		if err != nil {
-			log.Printf("Error while inserting new Member into DB - %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "Something went wrong",
-			})
-			return
-		}
-		if index == 0 {
-			contactMemberID = insertedMemberID
+			log.Printf("Error while setting Contact MemberID to Family - %v\n", err)
 		}
+		return err
+	})
+	if svc.IsDuplicateKeyErr(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Phone # has been used! Try another one",
+		})
+		return
 	}
-
-	// Set Contact Member ID to Family
-	_, err = svc.SetFamilyContactMemberID(insertedFamilyID, contactMemberID)
 	if err != nil {
-		log.Printf("Error while setting Contact MemberID to Family - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
+	after := svc.Family{}
+	s.Stores.Families.GetFamilyByID(c.Request.Context(), insertedFamilyID).Decode(&after)
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetFamily, insertedFamilyID, after.InstID, nil, after)
+	for _, memberID := range memberIDs {
+		s.recordMemberCreated(c, memberID)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Family Registered Successfully",
 	})
@@ -294,15 +296,16 @@ func (s *CCServer) CreateFamily(c *gin.Context) {
 func (s *CCServer) DeleteFamilyByID(c *gin.Context) {
 	idToDelete := c.Param("id")
 
-	_, err := svc.DeleteFamilyByID(idToDelete)
+	before := svc.Family{}
+	s.Stores.Families.GetFamilyByID(c.Request.Context(), idToDelete).Decode(&before)
+	_, err := s.Stores.Families.DeleteFamilyByID(c.Request.Context(), idToDelete)
 
 	if err != nil {
 		log.Printf("Error while deleting Family in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetFamily, idToDelete, before.InstID, before, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Family deleted Successfully",
@@ -312,12 +315,10 @@ func (s *CCServer) DeleteFamilyByID(c *gin.Context) {
 
 func (s *CCServer) getFamilyByMemberID(c *gin.Context, memberID string) {
 	var family svc.Family
-	err := svc.GetFamilyByMemberID(memberID).Decode(&family)
+	err := s.Stores.Families.GetFamilyByMemberID(c.Request.Context(), memberID).Decode(&family)
 	if err != nil {
 		log.Printf("Error while Getting Family by Member ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -330,12 +331,10 @@ func (s *CCServer) getFamilyByMemberID(c *gin.Context, memberID string) {
 // GetFamilyByWardID - as is
 func (s *CCServer) getFamilyByWardID(c *gin.Context, wardID string) {
 	family := svc.Family{}
-	err := svc.GetFamilyByWardID(wardID).Decode(&family)
+	err := s.Stores.Families.GetFamilyByWardID(c.Request.Context(), wardID).Decode(&family)
 	if err != nil {
 		log.Printf("Error while Getting Family by wardID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -345,7 +344,8 @@ func (s *CCServer) getFamilyByWardID(c *gin.Context, wardID string) {
 	return
 }
 
-func handleCreateFamily(c *gin.Context, fRegForm *svc.FamilyRegForm) (string, bool) {
+// createFamily - create a Family, without its Contact Member ID, within "tx"; returns the Family ID
+func (s *CCServer) createFamily(ctx context.Context, tx *svc.Tx, fRegForm *svc.FamilyRegForm) (string, error) {
 
 	// Preparing for Creating Family
 	wardsToCreate := []svc.Ward{}
@@ -361,13 +361,15 @@ func handleCreateFamily(c *gin.Context, fRegForm *svc.FamilyRegForm) (string, bo
 
 	// Create Family, but without family info
 	cMemberInFamilyRegForm := fRegForm.Members[0]
-	fRes, err := svc.CreateFamily(*fRegForm, cMemberInFamilyRegForm, wardsToCreate, vehiclesToCreate)
+	fRes, err := s.Stores.Families.CreateFamily(ctx, *fRegForm, cMemberInFamilyRegForm, wardsToCreate, vehiclesToCreate)
 	if err != nil {
 		log.Printf("Error while inserting new Family into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
-		return "", false
+		return "", err
 	}
-	return fRes.InsertedID.(primitive.ObjectID).Hex(), true
+	familyID := fRes.InsertedID.(primitive.ObjectID).Hex()
+	tx.OnRollback(func(ctx context.Context) error {
+		_, err := s.Stores.Families.DeleteFamilyByID(ctx, familyID)
+		return err
+	})
+	return familyID, nil
 }
@@ -4,7 +4,6 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	svc "cloudminds.com/harix/cc-server/services"
@@ -15,48 +14,123 @@ import (
 // HandleCCScanEvent - Accepts Gatekeeper Scan Postings, and Process it in one of three modes ["guardian", "standard" or "tag"]
 func (s *CCServer) HandleCCScanEvent(c *gin.Context) {
 
-	var tempThrd = s.Config.TempThrd
 	// Parse Posting Form
 	var sPostingForm svc.ScanPostingForm
 	// c.BindJSON(&sPostingForm)
 	sPostingForm.ScanResult = c.PostForm("unique_transaction_id")
 	temperature, _ := strconv.ParseFloat(c.PostForm("temperature"), 32)
-	sPostingForm.Temperature = float32(temperature)
+	sPostingForm.ReportedTemperature = float32(temperature)
+	sPostingForm.ReportedUnit = scanTemperatureUnit(c)
 	scanType, _ := strconv.ParseInt(c.PostForm("scan_type"), 10, 0)
 	sPostingForm.ScanType = svc.CCScanType(int(scanType))
 	mask, _ := strconv.ParseBool(c.PostForm("mask"))
 	sPostingForm.Mask = mask
 	sPostingForm.DeviceID = c.PostForm("device_id")
 	log.Printf("CCRecordForm is - %v\n", sPostingForm)
-	sResultContent := parseScanResult(sPostingForm.ScanResult)
+	scanEvent := startScanEvent(c, svc.ScanEvent{
+		Source:      svc.ScanEventSourceGatekeeper,
+		DeviceID:    sPostingForm.DeviceID,
+		RawPayload:  sPostingForm.ScanResult,
+		Temperature: sPostingForm.ReportedTemperature,
+		Unit:        sPostingForm.ReportedUnit,
+		Mask:        sPostingForm.Mask,
+		ScanType:    sPostingForm.ScanType,
+	})
+	defer s.recordScanEvent(c, scanEvent)
+	if !svc.IsValidTemperatureUnit(sPostingForm.ReportedUnit) {
+		log.Printf("Scan rejected - Unknown Temperature Unit - %v\n", sPostingForm.ReportedUnit)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success":     false,
+			"message":     "Unknown Temperature Unit",
+			"reason_code": "bad_temperature_unit",
+		})
+		return
+	}
+	sPostingForm.Temperature = svc.ToCanonicalTemperature(sPostingForm.ReportedTemperature, sPostingForm.ReportedUnit)
+	payload, signature := splitScanSignature(sPostingForm.ScanResult)
+	sResultContent, err := ParseScanPayload(payload)
+	scanEvent.ParseResult = newScanParseResult(sResultContent, err)
+	if err != nil {
+		log.Printf("Scan rejected - Unrecognized Scan Payload - %v\n", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success":     false,
+			"message":     "Unrecognized Scan Payload",
+			"reason_code": scanPayloadReason(err),
+		})
+		return
+	}
+	var inst svc.Institution
+	var workflow svc.CCWorkflow
+	var member svc.Member
+	var ok bool
+	if sResultContent.Type != ScanResultTagType {
+		if ok := s.verifyScanPayload(c, payload, signature, sResultContent); !ok {
+			return
+		}
+		err := s.Stores.Members.GetMemberByID(c.Request.Context(), sResultContent.MemberTagID).Decode(&member)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				rejectScanPayload(c, "Scan Payload is not valid")
+				return
+			}
+			log.Printf("Error while Getting Member By ID - %v\n", err)
+			respondServerError(c, err)
+			return
+		}
+		if ok := checkScanDeviceInst(c, member.InstID); !ok {
+			return
+		}
+		if ok := s.checkGuardianScanWard(c, sResultContent, member); !ok {
+			return
+		}
+		scanEvent.Group = member.Group
+		if inst, ok = s.getInst(c, member.InstID); !ok {
+			return
+		}
+		if workflow, ok = getInstWorkflow(c, inst); !ok {
+			return
+		}
+	}
 
-	// Get Stage Param
+	// Get Stage Param - the Status the Workflow needs the Record in; Tag Scans find it from their Record
 	stage := sResultContent.Stage
 	var statusParam int = -1
-	if stage == "checkin" {
-		statusParam = int(svc.CCrInit)
-	} else if stage == "checkout" {
-		if sResultContent.Type == ScanResultGWType {
-			statusParam = int(svc.CCrScheduleComplete)
-		} else {
-			statusParam = int(svc.CCrCheckInComplete)
+	if sResultContent.Type != ScanResultTagType {
+		from, err := workflow.From(scanMemberType(sResultContent.Type), svc.CCStage(stage))
+		if ok := checkTransition(c, err); !ok {
+			return
 		}
+		statusParam = int(from)
 	}
 
-	// Check if Failed
-	var isScanFailed = false
-	if sPostingForm.Temperature > s.Config.TempThrd {
-		isScanFailed = true
+	// Refuse Check-Ins of those excluded after a failed screening; Tag Scans are refused before a Record is made
+	if sResultContent.Type != ScanResultTagType && svc.CCStage(stage) == svc.CCStageCheckIn {
+		subjectIDs, ok := s.scanSubjectIDs(c, sResultContent, member)
+		if !ok {
+			return
+		}
+		if ok := s.refuseExcluded(c, inst.ID.Hex(), subjectIDs); !ok {
+			return
+		}
+	}
+
+	// Screen by the Institution's Policy; Tag Scans are screened once their Stage is found
+	var policy svc.ScreeningPolicy
+	var screening svc.Screening
+	if sResultContent.Type != ScanResultTagType {
+		policy = s.instScreeningPolicy(inst)
+		if screening, ok = s.screenScan(c, policy, svc.CCStage(stage), sPostingForm); !ok {
+			return
+		}
 	}
 
-	var ok bool
 	var tagStage string
 	if sResultContent.Type == ScanResultGWType {
-		ok = s.handleCCScanGuardianEvent(c, sPostingForm, sResultContent, statusParam, isScanFailed)
+		ok = s.handleCCScanGuardianEvent(c, sPostingForm, sResultContent, workflow.Type, statusParam, policy)
 	} else if sResultContent.Type == ScanResultMemberType {
-		ok = s.handleCCScanMemberEvent(c, sPostingForm, sResultContent, statusParam, isScanFailed)
+		ok = s.handleCCScanMemberEvent(c, sPostingForm, sResultContent, workflow.Type, statusParam, policy)
 	} else if sResultContent.Type == ScanResultTagType {
-		ok, tagStage = s.handleCCScanTagEvent(c, sPostingForm, sResultContent, isScanFailed)
+		ok, tagStage, screening = s.handleCCScanTagEvent(c, sPostingForm, sResultContent)
 	}
 	if !ok {
 		return
@@ -79,41 +153,43 @@ func (s *CCServer) HandleCCScanEvent(c *gin.Context) {
 	}
 
 	if responseStage == "checkin" {
-		if sPostingForm.Temperature < tempThrd {
+		if screening.Passed {
 			// TODO - generate a url with guardianID
 			log.Println("Checkin Scan Received, returning Success & Survey URL")
-			c.JSON(http.StatusOK, gin.H{
+			c.JSON(http.StatusOK, withScreeningWarnings(gin.H{
 				"success": true,
 				"data":    s.Config.ServerAddr + surveyBaseAddr + "succeed-page.html",
 				// "data":  s.Config.ServerAddr + surveyBaseAddr + "check-in-survey.html",
 				"stage": responseStage,
-			})
+			}, screening))
 			return
 		}
-		if sPostingForm.Temperature >= tempThrd {
+		if !screening.Passed {
 			log.Println("Checkin Scan Received, returning Failed")
 			c.JSON(http.StatusOK, gin.H{
 				"success": false,
 				"data":    s.Config.ServerAddr + surveyBaseAddr + "failed-page.html",
 				"stage":   responseStage,
+				"reasons": screening.Reasons,
 			})
 			return
 		}
 	}
 	if responseStage == "checkout" {
-		if !s.Config.RequireCheckOutTemp || sPostingForm.Temperature < tempThrd {
+		if screening.Passed {
 			log.Println("CheckOut Scan Received, returning Success")
-			c.JSON(http.StatusOK, gin.H{
+			c.JSON(http.StatusOK, withScreeningWarnings(gin.H{
 				"success": true,
 				"stage":   responseStage,
-			})
+			}, screening))
 			return
 		}
-		if sPostingForm.Temperature > tempThrd {
+		if !screening.Passed {
 			log.Println("CheckOut Scan Received, returning Failed")
 			c.JSON(http.StatusOK, gin.H{
 				"success": false,
 				"stage":   responseStage,
+				"reasons": screening.Reasons,
 			})
 			return
 		}
@@ -121,17 +197,15 @@ func (s *CCServer) HandleCCScanEvent(c *gin.Context) {
 }
 
 func (s *CCServer) handleCCScanGuardianEvent(c *gin.Context,
-	sPostingForm svc.ScanPostingForm, sResultContent *parsedScanResult, statusParam int, scanFailed bool) bool {
+	sPostingForm svc.ScanPostingForm, sResultContent *ScanPayload, workflow svc.WorkflowType, statusParam int, policy svc.ScreeningPolicy) bool {
 	// "scanResultContent" contains "MemberID|WardID|checkin/out|single/all|timestamp"
 
 	// Get Member
 	memberToUpdate := svc.Member{}
-	err := svc.GetMemberByID(sResultContent.MemberTagID).Decode(&memberToUpdate)
+	err := s.Stores.Members.GetMemberByID(c.Request.Context(), sResultContent.MemberTagID).Decode(&memberToUpdate)
 	if err != nil {
 		log.Printf("Error while Getting Member By ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
 
@@ -144,37 +218,39 @@ func (s *CCServer) handleCCScanGuardianEvent(c *gin.Context,
 		Group:    memberToUpdate.Group,
 	}
 	gEventToAdd := svc.GuardianEvent{
-		IsSingleEvent: sResultContent.isSingleEvent,
-		GuardianInfo:  gInfo,
-		ScanType:      sPostingForm.ScanType,
-		DeviceID:      sPostingForm.DeviceID,
-		Temperature:   sPostingForm.Temperature,
-		Mask:          sPostingForm.Mask,
-		Time:          sResultContent.Time,
+		IsSingleEvent:       sResultContent.IsSingleEvent,
+		GuardianInfo:        gInfo,
+		ScanType:            sPostingForm.ScanType,
+		DeviceID:            sPostingForm.DeviceID,
+		Temperature:         sPostingForm.Temperature,
+		ReportedTemperature: sPostingForm.ReportedTemperature,
+		ReportedUnit:        sPostingForm.ReportedUnit,
+		Mask:                sPostingForm.Mask,
+		Time:                sResultContent.Time,
 	}
 	newEventData := svc.NewEventData{
 		GuardianEvent: &gEventToAdd,
 		Stage:         sResultContent.Stage,
-		IsScanFailed:  scanFailed,
+		Policy:        &policy,
+		Workflow:      workflow,
+		MemberType:    svc.MemberTypeGuardian,
 	}
 
-	if sResultContent.isSingleEvent {
+	if sResultContent.IsSingleEvent {
 		//Single Scan Event
 		ccParams := svc.GetCCRecordParams{
 			WardID: sResultContent.WardID,
 			Status: statusParam,
 		}
-		return getAndUpdateCCRecordWithEvent(c, ccParams, newEventData)
+		return s.getAndUpdateCCRecordWithEvent(c, ccParams, newEventData)
 	}
 	//Family Scan Event
 	// Get Family
 	familyToUpdate := svc.Family{}
-	err = svc.GetFamilyByID(memberToUpdate.FamilyInfo.ID).Decode(&familyToUpdate)
+	err = s.Stores.Families.GetFamilyByID(c.Request.Context(), memberToUpdate.FamilyInfo.ID).Decode(&familyToUpdate)
 	if err != nil {
 		log.Printf("Error while Getting Family By ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return false
 	}
 	// Update CCRecords
@@ -183,7 +259,7 @@ func (s *CCServer) handleCCScanGuardianEvent(c *gin.Context,
 			WardID: ward.ID.Hex(),
 			Status: statusParam,
 		}
-		if ok := getAndUpdateCCRecordWithEvent(c, ccParams, newEventData); !ok {
+		if ok := s.getAndUpdateCCRecordWithEvent(c, ccParams, newEventData); !ok {
 			return false
 		}
 	}
@@ -191,21 +267,25 @@ func (s *CCServer) handleCCScanGuardianEvent(c *gin.Context,
 }
 
 func (s *CCServer) handleCCScanMemberEvent(c *gin.Context,
-	sPostingForm svc.ScanPostingForm, sResultContent *parsedScanResult, statusParam int, isScanFailed bool) bool {
+	sPostingForm svc.ScanPostingForm, sResultContent *ScanPayload, workflow svc.WorkflowType, statusParam int, policy svc.ScreeningPolicy) bool {
 	// "scanResultContent" contains "MemberID|checkin/out|timestamp"
 
 	// Make EventData
 	mEventToAdd := svc.MemberTagEvent{
-		ScanType:    sPostingForm.ScanType,
-		DeviceID:    sPostingForm.DeviceID,
-		Temperature: sPostingForm.Temperature,
-		Mask:        sPostingForm.Mask,
-		Time:        sResultContent.Time,
+		ScanType:            sPostingForm.ScanType,
+		DeviceID:            sPostingForm.DeviceID,
+		Temperature:         sPostingForm.Temperature,
+		ReportedTemperature: sPostingForm.ReportedTemperature,
+		ReportedUnit:        sPostingForm.ReportedUnit,
+		Mask:                sPostingForm.Mask,
+		Time:                sResultContent.Time,
 	}
 	newEventData := svc.NewEventData{
 		MemberTagEvent: &mEventToAdd,
 		Stage:          sResultContent.Stage,
-		IsScanFailed:   isScanFailed,
+		Policy:         &policy,
+		Workflow:       workflow,
+		MemberType:     svc.MemberTypeStandard,
 	}
 
 	// Get CCRecord
@@ -215,15 +295,15 @@ func (s *CCServer) handleCCScanMemberEvent(c *gin.Context,
 	}
 
 	log.Printf("handleCCScanMemberEvent - getCCRecordParams: %v\n", params)
-	return getAndUpdateCCRecordWithEvent(c, params, newEventData)
+	return s.getAndUpdateCCRecordWithEvent(c, params, newEventData)
 }
 
 func (s *CCServer) handleCCScanTagEvent(c *gin.Context,
-	sPostingForm svc.ScanPostingForm, sResultContent *parsedScanResult, isScanFailed bool) (bool, string) {
+	sPostingForm svc.ScanPostingForm, sResultContent *ScanPayload) (bool, string, svc.Screening) {
 	// "scanResultContent" contains ONLY a "TagString" param
 	//// Get Institution
 	inst := svc.Institution{}
-	err := svc.GetInstByIdentifier(sResultContent.InstIdentifier).Decode(&inst)
+	err := s.Stores.Insts.GetInstByIdentifier(c.Request.Context(), sResultContent.InstIdentifier).Decode(&inst)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// If Institution does not exist, abort
@@ -231,15 +311,17 @@ func (s *CCServer) handleCCScanTagEvent(c *gin.Context,
 			c.JSON(http.StatusForbidden, gin.H{
 				"message": "No Institution maching the Identifier! Tag Scan Failed.",
 			})
-			return false, ""
+			return false, "", svc.Screening{}
 
 		}
 		log.Printf("Error while getting Institution by Identifier - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
-		return false, ""
+		respondServerError(c, err)
+		return false, "", svc.Screening{}
+
+	}
 
+	if ok := checkScanDeviceInst(c, inst.ID.Hex()); !ok {
+		return false, "", svc.Screening{}
 	}
 
 	//// Get Tag
@@ -248,18 +330,20 @@ func (s *CCServer) handleCCScanTagEvent(c *gin.Context,
 		TagString: sResultContent.MemberTagID,
 	}
 
-	tagToProcess, ok := getOrCreateTag(c, &tParams)
+	tagToProcess, ok := s.getOrCreateTag(c, &tParams)
 	if !ok {
-		return false, ""
+		return false, "", svc.Screening{}
+	}
+	if scanEvent := getScanEvent(c); scanEvent != nil {
+		scanEvent.Group = tagToProcess.Group
 	}
 
 	//// Determine Status to Exclude
-	var excludeStatusList []int
-	if inst.WorkflowType == svc.WorkflowTypeCC {
-		excludeStatusList = []int{int(svc.CCrCheckOutComplete), int(svc.CCrFailed)}
-	} else if inst.WorkflowType == svc.WorkflowTypeCheckIn {
-		excludeStatusList = []int{int(svc.CCrCheckInComplete), int(svc.CCrFailed)}
+	workflow, ok := getInstWorkflow(c, inst)
+	if !ok {
+		return false, "", svc.Screening{}
 	}
+	excludeStatusList := workflow.FinalStatuses()
 
 	//// Get OR Create CCRecord & Determine Stage
 	tagID := sResultContent.MemberTagID
@@ -272,49 +356,58 @@ func (s *CCServer) handleCCScanTagEvent(c *gin.Context,
 	log.Printf("getCCRecordParams - %v\n", ccParams)
 
 	ccRecord := svc.CCRecord{}
-	var stage string
-	var statusParam int
-	err = svc.GetCCRecord(&ccParams).Decode(&ccRecord)
+	err = s.Stores.CCRecords.GetCCRecord(c.Request.Context(), &ccParams).Decode(&ccRecord)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			// When no CCRecord Found, Create a New One and return
-			if ok := createCCRecordTByTag(c, *tagToProcess); !ok {
-				return false, ""
+			// When no CCRecord Found, Create a New One and return; unless the Tag is excluded from Check-Ins
+			if ok := s.refuseExcluded(c, inst.ID.Hex(), []string{tagID}); !ok {
+				return false, "", svc.Screening{}
+			}
+			if ok := s.createCCRecordTByTag(c, *tagToProcess); !ok {
+				return false, "", svc.Screening{}
 			}
-			stage = "checkin"
-			statusParam = int(svc.CCrInit)
+			ccRecord.Status = svc.CCrInit
 		} else {
 			log.Printf("Error while getting CCRecord - %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "Something went wrong",
-			})
-			return false, ""
+			respondServerError(c, err)
+			return false, "", svc.Screening{}
 
 		}
 	} else {
-		// If CCRecord already exists, figure out the next stage for the record
 		log.Printf("record found: - %v\n", ccRecord)
-		if ccRecord.Status == svc.CCrInit {
-			stage = "checkin"
-			statusParam = int(svc.CCrInit)
-		} else if ccRecord.Status == svc.CCrCheckInComplete {
-			stage = "checkout"
-			statusParam = int(svc.CCrCheckInComplete)
-		}
+	}
+	// Figure out the next stage for the record; none leaves a final Status
+	nextStage, ok := workflow.StageAfter(svc.MemberTypeTag, ccRecord.Status)
+	if !ok {
+		checkTransition(c, &svc.InvalidTransitionError{Workflow: workflow.Type, MemberType: svc.MemberTypeTag, From: ccRecord.Status})
+		return false, "", svc.Screening{}
+	}
+	stage := string(nextStage)
+	statusParam := int(ccRecord.Status)
+
+	// Screen by the Institution's Policy, now that the Stage is known
+	policy := s.instScreeningPolicy(inst)
+	screening, ok := s.screenScan(c, policy, nextStage, sPostingForm)
+	if !ok {
+		return false, "", svc.Screening{}
 	}
 
 	// Make EventData
 	mEventToAdd := svc.MemberTagEvent{
-		ScanType:    sPostingForm.ScanType,
-		DeviceID:    sPostingForm.DeviceID,
-		Temperature: sPostingForm.Temperature,
-		Mask:        sPostingForm.Mask,
-		Time:        time.Now(),
+		ScanType:            sPostingForm.ScanType,
+		DeviceID:            sPostingForm.DeviceID,
+		Temperature:         sPostingForm.Temperature,
+		ReportedTemperature: sPostingForm.ReportedTemperature,
+		ReportedUnit:        sPostingForm.ReportedUnit,
+		Mask:                sPostingForm.Mask,
+		Time:                time.Now(),
 	}
 	newEventData := svc.NewEventData{
 		MemberTagEvent: &mEventToAdd,
 		Stage:          stage,
-		IsScanFailed:   isScanFailed,
+		Policy:         &policy,
+		Workflow:       workflow.Type,
+		MemberType:     svc.MemberTypeTag,
 	}
 
 	// Get CCRecord
@@ -324,78 +417,95 @@ func (s *CCServer) handleCCScanTagEvent(c *gin.Context,
 		Status:      statusParam,
 	}
 	log.Printf("getAndUpdateCCRecordParams - %v\n", ccParams)
-	return getAndUpdateCCRecordWithEvent(c, ccParams, newEventData), stage
+	return s.getAndUpdateCCRecordWithEvent(c, ccParams, newEventData), stage, screening
 
 }
 
-// ScanResultType - as is
-type ScanResultType int
-
-// ScanResultType Enum Defs
-const (
-	ScanResultGWType     ScanResultType = 1
-	ScanResultMemberType                = 2
-	ScanResultTagType                   = 3
-)
-
-type parsedScanResult struct {
-	InstIdentifier string
-	MemberTagID    string
-	WardID         string
-	Stage          string
-	isSingleEvent  bool
-	Time           time.Time
-	Type           ScanResultType
+// checkGuardianScanWard - refuse single-Ward Guardian Scans of a Ward not of the Guardian's Family; the signature
+// only proves who the Guardian is, not that the Ward is theirs
+func (s *CCServer) checkGuardianScanWard(c *gin.Context, sResultContent *ScanPayload, member svc.Member) bool {
+	if sResultContent.Type != ScanResultGWType || !sResultContent.IsSingleEvent {
+		return true
+	}
+	if member.FamilyInfo == nil {
+		return rejectScanPayload(c, "Ward is not of the Guardian's Family")
+	}
+	family := svc.Family{}
+	err := s.Stores.Families.GetFamilyByID(c.Request.Context(), member.FamilyInfo.ID).Decode(&family)
+	if err == mongo.ErrNoDocuments {
+		return rejectScanPayload(c, "Ward is not of the Guardian's Family")
+	}
+	if err != nil {
+		log.Printf("Error while Getting Family By ID - %v\n", err)
+		respondServerError(c, err)
+		return false
+	}
+	for _, ward := range family.Wards {
+		if ward.ID.Hex() == sResultContent.WardID {
+			return true
+		}
+	}
+	return rejectScanPayload(c, "Ward is not of the Guardian's Family")
 }
 
-func parseScanResult(s string) *parsedScanResult {
-	contents := strings.Split(s, "|")
-	timestamp, _ := strconv.ParseInt(contents[len(contents)-1], 10, 64)
-	scanTime := time.Unix(timestamp/1000, 0)
-	// log.Println("scanned timestamp - ", timestamp)
-
-	// Member Case
-	if len(contents) == 3 {
-		return &parsedScanResult{
-			MemberTagID: contents[0],
-			Stage:       contents[1],
-			Time:        scanTime,
-			Type:        ScanResultMemberType,
-		}
+// scanSubjectIDs - the Member, or the Ward(s), a Member or Guardian Scan is of
+func (s *CCServer) scanSubjectIDs(c *gin.Context, sResultContent *ScanPayload, member svc.Member) ([]string, bool) {
+	if sResultContent.Type == ScanResultMemberType {
+		return []string{sResultContent.MemberTagID}, true
+	}
+	if sResultContent.IsSingleEvent {
+		return []string{sResultContent.WardID}, true
+	}
+	family := svc.Family{}
+	err := s.Stores.Families.GetFamilyByID(c.Request.Context(), member.FamilyInfo.ID).Decode(&family)
+	if err != nil {
+		log.Printf("Error while Getting Family By ID - %v\n", err)
+		respondServerError(c, err)
+		return nil, false
+	}
+	wardIDs := []string{}
+	for _, ward := range family.Wards {
+		wardIDs = append(wardIDs, ward.ID.Hex())
 	}
+	return wardIDs, true
+}
 
-	// Tag Case
-	// If [length of Identifier] < 24, regard it as Tag case
-	if len(contents) == 4 && len(contents[0]) < 24 {
-		return &parsedScanResult{
-			InstIdentifier: contents[0],
-			MemberTagID:    contents[1],
-			Time:           scanTime,
-			Type:           ScanResultTagType,
-		}
+// scanTemperatureUnit - the unit a Scan declares its reading in, or else the one of its Gatekeeper;
+// readings of neither are canonical
+func scanTemperatureUnit(c *gin.Context) svc.TemperatureUnit {
+	if unit := c.PostForm("temperature_unit"); len(unit) > 0 {
+		return svc.TemperatureUnit(unit)
+	}
+	if device := getScanDevice(c); device != nil && len(device.TemperatureUnit) > 0 {
+		return device.TemperatureUnit
 	}
+	return svc.CanonicalTemperatureUnit
+}
 
-	// GW Case - All
-	if len(contents) == 4 && contents[2] == "all" {
-		return &parsedScanResult{
-			MemberTagID:   contents[0],
-			Stage:         contents[1],
-			isSingleEvent: false,
-			Time:          scanTime,
-			Type:          ScanResultGWType,
-		}
+// withScreeningWarnings - add the warnings of a Scan that passed, e.g. of a missing mask, to its response
+func withScreeningWarnings(res gin.H, screening svc.Screening) gin.H {
+	if len(screening.Warnings) > 0 {
+		res["warnings"] = screening.Warnings
 	}
-	// GW Case - Single
-	if len(contents) == 5 && contents[3] == "single" {
-		return &parsedScanResult{
-			MemberTagID:   contents[0],
-			WardID:        contents[1],
-			Stage:         contents[2],
-			isSingleEvent: true,
-			Time:          scanTime,
-			Type:          ScanResultGWType,
-		}
+	return res
+}
+
+// scanMemberType - the kind of Record a Scan is of
+func scanMemberType(scanType ScanResultType) svc.MemberType {
+	switch scanType {
+	case ScanResultGWType:
+		return svc.MemberTypeGuardian
+	case ScanResultTagType:
+		return svc.MemberTypeTag
 	}
+	return svc.MemberTypeStandard
+}
 
-	return nil
+// checkScanDeviceInst - Gatekeepers only accept Scans of the Institution they are registered to
+func checkScanDeviceInst(c *gin.Context, instID string) bool {
+	device := getScanDevice(c)
+	if device == nil || device.InstID != instID {
+		return rejectScanPayload(c, "Device is not registered to the Institution")
+	}
+	return true
 }
@@ -29,6 +29,7 @@ func (s *CCServer) ImportManyTags(c *gin.Context) {
 	// TODO - Update/Merge the Existing Tag with Form Data
 	r := csv.NewReader(file)
 	count := 0
+	imported := 0
 	for {
 		record, err := r.Read()
 		if count == 0 {
@@ -45,17 +46,6 @@ func (s *CCServer) ImportManyTags(c *gin.Context) {
 			log.Panic("record fields are too few!")
 		}
 
-		// Check if Tag already exists
-		countTagParams := svc.CountTagParams{
-			TagString: record[0],
-			InstID:    instID,
-		}
-		tagCount, _ := svc.CountTag(countTagParams)
-		if tagCount > 0 {
-			log.Printf("Tag %v already exists!\n", record[0])
-			continue
-		}
-
 		tRegForm := svc.TagRegForm{
 			InstID:    instID,
 			TagString: record[0],
@@ -65,9 +55,15 @@ func (s *CCServer) ImportManyTags(c *gin.Context) {
 			PhoneNum:  record[4],
 			Email:     record[5],
 		}
-		_, err = svc.CreateTag(tRegForm)
+		_, err = s.Stores.Tags.CreateTag(c.Request.Context(), tRegForm)
+		if svc.IsDuplicateKeyErr(err) {
+			log.Printf("Tag %v already exists!\n", record[0])
+			continue
+		}
 		if err != nil {
 			log.Printf("Error Encountered while importing tags! %v\n", err)
+		} else {
+			imported++
 		}
 		count++
 	}
@@ -75,6 +71,10 @@ func (s *CCServer) ImportManyTags(c *gin.Context) {
 	if err != nil {
 		log.Panic(err)
 	}
+	s.recordAudit(c, svc.AuditActionImport, svc.AuditTargetTag, "", instID, nil, gin.H{
+		"file":     header.Filename,
+		"imported": imported,
+	})
 	c.JSON(http.StatusOK, gin.H{
 		"message": fmt.Sprintf("'%s' uploaded!", header.Filename),
 	})
@@ -91,6 +91,7 @@ func (s *CCServer) ImportManyMembers(c *gin.Context) {
 
 	r := csv.NewReader(file)
 	count := 0
+	imported := 0
 	for {
 		record, err := r.Read()
 		if count == 0 {
@@ -107,25 +108,21 @@ func (s *CCServer) ImportManyMembers(c *gin.Context) {
 			log.Panic("record fields are too few")
 		}
 
-		// Check if Member already exists
-
-		phoneNum := record[len(record)-1]
-
-		memberCount, err := svc.CountMembersByPhoneNum(phoneNum)
-		if memberCount > 0 {
-			log.Printf("Member PhoneNumb %v already exists!\n", phoneNum)
-			continue
-		}
-
 		mRegForm := svc.MemberRegForm{
 			InstID:    instID,
 			FirstName: record[0],
 			LastName:  record[1],
 			PhoneNum:  record[len(record)-1],
 		}
-		_, err = svc.CreateMember(mRegForm)
+		_, err = s.Stores.Members.CreateMember(c.Request.Context(), mRegForm)
+		if svc.IsDuplicateKeyErr(err) {
+			log.Printf("Member PhoneNumb %v already exists!\n", mRegForm.PhoneNum)
+			continue
+		}
 		if err != nil {
 			log.Printf("Error Encountered while importing members! %v\n", err)
+		} else {
+			imported++
 		}
 		count++
 	}
@@ -133,6 +130,10 @@ func (s *CCServer) ImportManyMembers(c *gin.Context) {
 	if err != nil {
 		log.Panic(err)
 	}
+	s.recordAudit(c, svc.AuditActionImport, svc.AuditTargetMember, "", instID, nil, gin.H{
+		"file":     header.Filename,
+		"imported": imported,
+	})
 	c.JSON(http.StatusOK, gin.H{
 		"message": fmt.Sprintf("'%s' uploaded!", header.Filename),
 	})
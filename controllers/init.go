@@ -1,9 +1,14 @@
 package controllers
 
+import (
+	svc "cloudminds.com/harix/cc-server/services"
+)
+
 // CCServer - root struct for the entire server
 type CCServer struct {
 	Validator CCValidator
 	Config    Config
+	Stores    svc.Stores
 }
 
 // InitServer - return the reference to a server instance
@@ -1,32 +1,30 @@
 package controllers
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // GetManyInsts - as is
 func (s *CCServer) GetManyInsts(c *gin.Context) {
-	cursor, err := svc.GetManyInsts()
+	cursor, err := s.Stores.Insts.GetManyInsts(c.Request.Context())
 
 	if err != nil {
 		log.Printf("Error while getting all institutions - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	// Iterate through the returned cursor
 	insts := []svc.Institution{}
-	if err = cursor.All(context.TODO(), &insts); err != nil {
+	if err = cursor.All(c.Request.Context(), &insts); err != nil {
 		panic(err)
 	}
 
@@ -41,7 +39,7 @@ func (s *CCServer) GetManyInsts(c *gin.Context) {
 func (s *CCServer) GetInstByID(c *gin.Context) {
 	id := c.Param("id")
 	inst := svc.Institution{}
-	err := svc.GetInstByID(id).Decode(&inst)
+	err := s.Stores.Insts.GetInstByID(c.Request.Context(), id).Decode(&inst)
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -52,9 +50,7 @@ func (s *CCServer) GetInstByID(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while getting institution by ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -87,15 +83,22 @@ func (s *CCServer) CreateInst(c *gin.Context) {
 		}
 	}
 
-	_, err = svc.CreateInst(instForm)
-
+	res, err := s.Stores.Insts.CreateInst(c.Request.Context(), instForm)
+	if svc.IsDuplicateKeyErr(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Identifier has been used! Try another one",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error while inserting new Institution into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	after := svc.Institution{}
+	s.Stores.Insts.GetInstByID(c.Request.Context(), instID).Decode(&after)
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetInst, instID, instID, nil, after)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Institution registered Successfully",
@@ -108,16 +111,20 @@ func (s *CCServer) UpdateInstByID(c *gin.Context) {
 	var instForm svc.InstitutionForm
 	c.BindJSON(&instForm)
 	idToUpdate := c.Param("id")
+	before := svc.Institution{}
+	s.Stores.Insts.GetInstByID(c.Request.Context(), idToUpdate).Decode(&before)
 
-	res, err := svc.UpdateInstByID(instForm, idToUpdate)
-
+	res, err := s.Stores.Insts.UpdateInstByID(c.Request.Context(), instForm, idToUpdate)
+	if svc.IsDuplicateKeyErr(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Identifier has been used! Try another one",
+		})
+		return
+	}
 	if err != nil {
 
 		log.Printf("Error while updating Institution to DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	if res.ModifiedCount == 0 {
@@ -126,6 +133,9 @@ func (s *CCServer) UpdateInstByID(c *gin.Context) {
 		})
 		return
 	}
+	after := svc.Institution{}
+	s.Stores.Insts.GetInstByID(c.Request.Context(), idToUpdate).Decode(&after)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetInst, idToUpdate, idToUpdate, before, after)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Institution updated Successfully",
@@ -135,11 +145,10 @@ func (s *CCServer) UpdateInstByID(c *gin.Context) {
 
 // DeleteInstByID - as is
 func (s *CCServer) DeleteInstByID(c *gin.Context) {
-	// TODO: Set CC Records to "expired"
 	idToDelete := c.Param("id")
 
 	// // Check Admins
-	// count, err := svc.CountAdminsByInstID(idToDelete)
+	// count, err := s.Stores.Admins.CountAdminsByInstID(c.Request.Context(), idToDelete)
 	// if err != nil {
 	// 	log.Printf("Error while finding Admins in DB - %v\n", err)
 	// 	return
@@ -164,23 +173,67 @@ func (s *CCServer) DeleteInstByID(c *gin.Context) {
 	// }
 
 	// Delete the Institution
-	res, err := svc.DeleteInstByID(idToDelete)
+	before := svc.Institution{}
+	s.Stores.Insts.GetInstByID(c.Request.Context(), idToDelete).Decode(&before)
+	deletedAt := time.Now()
+	res, err := s.Stores.Insts.SoftDeleteInstByID(c.Request.Context(), idToDelete, deletedAt)
 	if err != nil {
 		log.Printf("Error while deleting Institution from DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
-	if res.DeletedCount == 0 {
+	if res.MatchedCount == 0 {
 		c.JSON(http.StatusForbidden, gin.H{
 			"message": "ID To Delete Institution Not Found",
 		})
 		return
 	}
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetInst, idToDelete, idToDelete, before, nil)
+
+	// Set CC-Records of the Institution to Expire
+	s.expireCCRecords(c, svc.MarkCCRecordAsExpiredParams{
+		InstID:    idToDelete,
+		ExpiredAt: deletedAt,
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Institution Deleted Successfully",
 	})
 	return
 }
+
+// RestoreInstByID - undo "DeleteInstByID", along with the expiry of its CC-Records
+func (s *CCServer) RestoreInstByID(c *gin.Context) {
+	idToRestore := c.Param("id")
+	before := svc.Institution{}
+	err := s.Stores.Insts.GetDeletedInstByID(c.Request.Context(), idToRestore).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "ID To Restore Institution Not Found",
+			})
+			return
+		}
+		log.Printf("Error while getting deleted Institution - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	_, err = s.Stores.Insts.RestoreInstByID(c.Request.Context(), idToRestore)
+	if err != nil {
+		log.Printf("Error while restoring Institution in DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	after := before
+	after.DeletedAt = nil
+	s.recordAudit(c, svc.AuditActionRestore, svc.AuditTargetInst, idToRestore, idToRestore, before, after)
+
+	s.unexpireCCRecords(c, svc.MarkCCRecordAsExpiredParams{
+		InstID:    idToRestore,
+		ExpiredAt: *before.DeletedAt,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Institution Restored Successfully",
+	})
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// instIDResolver - returns IDs of the Institutions a request is going to touch
+type instIDResolver func(c *gin.Context) ([]string, error)
+
+// instIDLookup - returns ID of the Institution owning the entity with the given ID
+type instIDLookup func(ctx context.Context, id string) (string, error)
+
+// instScope - reject requests touching any Institution other than the one in the session token.
+// Super Admins are not bound to an Institution.
+func (s *CCServer) instScope(resolvers ...instIDResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := getSessionClaims(c)
+		if claims == nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if claims.Role == SessionRoleSuperAdmin {
+			return
+		}
+
+		for _, resolve := range resolvers {
+			instIDs, err := resolve(c)
+			if err != nil {
+				log.Printf("instScope - Error while resolving Institution of the request - %v\n", err)
+				respondServerError(c, err)
+				return
+			}
+			for _, instID := range instIDs {
+				if instID != claims.InstID {
+					log.Printf("instScope - %v of Institution %v tried to access Institution %v\n",
+						claims.Subject, claims.InstID, instID)
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+						"message": "Access to another Institution is not allowed",
+					})
+					return
+				}
+			}
+		}
+	}
+}
+
+// fromQuery - resolve by a QueryString param; skipped when the param is absent
+func fromQuery(key string, lookup instIDLookup) instIDResolver {
+	return func(c *gin.Context) ([]string, error) {
+		id, ok := c.GetQuery(key)
+		if !ok {
+			return nil, nil
+		}
+		return lookupInstIDs(c.Request.Context(), []string{id}, lookup)
+	}
+}
+
+// fromParam - resolve by a Path param, e.g. ":id"
+func fromParam(key string, lookup instIDLookup) instIDResolver {
+	return func(c *gin.Context) ([]string, error) {
+		return lookupInstIDs(c.Request.Context(), []string{c.Param(key)}, lookup)
+	}
+}
+
+// fromBody - resolve by a field of the JSON body; nested fields are separated by ".",
+// and both string & list of string fields are supported
+func fromBody(path string, lookup instIDLookup) instIDResolver {
+	return func(c *gin.Context) ([]string, error) {
+		body, err := peekJSONBody(c)
+		if err != nil || body == nil {
+			// Malformed body is left to the handler to reject
+			return nil, nil
+		}
+		var value interface{} = body
+		for _, key := range strings.Split(path, ".") {
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, nil
+			}
+			value = obj[key]
+		}
+
+		var ids []string
+		switch v := value.(type) {
+		case string:
+			ids = append(ids, v)
+		case []interface{}:
+			for _, item := range v {
+				if id, ok := item.(string); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+		return lookupInstIDs(c.Request.Context(), ids, lookup)
+	}
+}
+
+// peekJSONBody - decode the JSON body while leaving it readable for the handler
+func peekJSONBody(c *gin.Context) (map[string]interface{}, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	raw, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(raw))
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// lookupInstIDs - entities not found are skipped, so that handlers can report them as usual
+func lookupInstIDs(ctx context.Context, ids []string, lookup instIDLookup) ([]string, error) {
+	var instIDs []string
+	for _, id := range ids {
+		instID, err := lookup(ctx, id)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		instIDs = append(instIDs, instID)
+	}
+	return instIDs, nil
+}
+
+// Lookups
+
+func instIDAsIs(ctx context.Context, id string) (string, error) {
+	return id, nil
+}
+
+// memberInstID - deleted Members are resolved too, so that they stay in the scope of their Institution
+func (s *CCServer) memberInstID(ctx context.Context, id string) (string, error) {
+	var member svc.Member
+	err := s.Stores.Members.GetMemberByID(ctx, id).Decode(&member)
+	if err == mongo.ErrNoDocuments {
+		err = s.Stores.Members.GetDeletedMemberByID(ctx, id).Decode(&member)
+	}
+	return member.InstID, err
+}
+
+func (s *CCServer) familyInstID(ctx context.Context, id string) (string, error) {
+	var family svc.Family
+	err := s.Stores.Families.GetFamilyByID(ctx, id).Decode(&family)
+	return family.InstID, err
+}
+
+func (s *CCServer) wardInstID(ctx context.Context, id string) (string, error) {
+	var family svc.Family
+	err := s.Stores.Families.GetFamilyByWardID(ctx, id).Decode(&family)
+	return family.InstID, err
+}
+
+func (s *CCServer) deletedWardInstID(ctx context.Context, id string) (string, error) {
+	var family svc.Family
+	err := s.Stores.Families.GetFamilyByDeletedWardID(ctx, id).Decode(&family)
+	return family.InstID, err
+}
+
+func (s *CCServer) vehicleInstID(ctx context.Context, id string) (string, error) {
+	var family svc.Family
+	err := s.Stores.Families.GetFamilyByVehicleID(ctx, id).Decode(&family)
+	return family.InstID, err
+}
+
+// tagInstID - deleted Tags are resolved too, as by "memberInstID"
+func (s *CCServer) tagInstID(ctx context.Context, id string) (string, error) {
+	var tag svc.Tag
+	err := s.Stores.Tags.GetTagByID(ctx, id).Decode(&tag)
+	if err == mongo.ErrNoDocuments {
+		err = s.Stores.Tags.GetDeletedTagByID(ctx, id).Decode(&tag)
+	}
+	return tag.InstID, err
+}
+
+func (s *CCServer) ccRecordInstID(ctx context.Context, id string) (string, error) {
+	var ccRecord svc.CCRecord
+	err := s.Stores.CCRecords.GetCCRecordByID(ctx, id).Decode(&ccRecord)
+	return ccRecord.InstID, err
+}
+
+func (s *CCServer) exclusionInstID(ctx context.Context, id string) (string, error) {
+	var exclusion svc.Exclusion
+	err := s.Stores.Exclusions.GetExclusionByID(ctx, id).Decode(&exclusion)
+	return exclusion.InstID, err
+}
+
+func (s *CCServer) screeningCaseInstID(ctx context.Context, id string) (string, error) {
+	var screeningCase svc.ScreeningCase
+	err := s.Stores.ScreeningCases.GetScreeningCaseByID(ctx, id).Decode(&screeningCase)
+	return screeningCase.InstID, err
+}
+
+func (s *CCServer) regCodeInstID(ctx context.Context, id string) (string, error) {
+	var regCode svc.RegCode
+	if err := s.Stores.RegCodes.GetRegCodeByID(ctx, id).Decode(&regCode); err != nil {
+		return "", err
+	}
+	return s.memberInstID(ctx, regCode.MemberID)
+}
+
+func (s *CCServer) adminInstIDByFrasUsername(ctx context.Context, frasUsername string) (string, error) {
+	var admin svc.Admin
+	err := s.Stores.Admins.GetAdminByFrasUsername(ctx, frasUsername).Decode(&admin)
+	return admin.InstID, err
+}
+
+func (s *CCServer) deviceInstID(ctx context.Context, id string) (string, error) {
+	var device svc.Device
+	err := s.Stores.Devices.GetDeviceByID(ctx, id).Decode(&device)
+	return device.InstID, err
+}
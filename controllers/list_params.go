@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// extractListParams - "limit", "cursor" & "sort" of a list query; lists are never unbounded: limits above
+// "page_config.max_limit", or none at all, are lowered to it
+func (s *CCServer) extractListParams(c *gin.Context) (svc.ListParams, error) {
+	params := svc.ListParams{
+		Limit:  s.Config.PageConf.DefaultLimit,
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+	}
+	if param, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || limit < 1 {
+			return params, fmt.Errorf("%w - limit should be a positive number", svc.ErrBadListParams)
+		}
+		params.Limit = limit
+	}
+	if s.Config.PageConf.MaxLimit > 0 && (params.Limit == 0 || params.Limit > s.Config.PageConf.MaxLimit) {
+		params.Limit = s.Config.PageConf.MaxLimit
+	}
+	return params, nil
+}
+
+// extractDateRange - "startDate" & "endDate" of a list query, in RFC3339
+func extractDateRange(c *gin.Context, start *time.Time, end *time.Time) error {
+	for key, t := range map[string]*time.Time{"startDate": start, "endDate": end} {
+		param, ok := c.GetQuery(key)
+		if !ok {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return fmt.Errorf("%w - %v should be in RFC3339", svc.ErrBadListParams, key)
+		}
+		*t = parsed
+	}
+	return nil
+}
+
+// respondListError - 400 for bad list parameters, otherwise as "respondServerError"
+func respondListError(c *gin.Context, err error) {
+	if errors.Is(err, svc.ErrBadListParams) {
+		log.Printf("Error while parsing List Query Parameters - %v\n", err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": "Bad List Query Parameters",
+		})
+		return
+	}
+	respondServerError(c, err)
+}
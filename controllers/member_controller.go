@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
@@ -30,28 +32,47 @@ func (s *CCServer) GetManyMembers(c *gin.Context) {
 
 	var queryParams svc.GetMemberParams
 	queryParams.InstID = c.DefaultQuery("instID", "000000000000000000000000")
+	queryParams.Group = c.Query("group")
+	queryParams.NamePrefix = c.Query("name")
+	queryParams.Deleted = c.Query("deleted") == "true"
+	if param, ok := c.GetQuery("status"); ok {
+		status, err := strconv.Atoi(param)
+		if err != nil {
+			respondListError(c, fmt.Errorf("%w - status should be a number", svc.ErrBadListParams))
+			return
+		}
+		memberStatus := svc.MemberStatus(status)
+		queryParams.Status = &memberStatus
+	}
+	err := extractDateRange(c, &queryParams.StartDate, &queryParams.EndDate)
+	if err == nil {
+		queryParams.List, err = s.extractListParams(c)
+	}
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
 
-	cursor, err := svc.GetManyMembers(&queryParams)
+	cursor, err := s.Stores.Members.GetManyMembers(c.Request.Context(), &queryParams)
 
 	if err != nil {
 		log.Printf("Error while getting all members - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondListError(c, err)
 		return
 	}
 
-	// Iterate through the returned cursor
 	members := []svc.Member{}
-	for cursor.Next(context.TODO()) {
-		var member svc.Member
-		cursor.Decode(&member)
-		members = append(members, member)
+	nextCursor, err := svc.DecodeList(c.Request.Context(), cursor, &members)
+	if err != nil {
+		log.Printf("Error while decoding members - %v\n", err)
+		respondServerError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "All Members",
-		"data":    members,
+		"message":     "All Members",
+		"data":        members,
+		"next_cursor": nextCursor,
 	})
 
 	return
@@ -79,19 +100,9 @@ func (s *CCServer) CreateMember(c *gin.Context) {
 		}
 	}
 
-	// Check if the phone number exists
-	count, err := svc.CountMembersByPhoneNum(mRegForm.PhoneNum)
-	if err != nil {
-		log.Printf("Error while finding Member by PhoneNum - %v\n", err)
-	}
-	if count > 0 {
-		c.JSON(http.StatusForbidden, gin.H{
-			"message": "Phone # has been used! Try another one",
-		})
-		return
-	}
-
-	_, ok := handleCreateMember(c, &mRegForm)
+	_, ok := s.handleCreateMember(c, &mRegForm, gin.H{
+		"message": "Phone # has been used! Try another one",
+	})
 
 	if ok {
 		c.JSON(http.StatusCreated, gin.H{
@@ -123,9 +134,26 @@ func (s *CCServer) ActivateMember(c *gin.Context) {
 		}
 	}
 
+	// Reject Phone #s locked out after too many failed attempts
+	now := time.Now()
+	attempt := svc.RegCodeAttempt{}
+	err = s.Stores.RegCodes.GetRegCodeAttemptByPhoneNum(c.Request.Context(), mActivateForm.PhoneNum).Decode(&attempt)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error while getting RegCode Attempts given PhoneNum - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	if attempt.IsLocked(now) {
+		log.Printf("Cannot Activate Member - %v locked out until %v\n", mActivateForm.PhoneNum, attempt.LockedUntil)
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"message": "Too many failed attempts, Try again later",
+		})
+		return
+	}
+
 	// Get Member
 	mToActivate := svc.Member{}
-	err = svc.GetMemberByPhoneNum(mActivateForm.PhoneNum).Decode(&mToActivate)
+	err = s.Stores.Members.GetMemberByPhoneNum(c.Request.Context(), mActivateForm.PhoneNum).Decode(&mToActivate)
 	if err != nil {
 		// When no RegCode found, return failed
 		if err == mongo.ErrNoDocuments {
@@ -135,16 +163,14 @@ func (s *CCServer) ActivateMember(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while getting Member giving PhoneNum - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
 	// Compare with RegCode in DB
 	regCode := svc.RegCode{}
 	// TODO - update func name
-	err = svc.GetRegCodeByMemberID(mToActivate.ID.Hex()).Decode(&regCode)
+	err = s.Stores.RegCodes.GetRegCodeByMemberID(c.Request.Context(), mToActivate.ID.Hex()).Decode(&regCode)
 	if err != nil {
 		// When no RegCode found, return failed
 		if err == mongo.ErrNoDocuments {
@@ -154,32 +180,51 @@ func (s *CCServer) ActivateMember(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while getting regCode given MemberID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
-	if mActivateForm.RegCode != regCode.RegCode {
+	if !regCode.Matches(mActivateForm.RegCode) {
 		log.Printf("Cannot Activate Member - RegCode not Match, Try Another One")
+		s.recordFailedRegCodeAttempt(mActivateForm.PhoneNum)
 		c.JSON(http.StatusForbidden, gin.H{
 			"message": "Cannot Activate Member - RegCode not Match, Try Another One",
 		})
 		return
 	}
+	if !regCode.IsActive(now) {
+		log.Printf("Cannot Activate Member - RegCode has expired or been used")
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Cannot Activate Member - RegCode has expired or been used, Ask for a new one",
+		})
+		return
+	}
 
 	// Activate Member
-	err = svc.ActivateMemberByID(mToActivate.ID.Hex())
+	err = s.Stores.Members.ActivateMemberByID(c.Request.Context(), mToActivate.ID.Hex())
 	if err != nil {
 		log.Printf("ActivateMember - Error while updating Member in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
+		return
+	}
+	if _, err := s.Stores.RegCodes.MarkRegCodeAsUsed(c.Request.Context(), regCode.ID); err != nil {
+		log.Printf("ActivateMember - Error while marking RegCode as used - %v\n", err)
+	}
+	if _, err := s.Stores.RegCodes.ResetRegCodeAttempts(c.Request.Context(), mActivateForm.PhoneNum); err != nil {
+		log.Printf("ActivateMember - Error while resetting RegCode Attempts - %v\n", err)
+	}
+	token, ok := s.signMemberSessionToken(c, mToActivate)
+	if !ok {
+		return
+	}
+	qrSigningKey, ok := s.getMemberQRSigningKey(c, mToActivate)
+	if !ok {
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"status":  http.StatusOK,
-		"message": "Member Activated Successfully",
-		"token":   s.Config.DebugTokenL.Mobile,
+		"status":         http.StatusOK,
+		"message":        "Member Activated Successfully",
+		"token":          token,
+		"qr_signing_key": qrSigningKey,
 	})
 	return
 }
@@ -208,7 +253,7 @@ func (s *CCServer) LoginMember(c *gin.Context) {
 	// Get Member
 	memberToLogin := svc.Member{}
 	if len(mLoginForm.PhoneNum) > 0 {
-		err = svc.GetMemberByPhoneNum(mLoginForm.PhoneNum).Decode(&memberToLogin)
+		err = s.Stores.Members.GetMemberByPhoneNum(c.Request.Context(), mLoginForm.PhoneNum).Decode(&memberToLogin)
 	} else if len(mLoginForm.DeviceID) > 0 {
 		//Check if DeviceID is available
 	} else {
@@ -227,9 +272,7 @@ func (s *CCServer) LoginMember(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while finding member from DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -242,16 +285,14 @@ func (s *CCServer) LoginMember(c *gin.Context) {
 		return
 	}
 
-	err = svc.UpdateMemberLoginTimeByID(memberToLogin.ID.Hex())
+	err = s.Stores.Members.UpdateMemberLoginTimeByID(c.Request.Context(), memberToLogin.ID.Hex())
 	if err != nil {
 		log.Printf("Error while updating Member Login Time into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	member := svc.Member{}
-	svc.GetMemberByID(memberToLogin.ID.Hex()).Decode(&member)
+	s.Stores.Members.GetMemberByID(c.Request.Context(), memberToLogin.ID.Hex()).Decode(&member)
 
 	// return Member
 	// TODO - Return Family if Member is Associated With One
@@ -260,14 +301,23 @@ func (s *CCServer) LoginMember(c *gin.Context) {
 	}
 	if member.FamilyInfo != nil {
 		family := svc.Family{}
-		svc.GetFamilyByID(member.FamilyInfo.ID).Decode(&family)
+		s.Stores.Families.GetFamilyByID(c.Request.Context(), member.FamilyInfo.ID).Decode(&family)
 		mLoginResponse.Family = &family
 	}
 
+	token, ok := s.signMemberSessionToken(c, member)
+	if !ok {
+		return
+	}
+	qrSigningKey, ok := s.getMemberQRSigningKey(c, member)
+	if !ok {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Member Login Succeed",
-		"data":    mLoginResponse,
-		"token":   s.Config.DebugTokenL.Mobile,
+		"message":        "Member Login Succeed",
+		"data":           mLoginResponse,
+		"token":          token,
+		"qr_signing_key": qrSigningKey,
 	})
 	return
 }
@@ -293,35 +343,21 @@ func (s *CCServer) CreateMemberAndSendSMS(c *gin.Context) {
 		}
 	}
 
-	// Check if the phone number exists
-	count, err := svc.CountMembersByPhoneNum(mRegForm.PhoneNum)
-	if err != nil {
-		log.Printf("Error while finding Member by PhoneNum - %v\n", err)
-	}
-	if count > 0 {
-		c.JSON(http.StatusForbidden, gin.H{
-			"message": "Phone # has been used! You can activate using the Registration Code in SMS",
-			"success": false,
-		})
-		return
-	}
-
 	// Create Member in DB
-	memberID, ok := handleCreateMember(c, &mRegForm)
+	memberID, ok := s.handleCreateMember(c, &mRegForm, gin.H{
+		"message": "Phone # has been used! You can activate using the Registration Code in SMS",
+		"success": false,
+	})
 	if !ok {
 		return
 	}
 
 	// Get RegCode
 	regCode := svc.RegCode{}
-	err = svc.GetRegCodeByMemberID(memberID).Decode(&regCode)
+	err = s.Stores.RegCodes.GetRegCodeByMemberID(c.Request.Context(), memberID).Decode(&regCode)
 	if err != nil {
 		log.Printf("Error while getting regcode by ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-			"success": false,
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -371,15 +407,29 @@ func (s *CCServer) UpdateMemberByID(c *gin.Context) {
 		}
 	}
 
-	_, err = svc.UpdateMemberByID(mForm, idToUpdate)
-
+	before := svc.Member{}
+	s.Stores.Members.GetMemberByID(c.Request.Context(), idToUpdate).Decode(&before)
+	res, err := s.Stores.Members.UpdateMemberByID(c.Request.Context(), mForm, idToUpdate)
+	if svc.IsDuplicateKeyErr(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Phone # has been used! Try another one",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error while updating Member in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
+		respondServerError(c, err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "ID To Update Member Not Found, or deleted",
 		})
 		return
 	}
+	after := svc.Member{}
+	s.Stores.Members.GetMemberByID(c.Request.Context(), idToUpdate).Decode(&after)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetMember, idToUpdate, after.InstID, before, after)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Member updated Successfully",
@@ -398,68 +448,193 @@ func (s *CCServer) UpdateMemberByID(c *gin.Context) {
 	return
 }
 
-// DeleteMemberByID - as is
+// DeleteMemberByID - as is; its RegCodes are kept, for "RestoreMemberByID" to undo the deletion. Deleted Members
+// are not found by Phone #, so cannot be activated by them meanwhile
 func (s *CCServer) DeleteMemberByID(c *gin.Context) {
 	// Get Member
 	idToDelete := c.Param("id")
-	_, err := svc.DeleteMemberByID(idToDelete)
+	before := svc.Member{}
+	err := s.Stores.Members.GetMemberByID(c.Request.Context(), idToDelete).Decode(&before)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error while getting Member by ID - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	memberNotFound := gin.H{
+		"message": "ID To Delete Member Not Found, or already deleted",
+	}
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusForbidden, memberNotFound)
+		return
+	}
+	deletedAt := time.Now()
+	res, err := s.Stores.Members.SoftDeleteMemberByID(c.Request.Context(), idToDelete, deletedAt)
 	if err != nil {
 		log.Printf("Error while deleting Member in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	if res.MatchedCount == 0 {
+		// Deleted by another Admin since it was read
+		c.JSON(http.StatusForbidden, memberNotFound)
+		return
+	}
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetMember, idToDelete, before.InstID, before, nil)
+
+	// Set CC-Records to Expire
+	s.expireCCRecords(c, svc.MarkCCRecordAsExpiredParams{
+		MemberType: s.instMemberType(c, before.InstID),
+		MemberID:   idToDelete,
+		ExpiredAt:  deletedAt,
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Member deleted Successfully",
 	})
+	return
+}
 
-	// Set CC-Records to Expire
-	// TODO - Update field name
-	// ccrParams := svc.MarkCCRecordAsExpiredParams{
-	// 	GuardianID: idToDelete,
-	// }
-	// _, err = svc.MarkCCRecordAsExpired(ccrParams)
-	// // If any error, log it in server, since it is not fatal
-	// if err != nil {
-	// 	log.Printf("Error while marking CCRecord as Expired - %v\n", err)
-	// }
-
-	// Delete RegCode
-	_, err = svc.DeleteRegCodeByMemberID(idToDelete)
-	// If any error, log it in server, since it is not fatal
+// RestoreMemberByID - undo "DeleteMemberByID", along with the expiry of its CC-Records
+func (s *CCServer) RestoreMemberByID(c *gin.Context) {
+	idToRestore := c.Param("id")
+	before := svc.Member{}
+	err := s.Stores.Members.GetDeletedMemberByID(c.Request.Context(), idToRestore).Decode(&before)
 	if err != nil {
-		log.Printf("Error while Deleting RegCode by GuardianID - %v\n", err)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Deleted Member Not Found",
+			})
+			return
+		}
+		log.Printf("Error while getting deleted Member - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	_, err = s.Stores.Members.RestoreMemberByID(c.Request.Context(), idToRestore)
+	if svc.IsDuplicateKeyErr(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "Phone # has been used since the Member was deleted",
+		})
+		return
 	}
+	if err != nil {
+		log.Printf("Error while restoring Member in DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	after := before
+	after.DeletedAt = nil
+	s.recordAudit(c, svc.AuditActionRestore, svc.AuditTargetMember, idToRestore, before.InstID, before, after)
+
+	s.unexpireCCRecords(c, svc.MarkCCRecordAsExpiredParams{
+		MemberType: s.instMemberType(c, before.InstID),
+		MemberID:   idToRestore,
+		ExpiredAt:  *before.DeletedAt,
+	})
 
-	return
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member restored Successfully",
+	})
 }
 
 // handleCreateMember - return (memberID, ok)
-func handleCreateMember(c *gin.Context, mRegForm *svc.MemberRegForm) (string, bool) {
+func (s *CCServer) handleCreateMember(c *gin.Context, mRegForm *svc.MemberRegForm, phoneNumUsed gin.H) (string, bool) {
+	var memberID string
+	err := s.Stores.Tx.RunInTx(c.Request.Context(), func(ctx context.Context, tx *svc.Tx) error {
+		var err error
+		memberID, err = s.createMember(ctx, tx, *mRegForm)
+		return err
+	})
+	if svc.IsDuplicateKeyErr(err) {
+		c.JSON(http.StatusForbidden, phoneNumUsed)
+		return "", false
+	}
+	if err != nil {
+		respondServerError(c, err)
+		return "", false
+	}
+
+	s.recordMemberCreated(c, memberID)
+	return memberID, true
+}
+
+// createMember - create a Member & its RegCode within "tx"; returns the Member ID
+func (s *CCServer) createMember(ctx context.Context, tx *svc.Tx, mRegForm svc.MemberRegForm) (string, error) {
 
-	// Create Member
-	res, err := svc.CreateMember(*mRegForm)
+	// Create Member; the unique index on Phone #s rejects one that has been used
+	res, err := s.Stores.Members.CreateMember(ctx, mRegForm)
+	if svc.IsDuplicateKeyErr(err) {
+		return "", err
+	}
 	if err != nil {
 		log.Printf("Error while inserting new Member into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
-		return "", false
+		return "", err
 	}
 	memberID := res.InsertedID.(primitive.ObjectID).Hex()
+	tx.OnRollback(func(ctx context.Context) error {
+		_, err := s.Stores.Members.DeleteMemberByID(ctx, memberID)
+		return err
+	})
 
 	// Register RegCode for the Guardian in DB
 	// TODO - update func name
-	res, err = svc.CreateRegCodeByMemberID(memberID)
+	_, err = s.Stores.RegCodes.CreateRegCodeByMemberID(ctx, memberID, s.Config.RegCodeConf.TTL())
 	if err != nil {
 		log.Printf("Error while Creating new RegCode into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		return "", err
+	}
+	tx.OnRollback(func(ctx context.Context) error {
+		_, err := s.Stores.RegCodes.DeleteRegCodeByMemberID(ctx, memberID)
+		return err
+	})
+	return memberID, nil
+}
+
+// recordMemberCreated - audit a Member, once its creation has been made
+func (s *CCServer) recordMemberCreated(c *gin.Context, memberID string) {
+	after := svc.Member{}
+	s.Stores.Members.GetMemberByID(c.Request.Context(), memberID).Decode(&after)
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetMember, memberID, after.InstID, nil, after)
+}
+
+// signMemberSessionToken - return (token, ok)
+func (s *CCServer) signMemberSessionToken(c *gin.Context, member svc.Member) (string, bool) {
+	token, err := s.SignSessionToken(SessionClaims{
+		Subject: member.ID.Hex(),
+		Role:    SessionRoleMobile,
+		InstID:  member.InstID,
+	})
+	if err != nil {
+		log.Printf("Error while signing session token for member - %v\n", err)
+		respondServerError(c, err)
 		return "", false
 	}
+	return token, true
+}
 
-	return memberID, true
+// getMemberQRSigningKey - key the Member's Mobile App signs QR payloads with; return (key, ok)
+func (s *CCServer) getMemberQRSigningKey(c *gin.Context, member svc.Member) (string, bool) {
+	instKey, err := s.Stores.Insts.GetInstQRSigningKey(c.Request.Context(), member.InstID)
+	if err != nil {
+		log.Printf("Error while getting QR Signing Key of Institution - %v\n", err)
+		respondServerError(c, err)
+		return "", false
+	}
+	return MemberQRSigningKey(instKey, member.ID.Hex()), true
+}
+
+// recordFailedRegCodeAttempt - count the failure towards locking out the Phone #;
+// not bound to the request, so that hanging up early does not escape the count
+func (s *CCServer) recordFailedRegCodeAttempt(phoneNum string) {
+	ctx, cancel := s.backgroundDBContext()
+	defer cancel()
+	regCodeConf := s.Config.RegCodeConf
+	attempt, err := s.Stores.RegCodes.RecordFailedRegCodeAttempt(ctx, phoneNum, regCodeConf.MaxFailedAttempts, regCodeConf.Lockout())
+	if err != nil {
+		log.Printf("Error while recording failed RegCode Attempt - %v\n", err)
+		return
+	}
+	if attempt.IsLocked(time.Now()) {
+		log.Printf("%v locked out until %v after too many failed RegCode Attempts\n", phoneNum, attempt.LockedUntil)
+	}
 }
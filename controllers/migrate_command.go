@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	svc "cloudminds.com/harix/cc-server/services"
+)
+
+const migrateUsage = `Usage: cc-server migrate up|down|status [--dry-run]
+  up        apply every pending migration
+  down      revert the latest applied migration
+  status    list migrations and when they were applied
+`
+
+// MigrateCommand - run "cc-server migrate"; returns the exit code
+func (s *CCServer) MigrateCommand(args []string) int {
+	stdout, stderr := os.Stdout, os.Stderr
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dryRun := flags.Bool("dry-run", false, "count the documents each step would change, without changing any")
+
+	// Flags may come before or after the direction
+	flagArgs := []string{}
+	directions := []string{}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			flagArgs = append(flagArgs, arg)
+		} else {
+			directions = append(directions, arg)
+		}
+	}
+	if err := flags.Parse(flagArgs); err != nil || len(directions) != 1 {
+		fmt.Fprint(stderr, migrateUsage)
+		return 2
+	}
+	direction := directions[0]
+	if direction != "up" && direction != "down" && direction != "status" {
+		fmt.Fprint(stderr, migrateUsage)
+		return 2
+	}
+
+	db := s.connectDB()
+	ctx, cancel := context.WithTimeout(context.Background(), schemaTimeout)
+	defer cancel()
+	if *dryRun {
+		fmt.Fprintln(stdout, "Dry run - nothing is changed; counts are of documents matched as the database is now")
+	}
+
+	switch direction {
+	case "up":
+		results, err := svc.MigrateUp(ctx, db, *dryRun)
+		for _, res := range results {
+			fmt.Fprintf(stdout, "Up   %v\n", res)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "Error while migrating up - %v\n", err)
+			return 1
+		}
+		if len(results) == 0 {
+			fmt.Fprintln(stdout, "No pending migration")
+		}
+	case "down":
+		res, err := svc.MigrateDown(ctx, db, *dryRun)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error while migrating down - %v\n", err)
+			return 1
+		}
+		if res == nil {
+			fmt.Fprintln(stdout, "No applied migration")
+			return 0
+		}
+		fmt.Fprintf(stdout, "Down %v\n", res)
+	case "status":
+		statuses, err := svc.GetMigrationStatus(ctx, db)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error while getting migration status - %v\n", err)
+			return 1
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Record != nil && status.Record.AppliedAt != nil {
+				state = "applied at " + status.Record.AppliedAt.Format("2006-01-02 15:04:05")
+			} else if status.Record != nil {
+				state = "unfinished, started at " + status.Record.StartedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(stdout, "%04d %-40v %v\n", status.Migration.Version, status.Migration.Name, state)
+		}
+	}
+	return 0
+}
@@ -20,20 +20,16 @@ func (s *CCServer) GetScanNameByDeviceID(c *gin.Context) {
 		Status:    -1, // set to -1 to disable status filter
 	}
 	var ccRecord svc.CCRecord
-	if err := svc.GetCCRecordByDeviceID(&ccParams, svc.MemberTypeGuardian).Decode(&ccRecord); err != nil {
+	if err := s.Stores.CCRecords.GetCCRecordByDeviceID(c.Request.Context(), &ccParams, svc.MemberTypeGuardian).Decode(&ccRecord); err != nil {
 		if err != mongo.ErrNoDocuments {
 			log.Printf("Error while getting CCRecord by DeviceID - %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "Something went wrong",
-			})
+			respondServerError(c, err)
 			return
 		}
-		if err := svc.GetCCRecordByDeviceID(&ccParams, svc.MemberTypeStandard).Decode(&ccRecord); err != nil {
+		if err := s.Stores.CCRecords.GetCCRecordByDeviceID(c.Request.Context(), &ccParams, svc.MemberTypeStandard).Decode(&ccRecord); err != nil {
 			if err != mongo.ErrNoDocuments {
 				log.Printf("Error while getting CCRecord by DeviceID - %v\n", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"message": "Something went wrong",
-				})
+				respondServerError(c, err)
 				return
 			}
 			log.Printf("CCRecord with DeviceID not found, Getting CCRecord Failed!")
@@ -2,17 +2,18 @@ package controllers
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"net/smtp"
 	"strings"
 	"text/template"
+	"time"
 
 	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
 	"github.com/sfreiberg/gotwilio"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -39,19 +40,16 @@ type SendRegCodeForm struct {
 
 // GetManyRegCodes - For Debug Purpose
 func (s *CCServer) GetManyRegCodes(c *gin.Context) {
-	cursor, err := svc.GetManyRegCodes()
+	cursor, err := s.Stores.RegCodes.GetManyRegCodes(c.Request.Context())
 
 	if err != nil {
 		log.Printf("Error while getting all regCodes - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 	// Iterate through the returned cursor
 	regCodes := []svc.RegCode{}
-	if err = cursor.All(context.TODO(), &regCodes); err != nil {
+	if err = cursor.All(c.Request.Context(), &regCodes); err != nil {
 		panic(err)
 	}
 
@@ -68,14 +66,11 @@ func (s *CCServer) GetRegCodeByMemberID(c *gin.Context) {
 	queryParams.MemberID = c.DefaultQuery("memberID", "000000000000000000000000")
 	// Iterate through the returned cursor
 	regCode := svc.RegCode{}
-	err := svc.GetRegCodeByMemberID(queryParams.MemberID).Decode(&regCode)
+	err := s.Stores.RegCodes.GetRegCodeByMemberID(c.Request.Context(), queryParams.MemberID).Decode(&regCode)
 
 	if err != nil {
 		log.Printf("Error while getting regcode by GuardianID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -86,6 +81,54 @@ func (s *CCServer) GetRegCodeByMemberID(c *gin.Context) {
 	return
 }
 
+// RegenerateRegCode - issue a new RegCode to the Member, invalidating the old one
+func (s *CCServer) RegenerateRegCode(c *gin.Context) {
+	var regenerateForm svc.RegenerateRegCodeForm
+	c.BindJSON(&regenerateForm)
+
+	// Get Member
+	member := svc.Member{}
+	err := s.Stores.Members.GetMemberByID(c.Request.Context(), regenerateForm.MemberID).Decode(&member)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Member does not exist, RegCode regeneration failed",
+			})
+			return
+		}
+		log.Printf("Error while getting Member by ID - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+
+	// Create RegCode
+	res, err := s.Stores.RegCodes.CreateRegCodeByMemberID(c.Request.Context(), member.ID.Hex(), s.Config.RegCodeConf.TTL())
+	if err != nil {
+		log.Printf("Error while Creating new RegCode into DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	// Lift the lockout, if any, so that the Member can use the new RegCode
+	if _, err := s.Stores.RegCodes.ResetRegCodeAttempts(c.Request.Context(), member.PhoneNum); err != nil {
+		log.Printf("Error while resetting RegCode Attempts - %v\n", err)
+	}
+
+	regCode := svc.RegCode{}
+	err = s.Stores.RegCodes.GetRegCodeByID(c.Request.Context(), res.InsertedID.(primitive.ObjectID).Hex()).Decode(&regCode)
+	if err != nil {
+		log.Printf("Error while getting regcode by ID - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetRegCode, regCode.ID.Hex(), member.InstID, nil, regCode)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Reg Code regenerated Successfully",
+		"data":    regCode,
+	})
+	return
+}
+
 // SendRegCodeWithEmail - as is
 func (s *CCServer) SendRegCodeWithEmail(c *gin.Context) {
 	sendRegCodeForm := SendRegCodeForm{}
@@ -94,13 +137,16 @@ func (s *CCServer) SendRegCodeWithEmail(c *gin.Context) {
 
 	// Get RegCode
 	regCode := svc.RegCode{}
-	err := svc.GetRegCodeByID(sendRegCodeForm.ID).Decode(&regCode)
+	err := s.Stores.RegCodes.GetRegCodeByID(c.Request.Context(), sendRegCodeForm.ID).Decode(&regCode)
 
 	if err != nil {
 		log.Printf("Error while getting regcode by ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
+		respondServerError(c, err)
+		return
+	}
+	if !regCode.IsActive(time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "RegCode has expired or been used, Regenerate a new one",
 		})
 		return
 	}
@@ -116,6 +162,7 @@ func (s *CCServer) SendRegCodeWithEmail(c *gin.Context) {
 		log.Printf("SendRegCodeWithEmail - Error while Sending Email - %v\n", err)
 	}
 	log.Println("Email Sent!")
+	s.recordRegCodeSentAudit(c, regCode)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Email Sent!",
 	})
@@ -130,13 +177,16 @@ func (s *CCServer) SendRegCodeWithSMS(c *gin.Context) {
 
 	// Get RegCode
 	regCode := svc.RegCode{}
-	err := svc.GetRegCodeByID(sendRegCodeForm.ID).Decode(&regCode)
+	err := s.Stores.RegCodes.GetRegCodeByID(c.Request.Context(), sendRegCodeForm.ID).Decode(&regCode)
 
 	if err != nil {
 		log.Printf("Error while getting regcode by ID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
+		respondServerError(c, err)
+		return
+	}
+	if !regCode.IsActive(time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "RegCode has expired or been used, Regenerate a new one",
 		})
 		return
 	}
@@ -150,6 +200,7 @@ func (s *CCServer) SendRegCodeWithSMS(c *gin.Context) {
 	s.handleSendRegCodeWithSMS(smsContent, sendRegCodeForm.PhoneNum)
 
 	log.Println("SMS Sent!")
+	s.recordRegCodeSentAudit(c, regCode)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "SMS Sent!",
 	})
@@ -201,10 +252,17 @@ func (s *CCServer) handleSendRegCodeWithSMS(sc RegCodeSMSContent, toPhoneNum str
 	twilio.SendSMS(smsConfig.FromPhoneNum, toPhoneNum, message, "", "")
 }
 
+// recordRegCodeSentAudit - the RegCode itself is unchanged, so only the Member's Institution is looked up
+func (s *CCServer) recordRegCodeSentAudit(c *gin.Context, regCode svc.RegCode) {
+	member := svc.Member{}
+	s.Stores.Members.GetMemberByID(c.Request.Context(), regCode.MemberID).Decode(&member)
+	s.recordAudit(c, svc.AuditActionSend, svc.AuditTargetRegCode, regCode.ID.Hex(), member.InstID, nil, nil)
+}
+
 func (s *CCServer) sendRegCodePostProcessing(c *gin.Context, phoneNum string) {
 	// Update Member Status
 	// Get Member
-	err := svc.SetMemberRegCodeSentByPhoneNum(phoneNum)
+	err := s.Stores.Members.SetMemberRegCodeSentByPhoneNum(c.Request.Context(), phoneNum)
 	if err != nil {
 		// When no member found, return failed
 		if err == mongo.ErrNoDocuments {
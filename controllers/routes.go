@@ -1,30 +1,34 @@
 package controllers
 
 import (
+	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
 )
 
 // Routes - All API definitions
 func (s *CCServer) Routes(router *gin.Engine) {
 
+	// Every API gets its DB calls bounded; to be set before any group is made
+	router.Use(s.dbDeadline())
+
 	superAdminTokenNeeded := router.Group("/")
 	adminTokenNeeded := router.Group("/")
 	mobileTokenNeeded := router.Group("/")
 	authNotNeeded := router.Group("/")
 
-	superAdminTokenNeeded.Use(s.tokenAuth(s.Config.DebugTokenL.SuperAdmin))
-	adminTokenNeeded.Use(s.tokenAuth(s.Config.DebugTokenL.Admin))
-	mobileTokenNeeded.Use(s.tokenAuth(s.Config.DebugTokenL.Mobile))
+	superAdminTokenNeeded.Use(s.tokenAuth(SessionRoleSuperAdmin))
+	adminTokenNeeded.Use(s.tokenAuth(SessionRoleSuperAdmin, SessionRoleAdmin, SessionRoleFrontDesk, SessionRoleNurse))
+	mobileTokenNeeded.Use(s.tokenAuth(SessionRoleMobile))
 
 	// Check-Me MobileApp APIs
-	mobileTokenNeeded.POST("api/cc-record/sync", s.GetOrCreateManyCCRecords)
-	mobileTokenNeeded.POST("api/cc-record/schedule", s.HandleCheckoutScheduleEvent)
+	mobileTokenNeeded.POST("api/cc-record/sync", s.instScope(fromBody("institution_id", instIDAsIs)), s.GetOrCreateManyCCRecords)
+	mobileTokenNeeded.POST("api/cc-record/schedule", s.instScope(fromBody("ward_ids", s.wardInstID)), s.HandleCheckoutScheduleEvent)
 	authNotNeeded.POST("api/member/login", s.LoginMember)
 	authNotNeeded.POST("api/member/activate", s.ActivateMember)
 	authNotNeeded.POST("api/member/register-and-sms", s.CreateMemberAndSendSMS)
 
 	// Gatekeeper APIs
-	authNotNeeded.POST("api/cc-record/scan", s.HandleCCScanEvent)
+	authNotNeeded.POST("api/cc-record/scan", s.deviceAuth(), s.HandleCCScanEvent)
 
 	// MobileAlert APIS
 	authNotNeeded.GET("api/cc-record/get-name", s.GetScanNameByDeviceID)
@@ -35,75 +39,105 @@ func (s *CCServer) Routes(router *gin.Engine) {
 	superAdminTokenNeeded.POST("api/institution", s.CreateInst)
 	superAdminTokenNeeded.PUT("api/institution/:id", s.UpdateInstByID)
 	superAdminTokenNeeded.DELETE("api/institution/:id", s.DeleteInstByID)
+	superAdminTokenNeeded.POST("api/institution/restore/:id", s.RestoreInstByID)
+	adminTokenNeeded.GET("api/screening-policy/:id", s.instScope(fromParam("id", instIDAsIs)), s.requirePermission(svc.PermissionViewCCRecords, svc.PermissionViewFailedCCRecords), s.GetScreeningPolicy)
+	adminTokenNeeded.PUT("api/screening-policy/:id", s.instScope(fromParam("id", instIDAsIs)), s.requirePermission(svc.PermissionManageScreening), s.UpdateScreeningPolicy)
 
 	// Admin APIs
 	superAdminTokenNeeded.GET("api/admins", s.GetManyAdminsByInstID)
 	superAdminTokenNeeded.POST("api/admin/register", s.RegisterAdmin)
 	superAdminTokenNeeded.PUT("api/admin/:id", s.UpdateAdminByID)
 	superAdminTokenNeeded.DELETE("api/admin/:id", s.DeleteAdminByID)
-	adminTokenNeeded.GET("api/admin", s.GetAdminByFrasUsername)
+	adminTokenNeeded.GET("api/admin", s.instScope(fromQuery("frasUsername", s.adminInstIDByFrasUsername)), s.GetAdminByFrasUsername)
 	authNotNeeded.POST("api/admin/login", s.AdminLogin)
 
+	// Device APIs
+	adminTokenNeeded.GET("api/devices", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionManageDevices), s.GetManyDevices)
+	adminTokenNeeded.POST("api/device", s.instScope(fromBody("institution_id", instIDAsIs)), s.requirePermission(svc.PermissionManageDevices), s.CreateDevice)
+	adminTokenNeeded.PUT("api/device/:id", s.instScope(fromParam("id", s.deviceInstID)), s.requirePermission(svc.PermissionManageDevices), s.UpdateDeviceByID)
+	adminTokenNeeded.POST("api/device/:id/rotate-key", s.instScope(fromParam("id", s.deviceInstID)), s.requirePermission(svc.PermissionManageDevices), s.RotateDeviceAPIKey)
+	adminTokenNeeded.DELETE("api/device/:id", s.instScope(fromParam("id", s.deviceInstID)), s.requirePermission(svc.PermissionManageDevices), s.DeleteDeviceByID)
+
 	// CC-Records APIs
-	adminTokenNeeded.GET("api/cc-records", s.GetManyCCRecords)
-	adminTokenNeeded.DELETE("api/cc-record/:id", s.DeleteCCRecordByID)
+	adminTokenNeeded.GET("api/cc-records", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewCCRecords, svc.PermissionViewFailedCCRecords), s.GetManyCCRecords)
+	adminTokenNeeded.DELETE("api/cc-record/:id", s.instScope(fromParam("id", s.ccRecordInstID)), s.requirePermission(svc.PermissionManageCCRecords), s.DeleteCCRecordByID)
+	adminTokenNeeded.POST("api/cc-record/rebuild/:id", s.instScope(fromParam("id", s.ccRecordInstID)), s.requirePermission(svc.PermissionManageCCRecords), s.RebuildCCRecord)
+	adminTokenNeeded.GET("api/scan-events", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewCCRecords), s.GetManyScanEvents)
+	adminTokenNeeded.GET("api/reports/mask-compliance", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewCCRecords), s.GetMaskComplianceReport)
+	adminTokenNeeded.GET("api/exclusions", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewCCRecords, svc.PermissionViewFailedCCRecords), s.GetManyExclusions)
+	adminTokenNeeded.POST("api/exclusion/clear/:id", s.instScope(fromParam("id", s.exclusionInstID)), s.requirePermission(svc.PermissionClearExclusions), s.ClearExclusionByID)
+	adminTokenNeeded.GET("api/screening-cases", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewFailedCCRecords), s.GetManyScreeningCases)
+	adminTokenNeeded.POST("api/screening-case/assign/:id", s.instScope(fromParam("id", s.screeningCaseInstID)), s.requirePermission(svc.PermissionManageScreeningCases), s.AssignScreeningCase)
+	adminTokenNeeded.POST("api/screening-case/note/:id", s.instScope(fromParam("id", s.screeningCaseInstID)), s.requirePermission(svc.PermissionManageScreeningCases), s.AddScreeningCaseNote)
+	adminTokenNeeded.POST("api/screening-case/retest/:id", s.instScope(fromParam("id", s.screeningCaseInstID)), s.requirePermission(svc.PermissionManageScreeningCases), s.RetestScreeningCase)
+	adminTokenNeeded.POST("api/screening-case/decide/:id", s.instScope(fromParam("id", s.screeningCaseInstID)), s.requirePermission(svc.PermissionManageScreeningCases), s.DecideScreeningCase)
+
+	// Search APIs
+	adminTokenNeeded.GET("api/search", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewRoster), s.Search)
 
 	// Tag APIs
-	adminTokenNeeded.GET("api/tags", s.GetManyTags)
-	adminTokenNeeded.GET("api/tag", s.GetTag)
-	adminTokenNeeded.POST("api/tag", s.CreateTag)
-	adminTokenNeeded.PUT("api/tag/:id", s.UpdateTagByID)
-	adminTokenNeeded.DELETE("api/tag/:id", s.DeleteTagByID)
+	adminTokenNeeded.GET("api/tags", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewRoster), s.GetManyTags)
+	adminTokenNeeded.GET("api/tag", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewRoster), s.GetTag)
+	adminTokenNeeded.POST("api/tag", s.instScope(fromBody("institution_id", instIDAsIs)), s.requirePermission(svc.PermissionManageRoster), s.CreateTag)
+	adminTokenNeeded.PUT("api/tag/:id", s.instScope(fromParam("id", s.tagInstID)), s.requirePermission(svc.PermissionManageRoster), s.UpdateTagByID)
+	adminTokenNeeded.DELETE("api/tag/:id", s.instScope(fromParam("id", s.tagInstID)), s.requirePermission(svc.PermissionManageRoster), s.DeleteTagByID)
+	adminTokenNeeded.POST("api/tag/restore/:id", s.instScope(fromParam("id", s.tagInstID)), s.requirePermission(svc.PermissionManageRoster), s.RestoreTagByID)
 
 	// Member APIs
-	adminTokenNeeded.GET("api/members", s.GetManyMembers)
-	adminTokenNeeded.POST("api/member", s.CreateMember)
-	adminTokenNeeded.PUT("api/member/:id", s.UpdateMemberByID)
-	adminTokenNeeded.DELETE("api/member/:id", s.DeleteMemberByID)
+	adminTokenNeeded.GET("api/members", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewRoster), s.GetManyMembers)
+	adminTokenNeeded.POST("api/member", s.instScope(fromBody("institution_id", instIDAsIs), fromBody("family_info.id", s.familyInstID)), s.requirePermission(svc.PermissionManageRoster), s.CreateMember)
+	adminTokenNeeded.PUT("api/member/:id", s.instScope(fromParam("id", s.memberInstID), fromBody("family_info.id", s.familyInstID)), s.requirePermission(svc.PermissionManageRoster), s.UpdateMemberByID)
+	adminTokenNeeded.DELETE("api/member/:id", s.instScope(fromParam("id", s.memberInstID)), s.requirePermission(svc.PermissionManageRoster), s.DeleteMemberByID)
+	adminTokenNeeded.POST("api/member/restore/:id", s.instScope(fromParam("id", s.memberInstID)), s.requirePermission(svc.PermissionManageRoster), s.RestoreMemberByID)
 
 	// Family APIs
-	adminTokenNeeded.GET("api/families", s.GetManyFamilies)
-	adminTokenNeeded.POST("api/family", s.CreateFamily)
-	adminTokenNeeded.DELETE("api/family/:id", s.DeleteFamilyByID)
-	adminTokenNeeded.GET("api/family", s.GetFamily)
-	adminTokenNeeded.GET("api/family-with-members", s.GetFamilyWithMembers)
-	adminTokenNeeded.GET("api/family-with-members/:id", s.GetFamilyWithMembersByID)
+	adminTokenNeeded.GET("api/families", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewRoster), s.GetManyFamilies)
+	adminTokenNeeded.POST("api/family", s.instScope(fromBody("institution_id", instIDAsIs)), s.requirePermission(svc.PermissionManageRoster), s.CreateFamily)
+	adminTokenNeeded.DELETE("api/family/:id", s.instScope(fromParam("id", s.familyInstID)), s.requirePermission(svc.PermissionManageRoster), s.DeleteFamilyByID)
+	adminTokenNeeded.GET("api/family", s.instScope(fromQuery("memberID", s.memberInstID), fromQuery("wardID", s.wardInstID)), s.requirePermission(svc.PermissionViewRoster), s.GetFamily)
+	adminTokenNeeded.GET("api/family-with-members", s.instScope(fromQuery("wardID", s.wardInstID)), s.requirePermission(svc.PermissionViewRoster), s.GetFamilyWithMembers)
+	adminTokenNeeded.GET("api/family-with-members/:id", s.instScope(fromParam("id", s.familyInstID)), s.requirePermission(svc.PermissionViewRoster), s.GetFamilyWithMembersByID)
 
 	// Ward APIs
-	adminTokenNeeded.POST("api/ward/add-new", s.AddWard)
-	adminTokenNeeded.PUT("api/ward/:id", s.UpdateWardByID)
-	adminTokenNeeded.DELETE("api/ward/:id", s.DeleteWardByID)
+	adminTokenNeeded.POST("api/ward/add-new", s.instScope(fromQuery("familyID", s.familyInstID)), s.requirePermission(svc.PermissionManageRoster), s.AddWard)
+	adminTokenNeeded.PUT("api/ward/:id", s.instScope(fromParam("id", s.wardInstID)), s.requirePermission(svc.PermissionManageRoster), s.UpdateWardByID)
+	adminTokenNeeded.DELETE("api/ward/:id", s.instScope(fromParam("id", s.wardInstID)), s.requirePermission(svc.PermissionManageRoster), s.DeleteWardByID)
+	adminTokenNeeded.POST("api/ward/restore/:id", s.instScope(fromParam("id", s.deletedWardInstID)), s.requirePermission(svc.PermissionManageRoster), s.RestoreWardByID)
 
 	// Vehicle APIs
-	adminTokenNeeded.POST("api/vehicle/add-new", s.AddVehicle)
-	adminTokenNeeded.PUT("api/vehicle/:id", s.UpdateVehicleByID)
-	adminTokenNeeded.DELETE("api/vehicle/:id", s.DeleteVehicleByID)
+	adminTokenNeeded.POST("api/vehicle/add-new", s.instScope(fromQuery("familyID", s.familyInstID)), s.requirePermission(svc.PermissionManageRoster), s.AddVehicle)
+	adminTokenNeeded.PUT("api/vehicle/:id", s.instScope(fromParam("id", s.vehicleInstID)), s.requirePermission(svc.PermissionManageRoster), s.UpdateVehicleByID)
+	adminTokenNeeded.DELETE("api/vehicle/:id", s.instScope(fromParam("id", s.vehicleInstID)), s.requirePermission(svc.PermissionManageRoster), s.DeleteVehicleByID)
 
 	// RegCode APIs
 	superAdminTokenNeeded.GET("api/reg-codes", s.GetManyRegCodes)
-	adminTokenNeeded.GET("api/reg-code", s.GetRegCodeByMemberID)
-	adminTokenNeeded.POST("api/reg-code/email", s.SendRegCodeWithEmail)
-	adminTokenNeeded.POST("api/reg-code/sms", s.SendRegCodeWithSMS)
+	adminTokenNeeded.GET("api/reg-code", s.instScope(fromQuery("memberID", s.memberInstID)), s.requirePermission(svc.PermissionViewRoster), s.GetRegCodeByMemberID)
+	adminTokenNeeded.POST("api/reg-code/email", s.instScope(fromBody("id", s.regCodeInstID)), s.requirePermission(svc.PermissionSendRegCodes), s.SendRegCodeWithEmail)
+	adminTokenNeeded.POST("api/reg-code/sms", s.instScope(fromBody("id", s.regCodeInstID)), s.requirePermission(svc.PermissionSendRegCodes), s.SendRegCodeWithSMS)
+	adminTokenNeeded.POST("api/reg-code/regenerate", s.instScope(fromBody("member_id", s.memberInstID)), s.requirePermission(svc.PermissionSendRegCodes), s.RegenerateRegCode)
 
 	// Survey APIs
-	adminTokenNeeded.GET("api/surveys", s.GetManySurveys)
+	adminTokenNeeded.GET("api/surveys", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewSurveys), s.GetManySurveys)
 	authNotNeeded.POST("api/survey", s.CreateSurvey)
 
 	// Export APIs
-	adminTokenNeeded.GET("api/export/cc-records", s.ExportManyCCRecords)
-	adminTokenNeeded.GET("api/export/members", s.ExportManyMembers)
-	adminTokenNeeded.GET("api/export/families", s.ExportManyFamilies)
-	adminTokenNeeded.GET("api/export/wards", s.ExportManyWards)
-	adminTokenNeeded.GET("api/export/surveys", s.ExportManySurveys)
+	adminTokenNeeded.GET("api/export/cc-records", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionExport), s.ExportManyCCRecords)
+	adminTokenNeeded.GET("api/export/members", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionExport), s.ExportManyMembers)
+	adminTokenNeeded.GET("api/export/families", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionExport), s.ExportManyFamilies)
+	adminTokenNeeded.GET("api/export/wards", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionExport), s.ExportManyWards)
+	adminTokenNeeded.GET("api/export/surveys", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionExport), s.ExportManySurveys)
 
 	// Import APIs
-	adminTokenNeeded.POST("api/import/tags", s.ImportManyTags)
-	adminTokenNeeded.POST("api/import/members", s.ImportManyMembers)
-
-	// Config APIs
-	adminTokenNeeded.GET("api/configs", s.GetManyConfigs)
-	adminTokenNeeded.POST("api/config", s.CreateConfig)
-	adminTokenNeeded.PUT("api/config/:id", s.UpdateConfigByID)
-	adminTokenNeeded.POST("api/config/reload", s.RunReloadConfig)
+	adminTokenNeeded.POST("api/import/tags", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionManageRoster), s.ImportManyTags)
+	adminTokenNeeded.POST("api/import/members", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionManageRoster), s.ImportManyMembers)
+
+	// Audit APIs
+	adminTokenNeeded.GET("api/audit", s.instScope(fromQuery("instID", instIDAsIs)), s.requirePermission(svc.PermissionViewAudit), s.GetManyAuditEntries)
+
+	// Config APIs (shared by all Institutions)
+	superAdminTokenNeeded.GET("api/configs", s.GetManyConfigs)
+	superAdminTokenNeeded.POST("api/config", s.CreateConfig)
+	superAdminTokenNeeded.PUT("api/config/:id", s.UpdateConfigByID)
+	superAdminTokenNeeded.POST("api/config/reload", s.RunReloadConfig)
 
 }
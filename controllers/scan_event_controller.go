@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ScanEventKey - gin context key holding the *svc.ScanEvent of the Scan being handled
+var ScanEventKey = "ScanEvent"
+
+// scanResponseWriter - keeps the response written, for the Scan Event to record what the Scan was answered with
+type scanResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *scanResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *scanResponseWriter) WriteString(str string) (int, error) {
+	w.body.WriteString(str)
+	return w.ResponseWriter.WriteString(str)
+}
+
+// startScanEvent - put the Scan Event on the context, for handlers to add the Records it is applied to;
+// "recordScanEvent" stores it once answered
+func startScanEvent(c *gin.Context, e svc.ScanEvent) *svc.ScanEvent {
+	e.ReceivedAt = time.Now()
+	if device := getScanDevice(c); device != nil {
+		e.InstID = device.InstID
+	}
+	c.Writer = &scanResponseWriter{ResponseWriter: c.Writer}
+	c.Set(ScanEventKey, &e)
+	return &e
+}
+
+// getScanEvent - the Scan Event put on the context by "startScanEvent"; nil on other routes
+func getScanEvent(c *gin.Context) *svc.ScanEvent {
+	e, ok := c.Get(ScanEventKey)
+	if !ok {
+		return nil
+	}
+	return e.(*svc.ScanEvent)
+}
+
+// recordScanEvent - store the Scan Event with the decision made on it, from the response code unless the handler
+// made it. Failing to record is logged only, since the Scan has already been answered
+func (s *CCServer) recordScanEvent(c *gin.Context, e *svc.ScanEvent) {
+	e.ResponseCode = c.Writer.Status()
+	switch {
+	case len(e.Decision) > 0:
+	case e.ResponseCode < http.StatusBadRequest:
+		e.Decision = svc.ScanDecisionAccepted
+	case e.ResponseCode == http.StatusConflict:
+		e.Decision = svc.ScanDecisionConflict
+	case e.ResponseCode >= http.StatusInternalServerError:
+		e.Decision = svc.ScanDecisionError
+	default:
+		e.Decision = svc.ScanDecisionRejected
+	}
+	if w, ok := c.Writer.(*scanResponseWriter); ok {
+		var res struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(w.body.Bytes(), &res); err == nil {
+			e.Reason = res.Message
+		}
+	}
+
+	// The Scan is answered by now, so its event is recorded even if the client has hung up
+	ctx, cancel := s.backgroundDBContext()
+	defer cancel()
+	if _, err := s.Stores.ScanEvents.CreateScanEvent(ctx, *e); err != nil {
+		log.Printf("Error while recording Scan Event - %v\n", err)
+	}
+}
+
+// newScanParseResult - as is; "err" is why the Payload was not recognized
+func newScanParseResult(p *ScanPayload, err error) svc.ScanParseResult {
+	if err != nil {
+		return svc.ScanParseResult{ReasonCode: string(scanPayloadReason(err))}
+	}
+	return svc.ScanParseResult{
+		Valid:          true,
+		Version:        p.Version,
+		Type:           string(scanMemberType(p.Type)),
+		InstIdentifier: p.InstIdentifier,
+		MemberTagID:    p.MemberTagID,
+		WardID:         p.WardID,
+		Stage:          p.Stage,
+		IsSingleEvent:  p.IsSingleEvent,
+		Time:           p.Time,
+	}
+}
+
+// getScanEventParams - parse the QueryString of Scan Event queries; bad dates are answered with 400
+func getScanEventParams(c *gin.Context) (svc.GetScanEventParams, bool) {
+	params := svc.GetScanEventParams{
+		InstID:     c.Query("instID"),
+		DeviceID:   c.Query("deviceID"),
+		CCRecordID: c.Query("ccRecordID"),
+		Decision:   c.Query("decision"),
+	}
+	for key, t := range map[string]*time.Time{"startDate": &params.StartDate, "endDate": &params.EndDate} {
+		param, ok := c.GetQuery(key)
+		if !ok {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Bad Scan Event Query Parameters",
+			})
+			return svc.GetScanEventParams{}, false
+		}
+		*t = parsed
+	}
+	return params, true
+}
+
+// getManyScanEvents - as is, with the cursor of the next page
+func (s *CCServer) getManyScanEvents(c *gin.Context, params svc.GetScanEventParams) ([]svc.ScanEvent, string, bool) {
+	cursor, err := s.Stores.ScanEvents.GetManyScanEvents(c.Request.Context(), &params)
+	if err != nil {
+		log.Printf("Error while getting Scan Events - %v\n", err)
+		respondListError(c, err)
+		return nil, "", false
+	}
+	events := []svc.ScanEvent{}
+	nextCursor, err := svc.DecodeList(c.Request.Context(), cursor, &events)
+	if err != nil {
+		log.Printf("Error while decoding Scan Events - %v\n", err)
+		respondServerError(c, err)
+		return nil, "", false
+	}
+	return events, nextCursor, true
+}
+
+// GetManyScanEvents - query a page of the Scan Events, oldest first unless sorted otherwise; Admins only see
+// those of their own Institution
+func (s *CCServer) GetManyScanEvents(c *gin.Context) {
+	params, ok := getScanEventParams(c)
+	if !ok {
+		return
+	}
+	if claims := getSessionClaims(c); claims != nil && claims.Role != SessionRoleSuperAdmin {
+		params.InstID = claims.InstID
+	}
+	var err error
+	if params.List, err = s.extractListParams(c); err != nil {
+		respondListError(c, err)
+		return
+	}
+	events, nextCursor, ok := s.getManyScanEvents(c, params)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "All Scan Events",
+		"data":        events,
+		"next_cursor": nextCursor,
+	})
+	return
+}
+
+// GetMaskComplianceReport - mask rates of the Check-Ins among the Scan Events queried, by group, device & day;
+// Admins only get those of their own Institution
+func (s *CCServer) GetMaskComplianceReport(c *gin.Context) {
+	params, ok := getScanEventParams(c)
+	if !ok {
+		return
+	}
+	if claims := getSessionClaims(c); claims != nil && claims.Role != SessionRoleSuperAdmin {
+		params.InstID = claims.InstID
+	}
+	events, _, ok := s.getManyScanEvents(c, params)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Mask Compliance Report",
+		"data":    svc.NewMaskComplianceReport(events),
+	})
+	return
+}
+
+// RebuildCCRecord - rebuild a CCRecord from the Scan Events applied to it; with "dry_run=true",
+// the rebuilt Record is returned without being saved
+func (s *CCServer) RebuildCCRecord(c *gin.Context) {
+	id := c.Param("id")
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	ccRecord := svc.CCRecord{}
+	err := s.Stores.CCRecords.GetCCRecordByID(c.Request.Context(), id).Decode(&ccRecord)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "CCRecord Not Found",
+			})
+			return
+		}
+		log.Printf("Error while getting CCRecord by ID - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	events, _, ok := s.getManyScanEvents(c, svc.GetScanEventParams{CCRecordID: id})
+	if !ok {
+		return
+	}
+
+	rebuilt, err := svc.ProjectCCRecord(ccRecord, events)
+	if err == svc.ErrNoScanEvents {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "CCRecord has no Scan Events to be rebuilt from",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error while rebuilding CCRecord %v from Scan Events - %v\n", id, err)
+		respondServerError(c, err)
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "CCRecord Rebuilt, not saved",
+			"data":    rebuilt,
+		})
+		return
+	}
+
+	_, err = s.Stores.CCRecords.ReplaceCCRecord(c.Request.Context(), ccRecord, rebuilt)
+	if err == svc.ErrCCRecordConflict {
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "CCRecord was updated while being rebuilt, try again",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error while replacing CCRecord - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetCCRecord, id, ccRecord.InstID, ccRecord, rebuilt)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "CCRecord Rebuilt Successfully",
+		"data":    rebuilt,
+	})
+	return
+}
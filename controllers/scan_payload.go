@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScanResultType - as is
+type ScanResultType int
+
+// ScanResultType Enum Defs
+const (
+	ScanResultGWType     ScanResultType = 1
+	ScanResultMemberType                = 2
+	ScanResultTagType                   = 3
+)
+
+// ScanPayload - what a Scan Payload is parsed into. Guardian payloads are of a single Ward, or of the whole Family;
+// Tag payloads carry no Stage, which is found from the Record of the Tag
+type ScanPayload struct {
+	Version        int
+	InstIdentifier string
+	MemberTagID    string
+	WardID         string
+	Stage          string
+	IsSingleEvent  bool
+	Time           time.Time
+	Type           ScanResultType
+}
+
+// ScanPayloadReason - why a Scan Payload could not be parsed; answered as "reason_code"
+type ScanPayloadReason string
+
+// ScanPayloadReason Enum Defs
+const (
+	ScanPayloadEmpty          ScanPayloadReason = "empty_payload"
+	ScanPayloadUnknownFormat  ScanPayloadReason = "unknown_format"
+	ScanPayloadUnknownVersion ScanPayloadReason = "unknown_version"
+	ScanPayloadMalformedField ScanPayloadReason = "malformed_field"
+	ScanPayloadUnknownField   ScanPayloadReason = "unknown_field"
+	ScanPayloadDuplicateField ScanPayloadReason = "duplicate_field"
+	ScanPayloadMissingField   ScanPayloadReason = "missing_field"
+	ScanPayloadBadType        ScanPayloadReason = "bad_type"
+	ScanPayloadBadID          ScanPayloadReason = "bad_id"
+	ScanPayloadBadStage       ScanPayloadReason = "bad_stage"
+	ScanPayloadBadTimestamp   ScanPayloadReason = "bad_timestamp"
+)
+
+// ScanPayloadError - returned by "ParseScanPayload"
+type ScanPayloadError struct {
+	Reason ScanPayloadReason
+	Detail string
+}
+
+func (e *ScanPayloadError) Error() string {
+	return fmt.Sprintf("%v - %v", e.Reason, e.Detail)
+}
+
+// scanPayloadReason - the Reason of an error of "ParseScanPayload"; errors of no Reason are of an unknown format
+func scanPayloadReason(err error) ScanPayloadReason {
+	var pErr *ScanPayloadError
+	if errors.As(err, &pErr) {
+		return pErr.Reason
+	}
+	return ScanPayloadUnknownFormat
+}
+
+func scanPayloadErr(reason ScanPayloadReason, format string, args ...interface{}) *ScanPayloadError {
+	return &ScanPayloadError{Reason: reason, Detail: fmt.Sprintf(format, args...)}
+}
+
+// scanPayloadV2Prefix - versioned payloads are "v<N>:" followed by "name=value" fields separated by ";"
+const scanPayloadV2Prefix = "v2:"
+
+// scanPayloadV2Fields - the fields of each type of v2 payload, and whether they are required.
+// Guardian payloads with a "ward" are of that Ward only
+var scanPayloadV2Fields = map[string]map[string]bool{
+	"member":   {"type": true, "id": true, "stage": true, "ts": true},
+	"guardian": {"type": true, "id": true, "ward": false, "stage": true, "ts": true},
+	"tag":      {"type": true, "inst": true, "tag": true, "ts": true},
+}
+
+// ParseScanPayload - parse a (signature-less) Scan Payload, in the v2 grammar:
+//
+//	v2:type=member;id=<MemberID>;stage=<checkin|checkout>;ts=<unix ms>
+//	v2:type=guardian;id=<MemberID>[;ward=<WardID>];stage=<checkin|checkout>;ts=<unix ms>
+//	v2:type=tag;inst=<Institution Identifier>;tag=<TagString>;ts=<unix ms>
+//
+// or in one of the legacy formats, separated by "|":
+//
+//	MemberID|stage|timestamp, MemberID|WardID|stage|single|timestamp, MemberID|stage|all|timestamp,
+//	and InstIdentifier|TagString|stage|timestamp
+func ParseScanPayload(s string) (*ScanPayload, error) {
+	if len(s) == 0 {
+		return nil, scanPayloadErr(ScanPayloadEmpty, "payload is empty")
+	}
+	if strings.HasPrefix(s, scanPayloadV2Prefix) {
+		return parseScanPayloadV2(strings.TrimPrefix(s, scanPayloadV2Prefix))
+	}
+	if len(s) > 1 && s[0] == 'v' && strings.Contains(s, ":") {
+		version := s[1:strings.Index(s, ":")]
+		if _, err := strconv.Atoi(version); err == nil {
+			return nil, scanPayloadErr(ScanPayloadUnknownVersion, "version %q is not supported", version)
+		}
+	}
+	return parseScanPayloadLegacy(s)
+}
+
+func parseScanPayloadV2(s string) (*ScanPayload, error) {
+	fields := map[string]string{}
+	for _, field := range strings.Split(s, ";") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 || len(kv[1]) == 0 {
+			return nil, scanPayloadErr(ScanPayloadMalformedField, "field %q is not name=value", field)
+		}
+		if _, ok := fields[kv[0]]; ok {
+			return nil, scanPayloadErr(ScanPayloadDuplicateField, "field %q is given twice", kv[0])
+		}
+		fields[kv[0]] = kv[1]
+	}
+	spec, ok := scanPayloadV2Fields[fields["type"]]
+	if !ok {
+		return nil, scanPayloadErr(ScanPayloadBadType, "type %q is not one of member, guardian or tag", fields["type"])
+	}
+	for name := range fields {
+		if _, ok := spec[name]; !ok {
+			return nil, scanPayloadErr(ScanPayloadUnknownField, "field %q is not one of a %v payload", name, fields["type"])
+		}
+	}
+	for name, required := range spec {
+		if _, ok := fields[name]; required && !ok {
+			return nil, scanPayloadErr(ScanPayloadMissingField, "field %q is missing", name)
+		}
+	}
+
+	p := ScanPayload{Version: 2}
+	var err error
+	if p.Time, err = parseScanTimestamp(fields["ts"]); err != nil {
+		return nil, err
+	}
+	switch fields["type"] {
+	case "tag":
+		p.Type = ScanResultTagType
+		p.InstIdentifier = fields["inst"]
+		p.MemberTagID = fields["tag"]
+		return &p, nil
+	case "guardian":
+		p.Type = ScanResultGWType
+		if ward, ok := fields["ward"]; ok {
+			if !isObjectIDHex(ward) {
+				return nil, scanPayloadErr(ScanPayloadBadID, "ward %q is not an ID", ward)
+			}
+			p.WardID = ward
+			p.IsSingleEvent = true
+		}
+	default:
+		p.Type = ScanResultMemberType
+	}
+	if !isObjectIDHex(fields["id"]) {
+		return nil, scanPayloadErr(ScanPayloadBadID, "id %q is not an ID", fields["id"])
+	}
+	p.MemberTagID = fields["id"]
+	if p.Stage, err = parseScanStage(fields["stage"]); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// FormatScanPayload - the v2 Payload of "p", as the Mobile App & Tags are to make it (before signing)
+func FormatScanPayload(p ScanPayload) string {
+	ts := "ts=" + strconv.FormatInt(p.Time.Unix()*1000, 10)
+	switch p.Type {
+	case ScanResultTagType:
+		return scanPayloadV2Prefix + strings.Join([]string{"type=tag", "inst=" + p.InstIdentifier, "tag=" + p.MemberTagID, ts}, ";")
+	case ScanResultGWType:
+		fields := []string{"type=guardian", "id=" + p.MemberTagID}
+		if p.IsSingleEvent {
+			fields = append(fields, "ward="+p.WardID)
+		}
+		return scanPayloadV2Prefix + strings.Join(append(fields, "stage="+p.Stage, ts), ";")
+	}
+	return scanPayloadV2Prefix + strings.Join([]string{"type=member", "id=" + p.MemberTagID, "stage=" + p.Stage, ts}, ";")
+}
+
+// parseScanPayloadLegacy - payloads of Mobile Apps & Tags made before v2. Member & Guardian payloads start with
+// an ObjectID; Tag payloads start with the Identifier of the Institution, which never is one
+func parseScanPayloadLegacy(s string) (*ScanPayload, error) {
+	contents := strings.Split(s, "|")
+	p := ScanPayload{Version: 1}
+	switch {
+	case len(contents) == 3:
+		// Member Case
+		p.Type = ScanResultMemberType
+		p.MemberTagID = contents[0]
+		p.Stage = contents[1]
+	case len(contents) == 4 && !isObjectIDHex(contents[0]):
+		// Tag Case
+		if len(contents[0]) == 0 || len(contents[1]) == 0 {
+			return nil, scanPayloadErr(ScanPayloadMissingField, "identifier or tag is empty")
+		}
+		p.Type = ScanResultTagType
+		p.InstIdentifier = contents[0]
+		p.MemberTagID = contents[1]
+	case len(contents) == 4 && contents[2] == "all":
+		// GW Case - All
+		p.Type = ScanResultGWType
+		p.MemberTagID = contents[0]
+		p.Stage = contents[1]
+	case len(contents) == 5 && contents[3] == "single":
+		// GW Case - Single
+		if !isObjectIDHex(contents[1]) {
+			return nil, scanPayloadErr(ScanPayloadBadID, "ward %q is not an ID", contents[1])
+		}
+		p.Type = ScanResultGWType
+		p.MemberTagID = contents[0]
+		p.WardID = contents[1]
+		p.Stage = contents[2]
+		p.IsSingleEvent = true
+	default:
+		return nil, scanPayloadErr(ScanPayloadUnknownFormat, "payload of %v fields is not in a known format", len(contents))
+	}
+
+	var err error
+	if p.Time, err = parseScanTimestamp(contents[len(contents)-1]); err != nil {
+		return nil, err
+	}
+	if p.Type == ScanResultTagType {
+		return &p, nil
+	}
+	if !isObjectIDHex(p.MemberTagID) {
+		return nil, scanPayloadErr(ScanPayloadBadID, "id %q is not an ID", p.MemberTagID)
+	}
+	if p.Stage, err = parseScanStage(p.Stage); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// parseScanTimestamp - as is; timestamps are in milliseconds
+func parseScanTimestamp(ts string) (time.Time, error) {
+	timestamp, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || timestamp < 0 {
+		return time.Time{}, scanPayloadErr(ScanPayloadBadTimestamp, "timestamp %q is not in unix milliseconds", ts)
+	}
+	return time.Unix(timestamp/1000, 0), nil
+}
+
+// parseScanStage - Gatekeepers only check in or out; check-outs are scheduled from the Mobile App
+func parseScanStage(stage string) (string, error) {
+	if stage != "checkin" && stage != "checkout" {
+		return "", scanPayloadErr(ScanPayloadBadStage, "stage %q is not checkin or checkout", stage)
+	}
+	return stage, nil
+}
+
+// isObjectIDHex - whether "s" is the hex of an ObjectID, as the IDs of Members & Wards are
+func isObjectIDHex(s string) bool {
+	_, err := primitive.ObjectIDFromHex(s)
+	return err == nil
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// scanSignaturePrefix - signed payloads end with "|sig:<hex HMAC-SHA256 of everything before it>"
+const scanSignaturePrefix = "|sig:"
+
+// SignScanPayload - append the signature of the payload, as done by the Mobile App
+func SignScanPayload(key string, payload string) string {
+	return payload + scanSignaturePrefix + signScanPayload(key, payload)
+}
+
+// splitScanSignature - return (payload, signature); signature is empty for unsigned payloads
+func splitScanSignature(signedPayload string) (string, string) {
+	i := strings.LastIndex(signedPayload, scanSignaturePrefix)
+	if i < 0 {
+		return signedPayload, ""
+	}
+	return signedPayload[:i], signedPayload[i+len(scanSignaturePrefix):]
+}
+
+// MemberQRSigningKey - key a Member's Mobile App signs payloads with, derived from the key of their Institution, so
+// that no Member can sign payloads of another
+func MemberQRSigningKey(instKey string, memberID string) string {
+	return signScanPayload(instKey, memberID)
+}
+
+// verifyScanPayload - check the signature with the key of the Member, and the freshness of the payload.
+// Only Mobile App payloads (Member & Guardian) are signed; Tag payloads carry no ObjectID to forge.
+func (s *CCServer) verifyScanPayload(c *gin.Context, payload string, signature string, sResultContent *ScanPayload) bool {
+	if len(signature) == 0 {
+		if !s.Config.ScanConf.AllowUnsignedPayloads {
+			return rejectScanPayload(c, "Scan Payload is not signed")
+		}
+		// Legacy payloads skip the signature only; they are not let through when replayed
+		log.Printf("verifyScanPayload - accepting unsigned legacy payload\n")
+		return s.checkScanPayloadFresh(c, sResultContent)
+	}
+
+	// Get Key of the Member's Institution
+	member := svc.Member{}
+	err := s.Stores.Members.GetMemberByID(c.Request.Context(), sResultContent.MemberTagID).Decode(&member)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return rejectScanPayload(c, "Scan Payload is not valid")
+		}
+		log.Printf("Error while Getting Member By ID - %v\n", err)
+		respondServerError(c, err)
+		return false
+	}
+	key, err := s.Stores.Insts.GetInstQRSigningKey(c.Request.Context(), member.InstID)
+	if err != nil {
+		log.Printf("Error while getting QR Signing Key of Institution - %v\n", err)
+		respondServerError(c, err)
+		return false
+	}
+
+	key = MemberQRSigningKey(key, sResultContent.MemberTagID)
+	if !hmac.Equal([]byte(signature), []byte(signScanPayload(key, payload))) {
+		return rejectScanPayload(c, "Scan Payload signature is not valid")
+	}
+	return s.checkScanPayloadFresh(c, sResultContent)
+}
+
+// checkScanPayloadFresh - reject replayed payloads, i.e. those timed outside the freshness window
+func (s *CCServer) checkScanPayloadFresh(c *gin.Context, sResultContent *ScanPayload) bool {
+	window := time.Duration(s.Config.ScanConf.FreshnessWindowSeconds) * time.Second
+	age := time.Since(sResultContent.Time)
+	if age > window || age < -window {
+		return rejectScanPayload(c, "Scan Payload has expired")
+	}
+	return true
+}
+
+func rejectScanPayload(c *gin.Context, reason string) bool {
+	log.Printf("Scan rejected - %v\n", reason)
+	c.JSON(http.StatusForbidden, gin.H{
+		"success": false,
+		"message": reason,
+	})
+	return false
+}
+
+func signScanPayload(key string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recordScreeningFailure - open a case for a Record the event failed, and exclude its subject as the policy the event
+// was screened by says. The Scan is applied by now, so failing to record either is logged only
+func (s *CCServer) recordScreeningFailure(ccRecord svc.CCRecord, newEventData svc.NewEventData) {
+	now := time.Now()
+	screeningCase, ok := svc.ScreeningCaseFor(ccRecord, newEventData, now)
+	if !ok {
+		return
+	}
+	ctx, cancel := s.backgroundDBContext()
+	defer cancel()
+	if exclusion, ok := svc.ExclusionFor(ccRecord, newEventData, now); ok {
+		res, err := s.Stores.Exclusions.CreateExclusion(ctx, exclusion)
+		if err != nil {
+			log.Printf("Error while recording Exclusion of %v - %v\n", exclusion.SubjectID, err)
+		} else {
+			screeningCase.ExclusionID = res.InsertedID.(primitive.ObjectID).Hex()
+		}
+	}
+	if _, err := s.Stores.ScreeningCases.CreateScreeningCase(ctx, screeningCase); err != nil {
+		log.Printf("Error while recording Screening Case of %v - %v\n", screeningCase.CCRecordID, err)
+	}
+}
+
+// getScreeningCaseOf - the case of the Record an Exclusion was made for; nil when there is none
+func (s *CCServer) getScreeningCaseOf(c *gin.Context, exclusion svc.Exclusion) (*svc.ScreeningCase, bool) {
+	screeningCase := svc.ScreeningCase{}
+	err := s.Stores.ScreeningCases.GetScreeningCaseByCCRecordID(c.Request.Context(), exclusion.CCRecordID).Decode(&screeningCase)
+	if err == mongo.ErrNoDocuments {
+		return nil, true
+	}
+	if err != nil {
+		log.Printf("Error while getting Screening Case of %v - %v\n", exclusion.CCRecordID, err)
+		respondServerError(c, err)
+		return nil, false
+	}
+	return &screeningCase, true
+}
+
+// GetManyScreeningCases - a page of the cases of the Institution, latest first unless sorted otherwise, with their
+// readings in its unit. Admins only see those of their own Institution
+func (s *CCServer) GetManyScreeningCases(c *gin.Context) {
+	params := svc.GetScreeningCaseParams{
+		InstID:     c.Query("instID"),
+		Status:     c.Query("status"),
+		AssigneeID: c.Query("assigneeID"),
+		SubjectID:  c.Query("subjectID"),
+		CCRecordID: c.Query("ccRecordID"),
+	}
+	if claims := getSessionClaims(c); claims != nil && claims.Role != SessionRoleSuperAdmin {
+		params.InstID = claims.InstID
+	}
+	var err error
+	if params.List, err = s.extractListParams(c); err != nil {
+		respondListError(c, err)
+		return
+	}
+	unit := svc.CanonicalTemperatureUnit
+	if len(params.InstID) > 0 {
+		inst, ok := s.getInst(c, params.InstID)
+		if !ok {
+			return
+		}
+		unit = s.instTemperatureUnit(inst)
+	}
+
+	cursor, err := s.Stores.ScreeningCases.GetManyScreeningCases(c.Request.Context(), &params)
+	if err != nil {
+		log.Printf("Error while getting Screening Cases - %v\n", err)
+		respondListError(c, err)
+		return
+	}
+	screeningCases := []svc.ScreeningCase{}
+	nextCursor, err := svc.DecodeList(c.Request.Context(), cursor, &screeningCases)
+	if err != nil {
+		log.Printf("Error while decoding Screening Cases - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	for i, screeningCase := range screeningCases {
+		screeningCases[i] = screeningCase.InUnit(unit)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "All Screening Cases",
+		"data":             screeningCases,
+		"temperature_unit": unit,
+		"next_cursor":      nextCursor,
+	})
+	return
+}
+
+// AssignScreeningCase - assign a case to an Admin of its Institution
+func (s *CCServer) AssignScreeningCase(c *gin.Context) {
+	var form svc.ScreeningCaseAssignForm
+	c.BindJSON(&form)
+	if ok := s.checkForm(c, form); !ok {
+		return
+	}
+
+	s.updateScreeningCase(c, func(before svc.ScreeningCase, entry svc.ScreeningCaseEntry) (svc.ScreeningCaseUpdate, bool) {
+		assignee := svc.Admin{}
+		err := s.Stores.Admins.GetAdminByID(c.Request.Context(), form.AssigneeID).Decode(&assignee)
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Printf("Error while getting Admin by ID - %v\n", err)
+			respondServerError(c, err)
+			return svc.ScreeningCaseUpdate{}, false
+		}
+		if err == mongo.ErrNoDocuments || assignee.InstID != before.InstID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Assignee is not an Admin of the Institution",
+			})
+			return svc.ScreeningCaseUpdate{}, false
+		}
+		entry.Type = svc.ScreeningCaseEntryAssigned
+		entry.AssigneeID = form.AssigneeID
+		return svc.ScreeningCaseUpdate{AssigneeID: form.AssigneeID, Entry: entry}, true
+	})
+}
+
+// AddScreeningCaseNote - add a follow-up note to a case
+func (s *CCServer) AddScreeningCaseNote(c *gin.Context) {
+	var form svc.ScreeningCaseNoteForm
+	c.BindJSON(&form)
+	if ok := s.checkForm(c, form); !ok {
+		return
+	}
+
+	s.updateScreeningCase(c, func(before svc.ScreeningCase, entry svc.ScreeningCaseEntry) (svc.ScreeningCaseUpdate, bool) {
+		entry.Type = svc.ScreeningCaseEntryNote
+		entry.Note = form.Note
+		return svc.ScreeningCaseUpdate{Entry: entry}, true
+	})
+}
+
+// RetestScreeningCase - record a retest reading, screened by the Institution's policy; the latest one is kept
+func (s *CCServer) RetestScreeningCase(c *gin.Context) {
+	var form svc.ScreeningCaseRetestForm
+	c.BindJSON(&form)
+	if ok := s.checkForm(c, form); !ok {
+		return
+	}
+
+	s.updateScreeningCase(c, func(before svc.ScreeningCase, entry svc.ScreeningCaseEntry) (svc.ScreeningCaseUpdate, bool) {
+		inst, ok := s.getInst(c, before.InstID)
+		if !ok {
+			return svc.ScreeningCaseUpdate{}, false
+		}
+		policy := s.instScreeningPolicy(inst)
+		unit := form.Unit
+		if len(unit) == 0 {
+			unit = policy.Unit
+		}
+		temperature := svc.ToCanonicalTemperature(form.Temperature, unit)
+		retest := svc.ScreeningCaseRetest{
+			Temperature:         temperature,
+			ReportedTemperature: form.Temperature,
+			ReportedUnit:        unit,
+			Passed:              policy.Screen(svc.CCStage(before.Stage), temperature, true).Passed,
+			At:                  entry.At,
+		}
+		entry.Type = svc.ScreeningCaseEntryRetest
+		entry.Note = form.Note
+		entry.Temperature = temperature
+		entry.Status = svc.ScreeningCaseRetested
+		return svc.ScreeningCaseUpdate{Status: svc.ScreeningCaseRetested, Retest: &retest, Entry: entry}, true
+	})
+}
+
+// DecideScreeningCase - close a case, sending its subject home or clearing them. Clearing also clears the
+// Exclusion of the case, letting them check in again
+func (s *CCServer) DecideScreeningCase(c *gin.Context) {
+	var form svc.ScreeningCaseDecisionForm
+	c.BindJSON(&form)
+	if ok := s.checkForm(c, form); !ok {
+		return
+	}
+
+	after, ok := s.updateScreeningCase(c, func(before svc.ScreeningCase, entry svc.ScreeningCaseEntry) (svc.ScreeningCaseUpdate, bool) {
+		entry.Type = svc.ScreeningCaseEntryDecided
+		entry.Note = form.Note
+		entry.Status = form.Decision
+		return svc.ScreeningCaseUpdate{Status: form.Decision, Entry: entry}, true
+	})
+	if !ok || after.Status != svc.ScreeningCaseCleared || len(after.ExclusionID) == 0 {
+		return
+	}
+	// The decision is made by now; an Exclusion left uncleared is not held against a cleared case anyway
+	_, err := s.Stores.Exclusions.ClearExclusion(c.Request.Context(), after.ExclusionID, getSessionSubject(c), time.Now())
+	if err != nil {
+		log.Printf("Error while clearing Exclusion of Screening Case %v - %v\n", after.ID.Hex(), err)
+	}
+}
+
+// updateScreeningCase - apply the update "makeUpdate" makes of an open case, given the entry to add to its Timeline,
+// and answer it. Closed cases are not updated
+func (s *CCServer) updateScreeningCase(c *gin.Context,
+	makeUpdate func(before svc.ScreeningCase, entry svc.ScreeningCaseEntry) (svc.ScreeningCaseUpdate, bool)) (svc.ScreeningCase, bool) {
+	idToUpdate := c.Param("id")
+	before := svc.ScreeningCase{}
+	err := s.Stores.ScreeningCases.GetScreeningCaseByID(c.Request.Context(), idToUpdate).Decode(&before)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error while getting Screening Case by ID - %v\n", err)
+		respondServerError(c, err)
+		return svc.ScreeningCase{}, false
+	}
+	if err == mongo.ErrNoDocuments || before.Status.IsClosed() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "ID To Update Screening Case Not Found, or closed",
+		})
+		return svc.ScreeningCase{}, false
+	}
+
+	update, ok := makeUpdate(before, svc.ScreeningCaseEntry{
+		ActorID: getSessionSubject(c),
+		Status:  before.Status,
+		At:      time.Now(),
+	})
+	if !ok {
+		return svc.ScreeningCase{}, false
+	}
+	res, err := s.Stores.ScreeningCases.UpdateOpenScreeningCase(c.Request.Context(), idToUpdate, update)
+	if err != nil {
+		log.Printf("Error while updating Screening Case in DB - %v\n", err)
+		respondServerError(c, err)
+		return svc.ScreeningCase{}, false
+	}
+	if res.MatchedCount == 0 {
+		// Closed by another Admin since it was read
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "ID To Update Screening Case Not Found, or closed",
+		})
+		return svc.ScreeningCase{}, false
+	}
+
+	after := svc.ScreeningCase{}
+	s.Stores.ScreeningCases.GetScreeningCaseByID(c.Request.Context(), idToUpdate).Decode(&after)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetScreeningCase, idToUpdate, before.InstID, before, after)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Screening Case updated Successfully",
+	})
+	return after, true
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultScreeningPolicy - the policy of Institutions without one of their own, from the Config
+func (s *CCServer) defaultScreeningPolicy() svc.ScreeningPolicy {
+	return svc.ScreeningPolicy{
+		Threshold:           s.Config.TempThrd,
+		Unit:                svc.TemperatureUnitF,
+		RequireCheckOutTemp: s.Config.RequireCheckOutTemp,
+	}
+}
+
+// instScreeningPolicy - the policy the Scans of "inst" are screened by
+func (s *CCServer) instScreeningPolicy(inst svc.Institution) svc.ScreeningPolicy {
+	if inst.ScreeningPolicy == nil {
+		return s.defaultScreeningPolicy()
+	}
+	return *inst.ScreeningPolicy
+}
+
+// instTemperatureUnit - the unit the temperatures of "inst" are rendered in, that of its policy
+func (s *CCServer) instTemperatureUnit(inst svc.Institution) svc.TemperatureUnit {
+	return s.instScreeningPolicy(inst).Unit
+}
+
+// screenScan - screen a Scan of "stage" by "policy"; readings outside of the allowed range are sensor errors,
+// rejected with 400, and Scans denied by the MaskPolicy are answered as failed. Neither is applied
+func (s *CCServer) screenScan(c *gin.Context, policy svc.ScreeningPolicy, stage svc.CCStage, sPostingForm svc.ScanPostingForm) (svc.Screening, bool) {
+	scanEvent := getScanEvent(c)
+	if scanEvent != nil {
+		scanEvent.Stage = string(stage)
+	}
+	if err := policy.CheckReading(stage, sPostingForm.Temperature); err != nil {
+		log.Printf("Scan rejected - %v - %v\n", err, sPostingForm.Temperature)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success":     false,
+			"message":     "Temperature is out of the allowed range",
+			"reason_code": "temperature_out_of_range",
+		})
+		return svc.Screening{}, false
+	}
+	screening := policy.Screen(stage, sPostingForm.Temperature, sPostingForm.Mask)
+	if screening.Denied {
+		log.Printf("Scan denied - %v\n", screening.Reasons)
+		if scanEvent != nil {
+			scanEvent.Decision = svc.ScanDecisionDenied
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "Scan denied - a mask is required",
+			"data":    s.Config.ServerAddr + surveyBaseAddr + "failed-page.html",
+			"stage":   stage,
+			"reasons": screening.Reasons,
+		})
+		return svc.Screening{}, false
+	}
+	return screening, true
+}
+
+// GetScreeningPolicy - the policy of the Institution; "is_default" when it has none of its own
+func (s *CCServer) GetScreeningPolicy(c *gin.Context) {
+	inst := svc.Institution{}
+	err := s.Stores.Insts.GetInstByID(c.Request.Context(), c.Param("id")).Decode(&inst)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Institution not found",
+			})
+			return
+		}
+		log.Printf("Error while getting institution by ID - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Screening Policy Found",
+		"data":       s.instScreeningPolicy(inst),
+		"is_default": inst.ScreeningPolicy == nil,
+	})
+	return
+}
+
+// UpdateScreeningPolicy - replace the policy of the Institution; the whole policy is to be given
+func (s *CCServer) UpdateScreeningPolicy(c *gin.Context) {
+	var policy svc.ScreeningPolicy
+	if err := c.BindJSON(&policy); err != nil {
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Bad Screening Policy - " + err.Error(),
+		})
+		return
+	}
+
+	idToUpdate := c.Param("id")
+	before := svc.Institution{}
+	s.Stores.Insts.GetInstByID(c.Request.Context(), idToUpdate).Decode(&before)
+	res, err := s.Stores.Insts.UpdateInstScreeningPolicy(c.Request.Context(), idToUpdate, policy)
+	if err != nil {
+		log.Printf("Error while updating Screening Policy in DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "ID To Update Institution Not Found",
+		})
+		return
+	}
+
+	after := svc.Institution{}
+	s.Stores.Insts.GetInstByID(c.Request.Context(), idToUpdate).Decode(&after)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetInst, idToUpdate, idToUpdate, before, after)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Screening Policy updated Successfully",
+	})
+	return
+}
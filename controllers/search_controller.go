@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	searchMinLength    = 2
+	searchDefaultLimit = 20
+	searchMaxLimit     = 100
+)
+
+// Search - find Members, Tags, Wards, Families & Vehicles of an Institution by part of a name, phone #,
+// tag string or plate; "limit" bounds the Members, Tags & Families searched, each
+func (s *CCServer) Search(c *gin.Context) {
+	instID := c.DefaultQuery("instID", "000000000000000000000000")
+	q := svc.NewSearchQuery(c.Query("q"))
+	limit := int64(searchDefaultLimit)
+	if param, ok := c.GetQuery("limit"); ok {
+		parsed, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Bad Search Query Parameters",
+			})
+			return
+		}
+		limit = parsed
+	}
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+	if q.Len() < searchMinLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Search term should be at least 2 characters",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	results := []svc.SearchResult{}
+
+	cursor, err := s.Stores.Members.SearchMembers(ctx, instID, q, limit)
+	members := []svc.Member{}
+	if err == nil {
+		_, err = svc.DecodeList(ctx, cursor, &members)
+	}
+	if err != nil {
+		log.Printf("Error while searching members - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	for _, m := range members {
+		if res, ok := q.SearchMember(m); ok {
+			results = append(results, res)
+		}
+	}
+
+	cursor, err = s.Stores.Tags.SearchTags(ctx, instID, q, limit)
+	tags := []svc.Tag{}
+	if err == nil {
+		_, err = svc.DecodeList(ctx, cursor, &tags)
+	}
+	if err != nil {
+		log.Printf("Error while searching Tags - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	for _, t := range tags {
+		if res, ok := q.SearchTag(t); ok {
+			results = append(results, res)
+		}
+	}
+
+	cursor, err = s.Stores.Families.SearchFamilies(ctx, instID, q, limit)
+	families := []svc.Family{}
+	if err == nil {
+		_, err = svc.DecodeList(ctx, cursor, &families)
+	}
+	if err != nil {
+		log.Printf("Error while searching families - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	for _, f := range families {
+		results = append(results, q.SearchFamily(f)...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Search Results",
+		"data":    results,
+	})
+	return
+}
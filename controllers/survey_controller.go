@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"context"
 	"log"
 	"net/http"
 
@@ -13,25 +12,35 @@ import (
 func (s *CCServer) GetManySurveys(c *gin.Context) {
 	var queryParams svc.GetSurveyParams
 	queryParams.InstID = c.DefaultQuery("instID", "000000000000000000000000")
+	queryParams.MemberID = c.Query("memberID")
+	err := extractDateRange(c, &queryParams.StartDate, &queryParams.EndDate)
+	if err == nil {
+		queryParams.List, err = s.extractListParams(c)
+	}
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
 
-	cursor, err := svc.GetManySurveys(&queryParams)
+	cursor, err := s.Stores.Surveys.GetManySurveys(c.Request.Context(), &queryParams)
 
 	if err != nil {
 		log.Printf("Error while getting all surveys - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		respondListError(c, err)
 		return
 	}
 
 	surveys := []svc.Survey{}
-	if err = cursor.All(context.TODO(), &surveys); err != nil {
-		panic(err)
+	nextCursor, err := svc.DecodeList(c.Request.Context(), cursor, &surveys)
+	if err != nil {
+		log.Printf("Error while decoding surveys - %v\n", err)
+		respondServerError(c, err)
+		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": "All Surveys",
-		"data":    surveys,
+		"message":     "All Surveys",
+		"data":        surveys,
+		"next_cursor": nextCursor,
 	})
 	return
 }
@@ -43,13 +52,11 @@ func (s *CCServer) CreateSurvey(c *gin.Context) {
 
 	log.Printf("survey Reg Form - %v\n", sRegForm)
 
-	_, err := svc.CreateSurvey(sRegForm, sRegForm.QAList)
+	_, err := s.Stores.Surveys.CreateSurvey(c.Request.Context(), sRegForm, sRegForm.QAList)
 
 	if err != nil {
 		log.Printf("Error while inserting new Survey into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -1,14 +1,15 @@
 package controllers
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -16,28 +17,38 @@ import (
 func (s *CCServer) GetManyTags(c *gin.Context) {
 	var queryParams svc.GetTagParams
 	queryParams.InstID = c.DefaultQuery("instID", "000000000000000000000000")
+	queryParams.Group = c.Query("group")
+	queryParams.NamePrefix = c.Query("name")
+	queryParams.Deleted = c.Query("deleted") == "true"
+	err := extractDateRange(c, &queryParams.StartDate, &queryParams.EndDate)
+	if err == nil {
+		queryParams.List, err = s.extractListParams(c)
+	}
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
 
-	cursor, err := svc.GetManyTags(&queryParams)
+	cursor, err := s.Stores.Tags.GetManyTags(c.Request.Context(), &queryParams)
 
 	if err != nil {
 		log.Printf("Error while getting all Tags - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondListError(c, err)
 		return
 	}
 
-	// Iterate through the returned cursor
 	tags := []svc.Tag{}
-	for cursor.Next(context.TODO()) {
-		var tag svc.Tag
-		cursor.Decode(&tag)
-		tags = append(tags, tag)
+	nextCursor, err := svc.DecodeList(c.Request.Context(), cursor, &tags)
+	if err != nil {
+		log.Printf("Error while decoding Tags - %v\n", err)
+		respondServerError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "All Tags",
-		"data":    tags,
+		"message":     "All Tags",
+		"data":        tags,
+		"next_cursor": nextCursor,
 	})
 
 	return
@@ -46,14 +57,16 @@ func (s *CCServer) GetManyTags(c *gin.Context) {
 // GetTag - as is
 func (s *CCServer) GetTag(c *gin.Context) {
 	var queryParams svc.GetTagParams
+	queryParams.InstID = c.DefaultQuery("instID", "000000000000000000000000")
 	queryParams.TagString = c.DefaultQuery("tagString", "")
 
 	tag := svc.Tag{}
 	params := svc.GetTagParams{
+		InstID:    queryParams.InstID,
 		TagString: queryParams.TagString,
 	}
 
-	err := svc.GetTag(&params).Decode(&tag)
+	err := s.Stores.Tags.GetTag(c.Request.Context(), &params).Decode(&tag)
 
 	if err != nil {
 		// When no institution found, return failed
@@ -64,9 +77,7 @@ func (s *CCServer) GetTag(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while finding Tag - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -85,7 +96,7 @@ func (s *CCServer) CreateTag(c *gin.Context) {
 
 	// Get Institution
 	var inst svc.Institution
-	err := svc.GetInstByID(tRegForm.InstID).Decode(&inst)
+	err := s.Stores.Insts.GetInstByID(c.Request.Context(), tRegForm.InstID).Decode(&inst)
 
 	if err != nil {
 		// When no institution found, return failed
@@ -96,9 +107,7 @@ func (s *CCServer) CreateTag(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while finding institution - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -119,31 +128,23 @@ func (s *CCServer) CreateTag(c *gin.Context) {
 		}
 	}
 
-	// Check if the TagString exists
-	countTagParams := svc.CountTagParams{
-		InstID:    tRegForm.InstID,
-		TagString: tRegForm.TagString,
-	}
-	count, err := svc.CountTag(countTagParams)
-	if err != nil {
-		log.Printf("Error while finding Tag by TagString - %v\n", err)
-	}
-	if count > 0 {
+	// Create Tag; the unique index on TagStrings of the Institution rejects one that has been registered
+	res, err := s.Stores.Tags.CreateTag(c.Request.Context(), tRegForm)
+	if svc.IsDuplicateKeyErr(err) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"message": "TagString has been registered!",
 		})
 		return
 	}
-
-	// Create Tag
-	_, err = svc.CreateTag(tRegForm)
 	if err != nil {
 		log.Printf("Error while inserting new Tag into DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	tagID := res.InsertedID.(primitive.ObjectID).Hex()
+	after := svc.Tag{}
+	s.Stores.Tags.GetTagByID(c.Request.Context(), tagID).Decode(&after)
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetTag, tagID, after.InstID, nil, after)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Tag registered Successfully",
@@ -175,11 +176,17 @@ func (s *CCServer) UpdateTagByID(c *gin.Context) {
 
 	// Perform Update
 	idToUpdate := c.Param("id")
-	_, err = svc.UpdateTagByID(tForm, idToUpdate)
+	before := svc.Tag{}
+	s.Stores.Tags.GetTagByID(c.Request.Context(), idToUpdate).Decode(&before)
+	res, err := s.Stores.Tags.UpdateTagByID(c.Request.Context(), tForm, idToUpdate)
 	if err != nil {
 		log.Printf("Error while updating Tag in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
+		respondServerError(c, err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "ID To Update Tag Not Found, or deleted",
 		})
 		return
 	}
@@ -190,10 +197,11 @@ func (s *CCServer) UpdateTagByID(c *gin.Context) {
 
 	// Get Tag & Institution
 	tag := svc.Tag{}
-	err = svc.GetTagByID(idToUpdate).Decode(&tag)
+	err = s.Stores.Tags.GetTagByID(c.Request.Context(), idToUpdate).Decode(&tag)
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetTag, idToUpdate, tag.InstID, before, tag)
 
 	inst := svc.Institution{}
-	err = svc.GetInstByID(tag.InstID).Decode(&inst)
+	err = s.Stores.Insts.GetInstByID(c.Request.Context(), tag.InstID).Decode(&inst)
 
 	// TODO - update Display Names in CCRecords
 	tagInfo := svc.MemberTagInfo{
@@ -202,7 +210,7 @@ func (s *CCServer) UpdateTagByID(c *gin.Context) {
 		PhoneNum: tag.PhoneNum,
 		Group:    tag.Group,
 	}
-	_, err = svc.UpdateManyCCRecordsMTInfoByMTID(tag.TagString, tagInfo, inst.MemberType)
+	_, err = s.Stores.CCRecords.UpdateManyCCRecordsMTInfoByMTID(c.Request.Context(), tag.TagString, tagInfo, inst.MemberType)
 	if err != nil {
 		log.Printf("Error while updating CCRecord in DB - %v\n", err)
 		// c.JSON(http.StatusInternalServerError, gin.H{
@@ -215,26 +223,72 @@ func (s *CCServer) UpdateTagByID(c *gin.Context) {
 // DeleteTagByID - as is
 func (s *CCServer) DeleteTagByID(c *gin.Context) {
 	idToDelete := c.Param("id")
-	_, err := svc.DeleteTagByID(idToDelete)
+	before := svc.Tag{}
+	s.Stores.Tags.GetTagByID(c.Request.Context(), idToDelete).Decode(&before)
+	deletedAt := time.Now()
+	res, err := s.Stores.Tags.SoftDeleteTagByID(c.Request.Context(), idToDelete, deletedAt)
 	if err != nil {
 		log.Printf("Error while deleting Tag in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetTag, idToDelete, before.InstID, before, nil)
+
+	// Set CC-Records to Expire; those of a Tag go by its TagString
+	if res.MatchedCount > 0 {
+		s.expireCCRecords(c, svc.MarkCCRecordAsExpiredParams{
+			MemberType: svc.MemberTypeTag,
+			TagID:      before.TagString,
+			ExpiredAt:  deletedAt,
+		})
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Tag deleted Successfully",
 	})
+}
+
+// RestoreTagByID - undo "DeleteTagByID", along with the expiry of its CC-Records
+func (s *CCServer) RestoreTagByID(c *gin.Context) {
+	idToRestore := c.Param("id")
+	before := svc.Tag{}
+	err := s.Stores.Tags.GetDeletedTagByID(c.Request.Context(), idToRestore).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Deleted Tag Not Found",
+			})
+			return
+		}
+		log.Printf("Error while getting deleted Tag - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	_, err = s.Stores.Tags.RestoreTagByID(c.Request.Context(), idToRestore)
+	if err != nil {
+		log.Printf("Error while restoring Tag in DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	after := before
+	after.DeletedAt = nil
+	s.recordAudit(c, svc.AuditActionRestore, svc.AuditTargetTag, idToRestore, before.InstID, before, after)
+
+	s.unexpireCCRecords(c, svc.MarkCCRecordAsExpiredParams{
+		MemberType: svc.MemberTypeTag,
+		TagID:      before.TagString,
+		ExpiredAt:  *before.DeletedAt,
+	})
 
-	// TODO - set CC-Records to Expire
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag restored Successfully",
+	})
 }
 
-func getOrCreateTag(c *gin.Context, tParams *svc.GetTagParams) (*svc.Tag, bool) {
+func (s *CCServer) getOrCreateTag(c *gin.Context, tParams *svc.GetTagParams) (*svc.Tag, bool) {
 
 	tag := svc.Tag{}
-	err := svc.GetTag(tParams).Decode(&tag)
+	err := s.Stores.Tags.GetTag(c.Request.Context(), tParams).Decode(&tag)
 	if err == nil {
 		return &tag, true
 	}
@@ -245,23 +299,26 @@ func getOrCreateTag(c *gin.Context, tParams *svc.GetTagParams) (*svc.Tag, bool)
 			InstID:    tParams.InstID,
 			TagString: tParams.TagString,
 		}
-		_, err = svc.CreateTag(tRegForm)
+		_, err = s.Stores.Tags.CreateTag(c.Request.Context(), tRegForm)
+		if svc.IsDuplicateKeyErr(err) {
+			// The TagString is still held by a deleted Tag
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Tag has been deleted",
+			})
+			return nil, false
+		}
 		if err != nil {
 			log.Printf("Error while inserting new Tag into DB - %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "Something went wrong",
-			})
+			respondServerError(c, err)
 			return nil, false
 		}
-		err = svc.GetTag(tParams).Decode(&tag)
+		err = s.Stores.Tags.GetTag(c.Request.Context(), tParams).Decode(&tag)
 		// log.Printf("Tag not found by TagString, New Tag Created!")
 		return &tag, true
 	}
 
 	log.Printf("Error while getting Tag by TagString - %v\n", err)
-	c.JSON(http.StatusInternalServerError, gin.H{
-		"message": "Something went wrong",
-	})
+	respondServerError(c, err)
 	return nil, false
 
 }
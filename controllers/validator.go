@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"fmt"
 	"log"
+	"net/http"
 	"regexp"
 	"strings"
 
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/gin-gonic/gin"
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -50,6 +54,12 @@ func (s *CCServer) InitValidator() {
 		return regexpZipCode.Match([]byte(fl.Field().String()))
 	})
 
+	// Rule for Temperature Units; empty is the canonical unit
+	_ = v.RegisterValidation("temperature_unit", func(fl validator.FieldLevel) bool {
+		unit := svc.TemperatureUnit(fl.Field().String())
+		return len(unit) == 0 || svc.IsValidTemperatureUnit(unit)
+	})
+
 	// Report for required
 	_ = v.RegisterTranslation("required", trans, func(ut ut.Translator) error {
 		return ut.Add("required", "{0} is a required field", true)
@@ -98,6 +108,22 @@ func (s *CCServer) InitValidator() {
 		return t
 	})
 
+	// Report for Temperature Units
+	_ = v.RegisterTranslation("temperature_unit", trans, func(ut ut.Translator) error {
+		return ut.Add("temperature_unit", "Temperature Unit must be F or C", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("temperature_unit", fe.Field())
+		return t
+	})
+
+	// Report for oneof
+	_ = v.RegisterTranslation("oneof", trans, func(ut ut.Translator) error {
+		return ut.Add("oneof", "{0} must be one of: {1}", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("oneof", fe.Field(), fe.Param())
+		return t
+	})
+
 	// Report for TagString
 	_ = v.RegisterTranslation("tag_string", trans, func(ut ut.Translator) error {
 		return ut.Add("tag_string", "TagString not comply with rule!", true)
@@ -110,6 +136,22 @@ func (s *CCServer) InitValidator() {
 	s.Validator.trans = &trans
 }
 
+// checkForm - validate an Input Form; the first violation is answered with 400
+func (s *CCServer) checkForm(c *gin.Context, form interface{}) bool {
+	err := s.Validator.v.Struct(form)
+	if err == nil {
+		return true
+	}
+	for _, e := range err.(validator.ValidationErrors) {
+		log.Println(e)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprint(e.Translate(*s.Validator.trans)),
+		})
+		break
+	}
+	return false
+}
+
 // RegisterTagStringValidator - dynamically load tag_string rule for each institution
 func (s *CCServer) RegisterTagStringValidator(tagStringRule string) {
 	// Convert to Regex String
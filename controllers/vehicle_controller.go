@@ -15,7 +15,7 @@ func (s *CCServer) AddVehicle(c *gin.Context) {
 	var queryParams svc.AddVehicleParams
 	queryParams.FamilyID = c.DefaultQuery("familyID", "000000000000000000000000")
 	familyToAppend := svc.Family{}
-	err := svc.GetFamilyByID(queryParams.FamilyID).Decode(&familyToAppend)
+	err := s.Stores.Families.GetFamilyByID(c.Request.Context(), queryParams.FamilyID).Decode(&familyToAppend)
 	if err != nil {
 		// When no family found, return failed
 		if err == mongo.ErrNoDocuments {
@@ -25,9 +25,7 @@ func (s *CCServer) AddVehicle(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while getting Family from DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
@@ -38,15 +36,15 @@ func (s *CCServer) AddVehicle(c *gin.Context) {
 
 	// Append New Vehicle to Family and Update in DB
 	vehicles := append(familyToAppend.Vehicles, newVehicle)
-	_, err = svc.ReplaceFamily(familyToAppend, familyToAppend.ContactGuardianInfo, familyToAppend.Wards, vehicles)
+	_, err = s.Stores.Families.ReplaceFamily(c.Request.Context(), familyToAppend, familyToAppend.ContactGuardianInfo, familyToAppend.Wards, vehicles)
 	if err != nil {
 		log.Printf("Error while adding Vehicle in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetVehicle, newVehicle.ID.Hex(), familyToAppend.InstID, nil, newVehicle)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Vehicle added Successfully",
 	})
@@ -58,7 +56,7 @@ func (s *CCServer) UpdateVehicleByID(c *gin.Context) {
 	// Get Family
 	idToUpdate := c.Param("id")
 	familyToUpdate := svc.Family{}
-	err := svc.GetFamilyByVehicleID(idToUpdate).Decode(&familyToUpdate)
+	err := s.Stores.Families.GetFamilyByVehicleID(c.Request.Context(), idToUpdate).Decode(&familyToUpdate)
 	if err != nil {
 		// When no family found, return failed
 		if err == mongo.ErrNoDocuments {
@@ -68,15 +66,15 @@ func (s *CCServer) UpdateVehicleByID(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while Getting Family By VehicleID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
 	// Replace Vehicle in Family
 	var vForm svc.VehicleForm
 	c.BindJSON(&vForm)
+	before := getVehicleInFamilyByID(familyToUpdate, idToUpdate)
+	var after *svc.Vehicle
 	vehicles := familyToUpdate.Vehicles
 	for index, prev := range vehicles {
 		// replace vehicle if ID matches
@@ -90,19 +88,19 @@ func (s *CCServer) UpdateVehicleByID(c *gin.Context) {
 			}
 			vehicles = append(vehicles[:index], vehicles[index+1:]...)
 			vehicles = append(vehicles, vehicle)
+			after = &vehicle
 			break
 		}
 	}
 
 	// Save updated family to DB
-	_, err = svc.ReplaceFamily(familyToUpdate, familyToUpdate.ContactGuardianInfo, familyToUpdate.Wards, vehicles)
+	_, err = s.Stores.Families.ReplaceFamily(c.Request.Context(), familyToUpdate, familyToUpdate.ContactGuardianInfo, familyToUpdate.Wards, vehicles)
 	if err != nil {
 		log.Printf("Error while updating Vehicle in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetVehicle, idToUpdate, familyToUpdate.InstID, before, after)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Vehicle updated Successfully",
 	})
@@ -114,7 +112,7 @@ func (s *CCServer) DeleteVehicleByID(c *gin.Context) {
 	// Get Family
 	idToDelete := c.Param("id")
 	familyToUpdate := svc.Family{}
-	err := svc.GetFamilyByVehicleID(idToDelete).Decode(&familyToUpdate)
+	err := s.Stores.Families.GetFamilyByVehicleID(c.Request.Context(), idToDelete).Decode(&familyToUpdate)
 	if err != nil {
 		// When no family found, return failed
 		if err == mongo.ErrNoDocuments {
@@ -124,13 +122,12 @@ func (s *CCServer) DeleteVehicleByID(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while Getting Family By VehicleID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
 	// Remove Vehicle from Family
+	before := getVehicleInFamilyByID(familyToUpdate, idToDelete)
 	vehicles := familyToUpdate.Vehicles
 	for index, prev := range vehicles {
 		// remove vehicle if ID matches
@@ -141,18 +138,27 @@ func (s *CCServer) DeleteVehicleByID(c *gin.Context) {
 	}
 
 	// Save updated family to DB
-	_, err = svc.ReplaceFamily(familyToUpdate, familyToUpdate.ContactGuardianInfo, familyToUpdate.Wards, vehicles)
+	_, err = s.Stores.Families.ReplaceFamily(c.Request.Context(), familyToUpdate, familyToUpdate.ContactGuardianInfo, familyToUpdate.Wards, vehicles)
 
 	if err != nil {
 		log.Printf("Error while removing Vehicle in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetVehicle, idToDelete, familyToUpdate.InstID, before, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Vehicle deleted Successfully",
 	})
 	return
 }
+
+func getVehicleInFamilyByID(f svc.Family, id string) *svc.Vehicle {
+	for _, v := range f.Vehicles {
+		if v.ID.Hex() == id {
+			return &v
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"log"
 	"net/http"
+	"time"
 
 	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
@@ -14,8 +15,23 @@ func (s *CCServer) AddWard(c *gin.Context) {
 	// retrieve Family
 	var queryParams svc.AddWardParams
 	queryParams.FamilyID = c.DefaultQuery("familyID", "000000000000000000000000")
+
+	// Assign new ID to the guardian
+	var wAddForm svc.WardForm
+	c.BindJSON(&wAddForm)
+	newWard := svc.GetNewWard(wAddForm)
+
+	// Append New Ward to Family in DB; the Ward is pushed in a single update rather than replacing
+	// the Family read, so Wards added or edited at the same time are not lost
 	familyToAppend := svc.Family{}
-	err := svc.GetFamilyByID(queryParams.FamilyID).Decode(&familyToAppend)
+	err := s.Stores.Families.GetFamilyByID(c.Request.Context(), queryParams.FamilyID).Decode(&familyToAppend)
+	if err == nil {
+		var res *mongo.UpdateResult
+		res, err = s.Stores.Families.AddFamilyWard(c.Request.Context(), queryParams.FamilyID, newWard)
+		if err == nil && res.MatchedCount == 0 {
+			err = mongo.ErrNoDocuments
+		}
+	}
 	if err != nil {
 		// When no family found, return failed
 		if err == mongo.ErrNoDocuments {
@@ -24,29 +40,13 @@ func (s *CCServer) AddWard(c *gin.Context) {
 			})
 			return
 		}
-		log.Printf("Error while getting Family from DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
-		return
-	}
-
-	// Assign new ID to the guardian
-	var wAddForm svc.WardForm
-	c.BindJSON(&wAddForm)
-	newWard := svc.GetNewWard(wAddForm)
-
-	// Append New Ward to Family and Update in DB
-	wards := append(familyToAppend.Wards, newWard)
-	_, err = svc.ReplaceFamily(familyToAppend, familyToAppend.ContactGuardianInfo, wards, familyToAppend.Vehicles)
-	if err != nil {
 		log.Printf("Error while adding Ward in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
+	s.recordAudit(c, svc.AuditActionCreate, svc.AuditTargetWard, newWard.ID.Hex(), familyToAppend.InstID, nil, newWard)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Ward added Successfully",
 	})
@@ -58,7 +58,7 @@ func (s *CCServer) UpdateWardByID(c *gin.Context) {
 	// Get Family
 	idToUpdate := c.Param("id")
 	familyToUpdate := svc.Family{}
-	err := svc.GetFamilyByWardID(idToUpdate).Decode(&familyToUpdate)
+	err := s.Stores.Families.GetFamilyByWardID(c.Request.Context(), idToUpdate).Decode(&familyToUpdate)
 	if err != nil {
 		// When no family found, return failed
 		if err == mongo.ErrNoDocuments {
@@ -68,15 +68,15 @@ func (s *CCServer) UpdateWardByID(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while Getting Family By WardID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
 	// Replace Ward In Family
 	var wForm svc.WardForm
 	c.BindJSON(&wForm)
+	before := getWardInFamilyByID(familyToUpdate, idToUpdate)
+	var after *svc.Ward
 	wards := familyToUpdate.Wards
 	for index, prevW := range wards {
 		// replace ward if ID matches
@@ -89,19 +89,19 @@ func (s *CCServer) UpdateWardByID(c *gin.Context) {
 			}
 			wards = append(wards[:index], wards[index+1:]...)
 			wards = append(wards, ward)
+			after = &ward
 			break
 		}
 	}
 
 	// Save updated family to DB
-	_, err = svc.ReplaceFamily(familyToUpdate, familyToUpdate.ContactGuardianInfo, wards, familyToUpdate.Vehicles)
+	_, err = s.Stores.Families.ReplaceFamily(c.Request.Context(), familyToUpdate, familyToUpdate.ContactGuardianInfo, wards, familyToUpdate.Vehicles)
 	if err != nil {
 		log.Printf("Error while updating Ward in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
+	s.recordAudit(c, svc.AuditActionUpdate, svc.AuditTargetWard, idToUpdate, familyToUpdate.InstID, before, after)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Ward updated Successfully",
 	})
@@ -112,7 +112,7 @@ func (s *CCServer) UpdateWardByID(c *gin.Context) {
 	// 	Name:  wForm.FirstName + " " + wForm.LastName,
 	// 	Group: wForm.Group,
 	// }
-	// _, err = svc.UpdateManyCCRecordsWardInfoByWardID(idToUpdate, wInfo)
+	// _, err = s.Stores.CCRecords.UpdateManyCCRecordsWardInfoByWardID(c.Request.Context(), idToUpdate, wInfo)
 	// if err != nil {
 	// 	log.Printf("Error when Updating Many CCRecords by Ward ID - %v\n", err)
 	// }
@@ -124,7 +124,7 @@ func (s *CCServer) DeleteWardByID(c *gin.Context) {
 	// Get Family
 	idToDelete := c.Param("id")
 	familyToUpdate := svc.Family{}
-	err := svc.GetFamilyByWardID(idToDelete).Decode(&familyToUpdate)
+	err := s.Stores.Families.GetFamilyByWardID(c.Request.Context(), idToDelete).Decode(&familyToUpdate)
 	if err != nil {
 		// When no family found, return failed
 		if err == mongo.ErrNoDocuments {
@@ -134,50 +134,99 @@ func (s *CCServer) DeleteWardByID(c *gin.Context) {
 			return
 		}
 		log.Printf("Error while Getting Family By WardID - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
-	// Remove Ward from Family
+	// Move Ward from Wards of the Family to its Deleted Wards
+	before := getWardInFamilyByID(familyToUpdate, idToDelete)
+	deletedAt := time.Now()
 	wards := familyToUpdate.Wards
 	for index, prevW := range wards {
 		// replace ward if ID matches
 		if idToDelete == prevW.ID.Hex() {
+			prevW.DeletedAt = &deletedAt
+			familyToUpdate.DeletedWards = append(familyToUpdate.DeletedWards, prevW)
 			wards = append(wards[:index], wards[index+1:]...)
 			break
 		}
 	}
 	// Save updated family to DB
-	_, err = svc.ReplaceFamily(familyToUpdate, familyToUpdate.ContactGuardianInfo, wards, familyToUpdate.Vehicles)
+	_, err = s.Stores.Families.ReplaceFamily(c.Request.Context(), familyToUpdate, familyToUpdate.ContactGuardianInfo, wards, familyToUpdate.Vehicles)
 
 	if err != nil {
 		log.Printf("Error while deleting Ward in DB - %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Something went wrong",
-		})
+		respondServerError(c, err)
 		return
 	}
 
+	s.recordAudit(c, svc.AuditActionDelete, svc.AuditTargetWard, idToDelete, familyToUpdate.InstID, before, nil)
+
+	// Set CC-Records to Expire
+	s.expireCCRecords(c, svc.MarkCCRecordAsExpiredParams{
+		MemberType: svc.MemberTypeGuardian,
+		WardID:     idToDelete,
+		ExpiredAt:  deletedAt,
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Ward deleted Successfully",
 	})
 
-	// Set CC-Records to Expire
-	// TODO
-	// ccrParams := svc.MarkCCRecordAsExpiredParams{
-	// 	WardID: idToDelete,
-	// }
-	// _, err = svc.MarkCCRecordAsExpired(ccrParams)
-	// // If any error, log it in server, since it is not fatal
-	// if err != nil {
-	// 	log.Printf("Error while marking CCRecord as Expired - %v\n", err)
-	// }
-
 	return
 }
 
+// RestoreWardByID - undo "DeleteWardByID", along with the expiry of its CC-Records
+func (s *CCServer) RestoreWardByID(c *gin.Context) {
+	// Get Family
+	idToRestore := c.Param("id")
+	familyToUpdate := svc.Family{}
+	err := s.Stores.Families.GetFamilyByDeletedWardID(c.Request.Context(), idToRestore).Decode(&familyToUpdate)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Deleted Ward Not Found",
+			})
+			return
+		}
+		log.Printf("Error while Getting Family By Deleted WardID - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+
+	// Move Ward back from Deleted Wards of the Family
+	var before svc.Ward
+	deletedWards := familyToUpdate.DeletedWards
+	for index, prevW := range deletedWards {
+		if idToRestore == prevW.ID.Hex() {
+			before = prevW
+			deletedWards = append(deletedWards[:index], deletedWards[index+1:]...)
+			break
+		}
+	}
+	after := before
+	after.DeletedAt = nil
+	familyToUpdate.DeletedWards = deletedWards
+	wards := append(familyToUpdate.Wards, after)
+	_, err = s.Stores.Families.ReplaceFamily(c.Request.Context(), familyToUpdate, familyToUpdate.ContactGuardianInfo, wards, familyToUpdate.Vehicles)
+	if err != nil {
+		log.Printf("Error while restoring Ward in DB - %v\n", err)
+		respondServerError(c, err)
+		return
+	}
+	s.recordAudit(c, svc.AuditActionRestore, svc.AuditTargetWard, idToRestore, familyToUpdate.InstID, before, after)
+
+	s.unexpireCCRecords(c, svc.MarkCCRecordAsExpiredParams{
+		MemberType: svc.MemberTypeGuardian,
+		WardID:     idToRestore,
+		ExpiredAt:  *before.DeletedAt,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ward restored Successfully",
+	})
+}
+
 func getWardInFamilyByID(f svc.Family, id string) *svc.Ward {
 	for _, w := range f.Wards {
 		if w.ID.Hex() == id {
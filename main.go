@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"cloudminds.com/harix/cc-server/controllers"
 	"github.com/gin-contrib/static"
@@ -15,6 +16,10 @@ func main() {
 
 	var ccServer controllers.CCServer = controllers.InitServer()
 	ccServer.InitConfig(appName, false)
+	// Schema Migrations, run instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(ccServer.MigrateCommand(os.Args[2:]))
+	}
 	// Database
 	ccServer.Connect()
 	ccServer.ReloadConfigFromDB()
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memAdminStore struct {
+	admins memCollection
+}
+
+// NewMemAdminStore - as is; FRAS usernames are unique
+func NewMemAdminStore() AdminStore {
+	return &memAdminStore{admins: memCollection{unique: []memUniqueKey{
+		func(doc interface{}) (string, bool) { return doc.(Admin).FrasUsername, true },
+	}}}
+}
+
+func (s *memAdminStore) GetManyAdmins(ctx context.Context) (Cursor, error) {
+	return s.admins.cursor(ctx, memMatchAll)
+}
+
+func (s *memAdminStore) CountAdminsByInstID(ctx context.Context, instID string) (int64, error) {
+	return s.admins.count(ctx, adminWithInstID(instID))
+}
+
+func (s *memAdminStore) GetManyAdminsByInstID(ctx context.Context, instID string) (Cursor, error) {
+	return s.admins.cursor(ctx, adminWithInstID(instID))
+}
+
+func (s *memAdminStore) CountAdminByFrasUsername(ctx context.Context, frasUsername string) (int64, error) {
+	return s.admins.count(ctx, adminWithFrasUsername(frasUsername))
+}
+
+func (s *memAdminStore) GetAdminByFrasUsername(ctx context.Context, frasUsername string) SingleResult {
+	return s.admins.findOne(ctx, adminWithFrasUsername(frasUsername))
+}
+
+func (s *memAdminStore) GetAdminByID(ctx context.Context, id string) SingleResult {
+	return s.admins.findOne(ctx, adminWithID(id))
+}
+
+func (s *memAdminStore) CreateAdmin(ctx context.Context, a AdminRegForm) (*mongo.InsertOneResult, error) {
+	newAdmin, err := newAdmin(a)
+	if err != nil {
+		return nil, err
+	}
+	return s.admins.insertOne(ctx, newAdmin.ID, newAdmin)
+}
+
+func (s *memAdminStore) UpdateAdminByID(ctx context.Context, i AdminEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+	return s.admins.updateOne(ctx, adminWithID(idToUpdate), func(doc interface{}) interface{} {
+		a := doc.(Admin)
+		if len(i.FrasUsername) > 0 {
+			a.FrasUsername = i.FrasUsername
+		}
+		if len(i.Role) > 0 {
+			a.Role = i.Role
+		}
+		a.ModifiedAt = time.Now()
+		return a
+	})
+}
+
+func (s *memAdminStore) UpdateAdminPassword(ctx context.Context, adminID string, password string) (*mongo.UpdateResult, error) {
+	passwordHash, err := HashAdminPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return s.admins.updateOne(ctx, adminWithID(adminID), func(doc interface{}) interface{} {
+		a := doc.(Admin)
+		a.Password = passwordHash
+		a.ModifiedAt = time.Now()
+		return a
+	})
+}
+
+func (s *memAdminStore) UpdateAdminLoginTime(ctx context.Context, adminID string, admin *Admin) error {
+	_, err := s.admins.updateOne(ctx, adminWithID(adminID), func(doc interface{}) interface{} {
+		a := doc.(Admin)
+		a.LastLoginAt = time.Now()
+		return a
+	})
+	s.GetAdminByID(ctx, adminID).Decode(admin)
+	return err
+}
+
+func (s *memAdminStore) DeleteAdminByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
+	return s.admins.deleteOne(ctx, adminWithID(idToDelete))
+}
+
+func adminWithID(id string) memMatch {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return func(doc interface{}) bool { return doc.(Admin).ID == oid }
+}
+
+func adminWithInstID(instID string) memMatch {
+	return func(doc interface{}) bool { return doc.(Admin).InstID == instID }
+}
+
+func adminWithFrasUsername(frasUsername string) memMatch {
+	return func(doc interface{}) bool { return doc.(Admin).FrasUsername == frasUsername }
+}
@@ -2,23 +2,111 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
 )
 
-// AdminRegForm - Input Form for Admin
+// AdminRole - as is
+type AdminRole string
+
+// AdminRole Enum Defs
+const (
+	AdminRoleSuperAdmin AdminRole = "super_admin"
+	AdminRoleAdmin      AdminRole = "admin"
+	AdminRoleFrontDesk  AdminRole = "front_desk"
+	AdminRoleNurse      AdminRole = "nurse"
+)
+
+// Permission - an action an Admin can take within the Institution
+type Permission string
+
+// Permission Enum Defs
+const (
+	PermissionViewCCRecords        Permission = "cc_records:view"
+	PermissionViewFailedCCRecords  Permission = "cc_records:view_failed"
+	PermissionManageCCRecords      Permission = "cc_records:manage"
+	PermissionViewRoster           Permission = "roster:view"
+	PermissionManageRoster         Permission = "roster:manage"
+	PermissionSendRegCodes         Permission = "reg_codes:send"
+	PermissionViewSurveys          Permission = "surveys:view"
+	PermissionExport               Permission = "export"
+	PermissionManageDevices        Permission = "devices:manage"
+	PermissionViewAudit            Permission = "audit:view"
+	PermissionManageScreening      Permission = "screening:manage"
+	PermissionClearExclusions      Permission = "exclusions:clear"
+	PermissionManageScreeningCases Permission = "screening_cases:manage"
+)
+
+// rolePermissions - Permissions granted to each AdminRole
+// - front_desk: read-only access to CC Records & the roster (Tags, Members, Families)
+// - nurse: CC Records with failed screening, and Surveys; following them up, and clearing those excluded after one
+var rolePermissions = map[AdminRole][]Permission{
+	AdminRoleSuperAdmin: allPermissions,
+	AdminRoleAdmin:      allPermissions,
+	AdminRoleFrontDesk: {
+		PermissionViewCCRecords,
+		PermissionViewFailedCCRecords,
+		PermissionViewRoster,
+		PermissionViewSurveys,
+	},
+	AdminRoleNurse: {
+		PermissionViewFailedCCRecords,
+		PermissionViewSurveys,
+		PermissionClearExclusions,
+		PermissionManageScreeningCases,
+	},
+}
+
+var allPermissions = []Permission{
+	PermissionViewCCRecords,
+	PermissionViewFailedCCRecords,
+	PermissionManageCCRecords,
+	PermissionViewRoster,
+	PermissionManageRoster,
+	PermissionSendRegCodes,
+	PermissionViewSurveys,
+	PermissionExport,
+	PermissionManageDevices,
+	PermissionViewAudit,
+	PermissionManageScreening,
+	PermissionClearExclusions,
+	PermissionManageScreeningCases,
+}
+
+// IsValidAdminRole - as name suggests
+func IsValidAdminRole(role AdminRole) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RoleHasPermission - as name suggests; unknown roles have no Permission
+func RoleHasPermission(role AdminRole, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminRegForm - Input Form for Admin; Role defaults to "admin"
 type AdminRegForm struct {
-	FrasUsername string `json:"fras_username"`
-	Password     string `bson:"password" json:"password"`
-	InstID       string `json:"institution_id"`
+	FrasUsername string    `json:"fras_username"`
+	Password     string    `bson:"password" json:"password"`
+	InstID       string    `json:"institution_id"`
+	Role         AdminRole `json:"role"`
 }
 
-// AdminEditForm - Input Form for Admin
+// AdminEditForm - Input Form for Admin; empty fields are left unchanged
 type AdminEditForm struct {
-	FrasUsername string `json:"fras_username"`
+	FrasUsername string    `json:"fras_username"`
+	Role         AdminRole `json:"role"`
 }
 
 // AdminLoginForm - Incoming Request for Login
@@ -27,85 +115,148 @@ type AdminLoginForm struct {
 	Password     string `bson:"password" json:"password"`
 }
 
-// Admin - DB Model for Admin
+// Admin - DB Model for Admin; Password holds a bcrypt hash, and is never sent to clients
 type Admin struct {
 	ID           primitive.ObjectID `bson:"_id" json:"_id"`
 	FrasUsername string             `bson:"fras_username" json:"fras_username"`
-	Password     string             `bson:"password" json:"password"`
+	Password     string             `bson:"password" json:"-"`
 	InstID       string             `bson:"institution_id" json:"institution_id"`
+	Role         AdminRole          `bson:"role" json:"role"`
 	LastLoginAt  time.Time          `bson:"last_login_at" json:"last_login_at"`
 	ModifiedAt   time.Time          `bson:"modified_at" json:"modified_at"`
 }
 
+// GetRole - Admins registered before roles were introduced are regular Admins
+func (a Admin) GetRole() AdminRole {
+	if len(a.Role) == 0 {
+		return AdminRoleAdmin
+	}
+	return a.Role
+}
+
+// HasPermission - as name suggests
+func (a Admin) HasPermission(permission Permission) bool {
+	return RoleHasPermission(a.GetRole(), permission)
+}
+
 // GetAdminParams - QueryString Params for GetAdmin
 type GetAdminParams struct {
 	FrasUsername string `json:"fras_username"`
 }
 
-var adminCollection *mongo.Collection
+// AdminStore - persistence of Admins
+type AdminStore interface {
+	GetManyAdmins(ctx context.Context) (Cursor, error)
+	CountAdminsByInstID(ctx context.Context, instID string) (int64, error)
+	GetManyAdminsByInstID(ctx context.Context, instID string) (Cursor, error)
+	CountAdminByFrasUsername(ctx context.Context, frasUsername string) (int64, error)
+	GetAdminByFrasUsername(ctx context.Context, frasUsername string) SingleResult
+	GetAdminByID(ctx context.Context, id string) SingleResult
+	CreateAdmin(ctx context.Context, a AdminRegForm) (*mongo.InsertOneResult, error)
+	UpdateAdminByID(ctx context.Context, i AdminEditForm, idToUpdate string) (*mongo.UpdateResult, error)
+	UpdateAdminPassword(ctx context.Context, adminID string, password string) (*mongo.UpdateResult, error)
+	UpdateAdminLoginTime(ctx context.Context, adminID string, admin *Admin) error
+	DeleteAdminByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error)
+}
+
+const adminPasswordCost = bcrypt.DefaultCost
 
-// AdminCollection returns reference to DB collection
-func AdminCollection(c *mongo.Database) {
-	adminCollection = c.Collection("admins")
+type mongoAdminStore struct {
+	adminCollection *mongo.Collection
+}
+
+// NewMongoAdminStore - as is
+func NewMongoAdminStore(db *mongo.Database) AdminStore {
+	return &mongoAdminStore{adminCollection: db.Collection("admins")}
 }
 
 // GetManyAdmins - as name suggests;
-func GetManyAdmins() (*mongo.Cursor, error) {
-	return adminCollection.Find(context.TODO(), bson.M{})
+func (s *mongoAdminStore) GetManyAdmins(ctx context.Context) (Cursor, error) {
+	return mongoCursor(s.adminCollection.Find(ctx, bson.M{}))
 }
 
 // CountAdminsByInstID - as name suggests; Invoked when deleting institution
-func CountAdminsByInstID(instID string) (int64, error) {
+func (s *mongoAdminStore) CountAdminsByInstID(ctx context.Context, instID string) (int64, error) {
 	// oid, _ := primitive.ObjectIDFromHex(instID)
 	// log.Printf("GetManyAdminsByInstID: Decoded InstID - %v\n", oid.String())
-	return adminCollection.CountDocuments(context.TODO(), bson.D{primitive.E{
+	return s.adminCollection.CountDocuments(ctx, bson.D{primitive.E{
 		Key: "institution_id", Value: instID,
 	}})
 }
 
 // GetManyAdminsByInstID - as name suggests
-func GetManyAdminsByInstID(instID string) (*mongo.Cursor, error) {
+func (s *mongoAdminStore) GetManyAdminsByInstID(ctx context.Context, instID string) (Cursor, error) {
 	// oid, _ := primitive.ObjectIDFromHex(instID)
 	// log.Printf("GetManyAdminsByInstID: Decoded InstID - %v\n", oid.String())
-	return adminCollection.Find(context.TODO(), bson.D{primitive.E{
+	return mongoCursor(s.adminCollection.Find(ctx, bson.D{primitive.E{
 		Key: "institution_id", Value: instID,
-	}})
+	}}))
 }
 
-// GetAdminByFrasUsername - as name suggests; Invoked when logging in from FRAS
-func CountAdminByFrasUsername(frasUsername string) (int64, error) {
+// CountAdminByFrasUsername - as name suggests; Invoked when registering admin
+func (s *mongoAdminStore) CountAdminByFrasUsername(ctx context.Context, frasUsername string) (int64, error) {
 	// log.Printf("Counting Admin with username - %v\n", frasUsername)
-	return adminCollection.CountDocuments(context.TODO(), bson.M{
+	return s.adminCollection.CountDocuments(ctx, bson.M{
 		"fras_username": frasUsername,
 	})
 }
 
 // GetAdminByFrasUsername - as name suggests; Invoked when logging in from FRAS
-func GetAdminByFrasUsername(frasUsername string) *mongo.SingleResult {
+func (s *mongoAdminStore) GetAdminByFrasUsername(ctx context.Context, frasUsername string) SingleResult {
 	// log.Printf("Getting Admin with username - %v\n", frasUsername)
-	return adminCollection.FindOne(context.TODO(), bson.M{
+	return s.adminCollection.FindOne(ctx, bson.M{
 		"fras_username": frasUsername,
 	})
 }
 
+// GetAdminByID - as name suggests
+func (s *mongoAdminStore) GetAdminByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.adminCollection.FindOne(ctx, bson.M{
+		"_id": oid,
+	})
+}
+
 // CreateAdmin - as name suggests;
-func CreateAdmin(a AdminRegForm) (*mongo.InsertOneResult, error) {
-	newAdmin := Admin{
-		ID:           primitive.NewObjectID(),
-		FrasUsername: a.FrasUsername,
-		Password:     a.Password,
-		InstID:       a.InstID,
-		ModifiedAt:   time.Now(),
+func (s *mongoAdminStore) CreateAdmin(ctx context.Context, a AdminRegForm) (*mongo.InsertOneResult, error) {
+	newAdmin, err := newAdmin(a)
+	if err != nil {
+		return nil, err
 	}
-	return adminCollection.InsertOne(context.TODO(), newAdmin)
+	return s.adminCollection.InsertOne(ctx, newAdmin)
 }
 
 // UpdateAdminByID as name suggests
-func UpdateAdminByID(i AdminEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+func (s *mongoAdminStore) UpdateAdminByID(ctx context.Context, i AdminEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
 	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
-	return adminCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	var fields bson.D
+	if len(i.FrasUsername) > 0 {
+		fields = append(fields, primitive.E{Key: "fras_username", Value: i.FrasUsername})
+	}
+	if len(i.Role) > 0 {
+		fields = append(fields, primitive.E{Key: "role", Value: i.Role})
+	}
+	update := bson.D{
+		primitive.E{Key: "$currentDate", Value: bson.D{
+			primitive.E{Key: "modified_at", Value: true},
+		}},
+	}
+	if len(fields) > 0 {
+		update = append(update, primitive.E{Key: "$set", Value: fields})
+	}
+	return s.adminCollection.UpdateOne(ctx, bson.M{"_id": oid}, update)
+}
+
+// UpdateAdminPassword - hash & store a new password for the Admin
+func (s *mongoAdminStore) UpdateAdminPassword(ctx context.Context, adminID string, password string) (*mongo.UpdateResult, error) {
+	passwordHash, err := HashAdminPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	oid, _ := primitive.ObjectIDFromHex(adminID)
+	return s.adminCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
-			primitive.E{Key: "fras_username", Value: i.FrasUsername},
+			primitive.E{Key: "password", Value: passwordHash},
 		}},
 		primitive.E{Key: "$currentDate", Value: bson.D{
 			primitive.E{Key: "modified_at", Value: true},
@@ -114,27 +265,65 @@ func UpdateAdminByID(i AdminEditForm, idToUpdate string) (*mongo.UpdateResult, e
 }
 
 // UpdateAdminLoginTime gets the Admin to be logged in, update the login time, and return Admin
-func UpdateAdminLoginTime(adminID string, admin *Admin) error {
+func (s *mongoAdminStore) UpdateAdminLoginTime(ctx context.Context, adminID string, admin *Admin) error {
 	oid, _ := primitive.ObjectIDFromHex(adminID)
-	_, err := adminCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	_, err := s.adminCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
 		primitive.E{Key: "$currentDate", Value: bson.D{
 			primitive.E{Key: "last_login_at", Value: true},
 		}},
 	})
-	getAdminByID(oid).Decode(&admin)
+	s.GetAdminByID(ctx, adminID).Decode(&admin)
 	return err
 }
 
 // DeleteAdminByID as name suggests
-func DeleteAdminByID(idToDelete string) (*mongo.DeleteResult, error) {
+func (s *mongoAdminStore) DeleteAdminByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
 	// TODO: err handling for ID Parsing
 	oid, _ := primitive.ObjectIDFromHex(idToDelete)
-	return adminCollection.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return s.adminCollection.DeleteOne(ctx, bson.M{"_id": oid})
 }
 
-// GetAdminByFrasUsername - as name suggests; Invoked when logging in from FRAS
-func getAdminByID(oid primitive.ObjectID) *mongo.SingleResult {
-	return adminCollection.FindOne(context.TODO(), bson.M{
-		"_id": oid,
-	})
+// HashAdminPassword - as name suggests
+func HashAdminPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), adminPasswordCost)
+	return string(hash), err
+}
+
+// VerifyAdminPassword - compare in constant time; returns (match, needsRehash).
+// Admins created before hashing was introduced still have plaintext passwords stored,
+// those need to be rehashed once they are verified.
+func VerifyAdminPassword(a Admin, password string) (bool, bool) {
+	if !isAdminPasswordHash(a.Password) {
+		match := subtle.ConstantTimeCompare([]byte(a.Password), []byte(password)) == 1
+		return match, match
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(a.Password), []byte(password)); err != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(a.Password))
+	return true, err != nil || cost < adminPasswordCost
+}
+
+// newAdmin - Admin to be inserted; Password is hashed, and Role defaults to "admin"
+func newAdmin(a AdminRegForm) (Admin, error) {
+	passwordHash, err := HashAdminPassword(a.Password)
+	if err != nil {
+		return Admin{}, err
+	}
+	role := a.Role
+	if len(role) == 0 {
+		role = AdminRoleAdmin
+	}
+	return Admin{
+		ID:           primitive.NewObjectID(),
+		FrasUsername: a.FrasUsername,
+		Password:     passwordHash,
+		InstID:       a.InstID,
+		Role:         role,
+		ModifiedAt:   time.Now(),
+	}, nil
+}
+
+func isAdminPasswordHash(password string) bool {
+	return strings.HasPrefix(password, "$2a$") || strings.HasPrefix(password, "$2b$") || strings.HasPrefix(password, "$2y$")
 }
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memAuditStore struct {
+	audits memCollection
+}
+
+// NewMemAuditStore - as is
+func NewMemAuditStore() AuditStore {
+	return &memAuditStore{}
+}
+
+func (s *memAuditStore) CreateAuditEntry(ctx context.Context, e AuditEntry) (*mongo.InsertOneResult, error) {
+	e.ID = primitive.NewObjectID()
+	e.CreatedAt = time.Now()
+	return s.audits.insertOne(ctx, e.ID, e)
+}
+
+func (s *memAuditStore) GetManyAuditEntries(ctx context.Context, params *GetAuditParams) (Cursor, error) {
+	found, err := s.audits.find(ctx, func(doc interface{}) bool {
+		e := doc.(AuditEntry)
+		for _, f := range []struct{ param, value string }{
+			{params.InstID, e.InstID},
+			{params.ActorID, e.ActorID},
+			{params.Action, string(e.Action)},
+			{params.TargetType, string(e.TargetType)},
+			{params.TargetID, e.TargetID},
+		} {
+			if len(f.param) > 0 && f.param != f.value {
+				return false
+			}
+		}
+		if !params.StartDate.IsZero() && e.CreatedAt.Before(params.StartDate) {
+			return false
+		}
+		if !params.EndDate.IsZero() && e.CreatedAt.After(params.EndDate) {
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first; entries recorded within the same millisecond keep reversed insertion order
+	for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+		found[i], found[j] = found[j], found[i]
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].(AuditEntry).CreatedAt.After(found[j].(AuditEntry).CreatedAt)
+	})
+	return &memCursor{docs: found}, nil
+}
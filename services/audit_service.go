@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditAction - as is
+type AuditAction string
+
+// AuditAction Enum Defs
+const (
+	AuditActionCreate  AuditAction = "create"
+	AuditActionUpdate  AuditAction = "update"
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+	AuditActionImport  AuditAction = "import"
+	AuditActionSend    AuditAction = "send"
+	AuditActionReload  AuditAction = "reload"
+	AuditActionClear   AuditAction = "clear"
+)
+
+// AuditTargetType - as is
+type AuditTargetType string
+
+// AuditTargetType Enum Defs
+const (
+	AuditTargetInst          AuditTargetType = "institution"
+	AuditTargetAdmin         AuditTargetType = "admin"
+	AuditTargetMember        AuditTargetType = "member"
+	AuditTargetFamily        AuditTargetType = "family"
+	AuditTargetWard          AuditTargetType = "ward"
+	AuditTargetVehicle       AuditTargetType = "vehicle"
+	AuditTargetTag           AuditTargetType = "tag"
+	AuditTargetConfig        AuditTargetType = "config"
+	AuditTargetCCRecord      AuditTargetType = "cc_record"
+	AuditTargetDevice        AuditTargetType = "device"
+	AuditTargetRegCode       AuditTargetType = "reg_code"
+	AuditTargetExclusion     AuditTargetType = "exclusion"
+	AuditTargetScreeningCase AuditTargetType = "screening_case"
+)
+
+// AuditRedacted - value recorded in place of secrets
+const AuditRedacted = "[redacted]"
+
+// auditRedactedFields - changes of these fields are recorded, but not their values
+var auditRedactedFields = map[string]bool{
+	"password":       true,
+	"qr_signing_key": true,
+	"api_key_hash":   true,
+	"sms_auth_token": true,
+	"reg_code":       true,
+}
+
+// AuditFieldChange - a top-level field that differs between before & after
+type AuditFieldChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// AuditEntry - DB Model for the Audit Log; entries are never updated or deleted
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id" json:"_id"`
+	ActorID    string             `bson:"actor_id" json:"actor_id"`
+	ActorRole  string             `bson:"actor_role" json:"actor_role"`
+	InstID     string             `bson:"institution_id" json:"institution_id"`
+	Action     AuditAction        `bson:"action" json:"action"`
+	TargetType AuditTargetType    `bson:"target_type" json:"target_type"`
+	TargetID   string             `bson:"target_id" json:"target_id"`
+	Changes    []AuditFieldChange `bson:"changes" json:"changes"`
+	ClientIP   string             `bson:"client_ip" json:"client_ip"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// GetAuditParams - QueryString Params for GetManyAuditEntries; empty ones are not filtered on
+type GetAuditParams struct {
+	InstID     string
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	StartDate  time.Time
+	EndDate    time.Time
+}
+
+// AuditStore - persistence of the Audit Log; entries are never updated or deleted
+type AuditStore interface {
+	CreateAuditEntry(ctx context.Context, e AuditEntry) (*mongo.InsertOneResult, error)
+	GetManyAuditEntries(ctx context.Context, params *GetAuditParams) (Cursor, error)
+}
+
+type mongoAuditStore struct {
+	auditCollection *mongo.Collection
+}
+
+// NewMongoAuditStore - as is
+func NewMongoAuditStore(db *mongo.Database) AuditStore {
+	return &mongoAuditStore{auditCollection: db.Collection("audits")}
+}
+
+// CreateAuditEntry - as name suggests; ID & CreatedAt are assigned here
+func (s *mongoAuditStore) CreateAuditEntry(ctx context.Context, e AuditEntry) (*mongo.InsertOneResult, error) {
+	e.ID = primitive.NewObjectID()
+	e.CreatedAt = time.Now()
+	return s.auditCollection.InsertOne(ctx, e)
+}
+
+// GetManyAuditEntries - newest first
+func (s *mongoAuditStore) GetManyAuditEntries(ctx context.Context, params *GetAuditParams) (Cursor, error) {
+	filters := bson.D{}
+	for _, f := range []primitive.E{
+		{Key: "institution_id", Value: params.InstID},
+		{Key: "actor_id", Value: params.ActorID},
+		{Key: "action", Value: params.Action},
+		{Key: "target_type", Value: params.TargetType},
+		{Key: "target_id", Value: params.TargetID},
+	} {
+		if len(f.Value.(string)) > 0 {
+			filters = append(filters, f)
+		}
+	}
+	timeFilter := bson.D{}
+	if !params.StartDate.IsZero() {
+		timeFilter = append(timeFilter, primitive.E{Key: "$gte", Value: params.StartDate})
+	}
+	if !params.EndDate.IsZero() {
+		timeFilter = append(timeFilter, primitive.E{Key: "$lte", Value: params.EndDate})
+	}
+	if len(timeFilter) > 0 {
+		filters = append(filters, primitive.E{Key: "created_at", Value: timeFilter})
+	}
+
+	queryOptions := options.FindOptions{}
+	queryOptions.SetSort(bson.D{
+		primitive.E{Key: "created_at", Value: -1},
+	})
+	return mongoCursor(s.auditCollection.Find(ctx, filters, &queryOptions))
+}
+
+// DiffAuditFields - compare the top-level fields of two DB Models as stored in DB;
+// nil "before" is a creation, and nil "after" a deletion
+func DiffAuditFields(before interface{}, after interface{}) ([]AuditFieldChange, error) {
+	beforeDoc, err := toAuditDoc(before)
+	if err != nil {
+		return nil, err
+	}
+	afterDoc, err := toAuditDoc(after)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{}
+	for field := range beforeDoc {
+		fields = append(fields, field)
+	}
+	for field := range afterDoc {
+		if _, ok := beforeDoc[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []AuditFieldChange{}
+	for _, field := range fields {
+		beforeValue, afterValue := beforeDoc[field], afterDoc[field]
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		if auditRedactedFields[field] {
+			beforeValue, afterValue = redactAuditValue(beforeValue), redactAuditValue(afterValue)
+		}
+		changes = append(changes, AuditFieldChange{
+			Field:  field,
+			Before: beforeValue,
+			After:  afterValue,
+		})
+	}
+	return changes, nil
+}
+
+func toAuditDoc(model interface{}) (bson.M, error) {
+	doc := bson.M{}
+	if model == nil || reflect.ValueOf(model).Kind() == reflect.Ptr && reflect.ValueOf(model).IsNil() {
+		return doc, nil
+	}
+	raw, err := bson.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	err = bson.Unmarshal(raw, &doc)
+	return doc, err
+}
+
+func redactAuditValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return AuditRedacted
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memCCRecordStore struct {
+	ccRecords memCollection
+}
+
+// NewMemCCRecordStore - as is
+func NewMemCCRecordStore() CCRecordStore {
+	return &memCCRecordStore{}
+}
+
+func (s *memCCRecordStore) GetManyCCRecords(ctx context.Context, params *GetCCRecordParams, mType MemberType) (Cursor, error) {
+	return s.ccRecords.list(ctx, func(doc interface{}) bool {
+		ccr := doc.(CCRecord)
+		if ccr.InstID != params.InstID || (ccr.HasExpired && !params.IncludeExpired) {
+			return false
+		}
+		if !params.StartDate.IsZero() || !params.EndDate.IsZero() {
+			checkInTime, ok := ccRecordCheckInTime(ccr, mType)
+			if !ok {
+				return false
+			}
+			if !params.StartDate.IsZero() && !checkInTime.After(params.StartDate) {
+				return false
+			}
+			if !params.EndDate.IsZero() && !checkInTime.Before(params.EndDate) {
+				return false
+			}
+		}
+		if params.TemperatureThrd != 0 && !(ccr.Temperature > params.TemperatureThrd) {
+			return false
+		}
+		if params.Status != -1 && int(ccr.Status) != params.Status {
+			return false
+		}
+		if len(params.Group) > 0 || len(params.NamePrefix) > 0 {
+			group, name, ok := ccRecordInfo(ccr, mType)
+			if !ok {
+				return false
+			}
+			if len(params.Group) > 0 && group != params.Group {
+				return false
+			}
+			if len(params.NamePrefix) > 0 && !hasPrefixFold(name, params.NamePrefix) {
+				return false
+			}
+		}
+		return true
+	}, params.List, ccRecordSorts(mType))
+}
+
+func (s *memCCRecordStore) GetCCRecord(ctx context.Context, params *GetCCRecordParams) SingleResult {
+	match := func(doc interface{}) bool {
+		ccr := doc.(CCRecord)
+		if ccr.HasExpired {
+			return false
+		}
+		if len(params.InstID) > 0 && ccr.InstID != params.InstID {
+			return false
+		}
+		if len(params.MemberTagID) > 0 && (ccr.MT == nil || ccr.MT.Info.ID != params.MemberTagID) {
+			return false
+		}
+		if len(params.WardID) > 0 && (ccr.GW == nil || ccr.GW.WardInfo.ID != params.WardID) {
+			return false
+		}
+		if params.Status != -1 {
+			return int(ccr.Status) == params.Status
+		}
+		for _, status := range params.ExcludeStatusList {
+			if int(ccr.Status) == status {
+				return false
+			}
+		}
+		return true
+	}
+	if params.GetLatest {
+		return s.ccRecords.findLast(ctx, match)
+	}
+	return s.ccRecords.findOne(ctx, match)
+}
+
+func (s *memCCRecordStore) GetCCRecordByDeviceID(ctx context.Context, params *GetCCRecordParams, mType MemberType) SingleResult {
+	match := func(doc interface{}) bool {
+		ccr := doc.(CCRecord)
+		if ccr.HasExpired {
+			return false
+		}
+		if len(params.DeviceID) == 0 {
+			return true
+		}
+		if mType == MemberTypeGuardian {
+			return ccr.GW != nil && ccr.GW.CheckInEvent.DeviceID == params.DeviceID
+		}
+		return ccr.MT != nil && ccr.MT.CheckInEvent.DeviceID == params.DeviceID
+	}
+	if params.GetLatest {
+		return s.ccRecords.findLast(ctx, match)
+	}
+	return s.ccRecords.findOne(ctx, match)
+}
+
+func (s *memCCRecordStore) GetCCRecordByID(ctx context.Context, id string) SingleResult {
+	return s.ccRecords.findOne(ctx, ccRecordWithID(id))
+}
+
+func (s *memCCRecordStore) CreateCCRecord(ctx context.Context, instID string, initData CreateCCRecordData) (*mongo.InsertOneResult, error) {
+	newCCRecord := newCCRecord(instID, initData)
+	return s.ccRecords.insertOne(ctx, newCCRecord.ID, newCCRecord)
+}
+
+func (s *memCCRecordStore) UpdateCCRecordWithEvent(ctx context.Context, ccr CCRecord, eventData NewEventData) (*mongo.UpdateResult, error) {
+	updatedCCR, err := getUpdatedCCRecordWithEvent(ccr, eventData)
+	if err != nil {
+		return nil, err
+	}
+	return s.ReplaceCCRecord(ctx, ccr, updatedCCR)
+}
+
+func (s *memCCRecordStore) ReplaceCCRecord(ctx context.Context, ccr CCRecord, replacement CCRecord) (*mongo.UpdateResult, error) {
+	withID := ccRecordWithID(ccr.ID.Hex())
+	res, err := s.ccRecords.updateOne(ctx, func(doc interface{}) bool {
+		return withID(doc) && doc.(CCRecord).Version == ccr.Version
+	}, func(doc interface{}) interface{} {
+		return replacement
+	})
+	if err == nil && res.MatchedCount == 0 {
+		return res, ErrCCRecordConflict
+	}
+	return res, err
+}
+
+func (s *memCCRecordStore) UpdateCCRecordScheduleTime(ctx context.Context, ccr CCRecord, time time.Time) (*mongo.UpdateResult, error) {
+	withID := ccRecordWithID(ccr.ID.Hex())
+	res, err := s.ccRecords.updateOne(ctx, func(doc interface{}) bool {
+		return withID(doc) && doc.(CCRecord).Version == ccr.Version
+	}, func(doc interface{}) interface{} {
+		scheduled := doc.(CCRecord)
+		scheduled.CheckOutScheduledAt = time
+		scheduled.Status = CCrScheduleComplete
+		scheduled.Version++
+		return scheduled
+	})
+	if err == nil && res.MatchedCount == 0 {
+		return res, ErrCCRecordConflict
+	}
+	return res, err
+}
+
+func (s *memCCRecordStore) MarkCCRecordAsExpired(ctx context.Context, params MarkCCRecordAsExpiredParams) (*mongo.UpdateResult, error) {
+	toExpire := ccRecordToExpire(params)
+	return s.ccRecords.updateMany(ctx, func(doc interface{}) bool {
+		ccr := doc.(CCRecord)
+		return !ccr.HasExpired && toExpire(ccr)
+	}, func(doc interface{}) interface{} {
+		ccr := doc.(CCRecord)
+		expiredAt := params.ExpiredAt
+		ccr.HasExpired = true
+		ccr.ExpiredAt = &expiredAt
+		ccr.Version++
+		return ccr
+	})
+}
+
+func (s *memCCRecordStore) RestoreExpiredCCRecords(ctx context.Context, params MarkCCRecordAsExpiredParams) (*mongo.UpdateResult, error) {
+	toExpire := ccRecordToExpire(params)
+	return s.ccRecords.updateMany(ctx, func(doc interface{}) bool {
+		ccr := doc.(CCRecord)
+		return ccr.HasExpired && ccr.ExpiredAt != nil && ccr.ExpiredAt.Equal(params.ExpiredAt) && toExpire(ccr)
+	}, func(doc interface{}) interface{} {
+		ccr := doc.(CCRecord)
+		ccr.HasExpired = false
+		ccr.ExpiredAt = nil
+		ccr.Version++
+		return ccr
+	})
+}
+
+// ccRecordToExpire - as "ccRecordFiltersToExpire"
+func ccRecordToExpire(params MarkCCRecordAsExpiredParams) func(ccr CCRecord) bool {
+	// Mark Inst ID
+	if len(params.InstID) > 0 {
+		return func(ccr CCRecord) bool { return ccr.InstID == params.InstID }
+	}
+
+	// Case 2 & 3 - Check MemberID/TagID in Info
+	if !(params.MemberType == MemberTypeGuardian) {
+		var mtID string
+		if len(params.MemberID) > 0 {
+			mtID = params.MemberID
+		}
+		if len(params.TagID) > 0 {
+			mtID = params.TagID
+		}
+		return func(ccr CCRecord) bool { return ccr.MT != nil && ccr.MT.Info.ID == mtID }
+	}
+	// Case 1 - Check MemberID in Events & Check WardID in Info
+	if len(params.MemberID) > 0 {
+		return func(ccr CCRecord) bool {
+			return ccr.GW != nil && (ccr.GW.CheckInEvent.GuardianInfo.ID == params.MemberID || ccr.GW.CheckOutEvent.GuardianInfo.ID == params.MemberID)
+		}
+	}
+	if len(params.WardID) > 0 {
+		return func(ccr CCRecord) bool { return ccr.GW != nil && ccr.GW.WardInfo.ID == params.WardID }
+	}
+	return func(ccr CCRecord) bool { return false }
+}
+
+func (s *memCCRecordStore) UpdateManyCCRecordsMTInfoByMTID(ctx context.Context, mtID string, mtInfo MemberTagInfo, mType MemberType) (*mongo.UpdateResult, error) {
+	setMTInfo := func(info *MemberTagInfo) {
+		info.Name = mtInfo.Name
+		info.PhoneNum = mtInfo.PhoneNum
+		info.Relation = mtInfo.Relation
+		info.Group = mtInfo.Group
+	}
+	// Case 2 & 3
+	if !(mType == MemberTypeGuardian) {
+		return s.ccRecords.updateMany(ctx, func(doc interface{}) bool {
+			ccr := doc.(CCRecord)
+			return ccr.MT != nil && ccr.MT.Info.ID == mtID
+		}, func(doc interface{}) interface{} {
+			ccr := doc.(CCRecord)
+			setMTInfo(&ccr.MT.Info)
+			ccr.Version++
+			return ccr
+		})
+	}
+	// Case 1
+	res, err := s.ccRecords.updateMany(ctx, func(doc interface{}) bool {
+		ccr := doc.(CCRecord)
+		return ccr.GW != nil && ccr.GW.CheckInEvent.GuardianInfo.ID == mtID
+	}, func(doc interface{}) interface{} {
+		ccr := doc.(CCRecord)
+		setMTInfo(&ccr.GW.CheckInEvent.GuardianInfo)
+		ccr.Version++
+		return ccr
+	})
+	if err != nil {
+		return res, err
+	}
+	return s.ccRecords.updateMany(ctx, func(doc interface{}) bool {
+		ccr := doc.(CCRecord)
+		return ccr.GW != nil && ccr.GW.CheckOutEvent.GuardianInfo.ID == mtID
+	}, func(doc interface{}) interface{} {
+		ccr := doc.(CCRecord)
+		setMTInfo(&ccr.GW.CheckOutEvent.GuardianInfo)
+		ccr.Version++
+		return ccr
+	})
+}
+
+func (s *memCCRecordStore) UpdateManyCCRecordsWardInfoByWardID(ctx context.Context, wID string, wInfo WardInfo) (*mongo.UpdateResult, error) {
+	return s.ccRecords.updateMany(ctx, func(doc interface{}) bool {
+		ccr := doc.(CCRecord)
+		return ccr.GW != nil && ccr.GW.WardInfo.ID == wID
+	}, func(doc interface{}) interface{} {
+		ccr := doc.(CCRecord)
+		ccr.GW.WardInfo.Name = wInfo.Name
+		ccr.GW.WardInfo.Group = wInfo.Group
+		ccr.Version++
+		return ccr
+	})
+}
+
+func (s *memCCRecordStore) DeleteCCRecordByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
+	return s.ccRecords.deleteOne(ctx, ccRecordWithID(idToDelete))
+}
+
+func ccRecordWithID(id string) memMatch {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return func(doc interface{}) bool { return doc.(CCRecord).ID == oid }
+}
+
+// ccRecordInfo - Group & Name as filtered on by "GetManyCCRecords"; not ok when there are no such fields
+func ccRecordInfo(ccr CCRecord, mType MemberType) (group string, name string, ok bool) {
+	if mType == MemberTypeGuardian {
+		if ccr.GW == nil {
+			return "", "", false
+		}
+		return ccr.GW.WardInfo.Group, ccr.GW.WardInfo.Name, true
+	}
+	if ccr.MT == nil {
+		return "", "", false
+	}
+	return ccr.MT.Info.Group, ccr.MT.Info.Name, true
+}
+
+// ccRecordCheckInTime - as filtered on by "GetManyCCRecords"; not ok when there is no such field
+func ccRecordCheckInTime(ccr CCRecord, mType MemberType) (time.Time, bool) {
+	if mType == MemberTypeGuardian {
+		if ccr.GW == nil {
+			return time.Time{}, false
+		}
+		return ccr.GW.CheckInEvent.Time, true
+	}
+	if ccr.MT == nil {
+		return time.Time{}, false
+	}
+	return ccr.MT.CheckInEvent.Time, true
+}
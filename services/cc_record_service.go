@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -48,14 +49,16 @@ type CCSyncPostingForm struct {
 	WardIDList *[]string `json:"ward_ids"`
 }
 
-// ScanPostingForm - As Name Suggests
+// ScanPostingForm - As Name Suggests; Temperature is canonical, as reported in ReportedUnit
 type ScanPostingForm struct {
 	// ScanResult = GaurdianID + WardID + "checkin/checkOut"+"single/all" + Timestamp
-	ScanResult  string     `json:"scan_result"`
-	Temperature float32    `json:"temperature"`
-	Mask        bool       `json:"mask"`
-	ScanType    CCScanType `bson:"scan_type" json:"scan_type"`
-	DeviceID    string     `bson:"device_id" json:"device_id"`
+	ScanResult          string          `json:"scan_result"`
+	Temperature         float32         `json:"temperature"`
+	ReportedTemperature float32         `json:"reported_temperature"`
+	ReportedUnit        TemperatureUnit `json:"reported_unit"`
+	Mask                bool            `json:"mask"`
+	ScanType            CCScanType      `bson:"scan_type" json:"scan_type"`
+	DeviceID            string          `bson:"device_id" json:"device_id"`
 }
 
 // SchedulePostingForm - As Name Suggests
@@ -64,24 +67,29 @@ type SchedulePostingForm struct {
 	TimeStamp int      `json:"timestamp"`
 }
 
-// GuardianEvent - Can Update multiple CCRecords of Wards under a Family
+// GuardianEvent - Can Update multiple CCRecords of Wards under a Family.
+// Temperature is canonical; the reading as shown by the Gatekeeper is kept in ReportedTemperature & ReportedUnit
 type GuardianEvent struct {
-	IsSingleEvent bool          `json:"is_single_event"`
-	GuardianInfo  MemberTagInfo `bson:"guardian_info" json:"guardian_info"`
-	ScanType      CCScanType    `bson:"scan_type" json:"scan_type"`
-	DeviceID      string        `bson:"device_id" json:"device_id"`
-	Temperature   float32       `json:"temperature"`
-	Mask          bool          `json:"mask"`
-	Time          time.Time     `json:"time"`
+	IsSingleEvent       bool            `json:"is_single_event"`
+	GuardianInfo        MemberTagInfo   `bson:"guardian_info" json:"guardian_info"`
+	ScanType            CCScanType      `bson:"scan_type" json:"scan_type"`
+	DeviceID            string          `bson:"device_id" json:"device_id"`
+	Temperature         float32         `json:"temperature"`
+	ReportedTemperature float32         `bson:"reported_temperature" json:"reported_temperature"`
+	ReportedUnit        TemperatureUnit `bson:"reported_unit" json:"reported_unit"`
+	Mask                bool            `json:"mask"`
+	Time                time.Time       `json:"time"`
 }
 
-// MemberTagEvent - Can Update multiple CCRecords of Wards under a Family
+// MemberTagEvent - Can Update multiple CCRecords of Wards under a Family; temperatures as of GuardianEvent
 type MemberTagEvent struct {
-	ScanType    CCScanType `bson:"scan_type" json:"scan_type"`
-	DeviceID    string     `bson:"device_id" json:"device_id"`
-	Temperature float32    `json:"temperature"`
-	Mask        bool       `json:"mask"`
-	Time        time.Time  `json:"time"`
+	ScanType            CCScanType      `bson:"scan_type" json:"scan_type"`
+	DeviceID            string          `bson:"device_id" json:"device_id"`
+	Temperature         float32         `json:"temperature"`
+	ReportedTemperature float32         `bson:"reported_temperature" json:"reported_temperature"`
+	ReportedUnit        TemperatureUnit `bson:"reported_unit" json:"reported_unit"`
+	Mask                bool            `json:"mask"`
+	Time                time.Time       `json:"time"`
 }
 
 type GW struct {
@@ -101,11 +109,14 @@ type CCRecord struct {
 	ID                  primitive.ObjectID `bson:"_id" json:"_id"`
 	InstID              string             `bson:"institution_id" json:"institution_id"`
 	HasExpired          bool               `bson:"has_expired" json:"has_expired"`
+	ExpiredAt           *time.Time         `bson:"expired_at,omitempty" json:"expired_at,omitempty"`
 	Temperature         float32            `json:"temperature"`
 	GW                  *GW                `json:"gw"`
 	MT                  *MT                `json:"mt"`
 	CheckOutScheduledAt time.Time          `bson:"check_out_scheduled_at" json:"check_out_scheduled_at"`
 	Status              CCRecordStatus     `json:"status"`
+	// Version - bumped by every update, so that an update made from a Record read before another one fails
+	Version int64 `bson:"version" json:"version"`
 }
 
 type GetCCRecordParams struct {
@@ -119,6 +130,13 @@ type GetCCRecordParams struct {
 	TemperatureThrd   float32
 	Status            int
 	ExcludeStatusList []int
+	// Filters & page of "GetManyCCRecords"; empty ones are not applied.
+	// Group & Name are those of the Ward, or of the Member/Tag
+	Group      string
+	NamePrefix string
+	List       ListParams
+	// IncludeExpired - also list Records of deleted entities
+	IncludeExpired bool
 }
 
 type MarkCCRecordAsExpiredParams struct {
@@ -127,6 +145,8 @@ type MarkCCRecordAsExpiredParams struct {
 	MemberID   string
 	TagID      string
 	InstID     string
+	// ExpiredAt - when the entity the Records are of was deleted
+	ExpiredAt time.Time
 }
 
 // CreateCCRecordData - used in "CreateCCRecord" to init Subject Data
@@ -140,23 +160,66 @@ type NewEventData struct {
 	GuardianEvent  *GuardianEvent
 	MemberTagEvent *MemberTagEvent
 	Stage          string
-	IsScanFailed   bool
+	// Policy - the ScreeningPolicy the event is screened by; events without one carry the outcome in IsScanFailed
+	Policy       *ScreeningPolicy
+	IsScanFailed bool
+	// Workflow & MemberType - pick the transition the event makes
+	Workflow   WorkflowType
+	MemberType MemberType
 }
 
-var ccRecordCollection *mongo.Collection
+// isScanFailed - whether the event fails screening
+func (e NewEventData) isScanFailed() bool {
+	if e.Policy == nil {
+		return e.IsScanFailed
+	}
+	var temperature float32
+	var mask bool
+	if e.GuardianEvent != nil {
+		temperature, mask = e.GuardianEvent.Temperature, e.GuardianEvent.Mask
+	} else if e.MemberTagEvent != nil {
+		temperature, mask = e.MemberTagEvent.Temperature, e.MemberTagEvent.Mask
+	}
+	return !e.Policy.Screen(CCStage(e.Stage), temperature, mask).Passed
+}
 
-// CCRecordCollection returns reference to DB collection
-func CCRecordCollection(c *mongo.Database) {
-	ccRecordCollection = c.Collection("CCRecords")
+// CCRecordStore - persistence of CCRecords
+type CCRecordStore interface {
+	GetManyCCRecords(ctx context.Context, params *GetCCRecordParams, mType MemberType) (Cursor, error)
+	GetCCRecord(ctx context.Context, params *GetCCRecordParams) SingleResult
+	GetCCRecordByDeviceID(ctx context.Context, params *GetCCRecordParams, mType MemberType) SingleResult
+	GetCCRecordByID(ctx context.Context, id string) SingleResult
+	CreateCCRecord(ctx context.Context, instID string, initData CreateCCRecordData) (*mongo.InsertOneResult, error)
+	UpdateCCRecordWithEvent(ctx context.Context, ccr CCRecord, eventData NewEventData) (*mongo.UpdateResult, error)
+	ReplaceCCRecord(ctx context.Context, ccr CCRecord, replacement CCRecord) (*mongo.UpdateResult, error)
+	UpdateCCRecordScheduleTime(ctx context.Context, ccr CCRecord, time time.Time) (*mongo.UpdateResult, error)
+	MarkCCRecordAsExpired(ctx context.Context, params MarkCCRecordAsExpiredParams) (*mongo.UpdateResult, error)
+	RestoreExpiredCCRecords(ctx context.Context, params MarkCCRecordAsExpiredParams) (*mongo.UpdateResult, error)
+	UpdateManyCCRecordsMTInfoByMTID(ctx context.Context, mtID string, mtInfo MemberTagInfo, mType MemberType) (*mongo.UpdateResult, error)
+	UpdateManyCCRecordsWardInfoByWardID(ctx context.Context, wID string, wInfo WardInfo) (*mongo.UpdateResult, error)
+	DeleteCCRecordByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error)
 }
 
-func GetManyCCRecords(params *GetCCRecordParams, mType MemberType) (*mongo.Cursor, error) {
+type mongoCCRecordStore struct {
+	ccRecordCollection *mongo.Collection
+}
+
+// NewMongoCCRecordStore - as is
+func NewMongoCCRecordStore(db *mongo.Database) CCRecordStore {
+	return &mongoCCRecordStore{ccRecordCollection: db.Collection("CCRecords")}
+}
+
+// GetManyCCRecords - as is
+func (s *mongoCCRecordStore) GetManyCCRecords(ctx context.Context, params *GetCCRecordParams, mType MemberType) (Cursor, error) {
 	// Determine Field on which to filter time
 	timeKey := getFilterRootKey(mType) + ".check_in_event.time"
 
 	// TODO: not sending status: "4 - deleted"
 	var filters bson.D
 	filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID})
+	if !params.IncludeExpired {
+		filters = append(filters, notExpired)
+	}
 	if !params.StartDate.IsZero() {
 		filters = append(filters, primitive.E{
 			Key: timeKey, Value: bson.D{primitive.E{
@@ -178,14 +241,36 @@ func GetManyCCRecords(params *GetCCRecordParams, mType MemberType) (*mongo.Curso
 			}},
 		})
 	}
+	if params.Status != -1 {
+		filters = append(filters, primitive.E{Key: "status", Value: params.Status})
+	}
+	if len(params.Group) > 0 {
+		filters = append(filters, primitive.E{Key: getInfoRootKey(mType) + ".group", Value: params.Group})
+	}
+	if len(params.NamePrefix) > 0 {
+		filters = append(filters, primitive.E{Key: getInfoRootKey(mType) + ".name", Value: prefixFilter(params.NamePrefix)})
+	}
 	// log.Printf("GetCCEvents: filters - %f\n", filters)
-	return ccRecordCollection.Find(context.TODO(), filters)
+	return mongoList(ctx, s.ccRecordCollection, filters, params.List, ccRecordSorts(mType))
 }
 
+// ccRecordSorts - sorts offered by "GetManyCCRecords"
+func ccRecordSorts(mType MemberType) map[string]string {
+	return map[string]string{
+		"check_in_time": getFilterRootKey(mType) + ".check_in_event.time",
+		"name":          getInfoRootKey(mType) + ".name",
+		"temperature":   "temperature",
+		"status":        "status",
+	}
+}
+
+// notExpired - filter of Records whose Ward, Member, Tag or Institution has not been deleted
+var notExpired = primitive.E{Key: "has_expired", Value: bson.D{primitive.E{Key: "$ne", Value: true}}}
+
 // GetCCRecord - find a Record by "WardID" and "Status"
-func GetCCRecord(params *GetCCRecordParams) *mongo.SingleResult {
+func (s *mongoCCRecordStore) GetCCRecord(ctx context.Context, params *GetCCRecordParams) SingleResult {
 
-	var filters bson.D
+	filters := bson.D{notExpired}
 	if len(params.InstID) > 0 {
 		// log.Printf("GetCCRecord - InstID filter - %v\n", params.InstID)
 		filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID})
@@ -213,15 +298,16 @@ func GetCCRecord(params *GetCCRecordParams) *mongo.SingleResult {
 		queryOptions.SetSort(bson.D{
 			primitive.E{Key: "$natural", Value: -1},
 		})
-		return ccRecordCollection.FindOne(context.TODO(), filters, &queryOptions)
+		return s.ccRecordCollection.FindOne(ctx, filters, &queryOptions)
 	}
 
-	return ccRecordCollection.FindOne(context.TODO(), filters)
+	return s.ccRecordCollection.FindOne(ctx, filters)
 }
 
-func GetCCRecordByDeviceID(params *GetCCRecordParams, mType MemberType) *mongo.SingleResult {
+// GetCCRecordByDeviceID - as is
+func (s *mongoCCRecordStore) GetCCRecordByDeviceID(ctx context.Context, params *GetCCRecordParams, mType MemberType) SingleResult {
 	deviceIDKey := getFilterRootKey(mType) + ".check_in_event.device_id"
-	var filters bson.D
+	filters := bson.D{notExpired}
 	if len(params.DeviceID) > 0 {
 		// log.Printf("GetCCRecord - WardID filter - %v\n", params.WardID)
 		filters = append(filters, primitive.E{Key: deviceIDKey, Value: params.DeviceID})
@@ -231,20 +317,21 @@ func GetCCRecordByDeviceID(params *GetCCRecordParams, mType MemberType) *mongo.S
 		queryOptions.SetSort(bson.D{
 			primitive.E{Key: "$natural", Value: -1},
 		})
-		return ccRecordCollection.FindOne(context.TODO(), filters, &queryOptions)
+		return s.ccRecordCollection.FindOne(ctx, filters, &queryOptions)
 	}
 
-	return ccRecordCollection.FindOne(context.TODO(), filters)
+	return s.ccRecordCollection.FindOne(ctx, filters)
 }
 
 // GetCCRecordByID - find a Record by "WardID" and "Status"
-func GetCCRecordByID(id string) *mongo.SingleResult {
+func (s *mongoCCRecordStore) GetCCRecordByID(ctx context.Context, id string) SingleResult {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	return ccRecordCollection.FindOne(context.TODO(), bson.M{
+	return s.ccRecordCollection.FindOne(ctx, bson.M{
 		"_id": oid})
 }
 
-func CreateCCRecord(instID string, initData CreateCCRecordData) (*mongo.InsertOneResult, error) {
+// newCCRecord - CCRecord to be inserted, with the Subject Data of "initData"
+func newCCRecord(instID string, initData CreateCCRecordData) CCRecord {
 	newCCRecord := CCRecord{
 		ID:         primitive.NewObjectID(),
 		InstID:     instID,
@@ -293,38 +380,100 @@ func CreateCCRecord(instID string, initData CreateCCRecordData) (*mongo.InsertOn
 		}
 		newCCRecord.MT = &T
 	}
-	return ccRecordCollection.InsertOne(context.TODO(), newCCRecord)
+	return newCCRecord
 }
 
-func UpdateCCRecordWithEvent(ccr CCRecord, eventData NewEventData) (*mongo.UpdateResult, error) {
-	updatedCCR := getUpdatedCCRecordWithEvent(ccr, eventData)
-	return ccRecordCollection.ReplaceOne(context.TODO(), bson.M{
-		"_id": updatedCCR.ID}, updatedCCR)
+// CreateCCRecord - as is
+func (s *mongoCCRecordStore) CreateCCRecord(ctx context.Context, instID string, initData CreateCCRecordData) (*mongo.InsertOneResult, error) {
+	return s.ccRecordCollection.InsertOne(ctx, newCCRecord(instID, initData))
 }
 
-// UpdateCCRecordScheduleTime - as is
-func UpdateCCRecordScheduleTime(id string, time time.Time) (*mongo.UpdateResult, error) {
-	oid, _ := primitive.ObjectIDFromHex(id)
-	return ccRecordCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+// ErrCCRecordConflict - the Record was updated since "ccr" was read, e.g. by a scan made at the same time
+var ErrCCRecordConflict = errors.New("cc record was updated since it was read")
+
+// bumpVersion - update of the "Version" of Records, made along with every other update of them
+var bumpVersion = primitive.E{Key: "$inc", Value: bson.D{primitive.E{Key: "version", Value: 1}}}
+
+// UpdateCCRecordWithEvent - replace "ccr" by the Record with the event added, unless the Record has been
+// updated since "ccr" was read, which fails with ErrCCRecordConflict. Events the Workflow does not allow
+// fail with an *InvalidTransitionError
+func (s *mongoCCRecordStore) UpdateCCRecordWithEvent(ctx context.Context, ccr CCRecord, eventData NewEventData) (*mongo.UpdateResult, error) {
+	updatedCCR, err := getUpdatedCCRecordWithEvent(ccr, eventData)
+	if err != nil {
+		return nil, err
+	}
+	return s.ReplaceCCRecord(ctx, ccr, updatedCCR)
+}
+
+// ReplaceCCRecord - replace "ccr" by "replacement", unless the Record has been updated since "ccr" was read,
+// which fails with ErrCCRecordConflict
+func (s *mongoCCRecordStore) ReplaceCCRecord(ctx context.Context, ccr CCRecord, replacement CCRecord) (*mongo.UpdateResult, error) {
+	res, err := s.ccRecordCollection.ReplaceOne(ctx, bson.M{
+		"_id": ccr.ID, "version": ccr.Version}, replacement)
+	if err == nil && res.MatchedCount == 0 {
+		return res, ErrCCRecordConflict
+	}
+	return res, err
+}
+
+// UpdateCCRecordScheduleTime - schedule the check-out of "ccr", unless the Record has been updated since "ccr"
+// was read, which fails with ErrCCRecordConflict
+func (s *mongoCCRecordStore) UpdateCCRecordScheduleTime(ctx context.Context, ccr CCRecord, time time.Time) (*mongo.UpdateResult, error) {
+	res, err := s.ccRecordCollection.UpdateOne(ctx, bson.M{"_id": ccr.ID, "version": ccr.Version}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "check_out_scheduled_at", Value: time},
 			primitive.E{Key: "status", Value: CCrScheduleComplete},
 		}},
+		bumpVersion,
+	})
+	if err == nil && res.MatchedCount == 0 {
+		return res, ErrCCRecordConflict
+	}
+	return res, err
+}
+
+// MarkCCRecordAsExpired - expire the Records of what "params" points to, stamped with "params.ExpiredAt";
+// Records already expired are left as they are
+func (s *mongoCCRecordStore) MarkCCRecordAsExpired(ctx context.Context, params MarkCCRecordAsExpiredParams) (*mongo.UpdateResult, error) {
+	return s.updateCCRecordsToExpire(ctx, params, bson.D{notExpired}, bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "has_expired", Value: true},
+			primitive.E{Key: "expired_at", Value: params.ExpiredAt},
+		}},
+		bumpVersion,
 	})
 }
 
-func MarkCCRecordAsExpired(params MarkCCRecordAsExpiredParams) (*mongo.UpdateResult, error) {
+// RestoreExpiredCCRecords - undo "MarkCCRecordAsExpired" called with the same "params"
+func (s *mongoCCRecordStore) RestoreExpiredCCRecords(ctx context.Context, params MarkCCRecordAsExpiredParams) (*mongo.UpdateResult, error) {
+	return s.updateCCRecordsToExpire(ctx, params, bson.D{
+		primitive.E{Key: "has_expired", Value: true},
+		primitive.E{Key: "expired_at", Value: params.ExpiredAt},
+	}, bson.D{
+		primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "has_expired", Value: false}}},
+		primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "expired_at", Value: ""}}},
+		bumpVersion,
+	})
+}
+
+func (s *mongoCCRecordStore) updateCCRecordsToExpire(ctx context.Context, params MarkCCRecordAsExpiredParams, state bson.D, update bson.D) (*mongo.UpdateResult, error) {
+	res := &mongo.UpdateResult{}
+	for _, filter := range ccRecordFiltersToExpire(params) {
+		updated, err := s.ccRecordCollection.UpdateMany(ctx, append(filter, state...), update)
+		if err != nil {
+			return res, err
+		}
+		res.MatchedCount += updated.MatchedCount
+		res.ModifiedCount += updated.ModifiedCount
+	}
+	return res, nil
+}
 
+// ccRecordFiltersToExpire - filters of the Records of what "params" points to
+func ccRecordFiltersToExpire(params MarkCCRecordAsExpiredParams) []bson.D {
 	// Mark Inst ID
 	if len(params.InstID) > 0 {
-		filter := bson.D{
-			primitive.E{Key: "institution_id", Value: params.InstID},
-		}
-		return ccRecordCollection.UpdateMany(context.TODO(), filter, bson.D{
-			primitive.E{Key: "$set", Value: bson.D{
-				primitive.E{Key: "has_expired", Value: true},
-			}},
-		})
+		return []bson.D{{primitive.E{Key: "institution_id", Value: params.InstID}}}
 	}
 
 	// Mark by Info
@@ -339,99 +488,75 @@ func MarkCCRecordAsExpired(params MarkCCRecordAsExpiredParams) (*mongo.UpdateRes
 		if len(params.TagID) > 0 {
 			mtID = params.TagID
 		}
-		filter := bson.D{
-			primitive.E{Key: "mt.info.id", Value: mtID},
-		}
-		return ccRecordCollection.UpdateMany(context.TODO(), filter, bson.D{
-			primitive.E{Key: "$set", Value: bson.D{
-				primitive.E{Key: "has_expired", Value: true},
-			}},
-		})
+		return []bson.D{{primitive.E{Key: "mt.info.id", Value: mtID}}}
 	}
 	// Mark by Info or Events
 	// Case 1 - Check MemberID in Events & Check WardID in Info
 	if len(params.MemberID) > 0 {
-		filter := bson.D{
-			primitive.E{Key: "gw.check_in_event.guardian_info.id", Value: params.MemberID},
-		}
-
-		res, err := ccRecordCollection.UpdateMany(context.TODO(), filter, bson.D{
-			primitive.E{Key: "$set", Value: bson.D{
-				primitive.E{Key: "has_expired", Value: true},
-			}},
-		})
-		if err != nil {
-			return res, err
+		return []bson.D{
+			{primitive.E{Key: "gw.check_in_event.guardian_info.id", Value: params.MemberID}},
+			{primitive.E{Key: "gw.check_out_event.guardian_info.id", Value: params.MemberID}},
 		}
-		filter = bson.D{
-			primitive.E{Key: "gw.check_out_event.guardian_info.id", Value: params.MemberID},
-		}
-		return ccRecordCollection.UpdateMany(context.TODO(), filter, bson.D{
-			primitive.E{Key: "$set", Value: bson.D{
-				primitive.E{Key: "has_expired", Value: true},
-			}},
-		})
 	}
 	if len(params.WardID) > 0 {
-		filter := bson.D{
-			primitive.E{Key: "gw.ward_info.id", Value: params.WardID},
-		}
-		return ccRecordCollection.UpdateMany(context.TODO(), filter, bson.D{
-			primitive.E{Key: "$set", Value: bson.D{
-				primitive.E{Key: "has_expired", Value: true},
-			}},
-		})
+		return []bson.D{{primitive.E{Key: "gw.ward_info.id", Value: params.WardID}}}
 	}
-	return nil, nil
+	return nil
 }
 
 // UpdateManyCCRecordsMTInfoByMTID - as is
-func UpdateManyCCRecordsMTInfoByMTID(mtID string, mtInfo MemberTagInfo, mType MemberType) (*mongo.UpdateResult, error) {
+func (s *mongoCCRecordStore) UpdateManyCCRecordsMTInfoByMTID(ctx context.Context, mtID string, mtInfo MemberTagInfo, mType MemberType) (*mongo.UpdateResult, error) {
 	// Case 2 & 3
 	if !(mType == MemberTypeGuardian) {
-		return handleUpdateMTInfo("mt.info", mtID, mtInfo)
+		return s.handleUpdateMTInfo(ctx, "mt.info", mtID, mtInfo)
 	}
 	// Case 1
-	res, err := handleUpdateMTInfo("gw.check_in_event.guardian_info", mtID, mtInfo)
+	res, err := s.handleUpdateMTInfo(ctx, "gw.check_in_event.guardian_info", mtID, mtInfo)
 	if err != nil {
 		return res, err
 	}
-	return handleUpdateMTInfo("gw.check_out_event.guardian_info", mtID, mtInfo)
+	return s.handleUpdateMTInfo(ctx, "gw.check_out_event.guardian_info", mtID, mtInfo)
 }
 
 // UpdateManyCCRecordsWardInfoByWardID - as is
-func UpdateManyCCRecordsWardInfoByWardID(wID string, wInfo WardInfo) (*mongo.UpdateResult, error) {
+func (s *mongoCCRecordStore) UpdateManyCCRecordsWardInfoByWardID(ctx context.Context, wID string, wInfo WardInfo) (*mongo.UpdateResult, error) {
 
-	return ccRecordCollection.UpdateMany(context.TODO(), bson.D{
+	return s.ccRecordCollection.UpdateMany(ctx, bson.D{
 		primitive.E{Key: "gw.ward_info.id", Value: wID},
 	}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "gw.ward_info.name", Value: wInfo.Name},
 			primitive.E{Key: "gw.ward_info.group", Value: wInfo.Group},
 		}},
+		bumpVersion,
 	})
 }
 
 // DeleteCCRecordByID - as is
-func DeleteCCRecordByID(idToDelete string) (*mongo.DeleteResult, error) {
+func (s *mongoCCRecordStore) DeleteCCRecordByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
 	// TODO: err handling for ID Parsing
 	oid, _ := primitive.ObjectIDFromHex(idToDelete)
-	return ccRecordCollection.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return s.ccRecordCollection.DeleteOne(ctx, bson.M{"_id": oid})
 }
 
-func getUpdatedCCRecordWithEvent(ccr CCRecord, eventData NewEventData) CCRecord {
+func getUpdatedCCRecordWithEvent(ccr CCRecord, eventData NewEventData) (CCRecord, error) {
+	workflow, err := GetCCWorkflow(eventData.Workflow)
+	if err != nil {
+		return CCRecord{}, err
+	}
+	status, err := workflow.Next(eventData.MemberType, ccr.Status, CCStage(eventData.Stage), eventData.isScanFailed())
+	if err != nil {
+		return CCRecord{}, err
+	}
 
 	newCCR := CCRecord{
-		ID:     ccr.ID,
-		InstID: ccr.InstID,
-	}
-	if eventData.IsScanFailed {
-		newCCR.Status = CCrFailed
-	} else if eventData.Stage == "checkin" {
-		newCCR.Status = CCrCheckInComplete
-	} else if eventData.Stage == "checkout" {
+		ID:      ccr.ID,
+		Version: ccr.Version + 1,
+		InstID:  ccr.InstID,
+		Status:  status,
+	}
+	if eventData.Stage == "checkout" {
 		newCCR.CheckOutScheduledAt = ccr.CheckOutScheduledAt
-		newCCR.Status = CCrCheckOutComplete
 	}
 
 	// Update Events & get New Temperature
@@ -476,7 +601,7 @@ func getUpdatedCCRecordWithEvent(ccr CCRecord, eventData NewEventData) CCRecord
 	}
 	newCCR.Temperature = maxTemp
 
-	return newCCR
+	return newCCR, nil
 
 }
 
@@ -487,11 +612,20 @@ func getFilterRootKey(mType MemberType) string {
 	return "mt"
 }
 
-func handleUpdateMTInfo(keyRoot string, mtID string, mtInfo MemberTagInfo) (*mongo.UpdateResult, error) {
-	return ccRecordCollection.UpdateMany(context.TODO(), bson.D{
+// getInfoRootKey - key of the Ward info, or of the Member/Tag info
+func getInfoRootKey(mType MemberType) string {
+	if mType == MemberTypeGuardian {
+		return "gw.ward_info"
+	}
+	return "mt.info"
+}
+
+func (s *mongoCCRecordStore) handleUpdateMTInfo(ctx context.Context, keyRoot string, mtID string, mtInfo MemberTagInfo) (*mongo.UpdateResult, error) {
+	return s.ccRecordCollection.UpdateMany(ctx, bson.D{
 		primitive.E{Key: keyRoot + ".id", Value: mtID},
 	}, bson.D{
 		primitive.E{Key: "$set", Value: getMTInfoBson(keyRoot, mtInfo)},
+		bumpVersion,
 	})
 }
 
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CCStage - what a scan does to a CC-Record
+type CCStage string
+
+// CCStage Enum Defs
+const (
+	CCStageCheckIn  CCStage = "checkin"
+	CCStageSchedule CCStage = "schedule"
+	CCStageCheckOut CCStage = "checkout"
+)
+
+// CCTransition - a scan of "Stage" moving a Record in "From" to "To"; a failed scan moves it to CCrFailed instead
+type CCTransition struct {
+	From  CCRecordStatus
+	Stage CCStage
+	To    CCRecordStatus
+	// MemberTypes - the kinds of Records the transition is for; all of them when empty
+	MemberTypes []MemberType
+}
+
+func (t CCTransition) isFor(mType MemberType) bool {
+	if len(t.MemberTypes) == 0 {
+		return true
+	}
+	for _, m := range t.MemberTypes {
+		if m == mType {
+			return true
+		}
+	}
+	return false
+}
+
+// CCWorkflow - the Statuses a Record of an Institution goes through, as a table of transitions.
+// Records start in CCrInit, and a Status no transition leaves is final: the next scan gets a new Record
+type CCWorkflow struct {
+	Type        WorkflowType
+	Transitions []CCTransition
+}
+
+// CCWorkflows - every Workflow, by type; a new Workflow type plugs in by declaring its table here
+var CCWorkflows = map[WorkflowType]CCWorkflow{
+	WorkflowTypeCC: {
+		Type: WorkflowTypeCC,
+		Transitions: []CCTransition{
+			{From: CCrInit, Stage: CCStageCheckIn, To: CCrCheckInComplete},
+			// Guardians schedule the check-out of their Wards before it is made
+			{From: CCrCheckInComplete, Stage: CCStageSchedule, To: CCrScheduleComplete, MemberTypes: []MemberType{MemberTypeGuardian}},
+			{From: CCrScheduleComplete, Stage: CCStageCheckOut, To: CCrCheckOutComplete, MemberTypes: []MemberType{MemberTypeGuardian}},
+			{From: CCrCheckInComplete, Stage: CCStageCheckOut, To: CCrCheckOutComplete, MemberTypes: []MemberType{MemberTypeStandard, MemberTypeTag}},
+		},
+	},
+	WorkflowTypeCheckIn: {
+		Type: WorkflowTypeCheckIn,
+		Transitions: []CCTransition{
+			{From: CCrInit, Stage: CCStageCheckIn, To: CCrCheckInComplete},
+		},
+	},
+}
+
+// ErrUnknownWorkflow - the Workflow type of an Institution is not in "CCWorkflows"
+var ErrUnknownWorkflow = errors.New("unknown workflow type")
+
+// InvalidTransitionError - a scan the Workflow does not allow for a Record in "From"
+type InvalidTransitionError struct {
+	Workflow   WorkflowType
+	MemberType MemberType
+	From       CCRecordStatus
+	Stage      CCStage
+}
+
+func (e *InvalidTransitionError) Error() string {
+	if e.From < 0 {
+		return fmt.Sprintf("%v workflow does not allow %v of %v records", e.Workflow, e.Stage, e.MemberType)
+	}
+	return fmt.Sprintf("%v workflow does not allow %v of %v records in status %v", e.Workflow, e.Stage, e.MemberType, e.From)
+}
+
+// GetCCWorkflow - the Workflow of a type; Institutions made before Workflow types were introduced have none,
+// and go by the CC Workflow
+func GetCCWorkflow(wType WorkflowType) (CCWorkflow, error) {
+	if len(wType) == 0 {
+		wType = WorkflowTypeCC
+	}
+	workflow, ok := CCWorkflows[wType]
+	if !ok {
+		return CCWorkflow{}, fmt.Errorf("%w - %q", ErrUnknownWorkflow, wType)
+	}
+	return workflow, nil
+}
+
+// From - the Status a Record must be in for a scan of "stage"
+func (w CCWorkflow) From(mType MemberType, stage CCStage) (CCRecordStatus, error) {
+	for _, t := range w.Transitions {
+		if t.Stage == stage && t.isFor(mType) {
+			return t.From, nil
+		}
+	}
+	// No Status allows it, which "From" -1 stands for
+	return 0, &InvalidTransitionError{Workflow: w.Type, MemberType: mType, From: -1, Stage: stage}
+}
+
+// Next - the Status a scan of "stage" moves a Record in "from" to
+func (w CCWorkflow) Next(mType MemberType, from CCRecordStatus, stage CCStage, failed bool) (CCRecordStatus, error) {
+	for _, t := range w.Transitions {
+		if t.From == from && t.Stage == stage && t.isFor(mType) {
+			if failed {
+				return CCrFailed, nil
+			}
+			return t.To, nil
+		}
+	}
+	return 0, &InvalidTransitionError{Workflow: w.Type, MemberType: mType, From: from, Stage: stage}
+}
+
+// StageAfter - the scan a Record in "status" waits for; not ok when the Status is final
+func (w CCWorkflow) StageAfter(mType MemberType, status CCRecordStatus) (CCStage, bool) {
+	for _, t := range w.Transitions {
+		if t.From == status && t.isFor(mType) {
+			return t.Stage, true
+		}
+	}
+	return "", false
+}
+
+// FinalStatuses - Statuses no transition leaves, whatever the kind of Record, CCrFailed included;
+// as ints, to be excluded when looking for the current Record
+func (w CCWorkflow) FinalStatuses() []int {
+	final := []int{}
+	seen := map[CCRecordStatus]bool{}
+	for _, t := range w.Transitions {
+		for _, status := range []CCRecordStatus{t.To, CCrFailed} {
+			if seen[status] {
+				continue
+			}
+			seen[status] = true
+			if w.isFinal(status) {
+				final = append(final, int(status))
+			}
+		}
+	}
+	return final
+}
+
+func (w CCWorkflow) isFinal(status CCRecordStatus) bool {
+	for _, t := range w.Transitions {
+		if t.From == status {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memConfigStore struct {
+	configs memCollection
+}
+
+// NewMemConfigStore - as is
+func NewMemConfigStore() ConfigStore {
+	return &memConfigStore{}
+}
+
+func (s *memConfigStore) GetManyConfigs(ctx context.Context) (Cursor, error) {
+	return s.configs.cursor(ctx, memMatchAll)
+}
+
+func (s *memConfigStore) GetConfigByID(ctx context.Context, id string) SingleResult {
+	return s.configs.findOne(ctx, configWithID(id))
+}
+
+func (s *memConfigStore) GetConfigByName(ctx context.Context, configName string) SingleResult {
+	return s.configs.findOne(ctx, configWithName(configName))
+}
+
+func (s *memConfigStore) CountConfigByName(ctx context.Context, configName string) (int64, error) {
+	return s.configs.count(ctx, configWithName(configName))
+}
+
+func (s *memConfigStore) CreateConfig(ctx context.Context, configForm ConfigForm) (*mongo.InsertOneResult, error) {
+	newConfig := newConfig(configForm)
+	return s.configs.insertOne(ctx, newConfig.ID, newConfig)
+}
+
+func (s *memConfigStore) UpdateConfigByID(ctx context.Context, c ConfigEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+	return s.configs.updateOne(ctx, configWithID(idToUpdate), func(doc interface{}) interface{} {
+		config := doc.(Config)
+		config.SMSAuthToken = c.SMSAuthToken
+		config.ServerAddr = c.ServerAddr
+		return config
+	})
+}
+
+func configWithID(id string) memMatch {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return func(doc interface{}) bool { return doc.(Config).ID == oid }
+}
+
+func configWithName(configName string) memMatch {
+	return func(doc interface{}) bool { return doc.(Config).Name == configName }
+}
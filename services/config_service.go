@@ -29,50 +29,72 @@ type Config struct {
 	ServerAddr   string             `bson:"server_address" json:"server_address"`
 }
 
-var configCollection *mongo.Collection
+// ConfigStore - persistence of Configs
+type ConfigStore interface {
+	GetManyConfigs(ctx context.Context) (Cursor, error)
+	GetConfigByID(ctx context.Context, id string) SingleResult
+	GetConfigByName(ctx context.Context, configName string) SingleResult
+	CountConfigByName(ctx context.Context, configName string) (int64, error)
+	CreateConfig(ctx context.Context, configForm ConfigForm) (*mongo.InsertOneResult, error)
+	UpdateConfigByID(ctx context.Context, c ConfigEditForm, idToUpdate string) (*mongo.UpdateResult, error)
+}
+
+type mongoConfigStore struct {
+	configCollection *mongo.Collection
+}
 
-// ConfigCollection returns reference to DB collection
-func ConfigCollection(c *mongo.Database) {
-	configCollection = c.Collection("configs")
+// NewMongoConfigStore - as is
+func NewMongoConfigStore(db *mongo.Database) ConfigStore {
+	return &mongoConfigStore{configCollection: db.Collection("configs")}
 }
 
 // GetManyConfigs - as is
-func GetManyConfigs() (*mongo.Cursor, error) {
-	return configCollection.Find(context.TODO(), bson.M{})
+func (s *mongoConfigStore) GetManyConfigs(ctx context.Context) (Cursor, error) {
+	return mongoCursor(s.configCollection.Find(ctx, bson.M{}))
+}
+
+// GetConfigByID - as is
+func (s *mongoConfigStore) GetConfigByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.configCollection.FindOne(ctx, bson.M{"_id": oid})
 }
 
 // GetConfigByName - as is
-func GetConfigByName(configName string) *mongo.SingleResult {
-	return configCollection.FindOne(context.TODO(), bson.D{
+func (s *mongoConfigStore) GetConfigByName(ctx context.Context, configName string) SingleResult {
+	return s.configCollection.FindOne(ctx, bson.D{
 		primitive.E{Key: "name", Value: configName},
 	})
 }
 
 // CountConfigByName - as is
-func CountConfigByName(configName string) (int64, error) {
-	return configCollection.CountDocuments(context.TODO(), bson.D{
+func (s *mongoConfigStore) CountConfigByName(ctx context.Context, configName string) (int64, error) {
+	return s.configCollection.CountDocuments(ctx, bson.D{
 		primitive.E{Key: "name", Value: configName},
 	})
 }
 
 // CreateConfig - as is
-func CreateConfig(configForm ConfigForm) (*mongo.InsertOneResult, error) {
-	newConfig := Config{
-		ID:           primitive.NewObjectID(),
-		Name:         configForm.Name,
-		SMSAuthToken: configForm.SMSAuthToken,
-		ServerAddr:   configForm.ServerAddr,
-	}
-	return configCollection.InsertOne(context.TODO(), newConfig)
+func (s *mongoConfigStore) CreateConfig(ctx context.Context, configForm ConfigForm) (*mongo.InsertOneResult, error) {
+	return s.configCollection.InsertOne(ctx, newConfig(configForm))
 }
 
 // UpdateConfigByID - as is
-func UpdateConfigByID(c ConfigEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+func (s *mongoConfigStore) UpdateConfigByID(ctx context.Context, c ConfigEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
 	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
-	return configCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	return s.configCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "sms_auth_token", Value: c.SMSAuthToken},
 			primitive.E{Key: "server_address", Value: c.ServerAddr},
 		}},
 	})
 }
+
+// newConfig - Config to be inserted
+func newConfig(configForm ConfigForm) Config {
+	return Config{
+		ID:           primitive.NewObjectID(),
+		Name:         configForm.Name,
+		SMSAuthToken: configForm.SMSAuthToken,
+		ServerAddr:   configForm.ServerAddr,
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memDeviceStore struct {
+	devices memCollection
+}
+
+// NewMemDeviceStore - as is
+func NewMemDeviceStore() DeviceStore {
+	return &memDeviceStore{}
+}
+
+func (s *memDeviceStore) GetManyDevices(ctx context.Context, params *GetDeviceParams) (Cursor, error) {
+	return s.devices.cursor(ctx, func(doc interface{}) bool {
+		return doc.(Device).InstID == params.InstID
+	})
+}
+
+func (s *memDeviceStore) GetDeviceByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.devices.findOne(ctx, deviceWithID(oid))
+}
+
+func (s *memDeviceStore) GetDeviceByDeviceID(ctx context.Context, deviceID string) SingleResult {
+	return s.devices.findOne(ctx, deviceWithDeviceID(deviceID))
+}
+
+func (s *memDeviceStore) CountDeviceByDeviceID(ctx context.Context, deviceID string) (int64, error) {
+	return s.devices.count(ctx, deviceWithDeviceID(deviceID))
+}
+
+func (s *memDeviceStore) CreateDevice(ctx context.Context, d DeviceRegForm) (*mongo.InsertOneResult, string, error) {
+	newDevice, apiKey, err := newDevice(d)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := s.devices.insertOne(ctx, newDevice.ID, newDevice)
+	return res, apiKey, err
+}
+
+func (s *memDeviceStore) UpdateDeviceByID(ctx context.Context, d DeviceEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
+	return s.devices.updateOne(ctx, deviceWithID(oid), func(doc interface{}) interface{} {
+		device := doc.(Device)
+		device.Location = d.Location
+		device.Enabled = d.Enabled
+		device.TemperatureUnit = d.TemperatureUnit
+		device.ModifiedAt = time.Now()
+		return device
+	})
+}
+
+func (s *memDeviceStore) RotateDeviceAPIKey(ctx context.Context, idToUpdate string) (*mongo.UpdateResult, string, error) {
+	apiKey, err := newDeviceAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
+	res, err := s.devices.updateOne(ctx, deviceWithID(oid), func(doc interface{}) interface{} {
+		device := doc.(Device)
+		device.APIKeyHash = hashDeviceAPIKey(apiKey)
+		device.ModifiedAt = time.Now()
+		return device
+	})
+	return res, apiKey, err
+}
+
+func (s *memDeviceStore) UpdateDeviceLastSeen(ctx context.Context, id primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return s.devices.updateOne(ctx, deviceWithID(id), func(doc interface{}) interface{} {
+		device := doc.(Device)
+		device.LastSeenAt = time.Now()
+		return device
+	})
+}
+
+func (s *memDeviceStore) DeleteDeviceByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToDelete)
+	return s.devices.deleteOne(ctx, deviceWithID(oid))
+}
+
+func deviceWithID(oid primitive.ObjectID) memMatch {
+	return func(doc interface{}) bool { return doc.(Device).ID == oid }
+}
+
+func deviceWithDeviceID(deviceID string) memMatch {
+	return func(doc interface{}) bool { return doc.(Device).DeviceID == deviceID }
+}
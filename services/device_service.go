@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DeviceRegForm - Input Form for Gatekeeper Device
+type DeviceRegForm struct {
+	DeviceID        string          `json:"device_id" validate:"required"`
+	InstID          string          `json:"institution_id"`
+	Location        string          `json:"location"`
+	TemperatureUnit TemperatureUnit `json:"temperature_unit" validate:"temperature_unit"`
+}
+
+// DeviceEditForm - Input Form for Gatekeeper Device
+type DeviceEditForm struct {
+	Location        string          `json:"location"`
+	Enabled         bool            `json:"enabled"`
+	TemperatureUnit TemperatureUnit `json:"temperature_unit" validate:"temperature_unit"`
+}
+
+// Device - DB Model for Gatekeeper Device; only the hash of the API Key is stored.
+// TemperatureUnit - what the Device reports readings in, unless a Scan says otherwise; empty is the canonical unit
+type Device struct {
+	ID              primitive.ObjectID `bson:"_id" json:"_id"`
+	DeviceID        string             `bson:"device_id" json:"device_id"`
+	InstID          string             `bson:"institution_id" json:"institution_id"`
+	Location        string             `bson:"location" json:"location"`
+	TemperatureUnit TemperatureUnit    `bson:"temperature_unit" json:"temperature_unit"`
+	APIKeyHash      string             `bson:"api_key_hash" json:"-"`
+	Enabled         bool               `bson:"enabled" json:"enabled"`
+	LastSeenAt      time.Time          `bson:"last_seen_at" json:"last_seen_at"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	ModifiedAt      time.Time          `bson:"modified_at" json:"modified_at"`
+}
+
+// MatchesAPIKey - compare in constant time
+func (d Device) MatchesAPIKey(apiKey string) bool {
+	return subtle.ConstantTimeCompare([]byte(d.APIKeyHash), []byte(hashDeviceAPIKey(apiKey))) == 1
+}
+
+// GetDeviceParams - QueryString Params for GetManyDevices
+type GetDeviceParams struct {
+	InstID string `json:"inst_id"`
+}
+
+// DeviceStore - persistence of Gatekeeper Devices
+type DeviceStore interface {
+	GetManyDevices(ctx context.Context, params *GetDeviceParams) (Cursor, error)
+	GetDeviceByID(ctx context.Context, id string) SingleResult
+	GetDeviceByDeviceID(ctx context.Context, deviceID string) SingleResult
+	CountDeviceByDeviceID(ctx context.Context, deviceID string) (int64, error)
+	CreateDevice(ctx context.Context, d DeviceRegForm) (*mongo.InsertOneResult, string, error)
+	UpdateDeviceByID(ctx context.Context, d DeviceEditForm, idToUpdate string) (*mongo.UpdateResult, error)
+	RotateDeviceAPIKey(ctx context.Context, idToUpdate string) (*mongo.UpdateResult, string, error)
+	UpdateDeviceLastSeen(ctx context.Context, id primitive.ObjectID) (*mongo.UpdateResult, error)
+	DeleteDeviceByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error)
+}
+
+type mongoDeviceStore struct {
+	deviceCollection *mongo.Collection
+}
+
+// NewMongoDeviceStore - as is
+func NewMongoDeviceStore(db *mongo.Database) DeviceStore {
+	return &mongoDeviceStore{deviceCollection: db.Collection("devices")}
+}
+
+// GetManyDevices - as is
+func (s *mongoDeviceStore) GetManyDevices(ctx context.Context, params *GetDeviceParams) (Cursor, error) {
+	return mongoCursor(s.deviceCollection.Find(ctx, bson.D{
+		primitive.E{Key: "institution_id", Value: params.InstID},
+	}))
+}
+
+// GetDeviceByID - as is
+func (s *mongoDeviceStore) GetDeviceByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.deviceCollection.FindOne(ctx, bson.M{
+		"_id": oid,
+	})
+}
+
+// GetDeviceByDeviceID - find by the IMEI posted by the Gatekeeper
+func (s *mongoDeviceStore) GetDeviceByDeviceID(ctx context.Context, deviceID string) SingleResult {
+	return s.deviceCollection.FindOne(ctx, bson.M{
+		"device_id": deviceID,
+	})
+}
+
+// CountDeviceByDeviceID - as is
+func (s *mongoDeviceStore) CountDeviceByDeviceID(ctx context.Context, deviceID string) (int64, error) {
+	return s.deviceCollection.CountDocuments(ctx, bson.M{
+		"device_id": deviceID,
+	})
+}
+
+// CreateDevice - return (result, API Key, error); the API Key is not retrievable afterwards
+func (s *mongoDeviceStore) CreateDevice(ctx context.Context, d DeviceRegForm) (*mongo.InsertOneResult, string, error) {
+	newDevice, apiKey, err := newDevice(d)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := s.deviceCollection.InsertOne(ctx, newDevice)
+	return res, apiKey, err
+}
+
+// UpdateDeviceByID - as is
+func (s *mongoDeviceStore) UpdateDeviceByID(ctx context.Context, d DeviceEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
+	return s.deviceCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "location", Value: d.Location},
+			primitive.E{Key: "enabled", Value: d.Enabled},
+			primitive.E{Key: "temperature_unit", Value: d.TemperatureUnit},
+		}},
+		primitive.E{Key: "$currentDate", Value: bson.D{
+			primitive.E{Key: "modified_at", Value: true},
+		}},
+	})
+}
+
+// RotateDeviceAPIKey - replace the API Key of the Device; return (result, new API Key, error)
+func (s *mongoDeviceStore) RotateDeviceAPIKey(ctx context.Context, idToUpdate string) (*mongo.UpdateResult, string, error) {
+	apiKey, err := newDeviceAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
+	res, err := s.deviceCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "api_key_hash", Value: hashDeviceAPIKey(apiKey)},
+		}},
+		primitive.E{Key: "$currentDate", Value: bson.D{
+			primitive.E{Key: "modified_at", Value: true},
+		}},
+	})
+	return res, apiKey, err
+}
+
+// UpdateDeviceLastSeen - as name suggests; Invoked on every authenticated Scan
+func (s *mongoDeviceStore) UpdateDeviceLastSeen(ctx context.Context, id primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return s.deviceCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.D{
+		primitive.E{Key: "$currentDate", Value: bson.D{
+			primitive.E{Key: "last_seen_at", Value: true},
+		}},
+	})
+}
+
+// DeleteDeviceByID - as is
+func (s *mongoDeviceStore) DeleteDeviceByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToDelete)
+	return s.deviceCollection.DeleteOne(ctx, bson.M{"_id": oid})
+}
+
+// newDevice - Device to be inserted, with its new API Key
+func newDevice(d DeviceRegForm) (Device, string, error) {
+	apiKey, err := newDeviceAPIKey()
+	if err != nil {
+		return Device{}, "", err
+	}
+	return Device{
+		ID:              primitive.NewObjectID(),
+		DeviceID:        d.DeviceID,
+		InstID:          d.InstID,
+		Location:        d.Location,
+		TemperatureUnit: d.TemperatureUnit,
+		APIKeyHash:      hashDeviceAPIKey(apiKey),
+		Enabled:         true,
+		CreatedAt:       time.Now(),
+		ModifiedAt:      time.Now(),
+	}, apiKey, nil
+}
+
+// generate a 256-bit key, hex encoded
+func newDeviceAPIKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// API Keys are random & long, so a plain SHA-256 is enough
+func hashDeviceAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memExclusionStore struct {
+	exclusions memCollection
+}
+
+// NewMemExclusionStore - as is
+func NewMemExclusionStore() ExclusionStore {
+	return &memExclusionStore{}
+}
+
+func (s *memExclusionStore) CreateExclusion(ctx context.Context, e Exclusion) (*mongo.InsertOneResult, error) {
+	e.ID = primitive.NewObjectID()
+	return s.exclusions.insertOne(ctx, e.ID, e)
+}
+
+func (s *memExclusionStore) GetExclusionByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.exclusions.findOne(ctx, exclusionWithID(oid))
+}
+
+func (s *memExclusionStore) GetActiveExclusion(ctx context.Context, instID string, subjectID string, now time.Time) SingleResult {
+	found, err := s.exclusions.find(ctx, func(doc interface{}) bool {
+		e := doc.(Exclusion)
+		return e.InstID == instID && e.SubjectID == subjectID && e.IsActive(now)
+	})
+	if err != nil {
+		return memSingleResult{err: err}
+	}
+	if len(found) == 0 {
+		return memNotFound()
+	}
+	latest := found[0]
+	for _, doc := range found[1:] {
+		if !doc.(Exclusion).CreatedAt.Before(latest.(Exclusion).CreatedAt) {
+			latest = doc
+		}
+	}
+	return memFound(latest)
+}
+
+func (s *memExclusionStore) GetManyExclusions(ctx context.Context, params *GetExclusionParams) (Cursor, error) {
+	return s.exclusions.list(ctx, func(doc interface{}) bool {
+		e := doc.(Exclusion)
+		if len(params.InstID) > 0 && params.InstID != e.InstID {
+			return false
+		}
+		if len(params.SubjectID) > 0 && params.SubjectID != e.SubjectID {
+			return false
+		}
+		return !params.Active || e.IsActive(params.Now)
+	}, params.listParams(), exclusionSorts)
+}
+
+func (s *memExclusionStore) ClearExclusion(ctx context.Context, id string, clearedBy string, now time.Time) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.exclusions.updateOne(ctx, func(doc interface{}) bool {
+		e := doc.(Exclusion)
+		return e.ID == oid && e.ClearedAt == nil
+	}, func(doc interface{}) interface{} {
+		e := doc.(Exclusion)
+		e.ClearedAt = &now
+		e.ClearedBy = clearedBy
+		return e
+	})
+}
+
+func exclusionWithID(oid primitive.ObjectID) memMatch {
+	return func(doc interface{}) bool { return doc.(Exclusion).ID == oid }
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Exclusion - DB Model of a re-entry lockout: the Ward, Member or Tag of a Record failed by screening is refused
+// new Check-Ins until "expires_at", or, without one, until an Admin clears it
+type Exclusion struct {
+	ID         primitive.ObjectID `bson:"_id" json:"_id"`
+	InstID     string             `bson:"institution_id" json:"institution_id"`
+	MemberType MemberType         `bson:"member_type" json:"member_type"`
+	// SubjectID - the WardID, MemberID or TagString excluded, as the Record's "gw.ward_info.id" or "mt.info.id"
+	SubjectID   string     `bson:"subject_id" json:"subject_id"`
+	SubjectName string     `bson:"subject_name" json:"subject_name"`
+	CCRecordID  string     `bson:"cc_record_id" json:"cc_record_id"`
+	Temperature float32    `bson:"temperature" json:"temperature"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt   *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	ClearedAt   *time.Time `bson:"cleared_at,omitempty" json:"cleared_at,omitempty"`
+	ClearedBy   string     `bson:"cleared_by,omitempty" json:"cleared_by,omitempty"`
+}
+
+// IsActive - neither cleared nor expired
+func (e Exclusion) IsActive(now time.Time) bool {
+	return e.ClearedAt == nil && (e.ExpiresAt == nil || now.Before(*e.ExpiresAt))
+}
+
+// ExclusionFor - the Exclusion "eventData" gives the subject of "ccr" when it fails the Record, by the policy
+// the event was screened by; ok is false when the Record is not failed, or the policy excludes no one
+func ExclusionFor(ccr CCRecord, eventData NewEventData, now time.Time) (Exclusion, bool) {
+	if eventData.Policy == nil || !eventData.Policy.Excludes() {
+		return Exclusion{}, false
+	}
+	failed, err := getUpdatedCCRecordWithEvent(ccr, eventData)
+	if err != nil || failed.Status != CCrFailed {
+		return Exclusion{}, false
+	}
+
+	subjectID, subjectName := recordSubject(ccr)
+	return Exclusion{
+		InstID:      ccr.InstID,
+		MemberType:  eventData.MemberType,
+		SubjectID:   subjectID,
+		SubjectName: subjectName,
+		CCRecordID:  ccr.ID.Hex(),
+		Temperature: failed.Temperature,
+		CreatedAt:   now,
+		ExpiresAt:   eventData.Policy.ExclusionExpiry(now),
+	}, true
+}
+
+// recordSubject - the ID & name of the Ward, Member or Tag a Record is of
+func recordSubject(ccr CCRecord) (string, string) {
+	if ccr.GW != nil {
+		return ccr.GW.WardInfo.ID, ccr.GW.WardInfo.Name
+	}
+	if ccr.MT != nil {
+		return ccr.MT.Info.ID, ccr.MT.Info.Name
+	}
+	return "", ""
+}
+
+// GetExclusionParams - QueryString Params for GetManyExclusions; "Active" leaves out those cleared or expired by "Now"
+type GetExclusionParams struct {
+	InstID    string
+	SubjectID string
+	Active    bool
+	Now       time.Time
+	List      ListParams
+}
+
+// exclusionSorts - sorts offered by GetManyExclusions
+var exclusionSorts = map[string]string{
+	"created_at": "created_at",
+}
+
+// listParams - page & sort of the query; latest first unless sorted otherwise
+func (p *GetExclusionParams) listParams() ListParams {
+	list := p.List
+	if len(list.Sort) == 0 {
+		list.Sort = "-created_at"
+	}
+	return list
+}
+
+// ExclusionStore - persistence of Exclusions
+type ExclusionStore interface {
+	CreateExclusion(ctx context.Context, e Exclusion) (*mongo.InsertOneResult, error)
+	GetExclusionByID(ctx context.Context, id string) SingleResult
+	GetActiveExclusion(ctx context.Context, instID string, subjectID string, now time.Time) SingleResult
+	GetManyExclusions(ctx context.Context, params *GetExclusionParams) (Cursor, error)
+	ClearExclusion(ctx context.Context, id string, clearedBy string, now time.Time) (*mongo.UpdateResult, error)
+}
+
+type mongoExclusionStore struct {
+	exclusionCollection *mongo.Collection
+}
+
+// NewMongoExclusionStore - as is
+func NewMongoExclusionStore(db *mongo.Database) ExclusionStore {
+	return &mongoExclusionStore{exclusionCollection: db.Collection("exclusions")}
+}
+
+// CreateExclusion - as name suggests; ID is assigned here
+func (s *mongoExclusionStore) CreateExclusion(ctx context.Context, e Exclusion) (*mongo.InsertOneResult, error) {
+	e.ID = primitive.NewObjectID()
+	return s.exclusionCollection.InsertOne(ctx, e)
+}
+
+// GetExclusionByID - as is
+func (s *mongoExclusionStore) GetExclusionByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.exclusionCollection.FindOne(ctx, bson.M{"_id": oid})
+}
+
+// GetActiveExclusion - the latest active Exclusion of the subject
+func (s *mongoExclusionStore) GetActiveExclusion(ctx context.Context, instID string, subjectID string, now time.Time) SingleResult {
+	filters := append(activeExclusion(now),
+		primitive.E{Key: "institution_id", Value: instID},
+		primitive.E{Key: "subject_id", Value: subjectID},
+	)
+	queryOptions := options.FindOneOptions{}
+	queryOptions.SetSort(bson.D{primitive.E{Key: "created_at", Value: -1}})
+	return s.exclusionCollection.FindOne(ctx, filters, &queryOptions)
+}
+
+// GetManyExclusions - a page of the Exclusions, latest first unless sorted otherwise
+func (s *mongoExclusionStore) GetManyExclusions(ctx context.Context, params *GetExclusionParams) (Cursor, error) {
+	filters := bson.D{}
+	if params.Active {
+		filters = activeExclusion(params.Now)
+	}
+	if len(params.InstID) > 0 {
+		filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID})
+	}
+	if len(params.SubjectID) > 0 {
+		filters = append(filters, primitive.E{Key: "subject_id", Value: params.SubjectID})
+	}
+
+	return mongoList(ctx, s.exclusionCollection, filters, params.listParams(), exclusionSorts)
+}
+
+// ClearExclusion - end an Exclusion not cleared yet; "clearedBy" is the Admin who did
+func (s *mongoExclusionStore) ClearExclusion(ctx context.Context, id string, clearedBy string, now time.Time) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.exclusionCollection.UpdateOne(ctx, bson.D{
+		primitive.E{Key: "_id", Value: oid},
+		primitive.E{Key: "cleared_at", Value: nil},
+	}, bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "cleared_at", Value: now},
+			primitive.E{Key: "cleared_by", Value: clearedBy},
+		}},
+	})
+}
+
+// activeExclusion - filter of Exclusions neither cleared nor expired by "now"
+func activeExclusion(now time.Time) bson.D {
+	return bson.D{
+		primitive.E{Key: "cleared_at", Value: nil},
+		primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: "expires_at", Value: nil}},
+			bson.D{primitive.E{Key: "expires_at", Value: bson.D{primitive.E{Key: "$gt", Value: now}}}},
+		}},
+	}
+}
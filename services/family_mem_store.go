@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memFamilyStore struct {
+	families memCollection
+}
+
+// NewMemFamilyStore - as is
+func NewMemFamilyStore() FamilyStore {
+	return &memFamilyStore{}
+}
+
+func (s *memFamilyStore) GetManyFamilies(ctx context.Context, params *GetFamilyParams) (Cursor, error) {
+	return s.families.list(ctx, func(doc interface{}) bool {
+		f := doc.(Family)
+		if f.InstID != params.InstID {
+			return false
+		}
+		if len(params.Group) > 0 {
+			inGroup := false
+			for _, w := range f.Wards {
+				inGroup = inGroup || w.Group == params.Group
+			}
+			if !inGroup {
+				return false
+			}
+		}
+		if len(params.NamePrefix) > 0 && !hasPrefixFold(f.ContactGuardianInfo.Name, params.NamePrefix) {
+			return false
+		}
+		return inTimeRange(f.ModifiedAt, params.StartDate, params.EndDate)
+	}, params.List, familySorts)
+}
+
+func (s *memFamilyStore) SearchFamilies(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error) {
+	return s.families.list(ctx, func(doc interface{}) bool {
+		f := doc.(Family)
+		return f.InstID == instID && len(q.SearchFamily(f)) > 0
+	}, ListParams{Limit: limit}, nil)
+}
+
+func (s *memFamilyStore) CreateFamily(ctx context.Context, f FamilyRegForm, cMember MemberInFamilyRegForm, ws []Ward, vs []Vehicle) (*mongo.InsertOneResult, error) {
+	newFamily := newFamily(f, cMember, ws, vs)
+	return s.families.insertOne(ctx, newFamily.ID, newFamily)
+}
+
+func (s *memFamilyStore) GetFamilyByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.families.findOne(ctx, familyWithID(oid))
+}
+
+func (s *memFamilyStore) GetFamilyByMemberID(ctx context.Context, memberID string) SingleResult {
+	return s.families.findOne(ctx, func(doc interface{}) bool {
+		return doc.(Family).ContactGuardianInfo.ID == memberID
+	})
+}
+
+func (s *memFamilyStore) GetFamilyByWardID(ctx context.Context, wardID string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(wardID)
+	return s.families.findOne(ctx, func(doc interface{}) bool {
+		for _, w := range doc.(Family).Wards {
+			if w.ID == oid {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (s *memFamilyStore) GetFamilyByDeletedWardID(ctx context.Context, wardID string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(wardID)
+	return s.families.findOne(ctx, func(doc interface{}) bool {
+		for _, w := range doc.(Family).DeletedWards {
+			if w.ID == oid {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (s *memFamilyStore) GetFamilyByVehicleID(ctx context.Context, vehicleID string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(vehicleID)
+	return s.families.findOne(ctx, func(doc interface{}) bool {
+		for _, v := range doc.(Family).Vehicles {
+			if v.ID == oid {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (s *memFamilyStore) ReplaceFamily(ctx context.Context, f Family, cMemberInfo MemberTagInfo, ws []Ward, vs []Vehicle) (*mongo.UpdateResult, error) {
+	familyToReplace := getFamilyToReplace(f, cMemberInfo, ws, vs)
+	return s.families.updateOne(ctx, familyWithID(f.ID), func(doc interface{}) interface{} {
+		return familyToReplace
+	})
+}
+
+func (s *memFamilyStore) AddFamilyWard(ctx context.Context, id string, w Ward) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.families.updateOne(ctx, familyWithID(oid), func(doc interface{}) interface{} {
+		f := doc.(Family)
+		f.Wards = append(append([]Ward{}, f.Wards...), w)
+		f.ModifiedAt = time.Now()
+		return f
+	})
+}
+
+func (s *memFamilyStore) SetFamilyContactMemberID(ctx context.Context, id string, cMemberID string) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.families.updateOne(ctx, familyWithID(oid), func(doc interface{}) interface{} {
+		f := doc.(Family)
+		f.ContactGuardianInfo.ID = cMemberID
+		return f
+	})
+}
+
+func (s *memFamilyStore) DeleteFamilyByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToDelete)
+	return s.families.deleteOne(ctx, familyWithID(oid))
+}
+
+func familyWithID(oid primitive.ObjectID) memMatch {
+	return func(doc interface{}) bool { return doc.(Family).ID == oid }
+}
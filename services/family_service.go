@@ -38,6 +38,7 @@ type Ward struct {
 	FirstName string             `bson:"first_name" json:"first_name"`
 	LastName  string             `bson:"last_name" json:"last_name"`
 	Group     string             `json:"group"`
+	DeletedAt *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
 // Vehicle - DB Model for Vehicle
@@ -58,11 +59,26 @@ type Family struct {
 	Wards               []Ward             `json:"wards"`
 	Vehicles            []Vehicle          `json:"vehicles"`
 	ModifiedAt          time.Time          `bson:"modified_at" json:"modified_at"`
+	// DeletedWards - soft-deleted Wards, kept apart so that they are left out wherever Wards are
+	DeletedWards []Ward `bson:"deleted_wards,omitempty" json:"deleted_wards,omitempty"`
 }
 
 // GetFamilyParams - QueryString Params for GetFamily
 type GetFamilyParams struct {
 	InstID string `json:"inst_id"`
+	// Filters & page of "GetManyFamilies"; empty ones are not applied.
+	// Group is that of any Ward, and Name that of the contact Member
+	Group      string
+	NamePrefix string
+	StartDate  time.Time
+	EndDate    time.Time
+	List       ListParams
+}
+
+// familySorts - sorts offered by "GetManyFamilies"
+var familySorts = map[string]string{
+	"modified_at": "modified_at",
+	"name":        "contact_member_info.name",
 }
 
 // AddGuardianParams - QueryString Params for AddGuardian
@@ -80,55 +96,74 @@ type AddVehicleParams struct {
 	FamilyID string `json:"family_id"`
 }
 
-var familyCollection *mongo.Collection
+// FamilyStore - persistence of Families, with their Wards & Vehicles
+type FamilyStore interface {
+	GetManyFamilies(ctx context.Context, params *GetFamilyParams) (Cursor, error)
+	SearchFamilies(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error)
+	CreateFamily(ctx context.Context, f FamilyRegForm, cMember MemberInFamilyRegForm, ws []Ward, vs []Vehicle) (*mongo.InsertOneResult, error)
+	GetFamilyByID(ctx context.Context, id string) SingleResult
+	GetFamilyByMemberID(ctx context.Context, memberID string) SingleResult
+	GetFamilyByWardID(ctx context.Context, wardID string) SingleResult
+	GetFamilyByDeletedWardID(ctx context.Context, wardID string) SingleResult
+	GetFamilyByVehicleID(ctx context.Context, vehicleID string) SingleResult
+	ReplaceFamily(ctx context.Context, f Family, cMemberInfo MemberTagInfo, ws []Ward, vs []Vehicle) (*mongo.UpdateResult, error)
+	AddFamilyWard(ctx context.Context, id string, w Ward) (*mongo.UpdateResult, error)
+	SetFamilyContactMemberID(ctx context.Context, id string, cMemberID string) (*mongo.UpdateResult, error)
+	DeleteFamilyByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error)
+}
 
-// FamilyCollection returns reference to DB collection
-func FamilyCollection(c *mongo.Database) {
-	familyCollection = c.Collection("families")
+type mongoFamilyStore struct {
+	familyCollection *mongo.Collection
+}
+
+// NewMongoFamilyStore - as is
+func NewMongoFamilyStore(db *mongo.Database) FamilyStore {
+	return &mongoFamilyStore{familyCollection: db.Collection("families")}
 }
 
 // GetManyFamilies returns Cursor to all Families in the Database
-func GetManyFamilies(params *GetFamilyParams) (*mongo.Cursor, error) {
+func (s *mongoFamilyStore) GetManyFamilies(ctx context.Context, params *GetFamilyParams) (Cursor, error) {
 	var filters bson.D
 
 	filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID})
+	if len(params.Group) > 0 {
+		filters = append(filters, primitive.E{Key: "wards.group", Value: params.Group})
+	}
+	if len(params.NamePrefix) > 0 {
+		filters = append(filters, primitive.E{Key: "contact_member_info.name", Value: prefixFilter(params.NamePrefix)})
+	}
+	if modifiedAt := timeRangeFilter(params.StartDate, params.EndDate); len(modifiedAt) > 0 {
+		filters = append(filters, primitive.E{Key: "modified_at", Value: modifiedAt})
+	}
+
+	return mongoList(ctx, s.familyCollection, filters, params.List, familySorts)
+}
 
-	return familyCollection.Find(context.TODO(), filters)
+// SearchFamilies - Families of an Institution whose contact, Wards or Vehicles hold the term
+func (s *mongoFamilyStore) SearchFamilies(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error) {
+	filters := bson.D{primitive.E{Key: "institution_id", Value: instID}}
+	filters = append(filters, q.mongoFilter([]string{"contact_member_info.name", "wards.first_name", "wards.last_name", "vehicles.plate_num"},
+		[]string{"contact_member_info.phone_num"})...)
+	return mongoList(ctx, s.familyCollection, filters, ListParams{Limit: limit}, nil)
 }
 
 // CreateFamily register a new Family in the DB
-func CreateFamily(f FamilyRegForm, cMember MemberInFamilyRegForm, ws []Ward, vs []Vehicle) (*mongo.InsertOneResult, error) {
+func (s *mongoFamilyStore) CreateFamily(ctx context.Context, f FamilyRegForm, cMember MemberInFamilyRegForm, ws []Ward, vs []Vehicle) (*mongo.InsertOneResult, error) {
 	// log.Printf("family to be created - %v\n", family.Guardians)
-	cMemberInfo := MemberTagInfo{
-		Name:     cMember.FirstName + " " + cMember.LastName,
-		PhoneNum: cMember.PhoneNum,
-		Relation: cMember.Relation,
-	}
-
-	newFamily := Family{
-		ID:                  primitive.NewObjectID(),
-		InstID:              f.InstID,
-		AllRegCodeSent:      false,
-		ContactGuardianInfo: cMemberInfo,
-		Wards:               ws,
-		Vehicles:            vs,
-		ModifiedAt:          time.Now(),
-	}
-
-	return familyCollection.InsertOne(context.TODO(), newFamily)
+	return s.familyCollection.InsertOne(ctx, newFamily(f, cMember, ws, vs))
 }
 
 // GetFamilyByID searches & returns a Family with Guardian matching the phone number
-func GetFamilyByID(id string) *mongo.SingleResult {
+func (s *mongoFamilyStore) GetFamilyByID(ctx context.Context, id string) SingleResult {
 	// TODO: err handling for ID Parsing
 	oid, _ := primitive.ObjectIDFromHex(id)
-	return familyCollection.FindOne(context.TODO(), bson.M{
+	return s.familyCollection.FindOne(ctx, bson.M{
 		"_id": oid})
 }
 
-func GetFamilyByMemberID(memberID string) *mongo.SingleResult {
+func (s *mongoFamilyStore) GetFamilyByMemberID(ctx context.Context, memberID string) SingleResult {
 	// TODO: err handling for ID Parsing
-	return familyCollection.FindOne(context.TODO(), bson.D{
+	return s.familyCollection.FindOne(ctx, bson.D{
 		primitive.E{Key: "contact_member_info.id", Value: bson.D{
 			primitive.E{Key: "$eq", Value: memberID},
 		}},
@@ -136,21 +171,29 @@ func GetFamilyByMemberID(memberID string) *mongo.SingleResult {
 }
 
 // GetFamilyByWardID  searches & returns a Family with Guardian matching GuardianID
-func GetFamilyByWardID(wardID string) *mongo.SingleResult {
+func (s *mongoFamilyStore) GetFamilyByWardID(ctx context.Context, wardID string) SingleResult {
 	// TODO: err handling for ID Parsing
 	oid, _ := primitive.ObjectIDFromHex(wardID)
-	return familyCollection.FindOne(context.TODO(), bson.D{
+	return s.familyCollection.FindOne(ctx, bson.D{
 		primitive.E{Key: "wards._id", Value: bson.D{
 			primitive.E{Key: "$eq", Value: oid},
 		}},
 	})
 }
 
+// GetFamilyByDeletedWardID - Family of a soft-deleted Ward
+func (s *mongoFamilyStore) GetFamilyByDeletedWardID(ctx context.Context, wardID string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(wardID)
+	return s.familyCollection.FindOne(ctx, bson.D{
+		primitive.E{Key: "deleted_wards._id", Value: oid},
+	})
+}
+
 // GetFamilyByVehicleID  searches & returns a Family with Vehicle matching VehicleID
-func GetFamilyByVehicleID(vehicleID string) *mongo.SingleResult {
+func (s *mongoFamilyStore) GetFamilyByVehicleID(ctx context.Context, vehicleID string) SingleResult {
 	// TODO: err handling for ID Parsing
 	oid, _ := primitive.ObjectIDFromHex(vehicleID)
-	return familyCollection.FindOne(context.TODO(), bson.D{
+	return s.familyCollection.FindOne(ctx, bson.D{
 		primitive.E{Key: "vehicles._id", Value: bson.D{
 			primitive.E{Key: "$eq", Value: oid},
 		}},
@@ -158,15 +201,24 @@ func GetFamilyByVehicleID(vehicleID string) *mongo.SingleResult {
 }
 
 // ReplaceFamily - Made a Family with updated "ContactMemberInfo", "Wards" and "Vehicles", and Replace the original
-func ReplaceFamily(f Family, cMemberInfo MemberTagInfo, ws []Ward, vs []Vehicle) (*mongo.UpdateResult, error) {
+func (s *mongoFamilyStore) ReplaceFamily(ctx context.Context, f Family, cMemberInfo MemberTagInfo, ws []Ward, vs []Vehicle) (*mongo.UpdateResult, error) {
 	familyToReplace := getFamilyToReplace(f, cMemberInfo, ws, vs)
-	return familyCollection.ReplaceOne(context.TODO(), bson.M{
+	return s.familyCollection.ReplaceOne(ctx, bson.M{
 		"_id": f.ID}, familyToReplace)
 }
 
-func SetFamilyContactMemberID(id string, cMemberID string) (*mongo.UpdateResult, error) {
+// AddFamilyWard - append a Ward to a Family in a single update, so Wards added at the same time are all kept
+func (s *mongoFamilyStore) AddFamilyWard(ctx context.Context, id string, w Ward) (*mongo.UpdateResult, error) {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	return familyCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	return s.familyCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
+		primitive.E{Key: "$push", Value: bson.D{primitive.E{Key: "wards", Value: w}}},
+		primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "modified_at", Value: time.Now()}}},
+	})
+}
+
+func (s *mongoFamilyStore) SetFamilyContactMemberID(ctx context.Context, id string, cMemberID string) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.familyCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "contact_member_info.id", Value: cMemberID},
 		}},
@@ -175,14 +227,33 @@ func SetFamilyContactMemberID(id string, cMemberID string) (*mongo.UpdateResult,
 }
 
 // DeleteFamilyByID deletes a Family Object from DB
-func DeleteFamilyByID(idToDelete string) (*mongo.DeleteResult, error) {
+func (s *mongoFamilyStore) DeleteFamilyByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
 	// TODO: err handling for ID Parsing
 	oid, _ := primitive.ObjectIDFromHex(idToDelete)
 
-	return familyCollection.DeleteOne(context.TODO(), bson.M{
+	return s.familyCollection.DeleteOne(ctx, bson.M{
 		"_id": oid})
 }
 
+// newFamily - Family to be inserted, with "cMember" as the Contact Member
+func newFamily(f FamilyRegForm, cMember MemberInFamilyRegForm, ws []Ward, vs []Vehicle) Family {
+	cMemberInfo := MemberTagInfo{
+		Name:     cMember.FirstName + " " + cMember.LastName,
+		PhoneNum: cMember.PhoneNum,
+		Relation: cMember.Relation,
+	}
+
+	return Family{
+		ID:                  primitive.NewObjectID(),
+		InstID:              f.InstID,
+		AllRegCodeSent:      false,
+		ContactGuardianInfo: cMemberInfo,
+		Wards:               ws,
+		Vehicles:            vs,
+		ModifiedAt:          time.Now(),
+	}
+}
+
 func getFamilyToReplace(f Family, cMemberInfo MemberTagInfo, ws []Ward, vs []Vehicle) Family {
 	return Family{
 		ID:                  f.ID,
@@ -192,5 +263,6 @@ func getFamilyToReplace(f Family, cMemberInfo MemberTagInfo, ws []Ward, vs []Veh
 		Wards:               ws,
 		Vehicles:            vs,
 		ModifiedAt:          time.Now(),
+		DeletedWards:        f.DeletedWards,
 	}
 }
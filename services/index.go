@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexSpec - an index a collection is expected to have
+type IndexSpec struct {
+	Collection string
+	Name       string
+	Keys       bson.D
+	Unique     bool
+	// Partial - when set, only documents matching it are indexed (and held unique)
+	Partial bson.D
+}
+
+// Indexes - every index of the database, ensured by "EnsureIndexes" at startup.
+// Unique indexes are what keeps duplicates out; Stores report a violation as ErrDuplicateKey
+var Indexes = []IndexSpec{
+	{Collection: "admins", Name: "fras_username_unique", Keys: bson.D{primitive.E{Key: "fras_username", Value: 1}}, Unique: true},
+	{Collection: "admins", Name: "institution_id", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}}},
+	{Collection: "audits", Name: "institution_id_created_at", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}, primitive.E{Key: "created_at", Value: -1}}},
+	{Collection: "CCRecords", Name: "institution_id_status", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}, primitive.E{Key: "status", Value: 1}}},
+	{Collection: "CCRecords", Name: "gw_ward_info_id", Keys: bson.D{primitive.E{Key: "gw.ward_info.id", Value: 1}}},
+	{Collection: "CCRecords", Name: "mt_info_id", Keys: bson.D{primitive.E{Key: "mt.info.id", Value: 1}}},
+	{Collection: "devices", Name: "device_id", Keys: bson.D{primitive.E{Key: "device_id", Value: 1}}},
+	{Collection: "exclusions", Name: "institution_id_subject_id", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}, primitive.E{Key: "subject_id", Value: 1}}},
+	{Collection: "families", Name: "institution_id", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}}},
+	{Collection: "families", Name: "wards_id", Keys: bson.D{primitive.E{Key: "wards._id", Value: 1}}},
+	{Collection: "families", Name: "contact_member_info_id", Keys: bson.D{primitive.E{Key: "contact_member_info.id", Value: 1}}},
+	{Collection: "institutions", Name: "identifier_unique", Keys: bson.D{primitive.E{Key: "identifier", Value: 1}}, Unique: true,
+		// Identifiers are only given to Institutions of Tags
+		Partial: bson.D{primitive.E{Key: "identifier", Value: bson.D{primitive.E{Key: "$gt", Value: ""}}}}},
+	{Collection: "members", Name: "phone_num_unique", Keys: bson.D{primitive.E{Key: "phone_num", Value: 1}}, Unique: true,
+		// Phone #s of deleted Members may be registered again
+		Partial: bson.D{notDeleted}},
+	{Collection: "members", Name: "institution_id", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}}},
+	{Collection: "members", Name: "family_info_id", Keys: bson.D{primitive.E{Key: "family_info.id", Value: 1}}},
+	{Collection: "regCodes", Name: "member_id", Keys: bson.D{primitive.E{Key: "member_id", Value: 1}}},
+	{Collection: "regCodeAttempts", Name: "phone_num", Keys: bson.D{primitive.E{Key: "phone_num", Value: 1}}},
+	{Collection: "scan_events", Name: "institution_id_received_at", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}, primitive.E{Key: "received_at", Value: 1}}},
+	{Collection: "scan_events", Name: "cc_records_cc_record_id", Keys: bson.D{primitive.E{Key: "cc_records.cc_record_id", Value: 1}}},
+	{Collection: "screening_cases", Name: "institution_id_status", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}, primitive.E{Key: "status", Value: 1}}},
+	{Collection: "screening_cases", Name: "cc_record_id", Keys: bson.D{primitive.E{Key: "cc_record_id", Value: 1}}},
+	{Collection: "tags", Name: "institution_id_tag_string_unique", Keys: bson.D{primitive.E{Key: "institution_id", Value: 1}, primitive.E{Key: "tag_string", Value: 1}}, Unique: true},
+}
+
+// ErrDuplicateKey - returned by the in-memory Stores when a write breaks a unique index
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// IsDuplicateKeyErr - whether a Store write failed because it would break a unique index
+func IsDuplicateKeyErr(err error) bool {
+	if errors.Is(err, ErrDuplicateKey) {
+		return true
+	}
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, e := range writeErr.WriteErrors {
+			if isDuplicateKeyCode(e.Code) {
+				return true
+			}
+		}
+		return false
+	}
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && isDuplicateKeyCode(int(cmdErr.Code))
+}
+
+func isDuplicateKeyCode(code int) bool {
+	return code == 11000 || code == 11001 || code == 12582
+}
+
+// IndexDrift - a difference between "Indexes" and the indexes in the database
+type IndexDrift struct {
+	Collection string
+	Name       string
+	Problem    string
+}
+
+func (d IndexDrift) String() string {
+	return fmt.Sprintf("%v.%v - %v", d.Collection, d.Name, d.Problem)
+}
+
+// IndexReport - what "EnsureIndexes" did, and what it left for an operator to look at
+type IndexReport struct {
+	Created []string
+	Drifts  []IndexDrift
+}
+
+// existingIndex - an index as listed by MongoDB
+type existingIndex struct {
+	Name    string `bson:"name"`
+	Keys    bson.D `bson:"key"`
+	Unique  bool   `bson:"unique"`
+	Partial bson.D `bson:"partialFilterExpression"`
+}
+
+// EnsureIndexes - create the missing "Indexes", and report drift.
+// Indexes that differ from their spec are never dropped; that is left to an operator, who can then restart
+// to have them recreated. A unique index that cannot be built because of existing duplicates is reported as drift.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) (IndexReport, error) {
+	report := IndexReport{}
+	declared := map[string]map[string]IndexSpec{}
+	collections := []string{}
+	for _, spec := range Indexes {
+		if _, ok := declared[spec.Collection]; !ok {
+			declared[spec.Collection] = map[string]IndexSpec{}
+			collections = append(collections, spec.Collection)
+		}
+		declared[spec.Collection][spec.Name] = spec
+	}
+
+	for _, collName := range collections {
+		coll := db.Collection(collName)
+		cursor, err := coll.Indexes().List(ctx)
+		if err != nil {
+			return report, err
+		}
+		existing := []existingIndex{}
+		if err = cursor.All(ctx, &existing); err != nil {
+			return report, err
+		}
+		found := map[string]existingIndex{}
+		for _, index := range existing {
+			found[index.Name] = index
+			if _, ok := declared[collName][index.Name]; !ok && index.Name != "_id_" {
+				report.Drifts = append(report.Drifts, IndexDrift{Collection: collName, Name: index.Name, Problem: "not declared"})
+			}
+		}
+
+		for _, spec := range Indexes {
+			if spec.Collection != collName {
+				continue
+			}
+			if index, ok := found[spec.Name]; ok {
+				if problem := indexDiff(spec, index); len(problem) > 0 {
+					report.Drifts = append(report.Drifts, IndexDrift{Collection: collName, Name: spec.Name, Problem: problem})
+				}
+				continue
+			}
+			opts := options.Index().SetName(spec.Name)
+			if spec.Unique {
+				opts.SetUnique(true)
+			}
+			if len(spec.Partial) > 0 {
+				opts.SetPartialFilterExpression(spec.Partial)
+			}
+			if _, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: spec.Keys, Options: opts}); err != nil {
+				if ctx.Err() != nil {
+					return report, err
+				}
+				report.Drifts = append(report.Drifts, IndexDrift{Collection: collName, Name: spec.Name, Problem: fmt.Sprintf("could not be created - %v", err)})
+				continue
+			}
+			report.Created = append(report.Created, collName+"."+spec.Name)
+		}
+	}
+	return report, nil
+}
+
+// indexDiff - how an existing index differs from its spec, empty if it does not
+func indexDiff(spec IndexSpec, index existingIndex) string {
+	problems := []string{}
+	if indexKeysString(spec.Keys) != indexKeysString(index.Keys) {
+		problems = append(problems, fmt.Sprintf("keys are %v, declared %v", indexKeysString(index.Keys), indexKeysString(spec.Keys)))
+	}
+	if spec.Unique != index.Unique {
+		problems = append(problems, fmt.Sprintf("unique is %v, declared %v", index.Unique, spec.Unique))
+	}
+	if fmt.Sprint(spec.Partial) != fmt.Sprint(index.Partial) {
+		problems = append(problems, fmt.Sprintf("partial filter is %v, declared %v", index.Partial, spec.Partial))
+	}
+	return strings.Join(problems, "; ")
+}
+
+// indexKeysString - keys of an index, with directions compared whatever their numeric BSON type is
+func indexKeysString(keys bson.D) string {
+	fields := []string{}
+	for _, key := range keys {
+		direction := fmt.Sprint(key.Value)
+		switch v := key.Value.(type) {
+		case int32:
+			direction = fmt.Sprint(int64(v))
+		case float64:
+			direction = fmt.Sprint(int64(v))
+		}
+		fields = append(fields, key.Key+":"+direction)
+	}
+	return strings.Join(fields, ",")
+}
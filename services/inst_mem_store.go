@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memInstStore struct {
+	insts memCollection
+}
+
+// NewMemInstStore - as is; Identifiers, when given, are unique
+func NewMemInstStore() InstStore {
+	return &memInstStore{insts: memCollection{unique: []memUniqueKey{
+		func(doc interface{}) (string, bool) {
+			identifier := doc.(Institution).Identifier
+			return identifier, len(identifier) > 0
+		},
+	}}}
+}
+
+func (s *memInstStore) GetManyInsts(ctx context.Context) (Cursor, error) {
+	return s.insts.cursor(ctx, instDeleted(memMatchAll, false))
+}
+
+func (s *memInstStore) GetInstByID(ctx context.Context, id string) SingleResult {
+	return s.insts.findOne(ctx, instDeleted(instWithID(id), false))
+}
+
+func (s *memInstStore) GetInstByIdentifier(ctx context.Context, identifier string) SingleResult {
+	return s.insts.findOne(ctx, instDeleted(func(doc interface{}) bool {
+		return doc.(Institution).Identifier == identifier
+	}, false))
+}
+
+func (s *memInstStore) GetInstByName(ctx context.Context, name string) SingleResult {
+	return s.insts.findOne(ctx, instWithName(name))
+}
+
+func (s *memInstStore) CountInstByName(ctx context.Context, name string) (int64, error) {
+	return s.insts.count(ctx, instWithName(name))
+}
+
+func (s *memInstStore) CreateInst(ctx context.Context, i InstitutionForm) (*mongo.InsertOneResult, error) {
+	newInst, err := newInst(i)
+	if err != nil {
+		return nil, err
+	}
+	return s.insts.insertOne(ctx, newInst.ID, newInst)
+}
+
+func (s *memInstStore) UpdateInstByID(ctx context.Context, i InstitutionForm, idToUpdate string) (*mongo.UpdateResult, error) {
+	return s.insts.updateOne(ctx, instWithID(idToUpdate), func(doc interface{}) interface{} {
+		inst := doc.(Institution)
+		inst.Type = InstType(i.Type)
+		inst.WorkflowType = WorkflowType(i.WorkflowType)
+		inst.MemberType = MemberType(i.MemberType)
+		inst.Identifier = i.Identifier
+		inst.CustomTagStringRegex = i.CustomTagStringRegex
+		inst.Name = i.Name
+		inst.Address = i.Address
+		inst.State = i.State
+		inst.ZipCode = i.ZipCode
+		inst.RequireSurvey = i.RequireSurvey
+		inst.ModifiedAt = time.Now()
+		return inst
+	})
+}
+
+func (s *memInstStore) UpdateInstScreeningPolicy(ctx context.Context, idToUpdate string, policy ScreeningPolicy) (*mongo.UpdateResult, error) {
+	return s.insts.updateOne(ctx, instDeleted(instWithID(idToUpdate), false), func(doc interface{}) interface{} {
+		inst := doc.(Institution)
+		inst.ScreeningPolicy = &policy
+		inst.ModifiedAt = time.Now()
+		return inst
+	})
+}
+
+func (s *memInstStore) GetInstQRSigningKey(ctx context.Context, instID string) (string, error) {
+	var inst Institution
+	if err := s.GetInstByID(ctx, instID).Decode(&inst); err != nil {
+		return "", err
+	}
+	if len(inst.QRSigningKey) > 0 {
+		return inst.QRSigningKey, nil
+	}
+
+	qrSigningKey, err := newQRSigningKey()
+	if err != nil {
+		return "", err
+	}
+	// Only set when still missing, so that concurrent callers end up with the same key
+	_, err = s.insts.updateOne(ctx, func(doc interface{}) bool {
+		return doc.(Institution).ID == inst.ID && len(doc.(Institution).QRSigningKey) == 0
+	}, func(doc interface{}) interface{} {
+		inst := doc.(Institution)
+		inst.QRSigningKey = qrSigningKey
+		return inst
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.GetInstByID(ctx, instID).Decode(&inst); err != nil {
+		return "", err
+	}
+	return inst.QRSigningKey, nil
+}
+
+func (s *memInstStore) DeleteInstByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
+	return s.insts.deleteOne(ctx, instWithID(idToDelete))
+}
+
+func (s *memInstStore) GetDeletedInstByID(ctx context.Context, id string) SingleResult {
+	return s.insts.findOne(ctx, instDeleted(instWithID(id), true))
+}
+
+func (s *memInstStore) SoftDeleteInstByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error) {
+	return s.insts.updateOne(ctx, instDeleted(instWithID(idToDelete), false), func(doc interface{}) interface{} {
+		inst := doc.(Institution)
+		inst.DeletedAt = &at
+		return inst
+	})
+}
+
+func (s *memInstStore) RestoreInstByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error) {
+	return s.insts.updateOne(ctx, instDeleted(instWithID(idToRestore), true), func(doc interface{}) interface{} {
+		inst := doc.(Institution)
+		inst.DeletedAt = nil
+		return inst
+	})
+}
+
+func instWithID(id string) memMatch {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return func(doc interface{}) bool { return doc.(Institution).ID == oid }
+}
+
+func instWithName(name string) memMatch {
+	return func(doc interface{}) bool { return doc.(Institution).Name == name }
+}
+
+// instDeleted - Institutions of "match" that are (or are not) soft-deleted
+func instDeleted(match memMatch, deleted bool) memMatch {
+	return func(doc interface{}) bool { return match(doc) && (doc.(Institution).DeletedAt != nil) == deleted }
+}
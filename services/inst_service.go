@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -61,58 +63,92 @@ type Institution struct {
 	State                string             `json:"state"`
 	ZipCode              string             `bson:"zip_code" json:"zip_code"`
 	RequireSurvey        bool               `bson:"require_survey" json:"require_survey"`
+	QRSigningKey         string             `bson:"qr_signing_key" json:"-"`
+	ScreeningPolicy      *ScreeningPolicy   `bson:"screening_policy,omitempty" json:"screening_policy,omitempty"`
 	CreatedAt            time.Time          `bson:"created_at" json:"created_at"`
 	ModifiedAt           time.Time          `bson:"modified_at" json:"modified_at"`
+	DeletedAt            *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
-var instCollection *mongo.Collection
+// InstStore - persistence of Institutions
+type InstStore interface {
+	GetManyInsts(ctx context.Context) (Cursor, error)
+	GetInstByID(ctx context.Context, id string) SingleResult
+	GetInstByIdentifier(ctx context.Context, identifier string) SingleResult
+	GetInstByName(ctx context.Context, name string) SingleResult
+	CountInstByName(ctx context.Context, name string) (int64, error)
+	CreateInst(ctx context.Context, i InstitutionForm) (*mongo.InsertOneResult, error)
+	UpdateInstByID(ctx context.Context, i InstitutionForm, idToUpdate string) (*mongo.UpdateResult, error)
+	UpdateInstScreeningPolicy(ctx context.Context, idToUpdate string, policy ScreeningPolicy) (*mongo.UpdateResult, error)
+	GetInstQRSigningKey(ctx context.Context, instID string) (string, error)
+	DeleteInstByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error)
+	GetDeletedInstByID(ctx context.Context, id string) SingleResult
+	SoftDeleteInstByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error)
+	RestoreInstByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error)
+}
+
+type mongoInstStore struct {
+	instCollection *mongo.Collection
+}
 
-// InstCollection returns reference to DB collection
-func InstCollection(c *mongo.Database) {
-	instCollection = c.Collection("institutions")
+// NewMongoInstStore - as is
+func NewMongoInstStore(db *mongo.Database) InstStore {
+	return &mongoInstStore{instCollection: db.Collection("institutions")}
 }
 
 // GetManyInsts as name suggests
-func GetManyInsts() (*mongo.Cursor, error) {
-	// TODO: not sending status: "2 - deleted"
-	return instCollection.Find(context.TODO(), bson.M{})
+func (s *mongoInstStore) GetManyInsts(ctx context.Context) (Cursor, error) {
+	return mongoCursor(s.instCollection.Find(ctx, bson.D{notDeleted}))
 }
 
 // GetInstByID as name suggests
-func GetInstByID(id string) *mongo.SingleResult {
+func (s *mongoInstStore) GetInstByID(ctx context.Context, id string) SingleResult {
 	// TODO: err handling for ID Parsing
 	oid, _ := primitive.ObjectIDFromHex(id)
-	return instCollection.FindOne(context.TODO(), bson.M{"_id": oid})
+	return s.instCollection.FindOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, notDeleted})
 }
 
 // GetInstByIdentifier as name suggests
-func GetInstByIdentifier(identifier string) *mongo.SingleResult {
+func (s *mongoInstStore) GetInstByIdentifier(ctx context.Context, identifier string) SingleResult {
 	// TODO: err handling for ID Parsing
-	return instCollection.FindOne(context.TODO(), bson.D{
-		primitive.E{Key: "identifier", Value: identifier},
+	return s.instCollection.FindOne(ctx, bson.D{
+		primitive.E{Key: "identifier", Value: identifier}, notDeleted,
 	})
 }
 
 // GetInstByName as name suggests
-func GetInstByName(name string) *mongo.SingleResult {
+func (s *mongoInstStore) GetInstByName(ctx context.Context, name string) SingleResult {
 	// TODO: err handling for ID Parsing
-	return instCollection.FindOne(context.TODO(), bson.D{
+	return s.instCollection.FindOne(ctx, bson.D{
 		primitive.E{Key: "name", Value: name},
 	})
 }
 
 // CountInstByName - as is
-func CountInstByName(name string) (int64, error) {
+func (s *mongoInstStore) CountInstByName(ctx context.Context, name string) (int64, error) {
 	// TODO: err handling for ID Parsing
-	return instCollection.CountDocuments(context.TODO(), bson.D{
+	return s.instCollection.CountDocuments(ctx, bson.D{
 		primitive.E{Key: "name", Value: name},
 	})
 }
 
 // CreateInst as name suggests
-func CreateInst(i InstitutionForm) (*mongo.InsertOneResult, error) {
+func (s *mongoInstStore) CreateInst(ctx context.Context, i InstitutionForm) (*mongo.InsertOneResult, error) {
+	newInst, err := newInst(i)
+	if err != nil {
+		return nil, err
+	}
+	return s.instCollection.InsertOne(ctx, newInst)
+}
+
+// newInst - Institution to be inserted, with its own QR Signing Key
+func newInst(i InstitutionForm) (Institution, error) {
 	// requireSurvey, _ := strconv.ParseBool(i.RequireSurvey)
-	newInst := Institution{
+	qrSigningKey, err := newQRSigningKey()
+	if err != nil {
+		return Institution{}, err
+	}
+	return Institution{
 		ID:                   primitive.NewObjectID(),
 		Type:                 InstType(i.Type),
 		WorkflowType:         WorkflowType(i.WorkflowType),
@@ -124,16 +160,16 @@ func CreateInst(i InstitutionForm) (*mongo.InsertOneResult, error) {
 		State:                i.State,
 		ZipCode:              i.ZipCode,
 		RequireSurvey:        i.RequireSurvey,
+		QRSigningKey:         qrSigningKey,
 		CreatedAt:            time.Now(),
 		ModifiedAt:           time.Now(),
-	}
-	return instCollection.InsertOne(context.TODO(), newInst)
+	}, nil
 }
 
 // UpdateInstByID as name suggests
-func UpdateInstByID(i InstitutionForm, idToUpdate string) (*mongo.UpdateResult, error) {
+func (s *mongoInstStore) UpdateInstByID(ctx context.Context, i InstitutionForm, idToUpdate string) (*mongo.UpdateResult, error) {
 	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
-	return instCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	return s.instCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "type", Value: InstType(i.Type)},
 			primitive.E{Key: "workflow_type", Value: WorkflowType(i.WorkflowType)},
@@ -152,9 +188,84 @@ func UpdateInstByID(i InstitutionForm, idToUpdate string) (*mongo.UpdateResult,
 	})
 }
 
+// UpdateInstScreeningPolicy - as name suggests
+func (s *mongoInstStore) UpdateInstScreeningPolicy(ctx context.Context, idToUpdate string, policy ScreeningPolicy) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
+	return s.instCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, notDeleted}, bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "screening_policy", Value: policy},
+		}},
+		primitive.E{Key: "$currentDate", Value: bson.D{
+			primitive.E{Key: "modified_at", Value: true},
+		}},
+	})
+}
+
+// GetInstQRSigningKey - key the Institution's Members sign QR scan payloads with;
+// Institutions created before signing was introduced get one generated
+func (s *mongoInstStore) GetInstQRSigningKey(ctx context.Context, instID string) (string, error) {
+	var inst Institution
+	if err := s.GetInstByID(ctx, instID).Decode(&inst); err != nil {
+		return "", err
+	}
+	if len(inst.QRSigningKey) > 0 {
+		return inst.QRSigningKey, nil
+	}
+
+	qrSigningKey, err := newQRSigningKey()
+	if err != nil {
+		return "", err
+	}
+	// Only set when still missing, so that concurrent callers end up with the same key
+	_, err = s.instCollection.UpdateOne(ctx, bson.D{
+		primitive.E{Key: "_id", Value: inst.ID},
+		primitive.E{Key: "qr_signing_key", Value: bson.D{
+			primitive.E{Key: "$in", Value: bson.A{nil, ""}},
+		}},
+	}, bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "qr_signing_key", Value: qrSigningKey},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.GetInstByID(ctx, instID).Decode(&inst); err != nil {
+		return "", err
+	}
+	return inst.QRSigningKey, nil
+}
+
 // DeleteInstByID as name suggests
-func DeleteInstByID(idToDelete string) (*mongo.DeleteResult, error) {
+func (s *mongoInstStore) DeleteInstByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
 	// TODO: err handling for ID Parsing
 	oid, _ := primitive.ObjectIDFromHex(idToDelete)
-	return instCollection.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return s.instCollection.DeleteOne(ctx, bson.M{"_id": oid})
+}
+
+// generate a 256-bit key, hex encoded
+// GetDeletedInstByID - a soft-deleted Institution
+func (s *mongoInstStore) GetDeletedInstByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.instCollection.FindOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, isDeleted})
+}
+
+// SoftDeleteInstByID - as is
+func (s *mongoInstStore) SoftDeleteInstByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToDelete)
+	return s.instCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, notDeleted}, softDelete(at))
+}
+
+// RestoreInstByID - as is
+func (s *mongoInstStore) RestoreInstByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToRestore)
+	return s.instCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, isDeleted}, restoreDeleted)
+}
+
+func newQRSigningKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
 }
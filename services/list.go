@@ -0,0 +1,372 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListParams - page & sort of a list query. Pages are sorted by "Sort" then "_id", and continue
+// after the position kept in "Cursor", so that inserts & deletes never shift them
+type ListParams struct {
+	// Limit - at most how many to list; 0 for all
+	Limit int64
+	// Cursor - "next_cursor" of the previous page; empty for the first
+	Cursor string
+	// Sort - one of the sorts offered by the list, "-" prefixed for descending; empty for "_id"
+	Sort string
+}
+
+// ErrBadListParams - returned by list queries given a sort they do not offer, or a cursor they did not issue
+var ErrBadListParams = errors.New("bad list parameters")
+
+// listSort - a sort resolved to its BSON key
+type listSort struct {
+	key  string
+	desc bool
+}
+
+// listPosition - where a page ends, as kept in a cursor
+type listPosition struct {
+	Key   string             `bson:"k"`
+	Value bson.RawValue      `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+// isPaged - whether the list is paged or sorted, rather than listed whole as is
+func (p ListParams) isPaged() bool {
+	return p.Limit > 0 || len(p.Cursor) > 0 || len(p.Sort) > 0
+}
+
+// resolve - the sort & start position of the list; "sorts" maps the sorts offered to their BSON keys
+func (p ListParams) resolve(sorts map[string]string) (listSort, *listPosition, error) {
+	by := listSort{key: "_id"}
+	if len(p.Sort) > 0 {
+		name := strings.TrimPrefix(p.Sort, "-")
+		key, ok := sorts[name]
+		if !ok {
+			return by, nil, fmt.Errorf("%w - unknown sort %v", ErrBadListParams, name)
+		}
+		by = listSort{key: key, desc: strings.HasPrefix(p.Sort, "-")}
+	}
+	if len(p.Cursor) == 0 {
+		return by, nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return by, nil, fmt.Errorf("%w - malformed cursor", ErrBadListParams)
+	}
+	var pos listPosition
+	if err = bson.Unmarshal(raw, &pos); err != nil {
+		return by, nil, fmt.Errorf("%w - malformed cursor", ErrBadListParams)
+	}
+	if pos.Key != by.key {
+		return by, nil, fmt.Errorf("%w - cursor is of another sort", ErrBadListParams)
+	}
+	return by, &pos, nil
+}
+
+// listCursor - Cursor over a page, holding one more than the limit to tell whether there is a next page
+type listCursor struct {
+	Cursor
+	by    listSort
+	limit int64
+}
+
+// DecodeList - decode a list into a pointer to slice; returns the cursor of the next page, empty if there is none
+func DecodeList(ctx context.Context, cursor Cursor, results interface{}) (string, error) {
+	if err := cursor.All(ctx, results); err != nil {
+		return "", err
+	}
+	lc, ok := cursor.(*listCursor)
+	if !ok || lc.limit == 0 {
+		return "", nil
+	}
+	sliceVal := reflect.ValueOf(results).Elem()
+	if int64(sliceVal.Len()) <= lc.limit {
+		return "", nil
+	}
+	sliceVal.Set(sliceVal.Slice(0, int(lc.limit)))
+
+	raw, err := bson.Marshal(sliceVal.Index(sliceVal.Len() - 1).Interface())
+	if err != nil {
+		return "", err
+	}
+	pos := listPosition{Key: lc.by.key, Value: listValue(raw, lc.by.key)}
+	pos.ID, _ = bson.Raw(raw).Lookup("_id").ObjectIDOK()
+	posRaw, err := bson.Marshal(pos)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(posRaw), nil
+}
+
+// listValue - value of a dotted key in a document; null when it is missing
+func listValue(doc bson.Raw, key string) bson.RawValue {
+	value, err := doc.LookupErr(strings.Split(key, ".")...)
+	if err != nil {
+		return bson.RawValue{Type: bsontype.Null}
+	}
+	return value
+}
+
+// prefixFilter - case-insensitive prefix match, as a MongoDB filter value
+func prefixFilter(prefix string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix), Options: "i"}
+}
+
+// hasPrefixFold - case-insensitive prefix match, as "prefixFilter" does
+func hasPrefixFold(s string, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
+
+// timeRangeFilter - MongoDB filter value of times after "start" and before "end"; empty when neither is set
+func timeRangeFilter(start time.Time, end time.Time) bson.D {
+	var timeFilter bson.D
+	if !start.IsZero() {
+		timeFilter = append(timeFilter, primitive.E{Key: "$gt", Value: start})
+	}
+	if !end.IsZero() {
+		timeFilter = append(timeFilter, primitive.E{Key: "$lt", Value: end})
+	}
+	return timeFilter
+}
+
+// inTimeRange - as "timeRangeFilter" matches
+func inTimeRange(t time.Time, start time.Time, end time.Time) bool {
+	if !start.IsZero() && !t.After(start) {
+		return false
+	}
+	if !end.IsZero() && !t.Before(end) {
+		return false
+	}
+	return true
+}
+
+// mongoList - find a page of the documents matching "filters"
+func mongoList(ctx context.Context, coll *mongo.Collection, filters bson.D, params ListParams, sorts map[string]string) (Cursor, error) {
+	if !params.isPaged() {
+		return mongoCursor(coll.Find(ctx, filters))
+	}
+	by, pos, err := params.resolve(sorts)
+	if err != nil {
+		return nil, err
+	}
+	if pos != nil {
+		if filters == nil {
+			filters = bson.D{}
+		}
+		filters = bson.D{primitive.E{Key: "$and", Value: bson.A{filters, mongoAfter(by, pos)}}}
+	}
+
+	direction := 1
+	if by.desc {
+		direction = -1
+	}
+	sortDoc := bson.D{primitive.E{Key: by.key, Value: direction}}
+	if by.key != "_id" {
+		sortDoc = append(sortDoc, primitive.E{Key: "_id", Value: direction})
+	}
+	opts := options.Find().SetSort(sortDoc)
+	if params.Limit > 0 {
+		opts.SetLimit(params.Limit + 1)
+	}
+	cursor, err := mongoCursor(coll.Find(ctx, filters, opts))
+	if err != nil {
+		return nil, err
+	}
+	return &listCursor{Cursor: cursor, by: by, limit: params.Limit}, nil
+}
+
+// mongoAfter - filter of the documents sorted after "pos". MongoDB sorts null (& missing) first,
+// but only matches null by equality
+func mongoAfter(by listSort, pos *listPosition) bson.D {
+	idOp := "$gt"
+	if by.desc {
+		idOp = "$lt"
+	}
+	afterID := bson.D{primitive.E{Key: idOp, Value: pos.ID}}
+	if by.key == "_id" {
+		return bson.D{primitive.E{Key: "_id", Value: afterID}}
+	}
+
+	var after bson.A
+	isNull := pos.Value.Type == bsontype.Null || pos.Value.Type == bsontype.Undefined
+	switch {
+	case isNull && !by.desc:
+		after = bson.A{
+			bson.D{primitive.E{Key: by.key, Value: bson.D{primitive.E{Key: "$ne", Value: nil}}}},
+			bson.D{primitive.E{Key: by.key, Value: nil}, primitive.E{Key: "_id", Value: afterID}},
+		}
+	case isNull && by.desc:
+		after = bson.A{
+			bson.D{primitive.E{Key: by.key, Value: nil}, primitive.E{Key: "_id", Value: afterID}},
+		}
+	default:
+		valueOp := "$gt"
+		if by.desc {
+			valueOp = "$lt"
+		}
+		after = bson.A{
+			bson.D{primitive.E{Key: by.key, Value: bson.D{primitive.E{Key: valueOp, Value: pos.Value}}}},
+			bson.D{primitive.E{Key: by.key, Value: pos.Value}, primitive.E{Key: "_id", Value: afterID}},
+		}
+		if by.desc {
+			after = append(after, bson.D{primitive.E{Key: by.key, Value: nil}})
+		}
+	}
+	return bson.D{primitive.E{Key: "$or", Value: after}}
+}
+
+// list - as "mongoList", for the in-memory Stores
+func (c *memCollection) list(ctx context.Context, match memMatch, params ListParams, sorts map[string]string) (Cursor, error) {
+	if !params.isPaged() {
+		return c.cursor(ctx, match)
+	}
+	by, pos, err := params.resolve(sorts)
+	if err != nil {
+		return nil, err
+	}
+	found, err := c.find(ctx, match)
+	if err != nil {
+		return nil, err
+	}
+
+	type sortable struct {
+		doc   interface{}
+		value bson.RawValue
+		id    primitive.ObjectID
+	}
+	docs := []sortable{}
+	for _, doc := range found {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := bson.Raw(raw).Lookup("_id").ObjectIDOK()
+		docs = append(docs, sortable{doc: doc, value: listValue(raw, by.key), id: id})
+	}
+	compare := func(valueA bson.RawValue, idA primitive.ObjectID, valueB bson.RawValue, idB primitive.ObjectID) int {
+		cmp := compareBSONValues(valueA, valueB)
+		if cmp == 0 {
+			cmp = bytes.Compare(idA[:], idB[:])
+		}
+		if by.desc {
+			return -cmp
+		}
+		return cmp
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		return compare(docs[i].value, docs[i].id, docs[j].value, docs[j].id) < 0
+	})
+
+	page := []interface{}{}
+	for _, d := range docs {
+		if pos != nil && compare(d.value, d.id, pos.Value, pos.ID) <= 0 {
+			continue
+		}
+		page = append(page, d.doc)
+		if params.Limit > 0 && int64(len(page)) > params.Limit {
+			break
+		}
+	}
+	return &listCursor{Cursor: &memCursor{docs: page}, by: by, limit: params.Limit}, nil
+}
+
+// bsonTypeOrder - order of BSON types when sorted by MongoDB
+func bsonTypeOrder(t bsontype.Type) int {
+	switch t {
+	case bsontype.Null, bsontype.Undefined:
+		return 1
+	case bsontype.Int32, bsontype.Int64, bsontype.Double, bsontype.Decimal128:
+		return 2
+	case bsontype.String, bsontype.Symbol:
+		return 3
+	case bsontype.EmbeddedDocument:
+		return 4
+	case bsontype.Array:
+		return 5
+	case bsontype.Binary:
+		return 6
+	case bsontype.ObjectID:
+		return 7
+	case bsontype.Boolean:
+		return 8
+	case bsontype.DateTime:
+		return 9
+	case bsontype.Timestamp:
+		return 10
+	}
+	return 11
+}
+
+// compareBSONValues - -1, 0 or 1, as MongoDB sorts two values
+func compareBSONValues(a bson.RawValue, b bson.RawValue) int {
+	orderA, orderB := bsonTypeOrder(a.Type), bsonTypeOrder(b.Type)
+	if orderA != orderB {
+		return compareInts(int64(orderA), int64(orderB))
+	}
+	switch orderA {
+	case 1:
+		return 0
+	case 2:
+		floatA, floatB := bsonNumber(a), bsonNumber(b)
+		if floatA < floatB {
+			return -1
+		} else if floatA > floatB {
+			return 1
+		}
+		return 0
+	case 3:
+		return strings.Compare(a.StringValue(), b.StringValue())
+	case 7:
+		idA, idB := a.ObjectID(), b.ObjectID()
+		return bytes.Compare(idA[:], idB[:])
+	case 8:
+		boolA, boolB := a.Boolean(), b.Boolean()
+		if boolA == boolB {
+			return 0
+		} else if !boolA {
+			return -1
+		}
+		return 1
+	case 9:
+		return compareInts(a.DateTime(), b.DateTime())
+	}
+	return bytes.Compare(a.Value, b.Value)
+}
+
+func bsonNumber(v bson.RawValue) float64 {
+	switch v.Type {
+	case bsontype.Int32:
+		return float64(v.Int32())
+	case bsontype.Int64:
+		return float64(v.Int64())
+	case bsontype.Double:
+		return v.Double()
+	}
+	return 0
+}
+
+func compareInts(a int64, b int64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package services
+
+import "sort"
+
+// MaskComplianceRow - the Check-In Scans of one group, device or day, and how many of them were masked
+type MaskComplianceRow struct {
+	Key    string  `json:"key"`
+	Scans  int     `json:"scans"`
+	Masked int     `json:"masked"`
+	Rate   float64 `json:"rate"`
+}
+
+// MaskComplianceReport - mask rates of Check-In Scans, by the group of who was scanned, by Gatekeeper & by day (UTC)
+type MaskComplianceReport struct {
+	Total    MaskComplianceRow   `json:"total"`
+	ByGroup  []MaskComplianceRow `json:"by_group"`
+	ByDevice []MaskComplianceRow `json:"by_device"`
+	ByDay    []MaskComplianceRow `json:"by_day"`
+}
+
+// NewMaskComplianceReport - report on the Check-Ins among "events" that were accepted or denied;
+// Scans rejected before screening are left out. Rows are sorted by key
+func NewMaskComplianceReport(events []ScanEvent) MaskComplianceReport {
+	total := MaskComplianceRow{Key: "total"}
+	byGroup := map[string]*MaskComplianceRow{}
+	byDevice := map[string]*MaskComplianceRow{}
+	byDay := map[string]*MaskComplianceRow{}
+	for _, e := range events {
+		if e.Stage != string(CCStageCheckIn) || (e.Decision != ScanDecisionAccepted && e.Decision != ScanDecisionDenied) {
+			continue
+		}
+		for _, row := range []*MaskComplianceRow{
+			&total,
+			complianceRow(byGroup, e.Group),
+			complianceRow(byDevice, e.DeviceID),
+			complianceRow(byDay, e.ReceivedAt.UTC().Format("2006-01-02")),
+		} {
+			row.Scans++
+			if e.Mask {
+				row.Masked++
+			}
+		}
+	}
+
+	return MaskComplianceReport{
+		Total:    withRate(total),
+		ByGroup:  complianceRows(byGroup),
+		ByDevice: complianceRows(byDevice),
+		ByDay:    complianceRows(byDay),
+	}
+}
+
+func complianceRow(rows map[string]*MaskComplianceRow, key string) *MaskComplianceRow {
+	if _, ok := rows[key]; !ok {
+		rows[key] = &MaskComplianceRow{Key: key}
+	}
+	return rows[key]
+}
+
+func complianceRows(rows map[string]*MaskComplianceRow) []MaskComplianceRow {
+	sorted := []MaskComplianceRow{}
+	for _, row := range rows {
+		sorted = append(sorted, withRate(*row))
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func withRate(row MaskComplianceRow) MaskComplianceRow {
+	if row.Scans > 0 {
+		row.Rate = float64(row.Masked) / float64(row.Scans)
+	}
+	return row
+}
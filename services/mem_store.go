@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// In-memory Stores keep DB Models as Go values. Models go through a BSON round trip
+// whenever they are stored or decoded, so that callers see what MongoDB would return
+// (millisecond times, BSON field names, no shared slices or pointers).
+// Like MongoDB, operations fail with the error of "ctx" once it is done.
+
+// memCollection - documents of a single type, in insertion ("$natural") order
+type memCollection struct {
+	mu   sync.Mutex
+	docs []interface{}
+	// unique - keys held unique, as by the unique "Indexes" of the collection
+	unique []memUniqueKey
+}
+
+// memUniqueKey - key of a document in a unique index; "indexed" is false for documents a partial index leaves out
+type memUniqueKey func(doc interface{}) (key string, indexed bool)
+
+// memMatch - the filter of a query
+type memMatch func(doc interface{}) bool
+
+func memMatchAll(doc interface{}) bool {
+	return true
+}
+
+func (c *memCollection) insertOne(ctx context.Context, id interface{}, doc interface{}) (*mongo.InsertOneResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	stored, err := memClone(doc)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.duplicates(stored, -1) {
+		return nil, ErrDuplicateKey
+	}
+	c.docs = append(c.docs, stored)
+	return &mongo.InsertOneResult{InsertedID: id}, nil
+}
+
+// find - matching documents; those are never modified in place, so can be read without the lock
+func (c *memCollection) find(ctx context.Context, match memMatch) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	found := []interface{}{}
+	for _, doc := range c.docs {
+		if match(doc) {
+			found = append(found, doc)
+		}
+	}
+	return found, nil
+}
+
+func (c *memCollection) cursor(ctx context.Context, match memMatch) (Cursor, error) {
+	found, err := c.find(ctx, match)
+	if err != nil {
+		return nil, err
+	}
+	return &memCursor{docs: found}, nil
+}
+
+func (c *memCollection) count(ctx context.Context, match memMatch) (int64, error) {
+	found, err := c.find(ctx, match)
+	return int64(len(found)), err
+}
+
+func (c *memCollection) findOne(ctx context.Context, match memMatch) SingleResult {
+	found, err := c.find(ctx, match)
+	if err != nil {
+		return memSingleResult{err: err}
+	}
+	if len(found) == 0 {
+		return memNotFound()
+	}
+	return memFound(found[0])
+}
+
+// findLast - as "findOne" sorted by "$natural" descending
+func (c *memCollection) findLast(ctx context.Context, match memMatch) SingleResult {
+	found, err := c.find(ctx, match)
+	if err != nil {
+		return memSingleResult{err: err}
+	}
+	if len(found) == 0 {
+		return memNotFound()
+	}
+	return memFound(found[len(found)-1])
+}
+
+// updateOne - replace the first matching document by what "update" returns
+func (c *memCollection) updateOne(ctx context.Context, match memMatch, update func(doc interface{}) interface{}) (*mongo.UpdateResult, error) {
+	return c.update(ctx, match, update, false)
+}
+
+// updateMany - replace every matching document by what "update" returns
+func (c *memCollection) updateMany(ctx context.Context, match memMatch, update func(doc interface{}) interface{}) (*mongo.UpdateResult, error) {
+	return c.update(ctx, match, update, true)
+}
+
+func (c *memCollection) update(ctx context.Context, match memMatch, update func(doc interface{}) interface{}, many bool) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := &mongo.UpdateResult{}
+	for index, doc := range c.docs {
+		if !match(doc) {
+			continue
+		}
+		// Work on a copy, so that nothing shared with "doc" is modified
+		working, err := memClone(doc)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := memClone(update(working))
+		if err != nil {
+			return nil, err
+		}
+		if c.duplicates(updated, index) {
+			return res, ErrDuplicateKey
+		}
+		res.MatchedCount++
+		if memModified(doc, updated) {
+			res.ModifiedCount++
+			c.docs[index] = updated
+		}
+		if !many {
+			break
+		}
+	}
+	return res, nil
+}
+
+// duplicates - whether "doc" has the unique key of a stored document, other than the one at "self"
+func (c *memCollection) duplicates(doc interface{}, self int) bool {
+	for _, uniqueKey := range c.unique {
+		key, indexed := uniqueKey(doc)
+		if !indexed {
+			continue
+		}
+		for index, other := range c.docs {
+			if index == self {
+				continue
+			}
+			if otherKey, otherIndexed := uniqueKey(other); otherIndexed && otherKey == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *memCollection) deleteOne(ctx context.Context, match memMatch) (*mongo.DeleteResult, error) {
+	return c.delete(ctx, match, false)
+}
+
+func (c *memCollection) deleteMany(ctx context.Context, match memMatch) (*mongo.DeleteResult, error) {
+	return c.delete(ctx, match, true)
+}
+
+func (c *memCollection) delete(ctx context.Context, match memMatch, many bool) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := &mongo.DeleteResult{}
+	kept := []interface{}{}
+	for _, doc := range c.docs {
+		if match(doc) && (many || res.DeletedCount == 0) {
+			res.DeletedCount++
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	c.docs = kept
+	return res, nil
+}
+
+// memSingleResult - SingleResult of the in-memory Stores
+type memSingleResult struct {
+	doc interface{}
+	err error
+}
+
+func memFound(doc interface{}) SingleResult {
+	return memSingleResult{doc: doc}
+}
+
+func memNotFound() SingleResult {
+	return memSingleResult{err: mongo.ErrNoDocuments}
+}
+
+// Decode - as is
+func (r memSingleResult) Decode(v interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return memDecode(r.doc, v)
+}
+
+// memCursor - Cursor of the in-memory Stores
+type memCursor struct {
+	docs []interface{}
+	pos  int
+}
+
+// Next - as is
+func (c *memCursor) Next(ctx context.Context) bool {
+	if c.pos >= len(c.docs) {
+		return false
+	}
+	c.pos++
+	return true
+}
+
+// Decode - decode the document the Cursor is at
+func (c *memCursor) Decode(v interface{}) error {
+	if c.pos == 0 || c.pos > len(c.docs) {
+		return errors.New("memCursor - Decode called without a current document")
+	}
+	return memDecode(c.docs[c.pos-1], v)
+}
+
+// All - decode the remaining documents into a pointer to slice
+func (c *memCursor) All(ctx context.Context, results interface{}) error {
+	sliceVal := reflect.ValueOf(results)
+	if sliceVal.Kind() != reflect.Ptr || sliceVal.Elem().Kind() != reflect.Slice {
+		return errors.New("memCursor - results argument must be a pointer to a slice")
+	}
+	sliceVal = sliceVal.Elem()
+	elemType := sliceVal.Type().Elem()
+
+	sliceVal.Set(sliceVal.Slice(0, 0))
+	for c.Next(ctx) {
+		elem := reflect.New(elemType)
+		if err := c.Decode(elem.Interface()); err != nil {
+			return err
+		}
+		sliceVal.Set(reflect.Append(sliceVal, elem.Elem()))
+	}
+	return nil
+}
+
+// Close - as is
+func (c *memCursor) Close(ctx context.Context) error {
+	c.pos = len(c.docs)
+	return nil
+}
+
+// memClone - copy through a BSON round trip
+func memClone(doc interface{}) (interface{}, error) {
+	clone := reflect.New(reflect.TypeOf(doc))
+	if err := memDecode(doc, clone.Interface()); err != nil {
+		return nil, err
+	}
+	return clone.Elem().Interface(), nil
+}
+
+func memDecode(doc interface{}, v interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, v)
+}
+
+// memModified - whether an update changed the document, as reported in "ModifiedCount"
+func memModified(before interface{}, after interface{}) bool {
+	rawBefore, errBefore := bson.Marshal(before)
+	rawAfter, errAfter := bson.Marshal(after)
+	if errBefore != nil || errAfter != nil {
+		return true
+	}
+	return !bytes.Equal(rawBefore, rawAfter)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memMemberStore struct {
+	members memCollection
+}
+
+// NewMemMemberStore - as is; phone numbers are unique among Members not deleted
+func NewMemMemberStore() MemberStore {
+	return &memMemberStore{members: memCollection{unique: []memUniqueKey{
+		func(doc interface{}) (string, bool) { return doc.(Member).PhoneNum, doc.(Member).DeletedAt == nil },
+	}}}
+}
+
+func (s *memMemberStore) GetManyMembers(ctx context.Context, params *GetMemberParams) (Cursor, error) {
+	return s.members.list(ctx, func(doc interface{}) bool {
+		m := doc.(Member)
+		if (m.DeletedAt != nil) != params.Deleted {
+			return false
+		}
+		if len(params.InstID) > 0 {
+			if m.InstID != params.InstID {
+				return false
+			}
+		} else if len(params.FamilyID) > 0 {
+			if m.FamilyInfo == nil || m.FamilyInfo.ID != params.FamilyID {
+				return false
+			}
+		}
+		if len(params.Group) > 0 && m.Group != params.Group {
+			return false
+		}
+		if len(params.NamePrefix) > 0 && !hasPrefixFold(m.FirstName, params.NamePrefix) && !hasPrefixFold(m.LastName, params.NamePrefix) {
+			return false
+		}
+		if params.Status != nil && m.Status != *params.Status {
+			return false
+		}
+		return inTimeRange(m.CreatedAt, params.StartDate, params.EndDate)
+	}, params.List, memberSorts)
+}
+
+func (s *memMemberStore) SearchMembers(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error) {
+	return s.members.list(ctx, func(doc interface{}) bool {
+		m := doc.(Member)
+		_, ok := q.SearchMember(m)
+		return m.InstID == instID && m.DeletedAt == nil && ok
+	}, ListParams{Limit: limit}, nil)
+}
+
+func (s *memMemberStore) GetMemberByID(ctx context.Context, id string) SingleResult {
+	return s.members.findOne(ctx, memberDeleted(memberWithID(id), false))
+}
+
+func (s *memMemberStore) GetMemberByPhoneNum(ctx context.Context, phoneNum string) SingleResult {
+	return s.members.findOne(ctx, memberDeleted(memberWithPhoneNum(phoneNum), false))
+}
+
+func (s *memMemberStore) CountMembersByPhoneNum(ctx context.Context, phoneNum string) (int64, error) {
+	return s.members.count(ctx, memberDeleted(memberWithPhoneNum(phoneNum), false))
+}
+
+func (s *memMemberStore) CreateMember(ctx context.Context, m MemberRegForm) (*mongo.InsertOneResult, error) {
+	newMember := newMember(m)
+	return s.members.insertOne(ctx, newMember.ID, newMember)
+}
+
+func (s *memMemberStore) UpdateMemberLoginTimeByID(ctx context.Context, id string) error {
+	_, err := s.members.updateOne(ctx, memberWithID(id), func(doc interface{}) interface{} {
+		m := doc.(Member)
+		m.LastLoginAt = time.Now()
+		return m
+	})
+	return err
+}
+
+func (s *memMemberStore) ActivateMemberByID(ctx context.Context, id string) error {
+	_, err := s.members.updateOne(ctx, memberWithID(id), func(doc interface{}) interface{} {
+		m := doc.(Member)
+		m.Status = MActivated
+		return m
+	})
+	return err
+}
+
+func (s *memMemberStore) SetMemberRegCodeSentByPhoneNum(ctx context.Context, phoneNum string) error {
+	_, err := s.members.updateOne(ctx, memberWithPhoneNum(phoneNum), func(doc interface{}) interface{} {
+		m := doc.(Member)
+		m.Status = MRegCodeSent
+		return m
+	})
+	return err
+}
+
+func (s *memMemberStore) UpdateMemberByID(ctx context.Context, i MemberEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+	return s.members.updateOne(ctx, memberDeleted(memberWithID(idToUpdate), false), func(doc interface{}) interface{} {
+		m := doc.(Member)
+		m.FamilyInfo = i.FamilyInfo
+		m.PhoneNum = i.PhoneNum
+		m.Email = i.Email
+		m.DeviceID = i.DeviceID
+		m.FirstName = i.FirstName
+		m.LastName = i.LastName
+		m.Group = i.Group
+		return m
+	})
+}
+
+func (s *memMemberStore) DeleteMemberByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
+	return s.members.deleteOne(ctx, memberWithID(idToDelete))
+}
+
+func (s *memMemberStore) GetDeletedMemberByID(ctx context.Context, id string) SingleResult {
+	return s.members.findOne(ctx, memberDeleted(memberWithID(id), true))
+}
+
+func (s *memMemberStore) SoftDeleteMemberByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error) {
+	return s.members.updateOne(ctx, memberDeleted(memberWithID(idToDelete), false), func(doc interface{}) interface{} {
+		m := doc.(Member)
+		m.DeletedAt = &at
+		return m
+	})
+}
+
+func (s *memMemberStore) RestoreMemberByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error) {
+	return s.members.updateOne(ctx, memberDeleted(memberWithID(idToRestore), true), func(doc interface{}) interface{} {
+		m := doc.(Member)
+		m.DeletedAt = nil
+		return m
+	})
+}
+
+func memberWithID(id string) memMatch {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return func(doc interface{}) bool { return doc.(Member).ID == oid }
+}
+
+func memberWithPhoneNum(phoneNum string) memMatch {
+	return func(doc interface{}) bool { return doc.(Member).PhoneNum == phoneNum }
+}
+
+// memberDeleted - Members of "match" that are (or are not) soft-deleted
+func memberDeleted(match memMatch, deleted bool) memMatch {
+	return func(doc interface{}) bool { return match(doc) && (doc.(Member).DeletedAt != nil) == deleted }
+}
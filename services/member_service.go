@@ -66,11 +66,29 @@ type Member struct {
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 	LastLoginAt time.Time          `bson:"last_login_at" json:"last_login_at"`
 	Status      MemberStatus       `json:"status"`
+	DeletedAt   *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
 type GetMemberParams struct {
 	InstID   string `json:"inst_id"`
 	FamilyID string `json:"family_id"`
+	// Filters & page of "GetManyMembers"; empty ones are not applied
+	Group      string
+	NamePrefix string
+	Status     *MemberStatus
+	StartDate  time.Time
+	EndDate    time.Time
+	List       ListParams
+	// Deleted - list deleted Members instead
+	Deleted bool
+}
+
+// memberSorts - sorts offered by "GetManyMembers"
+var memberSorts = map[string]string{
+	"created_at":    "created_at",
+	"last_login_at": "last_login_at",
+	"first_name":    "first_name",
+	"last_name":     "last_name",
 }
 
 type MemberEditForm struct {
@@ -83,44 +101,93 @@ type MemberEditForm struct {
 	Group      string      `json:"group"`
 }
 
-var memberCollection *mongo.Collection
+// MemberStore - persistence of Members
+type MemberStore interface {
+	GetManyMembers(ctx context.Context, params *GetMemberParams) (Cursor, error)
+	SearchMembers(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error)
+	GetMemberByID(ctx context.Context, id string) SingleResult
+	GetMemberByPhoneNum(ctx context.Context, phoneNum string) SingleResult
+	CountMembersByPhoneNum(ctx context.Context, phoneNum string) (int64, error)
+	CreateMember(ctx context.Context, m MemberRegForm) (*mongo.InsertOneResult, error)
+	UpdateMemberLoginTimeByID(ctx context.Context, id string) error
+	ActivateMemberByID(ctx context.Context, id string) error
+	SetMemberRegCodeSentByPhoneNum(ctx context.Context, phoneNum string) error
+	UpdateMemberByID(ctx context.Context, m MemberEditForm, idToUpdate string) (*mongo.UpdateResult, error)
+	DeleteMemberByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error)
+	GetDeletedMemberByID(ctx context.Context, id string) SingleResult
+	SoftDeleteMemberByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error)
+	RestoreMemberByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error)
+}
+
+type mongoMemberStore struct {
+	memberCollection *mongo.Collection
+}
 
-func MemberCollection(c *mongo.Database) {
-	memberCollection = c.Collection("members")
+// NewMongoMemberStore - as is
+func NewMongoMemberStore(db *mongo.Database) MemberStore {
+	return &mongoMemberStore{memberCollection: db.Collection("members")}
 }
 
-func GetManyMembers(params *GetMemberParams) (*mongo.Cursor, error) {
-	var filters bson.D
+func (s *mongoMemberStore) GetManyMembers(ctx context.Context, params *GetMemberParams) (Cursor, error) {
+	filters := bson.D{deletedFilter(params.Deleted)}
 	if len(params.InstID) > 0 {
 		filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID})
 	} else if len(params.FamilyID) > 0 {
 		filters = append(filters, primitive.E{Key: "family_info.id", Value: params.FamilyID})
 
 	}
-	return memberCollection.Find(context.TODO(), filters)
+	if len(params.Group) > 0 {
+		filters = append(filters, primitive.E{Key: "group", Value: params.Group})
+	}
+	if len(params.NamePrefix) > 0 {
+		filters = append(filters, primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: "first_name", Value: prefixFilter(params.NamePrefix)}},
+			bson.D{primitive.E{Key: "last_name", Value: prefixFilter(params.NamePrefix)}},
+		}})
+	}
+	if params.Status != nil {
+		filters = append(filters, primitive.E{Key: "status", Value: *params.Status})
+	}
+	if createdAt := timeRangeFilter(params.StartDate, params.EndDate); len(createdAt) > 0 {
+		filters = append(filters, primitive.E{Key: "created_at", Value: createdAt})
+	}
+	return mongoList(ctx, s.memberCollection, filters, params.List, memberSorts)
 }
 
-func GetMemberByID(id string) *mongo.SingleResult {
+// SearchMembers - Members of an Institution whose name or phone # holds the term
+func (s *mongoMemberStore) SearchMembers(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error) {
+	filters := bson.D{primitive.E{Key: "institution_id", Value: instID}, notDeleted}
+	filters = append(filters, q.mongoFilter([]string{"first_name", "last_name"}, []string{"phone_num"})...)
+	return mongoList(ctx, s.memberCollection, filters, ListParams{Limit: limit}, nil)
+}
+
+func (s *mongoMemberStore) GetMemberByID(ctx context.Context, id string) SingleResult {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	return memberCollection.FindOne(context.TODO(), bson.M{
-		"_id": oid,
+	return s.memberCollection.FindOne(ctx, bson.D{
+		primitive.E{Key: "_id", Value: oid}, notDeleted,
 	})
 }
 
-func GetMemberByPhoneNum(phoneNum string) *mongo.SingleResult {
-	return memberCollection.FindOne(context.TODO(), bson.D{
-		primitive.E{Key: "phone_num", Value: phoneNum},
+func (s *mongoMemberStore) GetMemberByPhoneNum(ctx context.Context, phoneNum string) SingleResult {
+	return s.memberCollection.FindOne(ctx, bson.D{
+		primitive.E{Key: "phone_num", Value: phoneNum}, notDeleted,
 	})
 }
 
-func CountMembersByPhoneNum(phoneNum string) (int64, error) {
-	return memberCollection.CountDocuments(context.TODO(), bson.D{
+func (s *mongoMemberStore) CountMembersByPhoneNum(ctx context.Context, phoneNum string) (int64, error) {
+	return s.memberCollection.CountDocuments(ctx, bson.D{
 		primitive.E{Key: "phone_num", Value: phoneNum},
+		notDeleted,
 	})
 }
 
-func CreateMember(m MemberRegForm) (*mongo.InsertOneResult, error) {
-	newMember := Member{
+func (s *mongoMemberStore) CreateMember(ctx context.Context, m MemberRegForm) (*mongo.InsertOneResult, error) {
+	return s.memberCollection.InsertOne(ctx, newMember(m))
+}
+
+// newMember - Member to be inserted, yet to be sent a RegCode
+func newMember(m MemberRegForm) Member {
+	return Member{
 		ID:         primitive.NewObjectID(),
 		InstID:     m.InstID,
 		FamilyInfo: m.FamilyInfo,
@@ -132,14 +199,12 @@ func CreateMember(m MemberRegForm) (*mongo.InsertOneResult, error) {
 		CreatedAt:  time.Now(),
 		Status:     MAssigned,
 	}
-
-	return memberCollection.InsertOne(context.TODO(), newMember)
 }
 
 // UpdateMemberLoginTimeByID - as is
-func UpdateMemberLoginTimeByID(id string) error {
+func (s *mongoMemberStore) UpdateMemberLoginTimeByID(ctx context.Context, id string) error {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	_, err := memberCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	_, err := s.memberCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
 		primitive.E{Key: "$currentDate", Value: bson.D{
 			primitive.E{Key: "last_login_at", Value: true},
 		}},
@@ -148,9 +213,9 @@ func UpdateMemberLoginTimeByID(id string) error {
 }
 
 // ActivateMemberByID - as is
-func ActivateMemberByID(id string) error {
+func (s *mongoMemberStore) ActivateMemberByID(ctx context.Context, id string) error {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	_, err := memberCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	_, err := s.memberCollection.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "status", Value: MActivated},
 		}},
@@ -159,8 +224,8 @@ func ActivateMemberByID(id string) error {
 }
 
 // SetMemberRegCodeSentByPhoneNum - as is
-func SetMemberRegCodeSentByPhoneNum(phoneNum string) error {
-	_, err := memberCollection.UpdateOne(context.TODO(), bson.D{
+func (s *mongoMemberStore) SetMemberRegCodeSentByPhoneNum(ctx context.Context, phoneNum string) error {
+	_, err := s.memberCollection.UpdateOne(ctx, bson.D{
 		primitive.E{Key: "phone_num", Value: phoneNum}}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "status", Value: MRegCodeSent},
@@ -169,10 +234,10 @@ func SetMemberRegCodeSentByPhoneNum(phoneNum string) error {
 	return err
 }
 
-// UpdateMemberByID - as is
-func UpdateMemberByID(m MemberEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+// UpdateMemberByID - as is; deleted Members are not updated
+func (s *mongoMemberStore) UpdateMemberByID(ctx context.Context, m MemberEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
 	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
-	return memberCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	return s.memberCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, notDeleted}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "family_info", Value: m.FamilyInfo},
 			primitive.E{Key: "phone_num", Value: m.PhoneNum},
@@ -186,7 +251,25 @@ func UpdateMemberByID(m MemberEditForm, idToUpdate string) (*mongo.UpdateResult,
 }
 
 // DeleteMemberByID - as is
-func DeleteMemberByID(idToDelete string) (*mongo.DeleteResult, error) {
+func (s *mongoMemberStore) DeleteMemberByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
 	oid, _ := primitive.ObjectIDFromHex(idToDelete)
-	return memberCollection.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return s.memberCollection.DeleteOne(ctx, bson.M{"_id": oid})
+}
+
+// GetDeletedMemberByID - a soft-deleted Member
+func (s *mongoMemberStore) GetDeletedMemberByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.memberCollection.FindOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, isDeleted})
+}
+
+// SoftDeleteMemberByID - as is
+func (s *mongoMemberStore) SoftDeleteMemberByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToDelete)
+	return s.memberCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, notDeleted}, softDelete(at))
+}
+
+// RestoreMemberByID - as is
+func (s *mongoMemberStore) RestoreMemberByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToRestore)
+	return s.memberCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, isDeleted}, restoreDeleted)
 }
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrationStep - an UpdateMany of a collection. Steps select only the documents still to be changed,
+// so that running one again changes nothing
+type MigrationStep struct {
+	Collection string
+	Filter     bson.D
+	Update     bson.D
+}
+
+// Migration - a numbered change to the schema of the database, with the steps to apply & revert it
+type Migration struct {
+	Version int
+	Name    string
+	Up      []MigrationStep
+	Down    []MigrationStep
+}
+
+// Migrations - every Migration, in Version order; append new ones with the next Version, and never renumber
+var Migrations = []Migration{
+	{
+		// Records of Guardians & Wards kept the Ward and its events at the top level, before Members & Tags had Records
+		Version: 1,
+		Name:    "move_ward_records_under_gw",
+		Up: []MigrationStep{{
+			Collection: "CCRecords",
+			Filter:     bson.D{primitive.E{Key: "ward_info", Value: bson.D{primitive.E{Key: "$exists", Value: true}}}},
+			Update: bson.D{primitive.E{Key: "$rename", Value: bson.D{
+				primitive.E{Key: "ward_info", Value: "gw.ward_info"},
+				primitive.E{Key: "check_in_event", Value: "gw.check_in_event"},
+				primitive.E{Key: "check_out_event", Value: "gw.check_out_event"},
+			}}},
+		}},
+		Down: []MigrationStep{{
+			Collection: "CCRecords",
+			Filter:     bson.D{primitive.E{Key: "gw.ward_info", Value: bson.D{primitive.E{Key: "$exists", Value: true}}}},
+			Update: bson.D{primitive.E{Key: "$rename", Value: bson.D{
+				primitive.E{Key: "gw.ward_info", Value: "ward_info"},
+				primitive.E{Key: "gw.check_in_event", Value: "check_in_event"},
+				primitive.E{Key: "gw.check_out_event", Value: "check_out_event"},
+			}}},
+		}, {
+			Collection: "CCRecords",
+			Filter:     bson.D{primitive.E{Key: "gw", Value: bson.D{}}},
+			Update:     bson.D{primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "gw", Value: ""}}}},
+		}},
+	},
+	{
+		// The contact of a Family was a Guardian, before any Member could be one
+		Version: 2,
+		Name:    "rename_family_contact_guardian_info",
+		Up: []MigrationStep{{
+			Collection: "families",
+			Filter:     bson.D{primitive.E{Key: "contact_guardian_info", Value: bson.D{primitive.E{Key: "$exists", Value: true}}}},
+			Update: bson.D{primitive.E{Key: "$rename", Value: bson.D{
+				primitive.E{Key: "contact_guardian_info", Value: "contact_member_info"},
+			}}},
+		}},
+		Down: []MigrationStep{{
+			Collection: "families",
+			Filter:     bson.D{primitive.E{Key: "contact_member_info", Value: bson.D{primitive.E{Key: "$exists", Value: true}}}},
+			Update: bson.D{primitive.E{Key: "$rename", Value: bson.D{
+				primitive.E{Key: "contact_member_info", Value: "contact_guardian_info"},
+			}}},
+		}},
+	},
+	{
+		// Records had no version before updates of them were made conditional on it
+		Version: 3,
+		Name:    "add_cc_record_version",
+		Up: []MigrationStep{{
+			Collection: "CCRecords",
+			Filter:     bson.D{primitive.E{Key: "version", Value: bson.D{primitive.E{Key: "$exists", Value: false}}}},
+			Update:     bson.D{primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "version", Value: int64(0)}}}},
+		}},
+		Down: []MigrationStep{{
+			Collection: "CCRecords",
+			Filter:     bson.D{primitive.E{Key: "version", Value: bson.D{primitive.E{Key: "$exists", Value: true}}}},
+			Update:     bson.D{primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "version", Value: ""}}}},
+		}},
+	},
+	{
+		// Screening Policies required a mask or not, before the MaskPolicy could warn
+		Version: 4,
+		Name:    "screening_policy_mask_policy",
+		Up: []MigrationStep{{
+			Collection: "institutions",
+			Filter:     bson.D{primitive.E{Key: "screening_policy.require_mask", Value: true}},
+			Update: bson.D{
+				primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "screening_policy.mask_policy", Value: MaskPolicyDeny}}},
+				primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "screening_policy.require_mask", Value: ""}}},
+			},
+		}, {
+			Collection: "institutions",
+			Filter:     bson.D{primitive.E{Key: "screening_policy.require_mask", Value: false}},
+			Update: bson.D{
+				primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "screening_policy.mask_policy", Value: MaskPolicyIgnore}}},
+				primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "screening_policy.require_mask", Value: ""}}},
+			},
+		}},
+		Down: []MigrationStep{{
+			Collection: "institutions",
+			Filter:     bson.D{primitive.E{Key: "screening_policy.mask_policy", Value: MaskPolicyDeny}},
+			Update: bson.D{
+				primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "screening_policy.require_mask", Value: true}}},
+				primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "screening_policy.mask_policy", Value: ""}}},
+			},
+		}, {
+			Collection: "institutions",
+			Filter:     bson.D{primitive.E{Key: "screening_policy.mask_policy", Value: bson.D{primitive.E{Key: "$exists", Value: true}}}},
+			Update: bson.D{
+				primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "screening_policy.require_mask", Value: false}}},
+				primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "screening_policy.mask_policy", Value: ""}}},
+			},
+		}},
+	},
+}
+
+// MigrationRecord - DB Model of an applied (or being applied) Migration, in "schema_migrations"
+type MigrationRecord struct {
+	Version   int        `bson:"_id" json:"version"`
+	Name      string     `bson:"name" json:"name"`
+	StartedAt time.Time  `bson:"started_at" json:"started_at"`
+	AppliedAt *time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+// MigrationStatus - a Migration, and its record if it has been applied
+type MigrationStatus struct {
+	Migration Migration
+	Record    *MigrationRecord
+}
+
+// MigrationResult - what a Migration changed; in a dry run, the documents its steps match as the database is now
+type MigrationResult struct {
+	Version int
+	Name    string
+	Counts  []int64
+}
+
+func (r MigrationResult) String() string {
+	return fmt.Sprintf("%04d %v - documents per step %v", r.Version, r.Name, r.Counts)
+}
+
+const migrationCollection = "schema_migrations"
+
+// GetMigrationStatus - every Migration, in order, with its record if any
+func GetMigrationStatus(ctx context.Context, db *mongo.Database) ([]MigrationStatus, error) {
+	cursor, err := db.Collection(migrationCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	records := []MigrationRecord{}
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*MigrationRecord{}
+	for index := range records {
+		byVersion[records[index].Version] = &records[index]
+	}
+
+	statuses := []MigrationStatus{}
+	for _, m := range Migrations {
+		statuses = append(statuses, MigrationStatus{Migration: m, Record: byVersion[m.Version]})
+	}
+	return statuses, nil
+}
+
+// MigrateUp - apply every pending Migration in order, stopping at the first to fail
+func MigrateUp(ctx context.Context, db *mongo.Database, dryRun bool) ([]MigrationResult, error) {
+	statuses, err := GetMigrationStatus(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	results := []MigrationResult{}
+	for _, status := range statuses {
+		if status.Record != nil {
+			if status.Record.AppliedAt == nil {
+				return results, fmt.Errorf("migration %04d %v was started at %v but never finished; check it, then remove its record from %v",
+					status.Migration.Version, status.Migration.Name, status.Record.StartedAt, migrationCollection)
+			}
+			continue
+		}
+		res, err := runMigration(ctx, db, status.Migration, status.Migration.Up, dryRun, true)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// MigrateDown - revert the latest applied Migration; nil result when none has been applied
+func MigrateDown(ctx context.Context, db *mongo.Database, dryRun bool) (*MigrationResult, error) {
+	statuses, err := GetMigrationStatus(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for index := len(statuses) - 1; index >= 0; index-- {
+		status := statuses[index]
+		if status.Record == nil {
+			continue
+		}
+		if status.Record.AppliedAt == nil {
+			return nil, fmt.Errorf("migration %04d %v was started at %v but never finished; check it, then remove its record from %v",
+				status.Migration.Version, status.Migration.Name, status.Record.StartedAt, migrationCollection)
+		}
+		res, err := runMigration(ctx, db, status.Migration, status.Migration.Down, dryRun, false)
+		if err != nil {
+			return nil, err
+		}
+		return &res, nil
+	}
+	return nil, nil
+}
+
+// runMigration - run the steps of a Migration, up or down. The record is claimed before any step runs,
+// so that servers starting together never run the same Migration twice
+func runMigration(ctx context.Context, db *mongo.Database, m Migration, steps []MigrationStep, dryRun bool, up bool) (MigrationResult, error) {
+	res := MigrationResult{Version: m.Version, Name: m.Name}
+	records := db.Collection(migrationCollection)
+
+	if dryRun {
+		for _, step := range steps {
+			count, err := db.Collection(step.Collection).CountDocuments(ctx, step.Filter)
+			if err != nil {
+				return res, err
+			}
+			res.Counts = append(res.Counts, count)
+		}
+		return res, nil
+	}
+
+	if up {
+		_, err := records.InsertOne(ctx, MigrationRecord{Version: m.Version, Name: m.Name, StartedAt: time.Now()})
+		if IsDuplicateKeyErr(err) {
+			return res, fmt.Errorf("migration %04d %v is being run by another server", m.Version, m.Name)
+		}
+		if err != nil {
+			return res, err
+		}
+	} else {
+		// Mark as unfinished while reverting, so a failure half-way is not taken as applied
+		claimed, err := records.UpdateOne(ctx, bson.D{
+			primitive.E{Key: "_id", Value: m.Version},
+			primitive.E{Key: "applied_at", Value: bson.D{primitive.E{Key: "$ne", Value: nil}}},
+		}, bson.D{
+			primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "applied_at", Value: nil}}},
+		})
+		if err != nil {
+			return res, err
+		}
+		if claimed.ModifiedCount == 0 {
+			return res, fmt.Errorf("migration %04d %v is being reverted by another server", m.Version, m.Name)
+		}
+	}
+
+	for index, step := range steps {
+		updated, err := db.Collection(step.Collection).UpdateMany(ctx, step.Filter, step.Update)
+		if err != nil {
+			return res, fmt.Errorf("migration %04d %v failed at step %v, left marked unfinished - %w", m.Version, m.Name, index+1, err)
+		}
+		res.Counts = append(res.Counts, updated.ModifiedCount)
+	}
+
+	if !up {
+		_, err := records.DeleteOne(ctx, bson.M{"_id": m.Version})
+		return res, err
+	}
+	_, err := records.UpdateOne(ctx, bson.M{"_id": m.Version}, bson.D{
+		primitive.E{Key: "$currentDate", Value: bson.D{primitive.E{Key: "applied_at", Value: true}}},
+	})
+	return res, err
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memRegCodeStore struct {
+	regCodes        memCollection
+	regCodeAttempts memCollection
+}
+
+// NewMemRegCodeStore - as is
+func NewMemRegCodeStore() RegCodeStore {
+	return &memRegCodeStore{}
+}
+
+func (s *memRegCodeStore) GetManyRegCodes(ctx context.Context) (Cursor, error) {
+	return s.regCodes.cursor(ctx, memMatchAll)
+}
+
+func (s *memRegCodeStore) GetRegCodeByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.regCodes.findOne(ctx, regCodeWithID(oid))
+}
+
+func (s *memRegCodeStore) GetRegCodeByMemberID(ctx context.Context, memberID string) SingleResult {
+	found, err := s.regCodes.find(ctx, func(doc interface{}) bool {
+		r := doc.(RegCode)
+		return r.MemberID == memberID && r.InvalidatedAt == nil
+	})
+	if err != nil {
+		return memSingleResult{err: err}
+	}
+	if len(found) == 0 {
+		return memNotFound()
+	}
+	latest := found[0]
+	for _, doc := range found[1:] {
+		if !doc.(RegCode).CreatedAt.Before(latest.(RegCode).CreatedAt) {
+			latest = doc
+		}
+	}
+	return memFound(latest)
+}
+
+func (s *memRegCodeStore) CreateRegCodeByMemberID(ctx context.Context, memberID string, ttl time.Duration) (*mongo.InsertOneResult, error) {
+	newRegCode, err := newRegCode(memberID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	_, err = s.regCodes.updateMany(ctx, func(doc interface{}) bool {
+		r := doc.(RegCode)
+		return r.MemberID == memberID && r.InvalidatedAt == nil
+	}, func(doc interface{}) interface{} {
+		r := doc.(RegCode)
+		r.InvalidatedAt = &now
+		return r
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.regCodes.insertOne(ctx, newRegCode.ID, newRegCode)
+}
+
+func (s *memRegCodeStore) MarkRegCodeAsUsed(ctx context.Context, id primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return s.regCodes.updateOne(ctx, regCodeWithID(id), func(doc interface{}) interface{} {
+		r := doc.(RegCode)
+		now := time.Now()
+		r.UsedAt = &now
+		return r
+	})
+}
+
+func (s *memRegCodeStore) DeleteRegCodeByMemberID(ctx context.Context, memberID string) (*mongo.DeleteResult, error) {
+	return s.regCodes.deleteMany(ctx, func(doc interface{}) bool {
+		return doc.(RegCode).MemberID == memberID
+	})
+}
+
+func (s *memRegCodeStore) GetRegCodeAttemptByPhoneNum(ctx context.Context, phoneNum string) SingleResult {
+	return s.regCodeAttempts.findOne(ctx, regCodeAttemptWithPhoneNum(phoneNum))
+}
+
+func (s *memRegCodeStore) RecordFailedRegCodeAttempt(ctx context.Context, phoneNum string, maxFailed int, lockout time.Duration) (RegCodeAttempt, error) {
+	var attempt RegCodeAttempt
+	res, err := s.regCodeAttempts.updateOne(ctx, regCodeAttemptWithPhoneNum(phoneNum), func(doc interface{}) interface{} {
+		a := doc.(RegCodeAttempt)
+		a.FailedCount++
+		a.LastFailedAt = time.Now()
+		return a
+	})
+	if err != nil {
+		return attempt, err
+	}
+	if res.MatchedCount == 0 {
+		_, err = s.regCodeAttempts.insertOne(ctx, nil, RegCodeAttempt{
+			ID:           primitive.NewObjectID(),
+			PhoneNum:     phoneNum,
+			FailedCount:  1,
+			LastFailedAt: time.Now(),
+		})
+		if err != nil {
+			return attempt, err
+		}
+	}
+	err = s.GetRegCodeAttemptByPhoneNum(ctx, phoneNum).Decode(&attempt)
+	if err != nil || attempt.FailedCount < maxFailed {
+		return attempt, err
+	}
+
+	attempt.FailedCount = 0
+	attempt.LockedUntil = time.Now().Add(lockout)
+	_, err = s.regCodeAttempts.updateOne(ctx, regCodeAttemptWithPhoneNum(phoneNum), func(doc interface{}) interface{} {
+		a := doc.(RegCodeAttempt)
+		a.FailedCount = attempt.FailedCount
+		a.LockedUntil = attempt.LockedUntil
+		return a
+	})
+	return attempt, err
+}
+
+func (s *memRegCodeStore) ResetRegCodeAttempts(ctx context.Context, phoneNum string) (*mongo.DeleteResult, error) {
+	return s.regCodeAttempts.deleteMany(ctx, regCodeAttemptWithPhoneNum(phoneNum))
+}
+
+func regCodeWithID(oid primitive.ObjectID) memMatch {
+	return func(doc interface{}) bool { return doc.(RegCode).ID == oid }
+}
+
+func regCodeAttemptWithPhoneNum(phoneNum string) memMatch {
+	return func(doc interface{}) bool { return doc.(RegCodeAttempt).PhoneNum == phoneNum }
+}
@@ -2,20 +2,57 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"fmt"
-	"math/rand"
+	"math/big"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// RegCode - DB Model for Guardian Account Activation Code
+// RegCode - DB Model for Guardian Account Activation Code.
+// A Member has at most one active RegCode; regenerated ones are kept with "invalidated_at" set
 type RegCode struct {
-	ID       primitive.ObjectID `bson:"_id" json:"_id"`
-	MemberID string             `bson:"member_id" json:"member_id"`
-	RegCode  string             `bson:"reg_code" json:"reg_code"`
+	ID            primitive.ObjectID `bson:"_id" json:"_id"`
+	MemberID      string             `bson:"member_id" json:"member_id"`
+	RegCode       string             `bson:"reg_code" json:"reg_code"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt     time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt        *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	InvalidatedAt *time.Time         `bson:"invalidated_at,omitempty" json:"invalidated_at,omitempty"`
+}
+
+// HasExpired - RegCodes created before expiry was introduced have no "expires_at", and are expired
+func (r RegCode) HasExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// IsActive - not used, not invalidated & not expired
+func (r RegCode) IsActive(now time.Time) bool {
+	return r.UsedAt == nil && r.InvalidatedAt == nil && !r.HasExpired(now)
+}
+
+// Matches - compare in constant time
+func (r RegCode) Matches(regCode string) bool {
+	return subtle.ConstantTimeCompare([]byte(r.RegCode), []byte(regCode)) == 1
+}
+
+// RegCodeAttempt - DB Model for failed Activation attempts of a Phone #
+type RegCodeAttempt struct {
+	ID           primitive.ObjectID `bson:"_id" json:"_id"`
+	PhoneNum     string             `bson:"phone_num" json:"phone_num"`
+	FailedCount  int                `bson:"failed_count" json:"failed_count"`
+	LastFailedAt time.Time          `bson:"last_failed_at" json:"last_failed_at"`
+	LockedUntil  time.Time          `bson:"locked_until" json:"locked_until"`
+}
+
+// IsLocked - as name suggests
+func (a RegCodeAttempt) IsLocked(now time.Time) bool {
+	return now.Before(a.LockedUntil)
 }
 
 // GetRegCodeParams - QueryString params for RegCode
@@ -23,57 +60,169 @@ type GetRegCodeParams struct {
 	MemberID string `json:"member_id"`
 }
 
-var regCodeCollection *mongo.Collection
+// RegenerateRegCodeForm - Input Form for regenerating a Member's RegCode
+type RegenerateRegCodeForm struct {
+	MemberID string `json:"member_id"`
+}
+
+// RegCodeStore - persistence of RegCodes, and of failed Activation attempts
+type RegCodeStore interface {
+	GetManyRegCodes(ctx context.Context) (Cursor, error)
+	GetRegCodeByID(ctx context.Context, id string) SingleResult
+	GetRegCodeByMemberID(ctx context.Context, memberID string) SingleResult
+	CreateRegCodeByMemberID(ctx context.Context, memberID string, ttl time.Duration) (*mongo.InsertOneResult, error)
+	MarkRegCodeAsUsed(ctx context.Context, id primitive.ObjectID) (*mongo.UpdateResult, error)
+	DeleteRegCodeByMemberID(ctx context.Context, memberID string) (*mongo.DeleteResult, error)
+	GetRegCodeAttemptByPhoneNum(ctx context.Context, phoneNum string) SingleResult
+	RecordFailedRegCodeAttempt(ctx context.Context, phoneNum string, maxFailed int, lockout time.Duration) (RegCodeAttempt, error)
+	ResetRegCodeAttempts(ctx context.Context, phoneNum string) (*mongo.DeleteResult, error)
+}
+
+type mongoRegCodeStore struct {
+	regCodeCollection        *mongo.Collection
+	regCodeAttemptCollection *mongo.Collection
+}
 
-// RegCodeCollection returns reference to DB collection
-func RegCodeCollection(c *mongo.Database) {
-	regCodeCollection = c.Collection("regCodes")
+// NewMongoRegCodeStore - as is
+func NewMongoRegCodeStore(db *mongo.Database) RegCodeStore {
+	return &mongoRegCodeStore{
+		regCodeCollection:        db.Collection("regCodes"),
+		regCodeAttemptCollection: db.Collection("regCodeAttempts"),
+	}
 }
 
 // GetManyRegCodes as name suggests
-func GetManyRegCodes() (*mongo.Cursor, error) {
+func (s *mongoRegCodeStore) GetManyRegCodes(ctx context.Context) (Cursor, error) {
 	// TODO: not sending status: "2 - deleted"
-	return regCodeCollection.Find(context.TODO(), bson.M{})
+	return mongoCursor(s.regCodeCollection.Find(ctx, bson.M{}))
 }
 
 // GetRegCodeByID - as is
-func GetRegCodeByID(id string) *mongo.SingleResult {
+func (s *mongoRegCodeStore) GetRegCodeByID(ctx context.Context, id string) SingleResult {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	return regCodeCollection.FindOne(context.TODO(), bson.M{
+	return s.regCodeCollection.FindOne(ctx, bson.M{
 		"_id": oid},
 	)
 }
 
-// GetRegCodeByMemberID as name suggests
-func GetRegCodeByMemberID(memberID string) *mongo.SingleResult {
+// GetRegCodeByMemberID - the latest RegCode of the Member not invalidated by regeneration
+func (s *mongoRegCodeStore) GetRegCodeByMemberID(ctx context.Context, memberID string) SingleResult {
 	// TODO: err handling for ID Parsing
-	return regCodeCollection.FindOne(context.TODO(), bson.D{
+	queryOptions := options.FindOneOptions{}
+	queryOptions.SetSort(bson.D{
+		primitive.E{Key: "created_at", Value: -1},
+	})
+	return s.regCodeCollection.FindOne(ctx, bson.D{
+		primitive.E{Key: "member_id", Value: memberID},
+		primitive.E{Key: "invalidated_at", Value: nil},
+	}, &queryOptions)
+}
+
+// CreateRegCodeByMemberID - invalidate RegCodes previously issued to the Member, and create a new one
+func (s *mongoRegCodeStore) CreateRegCodeByMemberID(ctx context.Context, memberID string, ttl time.Duration) (*mongo.InsertOneResult, error) {
+	newRegCode, err := newRegCode(memberID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.invalidateRegCodesByMemberID(ctx, memberID); err != nil {
+		return nil, err
+	}
+
+	return s.regCodeCollection.InsertOne(ctx, newRegCode)
+}
+
+// MarkRegCodeAsUsed - as name suggests; Invoked when a Member gets activated
+func (s *mongoRegCodeStore) MarkRegCodeAsUsed(ctx context.Context, id primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return s.regCodeCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.D{
+		primitive.E{Key: "$currentDate", Value: bson.D{
+			primitive.E{Key: "used_at", Value: true},
+		}},
+	})
+}
+
+// DeleteRegCodeByMemberID as name suggests
+func (s *mongoRegCodeStore) DeleteRegCodeByMemberID(ctx context.Context, memberID string) (*mongo.DeleteResult, error) {
+	return s.regCodeCollection.DeleteMany(ctx, bson.D{
 		primitive.E{Key: "member_id", Value: memberID},
 	})
 }
 
-// CreateRegCodeByMemberID as name suggests
-func CreateRegCodeByMemberID(memberID string) (*mongo.InsertOneResult, error) {
+// GetRegCodeAttemptByPhoneNum - as is
+func (s *mongoRegCodeStore) GetRegCodeAttemptByPhoneNum(ctx context.Context, phoneNum string) SingleResult {
+	return s.regCodeAttemptCollection.FindOne(ctx, bson.M{
+		"phone_num": phoneNum,
+	})
+}
 
-	newRegCode := RegCode{
-		ID:       primitive.NewObjectID(),
-		MemberID: memberID,
-		RegCode:  getNewRegCode(),
+// RecordFailedRegCodeAttempt - count a failed Activation of the Phone #;
+// the Phone # gets locked out for "lockout" once "maxFailed" is reached, and counting starts over
+func (s *mongoRegCodeStore) RecordFailedRegCodeAttempt(ctx context.Context, phoneNum string, maxFailed int, lockout time.Duration) (RegCodeAttempt, error) {
+	var attempt RegCodeAttempt
+	queryOptions := options.FindOneAndUpdateOptions{}
+	queryOptions.SetUpsert(true)
+	queryOptions.SetReturnDocument(options.After)
+	err := s.regCodeAttemptCollection.FindOneAndUpdate(ctx, bson.M{"phone_num": phoneNum}, bson.D{
+		primitive.E{Key: "$inc", Value: bson.D{
+			primitive.E{Key: "failed_count", Value: 1},
+		}},
+		primitive.E{Key: "$currentDate", Value: bson.D{
+			primitive.E{Key: "last_failed_at", Value: true},
+		}},
+	}, &queryOptions).Decode(&attempt)
+	if err != nil || attempt.FailedCount < maxFailed {
+		return attempt, err
 	}
 
-	return regCodeCollection.InsertOne(context.TODO(), newRegCode)
+	attempt.FailedCount = 0
+	attempt.LockedUntil = time.Now().Add(lockout)
+	_, err = s.regCodeAttemptCollection.UpdateOne(ctx, bson.M{"_id": attempt.ID}, bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "failed_count", Value: attempt.FailedCount},
+			primitive.E{Key: "locked_until", Value: attempt.LockedUntil},
+		}},
+	})
+	return attempt, err
 }
 
-// DeleteRegCodeByMemberID as name suggests
-func DeleteRegCodeByMemberID(memberID string) (*mongo.DeleteResult, error) {
-	return regCodeCollection.DeleteOne(context.TODO(), bson.D{
+// ResetRegCodeAttempts - as name suggests; Invoked on successful Activation & regeneration
+func (s *mongoRegCodeStore) ResetRegCodeAttempts(ctx context.Context, phoneNum string) (*mongo.DeleteResult, error) {
+	return s.regCodeAttemptCollection.DeleteMany(ctx, bson.M{
+		"phone_num": phoneNum,
+	})
+}
+
+func (s *mongoRegCodeStore) invalidateRegCodesByMemberID(ctx context.Context, memberID string) (*mongo.UpdateResult, error) {
+	return s.regCodeCollection.UpdateMany(ctx, bson.D{
 		primitive.E{Key: "member_id", Value: memberID},
+		primitive.E{Key: "invalidated_at", Value: nil},
+	}, bson.D{
+		primitive.E{Key: "$currentDate", Value: bson.D{
+			primitive.E{Key: "invalidated_at", Value: true},
+		}},
 	})
 }
 
-// generate a regCode of length 6, all digits
-func getNewRegCode() string {
-	rand.Seed(time.Now().UnixNano())
-	s := fmt.Sprintf("%06d", rand.Intn(1000000))
-	return s
+// newRegCode - RegCode to be inserted, valid for "ttl"
+func newRegCode(memberID string, ttl time.Duration) (RegCode, error) {
+	code, err := getNewRegCode()
+	if err != nil {
+		return RegCode{}, err
+	}
+	now := time.Now()
+	return RegCode{
+		ID:        primitive.NewObjectID(),
+		MemberID:  memberID,
+		RegCode:   code,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
+
+// generate a regCode of length 6, all digits, from a cryptographically secure source
+func getNewRegCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
 }
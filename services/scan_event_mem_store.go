@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memScanEventStore struct {
+	scanEvents memCollection
+}
+
+// NewMemScanEventStore - as is
+func NewMemScanEventStore() ScanEventStore {
+	return &memScanEventStore{}
+}
+
+func (s *memScanEventStore) CreateScanEvent(ctx context.Context, e ScanEvent) (*mongo.InsertOneResult, error) {
+	e.ID = primitive.NewObjectID()
+	return s.scanEvents.insertOne(ctx, e.ID, e)
+}
+
+func (s *memScanEventStore) GetManyScanEvents(ctx context.Context, params *GetScanEventParams) (Cursor, error) {
+	return s.scanEvents.list(ctx, func(doc interface{}) bool {
+		e := doc.(ScanEvent)
+		for _, f := range []struct{ param, value string }{
+			{params.InstID, e.InstID},
+			{params.DeviceID, e.DeviceID},
+			{params.Decision, string(e.Decision)},
+		} {
+			if len(f.param) > 0 && f.param != f.value {
+				return false
+			}
+		}
+		if len(params.CCRecordID) > 0 && e.versionOf(params.CCRecordID) == 0 {
+			return false
+		}
+		if !params.StartDate.IsZero() && e.ReceivedAt.Before(params.StartDate) {
+			return false
+		}
+		if !params.EndDate.IsZero() && e.ReceivedAt.After(params.EndDate) {
+			return false
+		}
+		return true
+	}, params.listParams(), scanEventSorts)
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ScanEventSource - what received the Scan Event
+type ScanEventSource string
+
+// ScanEventSource Enum Defs
+const (
+	ScanEventSourceGatekeeper ScanEventSource = "gatekeeper"
+	ScanEventSourceSchedule   ScanEventSource = "schedule"
+)
+
+// ScanDecision - what was made of a Scan Event
+type ScanDecision string
+
+// ScanDecision Enum Defs
+const (
+	ScanDecisionAccepted ScanDecision = "accepted"
+	ScanDecisionRejected ScanDecision = "rejected"
+	ScanDecisionDenied   ScanDecision = "denied"
+	ScanDecisionExcluded ScanDecision = "excluded"
+	ScanDecisionConflict ScanDecision = "conflict"
+	ScanDecisionError    ScanDecision = "error"
+)
+
+// ScanParseResult - what the Scan Payload was parsed into; Valid is false when it was not recognized,
+// for the ReasonCode given
+type ScanParseResult struct {
+	Valid          bool      `bson:"valid" json:"valid"`
+	ReasonCode     string    `bson:"reason_code,omitempty" json:"reason_code,omitempty"`
+	Version        int       `bson:"version,omitempty" json:"version,omitempty"`
+	Type           string    `bson:"type" json:"type"`
+	InstIdentifier string    `bson:"institution_identifier,omitempty" json:"institution_identifier,omitempty"`
+	MemberTagID    string    `bson:"member_tag_id,omitempty" json:"member_tag_id,omitempty"`
+	WardID         string    `bson:"ward_id,omitempty" json:"ward_id,omitempty"`
+	Stage          string    `bson:"stage,omitempty" json:"stage,omitempty"`
+	IsSingleEvent  bool      `bson:"is_single_event" json:"is_single_event"`
+	Time           time.Time `bson:"time" json:"time"`
+}
+
+// ScanEventRecord - a CCRecord a Scan Event was applied to, and the Version it gave the Record
+type ScanEventRecord struct {
+	CCRecordID string `bson:"cc_record_id" json:"cc_record_id"`
+	Version    int64  `bson:"version" json:"version"`
+}
+
+// ScanEvent - DB Model of a Scan received, as received; entries are never updated or deleted.
+// CCRecords are projections of the Scan Events applied to them, see "ProjectCCRecord"
+type ScanEvent struct {
+	ID          primitive.ObjectID `bson:"_id" json:"_id"`
+	Source      ScanEventSource    `bson:"source" json:"source"`
+	InstID      string             `bson:"institution_id" json:"institution_id"`
+	DeviceID    string             `bson:"device_id" json:"device_id"`
+	RawPayload  string             `bson:"raw_payload" json:"raw_payload"`
+	Temperature float32            `bson:"temperature" json:"temperature"`
+	Unit        TemperatureUnit    `bson:"temperature_unit" json:"temperature_unit"`
+	Mask        bool               `bson:"mask" json:"mask"`
+	ScanType    CCScanType         `bson:"scan_type" json:"scan_type"`
+	Group       string             `bson:"group" json:"group"`
+	ParseResult ScanParseResult    `bson:"parse_result" json:"parse_result"`
+	// Decision & Reason - the outcome, and the message the Scan was answered with
+	Decision     ScanDecision `bson:"decision" json:"decision"`
+	Reason       string       `bson:"reason" json:"reason"`
+	ResponseCode int          `bson:"response_code" json:"response_code"`
+	// Records - the CCRecords the event was applied to; a Family Scan stopped half-way lists those it did update
+	Records []ScanEventRecord `bson:"cc_records" json:"cc_records"`
+	// The event as applied to "Records"
+	Stage          string           `bson:"stage" json:"stage"`
+	IsScanFailed   bool             `bson:"is_scan_failed" json:"is_scan_failed"`
+	Policy         *ScreeningPolicy `bson:"screening_policy,omitempty" json:"screening_policy,omitempty"`
+	Workflow       WorkflowType     `bson:"workflow_type" json:"workflow_type"`
+	MemberType     MemberType       `bson:"member_type" json:"member_type"`
+	GuardianEvent  *GuardianEvent   `bson:"guardian_event,omitempty" json:"guardian_event,omitempty"`
+	MemberTagEvent *MemberTagEvent  `bson:"member_tag_event,omitempty" json:"member_tag_event,omitempty"`
+	ScheduledAt    *time.Time       `bson:"scheduled_at,omitempty" json:"scheduled_at,omitempty"`
+	ReceivedAt     time.Time        `bson:"received_at" json:"received_at"`
+}
+
+// AddApplied - record that "eventData" was applied to a Record, giving it "version"
+func (e *ScanEvent) AddApplied(ccRecordID string, version int64, eventData NewEventData) {
+	e.Records = append(e.Records, ScanEventRecord{CCRecordID: ccRecordID, Version: version})
+	e.Stage = eventData.Stage
+	e.IsScanFailed = eventData.isScanFailed()
+	e.Policy = eventData.Policy
+	e.Workflow = eventData.Workflow
+	e.MemberType = eventData.MemberType
+	e.GuardianEvent = eventData.GuardianEvent
+	e.MemberTagEvent = eventData.MemberTagEvent
+}
+
+// versionOf - the Version the event gave a Record; 0 when it was not applied to it
+func (e ScanEvent) versionOf(ccRecordID string) int64 {
+	for _, r := range e.Records {
+		if r.CCRecordID == ccRecordID {
+			return r.Version
+		}
+	}
+	return 0
+}
+
+func (e ScanEvent) eventData() NewEventData {
+	return NewEventData{
+		GuardianEvent:  e.GuardianEvent,
+		MemberTagEvent: e.MemberTagEvent,
+		Stage:          e.Stage,
+		Policy:         e.Policy,
+		IsScanFailed:   e.IsScanFailed,
+		Workflow:       e.Workflow,
+		MemberType:     e.MemberType,
+	}
+}
+
+// GetScanEventParams - QueryString Params for GetManyScanEvents; empty ones are not filtered on
+type GetScanEventParams struct {
+	InstID     string
+	DeviceID   string
+	CCRecordID string
+	Decision   string
+	StartDate  time.Time
+	EndDate    time.Time
+	List       ListParams
+}
+
+// scanEventSorts - sorts offered by GetManyScanEvents
+var scanEventSorts = map[string]string{
+	"received_at": "received_at",
+}
+
+// listParams - page & sort of the query; oldest first unless sorted otherwise
+func (p *GetScanEventParams) listParams() ListParams {
+	list := p.List
+	if len(list.Sort) == 0 {
+		list.Sort = "received_at"
+	}
+	return list
+}
+
+// ScanEventStore - persistence of Scan Events; entries are never updated or deleted
+type ScanEventStore interface {
+	CreateScanEvent(ctx context.Context, e ScanEvent) (*mongo.InsertOneResult, error)
+	GetManyScanEvents(ctx context.Context, params *GetScanEventParams) (Cursor, error)
+}
+
+type mongoScanEventStore struct {
+	scanEventCollection *mongo.Collection
+}
+
+// NewMongoScanEventStore - as is
+func NewMongoScanEventStore(db *mongo.Database) ScanEventStore {
+	return &mongoScanEventStore{scanEventCollection: db.Collection("scan_events")}
+}
+
+// CreateScanEvent - as name suggests; ID is assigned here
+func (s *mongoScanEventStore) CreateScanEvent(ctx context.Context, e ScanEvent) (*mongo.InsertOneResult, error) {
+	e.ID = primitive.NewObjectID()
+	return s.scanEventCollection.InsertOne(ctx, e)
+}
+
+// GetManyScanEvents - a page of the Scan Events, oldest first unless sorted otherwise
+func (s *mongoScanEventStore) GetManyScanEvents(ctx context.Context, params *GetScanEventParams) (Cursor, error) {
+	filters := bson.D{}
+	for _, f := range []primitive.E{
+		{Key: "institution_id", Value: params.InstID},
+		{Key: "device_id", Value: params.DeviceID},
+		{Key: "cc_records.cc_record_id", Value: params.CCRecordID},
+		{Key: "decision", Value: params.Decision},
+	} {
+		if len(f.Value.(string)) > 0 {
+			filters = append(filters, f)
+		}
+	}
+	timeFilter := bson.D{}
+	if !params.StartDate.IsZero() {
+		timeFilter = append(timeFilter, primitive.E{Key: "$gte", Value: params.StartDate})
+	}
+	if !params.EndDate.IsZero() {
+		timeFilter = append(timeFilter, primitive.E{Key: "$lte", Value: params.EndDate})
+	}
+	if len(timeFilter) > 0 {
+		filters = append(filters, primitive.E{Key: "received_at", Value: timeFilter})
+	}
+
+	return mongoList(ctx, s.scanEventCollection, filters, params.listParams(), scanEventSorts)
+}
+
+// ErrNoScanEvents - a Record past CCrInit has no Scan Events to be rebuilt from, e.g. one
+// updated before Scan Events were recorded
+var ErrNoScanEvents = errors.New("cc record has no scan events")
+
+// ProjectCCRecord - "ccr" as rebuilt from the Scan Events applied to it, in the order they were applied.
+// What the Record is of, its expiry & Version are kept; the result has the Version it is to be written with
+func ProjectCCRecord(ccr CCRecord, events []ScanEvent) (CCRecord, error) {
+	id := ccr.ID.Hex()
+	applied := []ScanEvent{}
+	for _, e := range events {
+		if e.versionOf(id) > 0 {
+			applied = append(applied, e)
+		}
+	}
+	if len(applied) == 0 && ccr.Status != CCrInit {
+		return CCRecord{}, ErrNoScanEvents
+	}
+	sort.SliceStable(applied, func(i, j int) bool {
+		return applied[i].versionOf(id) < applied[j].versionOf(id)
+	})
+
+	projected := CCRecord{
+		ID:     ccr.ID,
+		InstID: ccr.InstID,
+		Status: CCrInit,
+	}
+	if ccr.GW != nil {
+		projected.GW = &GW{WardInfo: ccr.GW.WardInfo}
+	}
+	if ccr.MT != nil {
+		projected.MT = &MT{Info: ccr.MT.Info}
+	}
+	for _, e := range applied {
+		if e.Source == ScanEventSourceSchedule {
+			workflow, err := GetCCWorkflow(e.Workflow)
+			if err != nil {
+				return CCRecord{}, err
+			}
+			status, err := workflow.Next(MemberTypeGuardian, projected.Status, CCStageSchedule, false)
+			if err != nil {
+				return CCRecord{}, err
+			}
+			projected.Status = status
+			if e.ScheduledAt != nil {
+				projected.CheckOutScheduledAt = *e.ScheduledAt
+			}
+			continue
+		}
+		next, err := getUpdatedCCRecordWithEvent(projected, e.eventData())
+		if err != nil {
+			return CCRecord{}, err
+		}
+		projected = next
+	}
+
+	projected.HasExpired = ccr.HasExpired
+	projected.ExpiredAt = ccr.ExpiredAt
+	projected.Version = ccr.Version + 1
+	return projected, nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memScreeningCaseStore struct {
+	screeningCases memCollection
+}
+
+// NewMemScreeningCaseStore - as is
+func NewMemScreeningCaseStore() ScreeningCaseStore {
+	return &memScreeningCaseStore{}
+}
+
+func (s *memScreeningCaseStore) CreateScreeningCase(ctx context.Context, sc ScreeningCase) (*mongo.InsertOneResult, error) {
+	sc.ID = primitive.NewObjectID()
+	return s.screeningCases.insertOne(ctx, sc.ID, sc)
+}
+
+func (s *memScreeningCaseStore) GetScreeningCaseByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.screeningCases.findOne(ctx, func(doc interface{}) bool {
+		return doc.(ScreeningCase).ID == oid
+	})
+}
+
+func (s *memScreeningCaseStore) GetScreeningCaseByCCRecordID(ctx context.Context, ccRecordID string) SingleResult {
+	return s.screeningCases.findOne(ctx, func(doc interface{}) bool {
+		return doc.(ScreeningCase).CCRecordID == ccRecordID
+	})
+}
+
+func (s *memScreeningCaseStore) GetManyScreeningCases(ctx context.Context, params *GetScreeningCaseParams) (Cursor, error) {
+	return s.screeningCases.list(ctx, func(doc interface{}) bool {
+		sc := doc.(ScreeningCase)
+		for _, f := range []struct{ param, value string }{
+			{params.InstID, sc.InstID},
+			{params.Status, string(sc.Status)},
+			{params.AssigneeID, sc.AssigneeID},
+			{params.SubjectID, sc.SubjectID},
+			{params.CCRecordID, sc.CCRecordID},
+		} {
+			if len(f.param) > 0 && f.param != f.value {
+				return false
+			}
+		}
+		return true
+	}, params.listParams(), screeningCaseSorts)
+}
+
+func (s *memScreeningCaseStore) UpdateOpenScreeningCase(ctx context.Context, id string, update ScreeningCaseUpdate) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.screeningCases.updateOne(ctx, func(doc interface{}) bool {
+		sc := doc.(ScreeningCase)
+		return sc.ID == oid && !sc.Status.IsClosed()
+	}, func(doc interface{}) interface{} {
+		sc := doc.(ScreeningCase)
+		sc.UpdatedAt = update.Entry.At
+		if len(update.Status) > 0 {
+			sc.Status = update.Status
+			if update.Status.IsClosed() {
+				closedAt := update.Entry.At
+				sc.ClosedAt = &closedAt
+			}
+		}
+		if len(update.AssigneeID) > 0 {
+			sc.AssigneeID = update.AssigneeID
+		}
+		if update.Retest != nil {
+			sc.Retest = update.Retest
+		}
+		sc.Timeline = append(sc.Timeline, update.Entry)
+		return sc
+	})
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ScreeningCaseStatus - where the follow-up of a failed screening stands
+type ScreeningCaseStatus string
+
+// ScreeningCaseStatus Enum Defs; "sent_home" & "cleared" are decisions, which close the case
+const (
+	ScreeningCaseOpen     ScreeningCaseStatus = "open"
+	ScreeningCaseRetested ScreeningCaseStatus = "retested"
+	ScreeningCaseSentHome ScreeningCaseStatus = "sent_home"
+	ScreeningCaseCleared  ScreeningCaseStatus = "cleared"
+)
+
+// IsClosed - whether a decision was made
+func (s ScreeningCaseStatus) IsClosed() bool {
+	return s == ScreeningCaseSentHome || s == ScreeningCaseCleared
+}
+
+// ScreeningCaseEntryType - what happened to a case
+type ScreeningCaseEntryType string
+
+// ScreeningCaseEntryType Enum Defs
+const (
+	ScreeningCaseEntryFlagged  ScreeningCaseEntryType = "flagged"
+	ScreeningCaseEntryAssigned ScreeningCaseEntryType = "assigned"
+	ScreeningCaseEntryNote     ScreeningCaseEntryType = "note"
+	ScreeningCaseEntryRetest   ScreeningCaseEntryType = "retest"
+	ScreeningCaseEntryDecided  ScreeningCaseEntryType = "decided"
+)
+
+// ScreeningCaseEntry - an entry of the timeline of a case; Status is that of the case after it.
+// Temperature is the (canonical) reading flagged or retested
+type ScreeningCaseEntry struct {
+	Type        ScreeningCaseEntryType `bson:"type" json:"type"`
+	ActorID     string                 `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	AssigneeID  string                 `bson:"assignee_id,omitempty" json:"assignee_id,omitempty"`
+	Note        string                 `bson:"note,omitempty" json:"note,omitempty"`
+	Temperature float32                `bson:"temperature,omitempty" json:"temperature,omitempty"`
+	Status      ScreeningCaseStatus    `bson:"status" json:"status"`
+	At          time.Time              `bson:"at" json:"at"`
+}
+
+// ScreeningCaseRetest - a reading taken by a nurse, canonical & as taken, and whether it passed the Institution's policy
+type ScreeningCaseRetest struct {
+	Temperature         float32         `bson:"temperature" json:"temperature"`
+	ReportedTemperature float32         `bson:"reported_temperature" json:"reported_temperature"`
+	ReportedUnit        TemperatureUnit `bson:"reported_unit" json:"reported_unit"`
+	Passed              bool            `bson:"passed" json:"passed"`
+	At                  time.Time       `bson:"at" json:"at"`
+}
+
+// ScreeningCase - DB Model of the follow-up of a CCRecord failed by screening: who was flagged, by which reading,
+// the nurse it is assigned to, a retest, and the decision. Every change is added to its Timeline
+type ScreeningCase struct {
+	ID          primitive.ObjectID `bson:"_id" json:"_id"`
+	InstID      string             `bson:"institution_id" json:"institution_id"`
+	CCRecordID  string             `bson:"cc_record_id" json:"cc_record_id"`
+	ExclusionID string             `bson:"exclusion_id,omitempty" json:"exclusion_id,omitempty"`
+	MemberType  MemberType         `bson:"member_type" json:"member_type"`
+	// SubjectID - the WardID, MemberID or TagString flagged, as for Exclusions
+	SubjectID   string               `bson:"subject_id" json:"subject_id"`
+	SubjectName string               `bson:"subject_name" json:"subject_name"`
+	Stage       string               `bson:"stage" json:"stage"`
+	Temperature float32              `bson:"temperature" json:"temperature"`
+	Status      ScreeningCaseStatus  `bson:"status" json:"status"`
+	AssigneeID  string               `bson:"assignee_id,omitempty" json:"assignee_id,omitempty"`
+	Retest      *ScreeningCaseRetest `bson:"retest,omitempty" json:"retest,omitempty"`
+	Timeline    []ScreeningCaseEntry `bson:"timeline" json:"timeline"`
+	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+	ClosedAt    *time.Time           `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+}
+
+// ScreeningCaseFor - the case opened for "ccr" when "eventData" fails it; ok is false when the Record is not failed
+func ScreeningCaseFor(ccr CCRecord, eventData NewEventData, now time.Time) (ScreeningCase, bool) {
+	failed, err := getUpdatedCCRecordWithEvent(ccr, eventData)
+	if err != nil || failed.Status != CCrFailed {
+		return ScreeningCase{}, false
+	}
+
+	subjectID, subjectName := recordSubject(ccr)
+	return ScreeningCase{
+		InstID:      ccr.InstID,
+		CCRecordID:  ccr.ID.Hex(),
+		MemberType:  eventData.MemberType,
+		SubjectID:   subjectID,
+		SubjectName: subjectName,
+		Stage:       eventData.Stage,
+		Temperature: failed.Temperature,
+		Status:      ScreeningCaseOpen,
+		Timeline: []ScreeningCaseEntry{
+			{Type: ScreeningCaseEntryFlagged, Temperature: failed.Temperature, Status: ScreeningCaseOpen, At: now},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, true
+}
+
+// InUnit - the case with its readings in "unit"
+func (sc ScreeningCase) InUnit(unit TemperatureUnit) ScreeningCase {
+	sc.Temperature = FromCanonicalTemperature(sc.Temperature, unit)
+	if sc.Retest != nil {
+		retest := *sc.Retest
+		retest.Temperature = FromCanonicalTemperature(retest.Temperature, unit)
+		sc.Retest = &retest
+	}
+	timeline := make([]ScreeningCaseEntry, len(sc.Timeline))
+	for i, entry := range sc.Timeline {
+		if entry.Temperature != 0 {
+			entry.Temperature = FromCanonicalTemperature(entry.Temperature, unit)
+		}
+		timeline[i] = entry
+	}
+	sc.Timeline = timeline
+	return sc
+}
+
+// ScreeningCaseAssignForm - Input Form for assigning a case to an Admin of its Institution
+type ScreeningCaseAssignForm struct {
+	AssigneeID string `json:"assignee_id" validate:"required"`
+}
+
+// ScreeningCaseNoteForm - Input Form for a follow-up note
+type ScreeningCaseNoteForm struct {
+	Note string `json:"note" validate:"required"`
+}
+
+// ScreeningCaseRetestForm - Input Form for a retest; a reading without a unit is in the Institution's
+type ScreeningCaseRetestForm struct {
+	Temperature float32         `json:"temperature" validate:"required"`
+	Unit        TemperatureUnit `json:"unit" validate:"temperature_unit"`
+	Note        string          `json:"note"`
+}
+
+// ScreeningCaseDecisionForm - Input Form for the decision closing a case
+type ScreeningCaseDecisionForm struct {
+	Decision ScreeningCaseStatus `json:"decision" validate:"required,oneof=sent_home cleared"`
+	Note     string              `json:"note"`
+}
+
+// ScreeningCaseUpdate - a change of an open case, with the entry it adds to its Timeline; zero fields are left as is
+type ScreeningCaseUpdate struct {
+	Status     ScreeningCaseStatus
+	AssigneeID string
+	Retest     *ScreeningCaseRetest
+	Entry      ScreeningCaseEntry
+}
+
+// GetScreeningCaseParams - QueryString Params for GetManyScreeningCases; empty ones are not filtered on
+type GetScreeningCaseParams struct {
+	InstID     string
+	Status     string
+	AssigneeID string
+	SubjectID  string
+	CCRecordID string
+	List       ListParams
+}
+
+// screeningCaseSorts - sorts offered by GetManyScreeningCases
+var screeningCaseSorts = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// listParams - page & sort of the query; latest first unless sorted otherwise
+func (p *GetScreeningCaseParams) listParams() ListParams {
+	list := p.List
+	if len(list.Sort) == 0 {
+		list.Sort = "-created_at"
+	}
+	return list
+}
+
+// ScreeningCaseStore - persistence of Screening Cases
+type ScreeningCaseStore interface {
+	CreateScreeningCase(ctx context.Context, sc ScreeningCase) (*mongo.InsertOneResult, error)
+	GetScreeningCaseByID(ctx context.Context, id string) SingleResult
+	GetScreeningCaseByCCRecordID(ctx context.Context, ccRecordID string) SingleResult
+	GetManyScreeningCases(ctx context.Context, params *GetScreeningCaseParams) (Cursor, error)
+	UpdateOpenScreeningCase(ctx context.Context, id string, update ScreeningCaseUpdate) (*mongo.UpdateResult, error)
+}
+
+type mongoScreeningCaseStore struct {
+	screeningCaseCollection *mongo.Collection
+}
+
+// NewMongoScreeningCaseStore - as is
+func NewMongoScreeningCaseStore(db *mongo.Database) ScreeningCaseStore {
+	return &mongoScreeningCaseStore{screeningCaseCollection: db.Collection("screening_cases")}
+}
+
+// CreateScreeningCase - as name suggests; ID is assigned here
+func (s *mongoScreeningCaseStore) CreateScreeningCase(ctx context.Context, sc ScreeningCase) (*mongo.InsertOneResult, error) {
+	sc.ID = primitive.NewObjectID()
+	return s.screeningCaseCollection.InsertOne(ctx, sc)
+}
+
+// GetScreeningCaseByID - as is
+func (s *mongoScreeningCaseStore) GetScreeningCaseByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.screeningCaseCollection.FindOne(ctx, bson.M{"_id": oid})
+}
+
+// GetScreeningCaseByCCRecordID - the case of a failed Record; a Record fails once, so has one at most
+func (s *mongoScreeningCaseStore) GetScreeningCaseByCCRecordID(ctx context.Context, ccRecordID string) SingleResult {
+	return s.screeningCaseCollection.FindOne(ctx, bson.M{"cc_record_id": ccRecordID})
+}
+
+// GetManyScreeningCases - a page of the cases, latest first unless sorted otherwise
+func (s *mongoScreeningCaseStore) GetManyScreeningCases(ctx context.Context, params *GetScreeningCaseParams) (Cursor, error) {
+	filters := bson.D{}
+	for _, f := range []primitive.E{
+		{Key: "institution_id", Value: params.InstID},
+		{Key: "status", Value: params.Status},
+		{Key: "assignee_id", Value: params.AssigneeID},
+		{Key: "subject_id", Value: params.SubjectID},
+		{Key: "cc_record_id", Value: params.CCRecordID},
+	} {
+		if len(f.Value.(string)) > 0 {
+			filters = append(filters, f)
+		}
+	}
+
+	return mongoList(ctx, s.screeningCaseCollection, filters, params.listParams(), screeningCaseSorts)
+}
+
+// UpdateOpenScreeningCase - apply "update" to a case not closed yet; decisions close it
+func (s *mongoScreeningCaseStore) UpdateOpenScreeningCase(ctx context.Context, id string, update ScreeningCaseUpdate) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	set := bson.D{primitive.E{Key: "updated_at", Value: update.Entry.At}}
+	if len(update.Status) > 0 {
+		set = append(set, primitive.E{Key: "status", Value: update.Status})
+		if update.Status.IsClosed() {
+			set = append(set, primitive.E{Key: "closed_at", Value: update.Entry.At})
+		}
+	}
+	if len(update.AssigneeID) > 0 {
+		set = append(set, primitive.E{Key: "assignee_id", Value: update.AssigneeID})
+	}
+	if update.Retest != nil {
+		set = append(set, primitive.E{Key: "retest", Value: update.Retest})
+	}
+
+	return s.screeningCaseCollection.UpdateOne(ctx, bson.D{
+		primitive.E{Key: "_id", Value: oid},
+		primitive.E{Key: "status", Value: bson.D{
+			primitive.E{Key: "$nin", Value: bson.A{ScreeningCaseSentHome, ScreeningCaseCleared}},
+		}},
+	}, bson.D{
+		primitive.E{Key: "$set", Value: set},
+		primitive.E{Key: "$push", Value: bson.D{
+			primitive.E{Key: "timeline", Value: update.Entry},
+		}},
+	})
+}
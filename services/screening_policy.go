@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaskPolicy - what comes of a Check-In without a mask
+type MaskPolicy string
+
+// MaskPolicy Enum Defs; an empty one ignores masks
+const (
+	MaskPolicyIgnore MaskPolicy = "ignore"
+	MaskPolicyWarn   MaskPolicy = "warn"
+	MaskPolicyDeny   MaskPolicy = "deny"
+)
+
+// ScreeningPolicy - how the Scans of an Institution are screened. Threshold & the allowed range are in Unit, the unit
+// the Institution's readings are rendered in; readings are screened as canonical ones. A zero range allows any reading.
+// Whoever fails a Record is excluded from Check-Ins for ExclusionHours, or until cleared; by default no one is.
+// Institutions without one are screened by the default policy of the Config
+type ScreeningPolicy struct {
+	Threshold           float32         `bson:"threshold" json:"threshold"`
+	Unit                TemperatureUnit `bson:"unit" json:"unit"`
+	RequireCheckOutTemp bool            `bson:"require_check_out_temperature" json:"require_check_out_temperature"`
+	MaskPolicy          MaskPolicy      `bson:"mask_policy" json:"mask_policy"`
+	MinTemperature      float32         `bson:"min_temperature" json:"min_temperature"`
+	MaxTemperature      float32         `bson:"max_temperature" json:"max_temperature"`
+	ExclusionHours      int             `bson:"exclusion_hours" json:"exclusion_hours"`
+	ExcludeUntilCleared bool            `bson:"exclude_until_cleared" json:"exclude_until_cleared"`
+}
+
+// ScreeningReason - why a Scan failed screening
+type ScreeningReason string
+
+// ScreeningReason Enum Defs
+const (
+	ScreeningReasonFever  ScreeningReason = "fever"
+	ScreeningReasonNoMask ScreeningReason = "no_mask"
+)
+
+// Screening - the outcome of screening a Scan by a ScreeningPolicy. A Scan that fails is applied to fail its Record;
+// one that passes may still be Denied by the MaskPolicy, and is then not applied at all
+type Screening struct {
+	Passed   bool
+	Denied   bool
+	Reasons  []ScreeningReason
+	Warnings []ScreeningReason
+}
+
+// ErrTemperatureOutOfRange - a reading outside of the allowed range, taken as a sensor error
+var ErrTemperatureOutOfRange = errors.New("temperature is out of the allowed range")
+
+// Validate - as is
+func (p ScreeningPolicy) Validate() error {
+	if !IsValidTemperatureUnit(p.Unit) {
+		return fmt.Errorf("unit %q is not F or C", p.Unit)
+	}
+	switch p.MaskPolicy {
+	case "", MaskPolicyIgnore, MaskPolicyWarn, MaskPolicyDeny:
+	default:
+		return fmt.Errorf("mask_policy %q is not ignore, warn or deny", p.MaskPolicy)
+	}
+	if p.Threshold <= 0 {
+		return errors.New("threshold is to be positive")
+	}
+	if p.ExclusionHours < 0 {
+		return errors.New("exclusion_hours is not to be negative")
+	}
+	if p.ExclusionHours > 0 && p.ExcludeUntilCleared {
+		return errors.New("exclusion_hours is not to be given with exclude_until_cleared")
+	}
+	if p.MinTemperature == 0 && p.MaxTemperature == 0 {
+		return nil
+	}
+	if p.MinTemperature >= p.MaxTemperature {
+		return errors.New("min_temperature is to be below max_temperature")
+	}
+	if p.Threshold < p.MinTemperature || p.Threshold > p.MaxTemperature {
+		return errors.New("threshold is to be within the allowed range")
+	}
+	return nil
+}
+
+// NeedsReading - Check-Ins always need a reading; Check-Outs only when the policy requires it
+func (p ScreeningPolicy) NeedsReading(stage CCStage) bool {
+	return stage == CCStageCheckIn || (stage == CCStageCheckOut && p.RequireCheckOutTemp)
+}
+
+// CheckReading - reject (canonical) readings outside of the allowed range, for the Stages that need one
+func (p ScreeningPolicy) CheckReading(stage CCStage, temperature float32) error {
+	if !p.NeedsReading(stage) || (p.MinTemperature == 0 && p.MaxTemperature == 0) {
+		return nil
+	}
+	if temperature < ToCanonicalTemperature(p.MinTemperature, p.Unit) || temperature > ToCanonicalTemperature(p.MaxTemperature, p.Unit) {
+		return ErrTemperatureOutOfRange
+	}
+	return nil
+}
+
+// Screen - a (canonical) reading at or above the Threshold fails. Check-Ins without a mask are warned or denied by the MaskPolicy
+func (p ScreeningPolicy) Screen(stage CCStage, temperature float32, mask bool) Screening {
+	screening := Screening{Passed: true}
+	if p.NeedsReading(stage) && temperature >= ToCanonicalTemperature(p.Threshold, p.Unit) {
+		screening.Passed = false
+		screening.Reasons = append(screening.Reasons, ScreeningReasonFever)
+	}
+	if stage != CCStageCheckIn || mask {
+		return screening
+	}
+	switch p.MaskPolicy {
+	case MaskPolicyWarn:
+		screening.Warnings = append(screening.Warnings, ScreeningReasonNoMask)
+	case MaskPolicyDeny:
+		screening.Denied = screening.Passed
+		screening.Reasons = append(screening.Reasons, ScreeningReasonNoMask)
+	}
+	return screening
+}
+
+// Excludes - whether failing a Record excludes its subject from Check-Ins
+func (p ScreeningPolicy) Excludes() bool {
+	return p.ExcludeUntilCleared || p.ExclusionHours > 0
+}
+
+// ExclusionExpiry - when an Exclusion made at "from" expires; nil for those until cleared
+func (p ScreeningPolicy) ExclusionExpiry(from time.Time) *time.Time {
+	if p.ExcludeUntilCleared {
+		return nil
+	}
+	expiresAt := from.Add(time.Duration(p.ExclusionHours) * time.Hour)
+	return &expiresAt
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SearchResultType - kind of the entity a SearchResult points to
+type SearchResultType string
+
+const (
+	// SearchResultMember - as is
+	SearchResultMember SearchResultType = "member"
+	// SearchResultTag - as is
+	SearchResultTag SearchResultType = "tag"
+	// SearchResultWard - as is
+	SearchResultWard SearchResultType = "ward"
+	// SearchResultFamily - as is
+	SearchResultFamily SearchResultType = "family"
+	// SearchResultVehicle - as is
+	SearchResultVehicle SearchResultType = "vehicle"
+)
+
+// SearchHighlight - the field that matched, and where; Start & End count characters (runes), End excluded
+type SearchHighlight struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// SearchResult - an entity found by "api/search"; wards & vehicles are found within their Family
+type SearchResult struct {
+	Type      SearchResultType `json:"type"`
+	ID        string           `json:"id"`
+	FamilyID  string           `json:"family_id,omitempty"`
+	Label     string           `json:"label"`
+	Highlight SearchHighlight  `json:"highlight"`
+}
+
+// SearchQuery - a search term; names, tag strings & plates match it anywhere, ignoring case, and
+// phone #s match its digits, ignoring separators
+type SearchQuery struct {
+	text   []rune
+	digits string
+}
+
+// searchMinPhoneDigits - fewer digits than this would match most phone #s
+const searchMinPhoneDigits = 3
+
+// NewSearchQuery - as is
+func NewSearchQuery(q string) SearchQuery {
+	query := SearchQuery{text: foldRunes(strings.TrimSpace(q))}
+	for _, r := range q {
+		if unicode.IsDigit(r) {
+			query.digits += string(r)
+		}
+	}
+	return query
+}
+
+// Len - characters in the term
+func (q SearchQuery) Len() int {
+	return len(q.text)
+}
+
+func foldRunes(s string) []rune {
+	runes := []rune(s)
+	for index, r := range runes {
+		runes[index] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// matchText - highlight of "value" in "field" when it holds the term
+func (q SearchQuery) matchText(field string, value string) (SearchHighlight, bool) {
+	runes := foldRunes(value)
+	for start := 0; len(q.text) > 0 && start+len(q.text) <= len(runes); start++ {
+		if string(runes[start:start+len(q.text)]) == string(q.text) {
+			return SearchHighlight{Field: field, Value: value, Start: start, End: start + len(q.text)}, true
+		}
+	}
+	return SearchHighlight{}, false
+}
+
+// matchPhone - highlight of "value" in "field" when its digits hold those of the term
+func (q SearchQuery) matchPhone(field string, value string) (SearchHighlight, bool) {
+	if len(q.digits) < searchMinPhoneDigits {
+		return SearchHighlight{}, false
+	}
+	runes := []rune(value)
+	for start := range runes {
+		matched := 0
+		for end := start; end < len(runes); end++ {
+			if !unicode.IsDigit(runes[end]) {
+				if matched == 0 {
+					break
+				}
+				continue
+			}
+			if runes[end] != rune(q.digits[matched]) {
+				break
+			}
+			matched++
+			if matched == len(q.digits) {
+				return SearchHighlight{Field: field, Value: value, Start: start, End: end + 1}, true
+			}
+		}
+	}
+	return SearchHighlight{}, false
+}
+
+// match - first highlight among text fields, then phone fields
+func (q SearchQuery) match(textFields [][2]string, phoneFields [][2]string) (SearchHighlight, bool) {
+	for _, f := range textFields {
+		if h, ok := q.matchText(f[0], f[1]); ok {
+			return h, true
+		}
+	}
+	for _, f := range phoneFields {
+		if h, ok := q.matchPhone(f[0], f[1]); ok {
+			return h, true
+		}
+	}
+	return SearchHighlight{}, false
+}
+
+// mongoFilter - documents where any of the text keys holds the term, or any of the phone keys its digits
+func (q SearchQuery) mongoFilter(textKeys []string, phoneKeys []string) bson.D {
+	or := bson.A{}
+	text := primitive.Regex{Pattern: regexp.QuoteMeta(string(q.text)), Options: "i"}
+	for _, key := range textKeys {
+		or = append(or, bson.D{primitive.E{Key: key, Value: text}})
+	}
+	if len(q.digits) >= searchMinPhoneDigits {
+		phone := primitive.Regex{Pattern: strings.Join(strings.Split(q.digits, ""), `\D*`)}
+		for _, key := range phoneKeys {
+			or = append(or, bson.D{primitive.E{Key: key, Value: phone}})
+		}
+	}
+	return bson.D{primitive.E{Key: "$or", Value: or}}
+}
+
+// SearchMember - result for a Member holding the term, if it does
+func (q SearchQuery) SearchMember(m Member) (SearchResult, bool) {
+	h, ok := q.match([][2]string{{"first_name", m.FirstName}, {"last_name", m.LastName}}, [][2]string{{"phone_num", m.PhoneNum}})
+	return SearchResult{Type: SearchResultMember, ID: m.ID.Hex(), Label: joinName(m.FirstName, m.LastName), Highlight: h}, ok
+}
+
+// SearchTag - result for a Tag holding the term, if it does
+func (q SearchQuery) SearchTag(t Tag) (SearchResult, bool) {
+	h, ok := q.match([][2]string{{"tag_string", t.TagString}, {"first_name", t.FirstName}, {"last_name", t.LastName}}, [][2]string{{"phone_num", t.PhoneNum}})
+	return SearchResult{Type: SearchResultTag, ID: t.ID.Hex(), Label: joinName(t.FirstName, t.LastName), Highlight: h}, ok
+}
+
+// SearchFamily - results for a Family, its Wards and its Vehicles holding the term
+func (q SearchQuery) SearchFamily(f Family) []SearchResult {
+	results := []SearchResult{}
+	contact := f.ContactGuardianInfo
+	if h, ok := q.match([][2]string{{"contact_member_info.name", contact.Name}}, [][2]string{{"contact_member_info.phone_num", contact.PhoneNum}}); ok {
+		results = append(results, SearchResult{Type: SearchResultFamily, ID: f.ID.Hex(), Label: contact.Name, Highlight: h})
+	}
+	for _, w := range f.Wards {
+		if h, ok := q.match([][2]string{{"first_name", w.FirstName}, {"last_name", w.LastName}}, nil); ok {
+			results = append(results, SearchResult{Type: SearchResultWard, ID: w.ID.Hex(), FamilyID: f.ID.Hex(), Label: joinName(w.FirstName, w.LastName), Highlight: h})
+		}
+	}
+	for _, v := range f.Vehicles {
+		if h, ok := q.match([][2]string{{"plate_num", v.PlateNum}}, nil); ok {
+			results = append(results, SearchResult{Type: SearchResultVehicle, ID: v.ID.Hex(), FamilyID: f.ID.Hex(), Label: v.PlateNum, Highlight: h})
+		}
+	}
+	return results
+}
+
+func joinName(firstName string, lastName string) string {
+	return strings.TrimSpace(firstName + " " + lastName)
+}
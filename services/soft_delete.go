@@ -0,0 +1,35 @@
+package services
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Members, Tags, Institutions & Wards are soft-deleted: "deleted_at" is set instead of removing them, and
+// queries leave them out unless asked for deleted ones. Phone #s of deleted Members are freed, so restoring
+// one fails as a duplicate once its Phone # is taken again; other unique keys (e.g. TagStrings) stay reserved
+// until they are restored
+
+// notDeleted - filter of documents that have not been soft-deleted
+var notDeleted = primitive.E{Key: "deleted_at", Value: nil}
+
+// isDeleted - filter of documents that have been soft-deleted
+var isDeleted = primitive.E{Key: "deleted_at", Value: bson.D{primitive.E{Key: "$ne", Value: nil}}}
+
+// deletedFilter - "notDeleted", or "isDeleted" when listing deleted documents
+func deletedFilter(deleted bool) primitive.E {
+	if deleted {
+		return isDeleted
+	}
+	return notDeleted
+}
+
+// softDelete - update soft-deleting a document at "at"
+func softDelete(at time.Time) bson.D {
+	return bson.D{primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "deleted_at", Value: at}}}}
+}
+
+// restoreDeleted - update restoring a soft-deleted document
+var restoreDeleted = bson.D{primitive.E{Key: "$unset", Value: bson.D{primitive.E{Key: "deleted_at", Value: ""}}}}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SingleResult - a DB Model found by a Store; mongo.SingleResult implements it.
+// Decode returns mongo.ErrNoDocuments when nothing is found, whatever the Store is.
+type SingleResult interface {
+	Decode(v interface{}) error
+}
+
+// Cursor - DB Models found by a Store; mongo.Cursor implements it
+type Cursor interface {
+	Next(ctx context.Context) bool
+	Decode(v interface{}) error
+	All(ctx context.Context, results interface{}) error
+	Close(ctx context.Context) error
+}
+
+// Stores - every entity's Store, injected into the server
+type Stores struct {
+	Admins         AdminStore
+	Audits         AuditStore
+	CCRecords      CCRecordStore
+	Configs        ConfigStore
+	Devices        DeviceStore
+	Exclusions     ExclusionStore
+	Families       FamilyStore
+	Insts          InstStore
+	Members        MemberStore
+	RegCodes       RegCodeStore
+	ScanEvents     ScanEventStore
+	ScreeningCases ScreeningCaseStore
+	Surveys        SurveyStore
+	Tags           TagStore
+	// Tx - runs writes of several Stores all or nothing
+	Tx Transactor
+}
+
+// NewMongoStores - Stores backed by the collections of a MongoDB database
+func NewMongoStores(db *mongo.Database) Stores {
+	return Stores{
+		Admins:         NewMongoAdminStore(db),
+		Audits:         NewMongoAuditStore(db),
+		CCRecords:      NewMongoCCRecordStore(db),
+		Configs:        NewMongoConfigStore(db),
+		Devices:        NewMongoDeviceStore(db),
+		Exclusions:     NewMongoExclusionStore(db),
+		Families:       NewMongoFamilyStore(db),
+		Insts:          NewMongoInstStore(db),
+		Members:        NewMongoMemberStore(db),
+		RegCodes:       NewMongoRegCodeStore(db),
+		ScanEvents:     NewMongoScanEventStore(db),
+		ScreeningCases: NewMongoScreeningCaseStore(db),
+		Surveys:        NewMongoSurveyStore(db),
+		Tags:           NewMongoTagStore(db),
+		Tx:             NewMongoTransactor(db),
+	}
+}
+
+// NewMemStores - Stores kept in memory, for tests & running without a database
+func NewMemStores() Stores {
+	return Stores{
+		Admins:         NewMemAdminStore(),
+		Audits:         NewMemAuditStore(),
+		CCRecords:      NewMemCCRecordStore(),
+		Configs:        NewMemConfigStore(),
+		Devices:        NewMemDeviceStore(),
+		Exclusions:     NewMemExclusionStore(),
+		Families:       NewMemFamilyStore(),
+		Insts:          NewMemInstStore(),
+		Members:        NewMemMemberStore(),
+		RegCodes:       NewMemRegCodeStore(),
+		ScanEvents:     NewMemScanEventStore(),
+		ScreeningCases: NewMemScreeningCaseStore(),
+		Surveys:        NewMemSurveyStore(),
+		Tags:           NewMemTagStore(),
+		Tx:             NewMemTransactor(),
+	}
+}
+
+// mongoCursor - keep a failed Find from turning into a non-nil Cursor
+func mongoCursor(cursor *mongo.Cursor, err error) (Cursor, error) {
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// IsTimeoutErr - whether a Store call failed because its deadline passed
+func IsTimeoutErr(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsUnavailableErr - whether a Store call failed because MongoDB could not be reached,
+// or because the request was given up on by the client
+func IsUnavailableErr(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, mongo.ErrClientDisconnected) {
+		return true
+	}
+	// The driver does not wrap server selection failures, so only the message tells
+	return err != nil && strings.Contains(err.Error(), "server selection error")
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memSurveyStore struct {
+	surveys memCollection
+}
+
+// NewMemSurveyStore - as is
+func NewMemSurveyStore() SurveyStore {
+	return &memSurveyStore{}
+}
+
+func (s *memSurveyStore) GetManySurveys(ctx context.Context, params *GetSurveyParams) (Cursor, error) {
+	return s.surveys.list(ctx, func(doc interface{}) bool {
+		sv := doc.(Survey)
+		if sv.InstID != params.InstID {
+			return false
+		}
+		if len(params.MemberID) > 0 && sv.MemberID != params.MemberID {
+			return false
+		}
+		return inTimeRange(sv.CreatedAt, params.StartDate, params.EndDate)
+	}, params.List, surveySorts)
+}
+
+func (s *memSurveyStore) CreateSurvey(ctx context.Context, sf SurveyRegForm, qas []QuestionAnswer) (*mongo.InsertOneResult, error) {
+	newSurvey := newSurvey(sf, qas)
+	return s.surveys.insertOne(ctx, newSurvey.ID, newSurvey)
+}
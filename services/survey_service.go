@@ -33,28 +33,56 @@ type Survey struct {
 
 type GetSurveyParams struct {
 	InstID string `json:"inst_id"`
+	// Filters & page of "GetManySurveys"; empty ones are not applied
+	MemberID  string
+	StartDate time.Time
+	EndDate   time.Time
+	List      ListParams
 }
 
-var surveyCollection *mongo.Collection
+// surveySorts - sorts offered by "GetManySurveys"
+var surveySorts = map[string]string{
+	"created_at": "created_at",
+}
+
+// SurveyStore - persistence of Surveys
+type SurveyStore interface {
+	GetManySurveys(ctx context.Context, params *GetSurveyParams) (Cursor, error)
+	CreateSurvey(ctx context.Context, sf SurveyRegForm, qas []QuestionAnswer) (*mongo.InsertOneResult, error)
+}
+
+type mongoSurveyStore struct {
+	surveyCollection *mongo.Collection
+}
 
-func SurveyCollection(c *mongo.Database) {
-	surveyCollection = c.Collection("surveys")
+// NewMongoSurveyStore - as is
+func NewMongoSurveyStore(db *mongo.Database) SurveyStore {
+	return &mongoSurveyStore{surveyCollection: db.Collection("surveys")}
 }
 
-func GetManySurveys(params *GetSurveyParams) (*mongo.Cursor, error) {
+func (s *mongoSurveyStore) GetManySurveys(ctx context.Context, params *GetSurveyParams) (Cursor, error) {
 	var filters bson.D
 	filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID})
-	return surveyCollection.Find(context.TODO(), filters)
+	if len(params.MemberID) > 0 {
+		filters = append(filters, primitive.E{Key: "member_id", Value: params.MemberID})
+	}
+	if createdAt := timeRangeFilter(params.StartDate, params.EndDate); len(createdAt) > 0 {
+		filters = append(filters, primitive.E{Key: "created_at", Value: createdAt})
+	}
+	return mongoList(ctx, s.surveyCollection, filters, params.List, surveySorts)
 }
 
-func CreateSurvey(s SurveyRegForm, qas []QuestionAnswer) (*mongo.InsertOneResult, error) {
+func (s *mongoSurveyStore) CreateSurvey(ctx context.Context, sf SurveyRegForm, qas []QuestionAnswer) (*mongo.InsertOneResult, error) {
+	return s.surveyCollection.InsertOne(ctx, newSurvey(sf, qas))
+}
 
-	newSurvey := Survey{
+// newSurvey - Survey to be inserted
+func newSurvey(sf SurveyRegForm, qas []QuestionAnswer) Survey {
+	return Survey{
 		ID:        primitive.NewObjectID(),
-		InstID:    s.InstID,
-		MemberID:  s.MemberID,
+		InstID:    sf.InstID,
+		MemberID:  sf.MemberID,
 		QAList:    qas,
 		CreatedAt: time.Now(),
 	}
-	return surveyCollection.InsertOne(context.TODO(), newSurvey)
 }
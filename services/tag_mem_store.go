@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memTagStore struct {
+	tags memCollection
+}
+
+// NewMemTagStore - as is; TagStrings are unique within an Institution
+func NewMemTagStore() TagStore {
+	return &memTagStore{tags: memCollection{unique: []memUniqueKey{
+		func(doc interface{}) (string, bool) {
+			t := doc.(Tag)
+			return t.InstID + "\x00" + t.TagString, true
+		},
+	}}}
+}
+
+func (s *memTagStore) GetManyTags(ctx context.Context, params *GetTagParams) (Cursor, error) {
+	return s.tags.list(ctx, func(doc interface{}) bool {
+		t := doc.(Tag)
+		if t.InstID != params.InstID || (t.DeletedAt != nil) != params.Deleted {
+			return false
+		}
+		if len(params.Group) > 0 && t.Group != params.Group {
+			return false
+		}
+		if len(params.NamePrefix) > 0 && !hasPrefixFold(t.FirstName, params.NamePrefix) && !hasPrefixFold(t.LastName, params.NamePrefix) {
+			return false
+		}
+		return inTimeRange(t.ModifiedAt, params.StartDate, params.EndDate)
+	}, params.List, tagSorts)
+}
+
+func (s *memTagStore) SearchTags(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error) {
+	return s.tags.list(ctx, func(doc interface{}) bool {
+		t := doc.(Tag)
+		_, ok := q.SearchTag(t)
+		return t.InstID == instID && t.DeletedAt == nil && ok
+	}, ListParams{Limit: limit}, nil)
+}
+
+func (s *memTagStore) GetTagByID(ctx context.Context, id string) SingleResult {
+	return s.tags.findOne(ctx, tagDeleted(tagWithID(id), false))
+}
+
+func (s *memTagStore) GetTag(ctx context.Context, params *GetTagParams) SingleResult {
+	return s.tags.findOne(ctx, func(doc interface{}) bool {
+		t := doc.(Tag)
+		if t.DeletedAt != nil {
+			return false
+		}
+		if len(params.InstID) > 0 && t.InstID != params.InstID {
+			return false
+		}
+		return len(params.TagString) == 0 || t.TagString == params.TagString
+	})
+}
+
+func (s *memTagStore) CountTag(ctx context.Context, countTagParams CountTagParams) (int64, error) {
+	return s.tags.count(ctx, func(doc interface{}) bool {
+		t := doc.(Tag)
+		return t.TagString == countTagParams.TagString && t.InstID == countTagParams.InstID
+	})
+}
+
+func (s *memTagStore) CreateTag(ctx context.Context, t TagRegForm) (*mongo.InsertOneResult, error) {
+	newTag := newTag(t)
+	return s.tags.insertOne(ctx, newTag.ID, newTag)
+}
+
+func (s *memTagStore) UpdateTagByID(ctx context.Context, i TagEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+	return s.tags.updateOne(ctx, tagDeleted(tagWithID(idToUpdate), false), func(doc interface{}) interface{} {
+		t := doc.(Tag)
+		t.PhoneNum = i.PhoneNum
+		t.Email = i.Email
+		t.FirstName = i.FirstName
+		t.LastName = i.LastName
+		t.Group = i.Group
+		t.ModifiedAt = time.Now()
+		return t
+	})
+}
+
+func (s *memTagStore) DeleteTagByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
+	return s.tags.deleteOne(ctx, tagWithID(idToDelete))
+}
+
+func (s *memTagStore) GetDeletedTagByID(ctx context.Context, id string) SingleResult {
+	return s.tags.findOne(ctx, tagDeleted(tagWithID(id), true))
+}
+
+func (s *memTagStore) SoftDeleteTagByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error) {
+	return s.tags.updateOne(ctx, tagDeleted(tagWithID(idToDelete), false), func(doc interface{}) interface{} {
+		t := doc.(Tag)
+		t.DeletedAt = &at
+		return t
+	})
+}
+
+func (s *memTagStore) RestoreTagByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error) {
+	return s.tags.updateOne(ctx, tagDeleted(tagWithID(idToRestore), true), func(doc interface{}) interface{} {
+		t := doc.(Tag)
+		t.DeletedAt = nil
+		return t
+	})
+}
+
+func tagWithID(id string) memMatch {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return func(doc interface{}) bool { return doc.(Tag).ID == oid }
+}
+
+// tagDeleted - Tags of "match" that are (or are not) soft-deleted
+func tagDeleted(match memMatch, deleted bool) memMatch {
+	return func(doc interface{}) bool { return match(doc) && (doc.(Tag).DeletedAt != nil) == deleted }
+}
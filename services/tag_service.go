@@ -40,12 +40,29 @@ type Tag struct {
 	LastName   string             `bson:"last_name" json:"last_name"`
 	Group      string             `json:"group"`
 	ModifiedAt time.Time          `bson:"modified_at" json:"modified_at"`
+	DeletedAt  *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
 // GetTagParams - QueryString Params for GetTag
 type GetTagParams struct {
 	InstID    string `json:"inst_id"`
 	TagString string `json:"tag_string"`
+	// Filters & page of "GetManyTags"; empty ones are not applied
+	Group      string
+	NamePrefix string
+	StartDate  time.Time
+	EndDate    time.Time
+	List       ListParams
+	// Deleted - list deleted Tags instead
+	Deleted bool
+}
+
+// tagSorts - sorts offered by "GetManyTags"
+var tagSorts = map[string]string{
+	"modified_at": "modified_at",
+	"tag_string":  "tag_string",
+	"first_name":  "first_name",
+	"last_name":   "last_name",
 }
 
 // CountTagParams - Searching Params for CountTag
@@ -54,33 +71,69 @@ type CountTagParams struct {
 	TagString string `json:"tag_string"`
 }
 
-var tagCollection *mongo.Collection
+// TagStore - persistence of Tags
+type TagStore interface {
+	GetManyTags(ctx context.Context, params *GetTagParams) (Cursor, error)
+	SearchTags(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error)
+	GetTagByID(ctx context.Context, id string) SingleResult
+	GetTag(ctx context.Context, params *GetTagParams) SingleResult
+	CountTag(ctx context.Context, countTagParams CountTagParams) (int64, error)
+	CreateTag(ctx context.Context, t TagRegForm) (*mongo.InsertOneResult, error)
+	UpdateTagByID(ctx context.Context, t TagEditForm, idToUpdate string) (*mongo.UpdateResult, error)
+	DeleteTagByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error)
+	GetDeletedTagByID(ctx context.Context, id string) SingleResult
+	SoftDeleteTagByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error)
+	RestoreTagByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error)
+}
+
+type mongoTagStore struct {
+	tagCollection *mongo.Collection
+}
 
-// TagCollection returns reference to DB collection
-func TagCollection(c *mongo.Database) {
-	tagCollection = c.Collection("tags")
+// NewMongoTagStore - as is
+func NewMongoTagStore(db *mongo.Database) TagStore {
+	return &mongoTagStore{tagCollection: db.Collection("tags")}
 }
 
 // GetManyTags as is
-func GetManyTags(params *GetTagParams) (*mongo.Cursor, error) {
+func (s *mongoTagStore) GetManyTags(ctx context.Context, params *GetTagParams) (Cursor, error) {
 	var filters bson.D
-	filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID})
+	filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID}, deletedFilter(params.Deleted))
+	if len(params.Group) > 0 {
+		filters = append(filters, primitive.E{Key: "group", Value: params.Group})
+	}
+	if len(params.NamePrefix) > 0 {
+		filters = append(filters, primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: "first_name", Value: prefixFilter(params.NamePrefix)}},
+			bson.D{primitive.E{Key: "last_name", Value: prefixFilter(params.NamePrefix)}},
+		}})
+	}
+	if modifiedAt := timeRangeFilter(params.StartDate, params.EndDate); len(modifiedAt) > 0 {
+		filters = append(filters, primitive.E{Key: "modified_at", Value: modifiedAt})
+	}
 
-	return tagCollection.Find(context.TODO(), filters)
+	return mongoList(ctx, s.tagCollection, filters, params.List, tagSorts)
+}
+
+// SearchTags - Tags of an Institution whose tag string, name or phone # holds the term
+func (s *mongoTagStore) SearchTags(ctx context.Context, instID string, q SearchQuery, limit int64) (Cursor, error) {
+	filters := bson.D{primitive.E{Key: "institution_id", Value: instID}, notDeleted}
+	filters = append(filters, q.mongoFilter([]string{"tag_string", "first_name", "last_name"}, []string{"phone_num"})...)
+	return mongoList(ctx, s.tagCollection, filters, ListParams{Limit: limit}, nil)
 }
 
 // GetTagByID as is
-func GetTagByID(id string) *mongo.SingleResult {
+func (s *mongoTagStore) GetTagByID(ctx context.Context, id string) SingleResult {
 	oid, _ := primitive.ObjectIDFromHex(id)
-	return tagCollection.FindOne(context.TODO(), bson.M{
-		"_id": oid,
+	return s.tagCollection.FindOne(ctx, bson.D{
+		primitive.E{Key: "_id", Value: oid}, notDeleted,
 	})
 }
 
 // GetTagByTagString as is
-func GetTag(params *GetTagParams) *mongo.SingleResult {
+func (s *mongoTagStore) GetTag(ctx context.Context, params *GetTagParams) SingleResult {
 
-	var filters bson.D
+	filters := bson.D{notDeleted}
 	if len(params.InstID) > 0 {
 		filters = append(filters, primitive.E{Key: "institution_id", Value: params.InstID})
 	}
@@ -88,20 +141,25 @@ func GetTag(params *GetTagParams) *mongo.SingleResult {
 		filters = append(filters, primitive.E{Key: "tag_string", Value: params.TagString})
 	}
 
-	return tagCollection.FindOne(context.TODO(), filters)
+	return s.tagCollection.FindOne(ctx, filters)
 }
 
 // CountTag as is
-func CountTag(countTagParams CountTagParams) (int64, error) {
-	return tagCollection.CountDocuments(context.TODO(), bson.D{
+func (s *mongoTagStore) CountTag(ctx context.Context, countTagParams CountTagParams) (int64, error) {
+	return s.tagCollection.CountDocuments(ctx, bson.D{
 		primitive.E{Key: "tag_string", Value: countTagParams.TagString},
 		primitive.E{Key: "institution_id", Value: countTagParams.InstID},
 	})
 }
 
 // CreateTag as is
-func CreateTag(t TagRegForm) (*mongo.InsertOneResult, error) {
-	newTag := Tag{
+func (s *mongoTagStore) CreateTag(ctx context.Context, t TagRegForm) (*mongo.InsertOneResult, error) {
+	return s.tagCollection.InsertOne(ctx, newTag(t))
+}
+
+// newTag - Tag to be inserted
+func newTag(t TagRegForm) Tag {
+	return Tag{
 		ID:         primitive.NewObjectID(),
 		InstID:     t.InstID,
 		TagString:  t.TagString,
@@ -112,14 +170,12 @@ func CreateTag(t TagRegForm) (*mongo.InsertOneResult, error) {
 		Group:      t.Group,
 		ModifiedAt: time.Now(),
 	}
-
-	return tagCollection.InsertOne(context.TODO(), newTag)
 }
 
-// UpdateTagByID as is
-func UpdateTagByID(t TagEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
+// UpdateTagByID as is; deleted Tags are not updated
+func (s *mongoTagStore) UpdateTagByID(ctx context.Context, t TagEditForm, idToUpdate string) (*mongo.UpdateResult, error) {
 	oid, _ := primitive.ObjectIDFromHex(idToUpdate)
-	return tagCollection.UpdateOne(context.TODO(), bson.M{"_id": oid}, bson.D{
+	return s.tagCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, notDeleted}, bson.D{
 		primitive.E{Key: "$set", Value: bson.D{
 			primitive.E{Key: "phone_num", Value: t.PhoneNum},
 			primitive.E{Key: "email", Value: t.Email},
@@ -134,7 +190,25 @@ func UpdateTagByID(t TagEditForm, idToUpdate string) (*mongo.UpdateResult, error
 }
 
 // DeleteTagByID as is
-func DeleteTagByID(idToDelete string) (*mongo.DeleteResult, error) {
+func (s *mongoTagStore) DeleteTagByID(ctx context.Context, idToDelete string) (*mongo.DeleteResult, error) {
 	oid, _ := primitive.ObjectIDFromHex(idToDelete)
-	return tagCollection.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return s.tagCollection.DeleteOne(ctx, bson.M{"_id": oid})
+}
+
+// GetDeletedTagByID - a soft-deleted Tag
+func (s *mongoTagStore) GetDeletedTagByID(ctx context.Context, id string) SingleResult {
+	oid, _ := primitive.ObjectIDFromHex(id)
+	return s.tagCollection.FindOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, isDeleted})
+}
+
+// SoftDeleteTagByID - as is
+func (s *mongoTagStore) SoftDeleteTagByID(ctx context.Context, idToDelete string, at time.Time) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToDelete)
+	return s.tagCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, notDeleted}, softDelete(at))
+}
+
+// RestoreTagByID - as is
+func (s *mongoTagStore) RestoreTagByID(ctx context.Context, idToRestore string) (*mongo.UpdateResult, error) {
+	oid, _ := primitive.ObjectIDFromHex(idToRestore)
+	return s.tagCollection.UpdateOne(ctx, bson.D{primitive.E{Key: "_id", Value: oid}, isDeleted}, restoreDeleted)
 }
@@ -0,0 +1,59 @@
+package services
+
+import "math"
+
+// TemperatureUnit - as is
+type TemperatureUnit string
+
+// TemperatureUnit Enum Defs
+const (
+	TemperatureUnitF TemperatureUnit = "F"
+	TemperatureUnitC TemperatureUnit = "C"
+)
+
+// CanonicalTemperatureUnit - readings are stored in °F, whatever unit they were reported in
+const CanonicalTemperatureUnit = TemperatureUnitF
+
+// IsValidTemperatureUnit - as name suggests; empty is not a unit
+func IsValidTemperatureUnit(unit TemperatureUnit) bool {
+	return unit == TemperatureUnitF || unit == TemperatureUnitC
+}
+
+// ToCanonicalTemperature - a reading in "unit" as a canonical one; empty units are canonical
+func ToCanonicalTemperature(temperature float32, unit TemperatureUnit) float32 {
+	if unit != TemperatureUnitC {
+		return temperature
+	}
+	return roundTemperature(temperature*9/5 + 32)
+}
+
+// FromCanonicalTemperature - a canonical reading, rendered in "unit"
+func FromCanonicalTemperature(temperature float32, unit TemperatureUnit) float32 {
+	if unit != TemperatureUnitC {
+		return temperature
+	}
+	return roundTemperature((temperature - 32) * 5 / 9)
+}
+
+// roundTemperature - to the hundredth, for converted readings not to carry float noise
+func roundTemperature(temperature float32) float32 {
+	return float32(math.Round(float64(temperature)*100) / 100)
+}
+
+// InUnit - the Record with its temperatures rendered in "unit"
+func (ccr CCRecord) InUnit(unit TemperatureUnit) CCRecord {
+	ccr.Temperature = FromCanonicalTemperature(ccr.Temperature, unit)
+	if ccr.GW != nil {
+		gw := *ccr.GW
+		gw.CheckInEvent.Temperature = FromCanonicalTemperature(gw.CheckInEvent.Temperature, unit)
+		gw.CheckOutEvent.Temperature = FromCanonicalTemperature(gw.CheckOutEvent.Temperature, unit)
+		ccr.GW = &gw
+	}
+	if ccr.MT != nil {
+		mt := *ccr.MT
+		mt.CheckInEvent.Temperature = FromCanonicalTemperature(mt.CheckInEvent.Temperature, unit)
+		mt.CheckOutEvent.Temperature = FromCanonicalTemperature(mt.CheckOutEvent.Temperature, unit)
+		ccr.MT = &mt
+	}
+	return ccr
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Tx - writes made together by "RunInTx"; each one registers how to undo it with "OnRollback",
+// which is how the writes are rolled back when the DB cannot run transactions
+type Tx struct {
+	undos []func(ctx context.Context) error
+}
+
+// OnRollback - register how to undo a write made within the Tx
+func (tx *Tx) OnRollback(undo func(ctx context.Context) error) {
+	tx.undos = append(tx.undos, undo)
+}
+
+// rollback - undo the writes made, latest first; undos failing are logged, since the write that
+// failed is the error to report
+func (tx *Tx) rollback(ctx context.Context) {
+	for index := len(tx.undos) - 1; index >= 0; index-- {
+		if err := tx.undos[index](ctx); err != nil {
+			log.Printf("Error while rolling back a write - %v\n", err)
+		}
+	}
+	tx.undos = nil
+}
+
+// Transactor - runs writes of several Stores all or nothing. Store calls within "fn" must use
+// the ctx it is given. "fn" may be run more than once, so should not keep state from a former run
+type Transactor interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context, tx *Tx) error) error
+}
+
+// runCompensated - run "fn", undoing its writes when it fails
+func runCompensated(ctx context.Context, fn func(ctx context.Context, tx *Tx) error) error {
+	tx := &Tx{}
+	err := fn(ctx, tx)
+	if err != nil {
+		// Undo even if "ctx" is done, e.g. when the write failed for its deadline
+		tx.rollback(context.Background())
+	}
+	return err
+}
+
+type mongoTransactor struct {
+	client *mongo.Client
+	db     *mongo.Database
+	// supported - whether the server runs transactions (replica sets & sharded clusters do,
+	// standalone servers do not); found out on the first "RunInTx" that can reach it
+	mu        sync.Mutex
+	checked   bool
+	supported bool
+}
+
+// NewMongoTransactor - runs writes in a session transaction, or compensates them on standalone servers
+func NewMongoTransactor(db *mongo.Database) Transactor {
+	return &mongoTransactor{client: db.Client(), db: db}
+}
+
+func (t *mongoTransactor) RunInTx(ctx context.Context, fn func(ctx context.Context, tx *Tx) error) error {
+	if !t.supportsTransactions(ctx) {
+		return runCompensated(ctx, fn)
+	}
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		// The transaction is aborted on errors, so undos are not needed
+		return nil, fn(sc, &Tx{})
+	})
+	return err
+}
+
+// supportsTransactions - whether the server is a replica set member or a mongos
+func (t *mongoTransactor) supportsTransactions(ctx context.Context) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.checked {
+		return t.supported
+	}
+	var res bson.M
+	err := t.db.RunCommand(ctx, bson.D{primitive.E{Key: "isMaster", Value: 1}}).Decode(&res)
+	if err != nil {
+		log.Printf("Error while checking for transaction support, compensating writes instead - %v\n", err)
+		return false
+	}
+	_, isReplicaSet := res["setName"]
+	t.checked = true
+	t.supported = isReplicaSet || res["msg"] == "isdbgrid"
+	return t.supported
+}
+
+type memTransactor struct{}
+
+// NewMemTransactor - in-memory Stores have no transactions, so writes are compensated
+func NewMemTransactor() Transactor {
+	return memTransactor{}
+}
+
+func (memTransactor) RunInTx(ctx context.Context, fn func(ctx context.Context, tx *Tx) error) error {
+	return runCompensated(ctx, fn)
+}
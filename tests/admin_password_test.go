@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVerifyAdminPassword(t *testing.T) {
+	// Plaintext Password left from before hashing; needs rehash once verified
+	match, needsRehash := svc.VerifyAdminPassword(svc.Admin{Password: "legacy_pswd"}, "legacy_pswd")
+	assert.True(t, match)
+	assert.True(t, needsRehash)
+	match, needsRehash = svc.VerifyAdminPassword(svc.Admin{Password: "legacy_pswd"}, "wrong_pswd")
+	assert.False(t, match)
+	assert.False(t, needsRehash)
+
+	hash, err := svc.HashAdminPassword("new_pswd")
+	assert.Nil(t, err)
+	assert.NotEqual(t, "new_pswd", hash)
+	match, needsRehash = svc.VerifyAdminPassword(svc.Admin{Password: hash}, "new_pswd")
+	assert.True(t, match)
+	assert.False(t, needsRehash)
+	match, _ = svc.VerifyAdminPassword(svc.Admin{Password: hash}, "wrong_pswd")
+	assert.False(t, match)
+}
+
+func TestAdminLoginHidesPassword(t *testing.T) {
+	frasUsername := "admin_pswd_test_" + primitive.NewObjectID().Hex()
+	_, err := testCCServer.Stores.Admins.CreateAdmin(context.TODO(), svc.AdminRegForm{
+		FrasUsername: frasUsername,
+		Password:     "test_pswd",
+		InstID:       primitive.NewObjectID().Hex(),
+	})
+	assert.Nil(t, err)
+
+	var admin svc.Admin
+	assert.Nil(t, testCCServer.Stores.Admins.GetAdminByFrasUsername(context.TODO(), frasUsername).Decode(&admin))
+	assert.NotEqual(t, "test_pswd", admin.Password)
+
+	body := `{"fras_username":"` + frasUsername + `","password":"test_pswd"}`
+	req, _ := http.NewRequest("POST", "/api/admin/login", strings.NewReader(body))
+	req.Header.Add("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "password")
+	assert.NotContains(t, w.Body.String(), admin.Password)
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminRolePermissions(t *testing.T) {
+	d := initTestInstScope(t)
+	frontDeskToken := getTestToken(controllers.SessionRoleFrontDesk, d.InstID)
+	nurseToken := getTestToken(controllers.SessionRoleNurse, d.InstID)
+
+	// Front Desk is read-only
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"GET", "/api/members?instID=" + d.InstID, ""}, frontDeskToken))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"GET", "/api/cc-records?instID=" + d.InstID, ""}, frontDeskToken))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"PUT", "/api/tag/" + d.TagID, `{"first_name":"Front"}`}, frontDeskToken))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"DELETE", "/api/cc-record/" + d.CCRecordID, ""}, frontDeskToken))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"GET", "/api/export/members?instID=" + d.InstID, ""}, frontDeskToken))
+
+	// Nurse sees failed screenings & Surveys only
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"GET", "/api/surveys?instID=" + d.InstID, ""}, nurseToken))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"GET", "/api/members?instID=" + d.InstID, ""}, nurseToken))
+
+	req, _ := http.NewRequest("GET", "/api/cc-records?instID="+d.InstID, nil)
+	req.Header.Add("Authorization", "Bearer "+nurseToken)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res struct {
+		Data []svc.CCRecord `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	for _, ccRecord := range res.Data {
+		assert.Equal(t, svc.CCrFailed, ccRecord.Status)
+	}
+
+	// Unknown Role is rejected on registration
+	superAdminToken := getTestToken(controllers.SessionRoleSuperAdmin, "")
+	body := `{"fras_username":"admin_role_test","institution_id":"` + d.InstID + `","role":"janitor"}`
+	assert.Equal(t, http.StatusBadRequest, sendWithToken(scopeTestCase{"POST", "/api/admin/register", body}, superAdminToken))
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type auditResponse struct {
+	Data []svc.AuditEntry `json:"data"`
+}
+
+func TestDiffAuditFields(t *testing.T) {
+	before := svc.Device{DeviceID: "diff_test", Location: "Front Gate", APIKeyHash: "old", Enabled: true}
+	after := before
+	after.Location = "Back Gate"
+	after.APIKeyHash = "new"
+
+	changes, err := svc.DiffAuditFields(before, after)
+	assert.Nil(t, err)
+	assert.Equal(t, []svc.AuditFieldChange{
+		{Field: "api_key_hash", Before: svc.AuditRedacted, After: svc.AuditRedacted},
+		{Field: "location", Before: "Front Gate", After: "Back Gate"},
+	}, changes)
+
+	// Creation
+	changes, err = svc.DiffAuditFields(nil, after)
+	assert.Nil(t, err)
+	for _, change := range changes {
+		assert.Nil(t, change.Before)
+	}
+
+	// Deletion, with a nil pointer
+	var deleted *svc.Device
+	changes, err = svc.DiffAuditFields(before, deleted)
+	assert.Nil(t, err)
+	for _, change := range changes {
+		assert.Nil(t, change.After)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	d := initTestInstScope(t)
+	adminToken := getTestToken(controllers.SessionRoleAdmin, d.InstID)
+	deviceID := "audit_test_" + primitive.NewObjectID().Hex()
+
+	var created deviceResponse
+	w := sendWithTokenRecorded(scopeTestCase{"POST", "/api/device", `{"device_id":"` + deviceID + `","institution_id":"` + d.InstID + `","location":"Front Gate"}`}, adminToken)
+	json.Unmarshal(w.Body.Bytes(), &created)
+	sendWithTokenRecorded(scopeTestCase{"PUT", "/api/device/" + created.Data.ID, `{"location":"Back Gate","enabled":true}`}, adminToken)
+	sendWithTokenRecorded(scopeTestCase{"DELETE", "/api/device/" + created.Data.ID, ""}, adminToken)
+
+	// Newest first, with Actor & diff
+	var audits auditResponse
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/audit?instID=" + d.InstID + "&targetID=" + created.Data.ID, ""}, adminToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &audits)
+	if assert.Equal(t, 3, len(audits.Data)) {
+		assert.Equal(t, svc.AuditActionDelete, audits.Data[0].Action)
+		assert.Equal(t, svc.AuditActionUpdate, audits.Data[1].Action)
+		assert.Equal(t, svc.AuditActionCreate, audits.Data[2].Action)
+		assert.Equal(t, "000000000000000000000000", audits.Data[1].ActorID)
+		assert.Equal(t, string(controllers.SessionRoleAdmin), audits.Data[1].ActorRole)
+		assert.Equal(t, svc.AuditTargetDevice, audits.Data[1].TargetType)
+		assert.Contains(t, audits.Data[1].Changes, svc.AuditFieldChange{Field: "location", Before: "Front Gate", After: "Back Gate"})
+		for _, change := range audits.Data[2].Changes {
+			if change.Field == "api_key_hash" {
+				assert.Equal(t, svc.AuditRedacted, change.After)
+			}
+		}
+	}
+
+	// Filters
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/audit?instID=" + d.InstID + "&targetID=" + created.Data.ID + "&action=update", ""}, adminToken)
+	json.Unmarshal(w.Body.Bytes(), &audits)
+	assert.Equal(t, 1, len(audits.Data))
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/audit?instID=" + d.InstID + "&startDate=yesterday", ""}, adminToken)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Admins of other Institutions see nothing
+	other := initTestInstScope(t)
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/audit?instID=" + other.InstID + "&targetID=" + created.Data.ID, ""}, getTestToken(controllers.SessionRoleAdmin, other.InstID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &audits)
+	assert.Equal(t, 0, len(audits.Data))
+
+	// Front Desk may not read the Audit Log
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/audit?instID=" + d.InstID, ""}, getTestToken(controllers.SessionRoleFrontDesk, d.InstID))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
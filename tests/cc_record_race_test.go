@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// racingCCRecordStore - a CC-Record Store where another scan always updates the Record first
+type racingCCRecordStore struct {
+	svc.CCRecordStore
+}
+
+func (s racingCCRecordStore) UpdateCCRecordWithEvent(ctx context.Context, ccr svc.CCRecord, eventData svc.NewEventData) (*mongo.UpdateResult, error) {
+	if _, err := s.CCRecordStore.UpdateCCRecordWithEvent(ctx, ccr, eventData); err != nil {
+		return nil, err
+	}
+	return s.CCRecordStore.UpdateCCRecordWithEvent(ctx, ccr, eventData)
+}
+
+func TestCCRecordConcurrentEvents(t *testing.T) {
+	ctx := context.TODO()
+	stores := svc.NewMemStores()
+	member := svc.Member{ID: primitive.NewObjectID(), FirstName: "Race"}
+	res, err := stores.CCRecords.CreateCCRecord(ctx, primitive.NewObjectID().Hex(), svc.CreateCCRecordData{Member: &member})
+	assert.Nil(t, err)
+	recordID := res.InsertedID.(primitive.ObjectID).Hex()
+
+	// Every scan has read the Record before any of them updates it
+	read := svc.CCRecord{}
+	assert.Nil(t, stores.CCRecords.GetCCRecordByID(ctx, recordID).Decode(&read))
+	const scans = 32
+	errs := make(chan error, scans)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for index := 0; index < scans; index++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			<-start
+			_, err := stores.CCRecords.UpdateCCRecordWithEvent(ctx, read, svc.NewEventData{
+				MemberTagEvent: &svc.MemberTagEvent{DeviceID: "race", Temperature: 36 + float32(index)/10, Time: time.Now()},
+				Stage:          "checkin",
+				MemberType:     svc.MemberTypeStandard,
+			})
+			errs <- err
+		}(index)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	applied := 0
+	for err := range errs {
+		if err == nil {
+			applied++
+			continue
+		}
+		assert.Equal(t, svc.ErrCCRecordConflict, err)
+	}
+	assert.Equal(t, 1, applied)
+
+	updated := svc.CCRecord{}
+	assert.Nil(t, stores.CCRecords.GetCCRecordByID(ctx, recordID).Decode(&updated))
+	assert.Equal(t, read.Version+1, updated.Version)
+	assert.Equal(t, svc.CCrCheckInComplete, updated.Status)
+	assert.Equal(t, updated.Temperature, updated.MT.CheckInEvent.Temperature)
+
+	// A check-out schedule & a check-out scan read at the same time; one is applied, the other conflicts
+	checkOut := svc.NewEventData{
+		MemberTagEvent: &svc.MemberTagEvent{DeviceID: "race"},
+		MemberType:     svc.MemberTypeStandard,
+		Stage:          "checkout",
+	}
+	raced := make(chan error, 2)
+	start = make(chan struct{})
+	go func() {
+		<-start
+		_, err := stores.CCRecords.UpdateCCRecordScheduleTime(ctx, updated, time.Now())
+		raced <- err
+	}()
+	go func() {
+		<-start
+		_, err := stores.CCRecords.UpdateCCRecordWithEvent(ctx, updated, checkOut)
+		raced <- err
+	}()
+	close(start)
+	first, second := <-raced, <-raced
+	if first == nil {
+		assert.Equal(t, svc.ErrCCRecordConflict, second)
+	} else {
+		assert.Equal(t, svc.ErrCCRecordConflict, first)
+		assert.Nil(t, second)
+	}
+	won := svc.CCRecord{}
+	assert.Nil(t, stores.CCRecords.GetCCRecordByID(ctx, recordID).Decode(&won))
+	assert.Equal(t, updated.Version+1, won.Version)
+
+	// Whichever was applied, the other is not applied over it; e.g. a Record checked out is not scheduled again
+	_, err = stores.CCRecords.UpdateCCRecordScheduleTime(ctx, updated, time.Now())
+	assert.Equal(t, svc.ErrCCRecordConflict, err)
+	_, err = stores.CCRecords.UpdateCCRecordWithEvent(ctx, updated, checkOut)
+	assert.Equal(t, svc.ErrCCRecordConflict, err)
+	latest := svc.CCRecord{}
+	assert.Nil(t, stores.CCRecords.GetCCRecordByID(ctx, recordID).Decode(&latest))
+	assert.Equal(t, won.Status, latest.Status)
+	assert.Equal(t, won.Version, latest.Version)
+}
+
+func TestCCScanConflict(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "CC Scan Conflict Test"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	res, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Race"})
+	assert.Nil(t, err)
+	memberID := res.InsertedID.(primitive.ObjectID).Hex()
+	useTestDevice(instID)
+	postCCSync(t, getSyncRequestMember(instID, memberID))
+
+	ccRecords := testCCServer.Stores.CCRecords
+	defer func() {
+		testCCServer.Stores.CCRecords = ccRecords
+	}()
+	testCCServer.Stores.CCRecords = racingCCRecordStore{CCRecordStore: ccRecords}
+
+	data := makeGateKeeperPost(testTemperatureNormal, testDeviceIMEI, getMemberUniqueID(memberID, "checkin"))
+	req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// The scan that won is kept as it was
+	ccRecord := svc.CCRecord{}
+	params := svc.GetCCRecordParams{MemberTagID: memberID, Status: int(svc.CCrCheckInComplete)}
+	assert.Nil(t, ccRecords.GetCCRecord(ctx, &params).Decode(&ccRecord))
+	assert.Equal(t, int64(1), ccRecord.Version)
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCCWorkflowTransitions(t *testing.T) {
+	cc, err := svc.GetCCWorkflow(svc.WorkflowTypeCC)
+	assert.Nil(t, err)
+	checkIn, err := svc.GetCCWorkflow(svc.WorkflowTypeCheckIn)
+	assert.Nil(t, err)
+	// Institutions without a Workflow type go by the CC Workflow
+	legacy, err := svc.GetCCWorkflow("")
+	assert.Nil(t, err)
+	assert.Equal(t, svc.WorkflowTypeCC, legacy.Type)
+
+	testCases := []struct {
+		workflow svc.CCWorkflow
+		mType    svc.MemberType
+		from     svc.CCRecordStatus
+		stage    svc.CCStage
+		failed   bool
+		to       svc.CCRecordStatus
+		valid    bool
+	}{
+		{cc, svc.MemberTypeStandard, svc.CCrInit, svc.CCStageCheckIn, false, svc.CCrCheckInComplete, true},
+		{cc, svc.MemberTypeStandard, svc.CCrInit, svc.CCStageCheckIn, true, svc.CCrFailed, true},
+		{cc, svc.MemberTypeStandard, svc.CCrCheckInComplete, svc.CCStageCheckOut, false, svc.CCrCheckOutComplete, true},
+		{cc, svc.MemberTypeStandard, svc.CCrCheckInComplete, svc.CCStageSchedule, false, 0, false},
+		{cc, svc.MemberTypeStandard, svc.CCrInit, svc.CCStageCheckOut, false, 0, false},
+		{cc, svc.MemberTypeTag, svc.CCrCheckInComplete, svc.CCStageCheckOut, false, svc.CCrCheckOutComplete, true},
+		{cc, svc.MemberTypeGuardian, svc.CCrCheckInComplete, svc.CCStageSchedule, false, svc.CCrScheduleComplete, true},
+		{cc, svc.MemberTypeGuardian, svc.CCrScheduleComplete, svc.CCStageCheckOut, true, svc.CCrFailed, true},
+		{cc, svc.MemberTypeGuardian, svc.CCrCheckInComplete, svc.CCStageCheckOut, false, 0, false},
+		{cc, svc.MemberTypeGuardian, svc.CCrCheckOutComplete, svc.CCStageCheckIn, false, 0, false},
+		{checkIn, svc.MemberTypeTag, svc.CCrInit, svc.CCStageCheckIn, false, svc.CCrCheckInComplete, true},
+		{checkIn, svc.MemberTypeStandard, svc.CCrCheckInComplete, svc.CCStageCheckOut, false, 0, false},
+		{checkIn, svc.MemberTypeGuardian, svc.CCrCheckInComplete, svc.CCStageSchedule, false, 0, false},
+	}
+	for _, tc := range testCases {
+		to, err := tc.workflow.Next(tc.mType, tc.from, tc.stage, tc.failed)
+		if !tc.valid {
+			var tErr *svc.InvalidTransitionError
+			assert.True(t, errors.As(err, &tErr), "%v %v %v %v", tc.workflow.Type, tc.mType, tc.from, tc.stage)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, tc.to, to, "%v %v %v %v", tc.workflow.Type, tc.mType, tc.from, tc.stage)
+	}
+
+	from, err := cc.From(svc.MemberTypeGuardian, svc.CCStageCheckOut)
+	assert.Nil(t, err)
+	assert.Equal(t, svc.CCrScheduleComplete, from)
+	_, err = checkIn.From(svc.MemberTypeStandard, svc.CCStageCheckOut)
+	assert.NotNil(t, err)
+
+	stage, ok := cc.StageAfter(svc.MemberTypeTag, svc.CCrCheckInComplete)
+	assert.True(t, ok)
+	assert.Equal(t, svc.CCStageCheckOut, stage)
+	_, ok = checkIn.StageAfter(svc.MemberTypeTag, svc.CCrCheckInComplete)
+	assert.False(t, ok)
+
+	assert.ElementsMatch(t, []int{int(svc.CCrCheckOutComplete), int(svc.CCrFailed)}, cc.FinalStatuses())
+	assert.ElementsMatch(t, []int{int(svc.CCrCheckInComplete), int(svc.CCrFailed)}, checkIn.FinalStatuses())
+
+	_, err = svc.GetCCWorkflow("unknown")
+	assert.True(t, errors.Is(err, svc.ErrUnknownWorkflow))
+}
+
+func TestCCScanInvalidTransition(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "CC Workflow Test"
+	instForm.WorkflowType = string(svc.WorkflowTypeCheckIn)
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	res, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Workflow"})
+	assert.Nil(t, err)
+	memberID := res.InsertedID.(primitive.ObjectID).Hex()
+	useTestDevice(instID)
+	postCCSync(t, getSyncRequestMember(instID, memberID))
+
+	// The Check-In Workflow has no check-out
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureNormal, getMemberUniqueID(memberID, "checkin")))
+	assert.Equal(t, http.StatusMethodNotAllowed, postGateKeeperScan(testTemperatureNormal, getMemberUniqueID(memberID, "checkout")))
+
+	ccRecord := svc.CCRecord{}
+	params := svc.GetCCRecordParams{MemberTagID: memberID, Status: -1}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecord(ctx, &params).Decode(&ccRecord))
+	assert.Equal(t, svc.CCrCheckInComplete, ccRecord.Status)
+}
+
+func TestTagScanWithoutNextStage(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormTagTest
+	instForm.Name = "Tag Workflow Test"
+	instForm.Identifier = "TWFT"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	tagForm := tagFormTagTest
+	tagForm.InstID = instID
+	res, err = testCCServer.Stores.Tags.CreateTag(ctx, tagForm)
+	assert.Nil(t, err)
+	tag := svc.Tag{}
+	assert.Nil(t, testCCServer.Stores.Tags.GetTagByID(ctx, res.InsertedID.(primitive.ObjectID).Hex()).Decode(&tag))
+	useTestDevice(instID)
+
+	// A Tag Record in a Status only Guardian scans leave is not final, but no Tag scan is allowed of it
+	res, err = testCCServer.Stores.CCRecords.CreateCCRecord(ctx, instID, svc.CreateCCRecordData{Tag: &tag})
+	assert.Nil(t, err)
+	ccRecord := svc.CCRecord{}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecordByID(ctx, res.InsertedID.(primitive.ObjectID).Hex()).Decode(&ccRecord))
+	scheduled := ccRecord
+	scheduled.Status = svc.CCrScheduleComplete
+	scheduled.Version++
+	_, err = testCCServer.Stores.CCRecords.ReplaceCCRecord(ctx, ccRecord, scheduled)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, postGateKeeperScan(testTemperatureNormal, getTagUniqueID(instForm.Identifier, tag.TagString, "")))
+	unchanged := svc.CCRecord{}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecordByID(ctx, ccRecord.ID.Hex()).Decode(&unchanged))
+	assert.Equal(t, scheduled.Version, unchanged.Version)
+	assert.Equal(t, svc.CCrScheduleComplete, unchanged.Status)
+}
+
+func TestScheduleFromWorkflow(t *testing.T) {
+	ctx := context.TODO()
+	// A Workflow where Guardians schedule before checking in
+	const scheduleFirst svc.WorkflowType = "schedule_first"
+	svc.CCWorkflows[scheduleFirst] = svc.CCWorkflow{
+		Type: scheduleFirst,
+		Transitions: []svc.CCTransition{
+			{From: svc.CCrInit, Stage: svc.CCStageSchedule, To: svc.CCrScheduleComplete, MemberTypes: []svc.MemberType{svc.MemberTypeGuardian}},
+		},
+	}
+	defer delete(svc.CCWorkflows, scheduleFirst)
+
+	instForm := instFormMemberTest
+	instForm.Name = "Schedule Workflow Test"
+	instForm.MemberType = string(svc.MemberTypeGuardian)
+	instForm.WorkflowType = string(scheduleFirst)
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	ward := svc.Ward{ID: primitive.NewObjectID(), FirstName: "Early"}
+	_, err = testCCServer.Stores.Families.CreateFamily(ctx, svc.FamilyRegForm{InstID: instID},
+		svc.MemberInFamilyRegForm{FirstName: "Scheduler", PhoneNum: getUniquePhoneNum()}, []svc.Ward{ward}, nil)
+	assert.Nil(t, err)
+	res, err = testCCServer.Stores.CCRecords.CreateCCRecord(ctx, instID, svc.CreateCCRecordData{Ward: &ward})
+	assert.Nil(t, err)
+
+	// Records are looked up in the Status the Workflow schedules from
+	postScheduleCheckOut(t, instID, svc.SchedulePostingForm{WardIDs: []string{ward.ID.Hex()}, TimeStamp: int(time.Now().Unix())})
+	ccRecord := svc.CCRecord{}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecordByID(ctx, res.InsertedID.(primitive.ObjectID).Hex()).Decode(&ccRecord))
+	assert.Equal(t, svc.CCrScheduleComplete, ccRecord.Status)
+}
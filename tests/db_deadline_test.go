@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// hungConfigStore - a Config Store whose DB never answers, or is unreachable
+type hungConfigStore struct {
+	svc.ConfigStore
+	unreachable bool
+}
+
+func (s hungConfigStore) GetManyConfigs(ctx context.Context) (svc.Cursor, error) {
+	if s.unreachable {
+		return nil, mongo.ErrClientDisconnected
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDBErrorClassification(t *testing.T) {
+	assert.True(t, svc.IsTimeoutErr(context.DeadlineExceeded))
+	assert.True(t, svc.IsTimeoutErr(fmt.Errorf("find - %w", context.DeadlineExceeded)))
+	assert.False(t, svc.IsTimeoutErr(mongo.ErrNoDocuments))
+
+	assert.True(t, svc.IsUnavailableErr(context.Canceled))
+	assert.True(t, svc.IsUnavailableErr(mongo.ErrClientDisconnected))
+	assert.True(t, svc.IsUnavailableErr(errors.New("server selection error: server selection timeout")))
+	assert.False(t, svc.IsUnavailableErr(mongo.ErrNoDocuments))
+	assert.False(t, svc.IsUnavailableErr(nil))
+}
+
+func TestDBDeadline(t *testing.T) {
+	superAdminToken := getTestToken(controllers.SessionRoleSuperAdmin, "")
+	configs := testCCServer.Stores.Configs
+	timeoutSeconds := testCCServer.Config.DBConf.TimeoutSeconds
+	defer func() {
+		testCCServer.Stores.Configs = configs
+		testCCServer.Config.DBConf.TimeoutSeconds = timeoutSeconds
+	}()
+	testCCServer.Config.DBConf.TimeoutSeconds = 1
+
+	// Hung DB is given up on once the deadline passes
+	testCCServer.Stores.Configs = hungConfigStore{ConfigStore: configs}
+	assert.Equal(t, http.StatusGatewayTimeout, getWithToken("/api/configs", superAdminToken))
+
+	// Unreachable DB
+	testCCServer.Stores.Configs = hungConfigStore{ConfigStore: configs, unreachable: true}
+	assert.Equal(t, http.StatusServiceUnavailable, getWithToken("/api/configs", superAdminToken))
+
+	testCCServer.Stores.Configs = configs
+	assert.Equal(t, http.StatusOK, getWithToken("/api/configs", superAdminToken))
+}
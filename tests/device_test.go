@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type deviceResponse struct {
+	Data struct {
+		ID     string `json:"_id"`
+		APIKey string `json:"api_key"`
+	} `json:"data"`
+}
+
+func TestDeviceRegistry(t *testing.T) {
+	d := initTestInstScope(t)
+	adminToken := getTestToken(controllers.SessionRoleAdmin, d.InstID)
+	deviceID := "device_test_" + primitive.NewObjectID().Hex()
+	payload := signTestScanPayload(d.MemberID,
+		strings.Join([]string{d.MemberID, "checkin", strconv.FormatInt(time.Now().Unix()*1000, 10)}, "|"))
+
+	// Unregistered
+	assert.Equal(t, http.StatusUnauthorized, postDeviceScan(deviceID, "", payload))
+
+	// Register
+	var created deviceResponse
+	w := sendWithTokenRecorded(scopeTestCase{"POST", "/api/device", `{"device_id":"` + deviceID + `","institution_id":"` + d.InstID + `","location":"Front Gate"}`}, adminToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.NotEmpty(t, created.Data.APIKey)
+	w = sendWithTokenRecorded(scopeTestCase{"POST", "/api/device", `{"device_id":"` + deviceID + `","institution_id":"` + d.InstID + `"}`}, adminToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Wrong Key
+	assert.Equal(t, http.StatusUnauthorized, postDeviceScan(deviceID, "wrong_key", payload))
+
+	// Registered & enabled
+	assert.NotEqual(t, http.StatusUnauthorized, postDeviceScan(deviceID, created.Data.APIKey, payload))
+	var device svc.Device
+	testCCServer.Stores.Devices.GetDeviceByDeviceID(context.TODO(), deviceID).Decode(&device)
+	assert.False(t, device.LastSeenAt.IsZero())
+	assert.NotEqual(t, created.Data.APIKey, device.APIKeyHash)
+
+	// Disabled
+	w = sendWithTokenRecorded(scopeTestCase{"PUT", "/api/device/" + created.Data.ID, `{"location":"Front Gate","enabled":false}`}, adminToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusForbidden, postDeviceScan(deviceID, created.Data.APIKey, payload))
+
+	// Rotated Key replaces the old one
+	sendWithTokenRecorded(scopeTestCase{"PUT", "/api/device/" + created.Data.ID, `{"enabled":true}`}, adminToken)
+	var rotated deviceResponse
+	w = sendWithTokenRecorded(scopeTestCase{"POST", "/api/device/" + created.Data.ID + "/rotate-key", ""}, adminToken)
+	json.Unmarshal(w.Body.Bytes(), &rotated)
+	assert.Equal(t, http.StatusUnauthorized, postDeviceScan(deviceID, created.Data.APIKey, payload))
+	assert.NotEqual(t, http.StatusUnauthorized, postDeviceScan(deviceID, rotated.Data.APIKey, payload))
+
+	// Bound to its Institution
+	other := initTestInstScope(t)
+	otherPayload := signTestScanPayload(other.MemberID,
+		strings.Join([]string{other.MemberID, "checkin", strconv.FormatInt(time.Now().Unix()*1000, 10)}, "|"))
+	assert.Equal(t, http.StatusForbidden, postDeviceScan(deviceID, rotated.Data.APIKey, otherPayload))
+
+	// Delete
+	w = sendWithTokenRecorded(scopeTestCase{"DELETE", "/api/device/" + created.Data.ID, ""}, adminToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, postDeviceScan(deviceID, rotated.Data.APIKey, payload))
+}
+
+func postDeviceScan(deviceID string, apiKey string, payload string) int {
+	data := makeGateKeeperPost(testTemperatureNormal, deviceID, payload)
+	req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add(controllers.DeviceKeyHeader, apiKey)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	return w.Code
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestExclusionPolicy(t *testing.T) {
+	now := time.Now()
+	policy := svc.ScreeningPolicy{Threshold: 99.2, Unit: svc.TemperatureUnitF}
+	assert.False(t, policy.Excludes())
+	policy.ExclusionHours = 24
+	assert.Nil(t, policy.Validate())
+	assert.Equal(t, now.Add(24*time.Hour), *policy.ExclusionExpiry(now))
+	policy.ExclusionHours, policy.ExcludeUntilCleared = 0, true
+	assert.Nil(t, policy.Validate())
+	assert.Nil(t, policy.ExclusionExpiry(now))
+
+	for _, bad := range []svc.ScreeningPolicy{
+		{Threshold: 99.2, Unit: svc.TemperatureUnitF, ExclusionHours: -1},
+		{Threshold: 99.2, Unit: svc.TemperatureUnitF, ExclusionHours: 24, ExcludeUntilCleared: true},
+	} {
+		assert.NotNil(t, bad.Validate(), bad)
+	}
+
+	expired := svc.Exclusion{ExpiresAt: &now}
+	assert.False(t, expired.IsActive(now))
+	assert.True(t, svc.Exclusion{}.IsActive(now))
+	assert.False(t, svc.Exclusion{ClearedAt: &now}.IsActive(now))
+}
+
+func TestExclusionScan(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "Exclusion Test"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	useTestDevice(instID)
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"PUT", "/api/screening-policy/" + instID, `{"threshold": 99.2, "unit": "F", "exclusion_hours": 24}`}, token))
+
+	res, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Exclusion"})
+	assert.Nil(t, err)
+	memberID := res.InsertedID.(primitive.ObjectID).Hex()
+	sync := func() (svc.CCRecord, []svc.Exclusion) {
+		body, _ := json.Marshal(getSyncRequestMember(instID, memberID))
+		req, _ := http.NewRequest("POST", "/api/cc-record/sync", strings.NewReader(string(body)))
+		req.Header.Add("Authorization", "Bearer "+getTestToken(controllers.SessionRoleMobile, instID))
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var syncRes struct {
+			Data       svc.CCRecord    `json:"data"`
+			Exclusions []svc.Exclusion `json:"exclusions"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &syncRes))
+		return syncRes.Data, syncRes.Exclusions
+	}
+	getExclusions := func() []svc.Exclusion {
+		w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/exclusions?active=true&instID=" + instID, ""}, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Data []svc.Exclusion `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	// Failing a Record excludes the Member for the policy's period
+	failed, _ := sync()
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureHigh, getMemberUniqueID(memberID, "checkin")))
+	exclusions := getExclusions()
+	if !assert.Len(t, exclusions, 1) {
+		return
+	}
+	exclusion := exclusions[0]
+	assert.Equal(t, memberID, exclusion.SubjectID)
+	assert.Equal(t, failed.ID.Hex(), exclusion.CCRecordID)
+	assert.Equal(t, testTemperatureHigh, exclusion.Temperature)
+	if assert.NotNil(t, exclusion.ExpiresAt) {
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), *exclusion.ExpiresAt, time.Minute)
+	}
+
+	// Syncing makes no new Record, and Check-Ins are denied
+	ccRecord, synced := sync()
+	assert.Equal(t, failed.ID, ccRecord.ID)
+	assert.Equal(t, svc.CCrFailed, ccRecord.Status)
+	assert.Equal(t, []svc.Exclusion{exclusion}, synced)
+	data := makeGateKeeperPost(testTemperatureNormal, testDeviceIMEI, getMemberUniqueID(memberID, "checkin"))
+	req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var scanRes struct {
+		ScanResponse
+		ReasonCode string `json:"reason_code"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &scanRes))
+	assert.False(t, scanRes.Success)
+	assert.Equal(t, "excluded", scanRes.ReasonCode)
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/scan-events?decision=excluded&instID=" + instID, ""}, token)
+	assert.Contains(t, w.Body.String(), memberID)
+
+	// Nurses clear Exclusions, once; Front Desk does not
+	clearURL := "/api/exclusion/clear/" + exclusion.ID.Hex()
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", clearURL, ""}, getTestToken(controllers.SessionRoleFrontDesk, instID)))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", clearURL, ""}, getTestToken(controllers.SessionRoleNurse, instID)))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", clearURL, ""}, token))
+	assert.Empty(t, getExclusions())
+
+	// Once cleared, the Member checks in again
+	ccRecord, synced = sync()
+	assert.NotEqual(t, failed.ID, ccRecord.ID)
+	assert.Empty(t, synced)
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureNormal, getMemberUniqueID(memberID, "checkin")))
+	params := svc.GetCCRecordParams{MemberTagID: memberID, Status: -1, GetLatest: true}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecord(ctx, &params).Decode(&ccRecord))
+	assert.Equal(t, svc.CCrCheckInComplete, ccRecord.Status)
+}
+
+func TestExclusionList(t *testing.T) {
+	ctx := context.TODO()
+	instID := primitive.NewObjectID().Hex()
+	now := time.Now().Truncate(time.Millisecond)
+	for index, subjectID := range []string{"first", "second", "third"} {
+		_, err := testCCServer.Stores.Exclusions.CreateExclusion(ctx, svc.Exclusion{
+			InstID: instID, SubjectID: subjectID, CreatedAt: now.Add(time.Duration(index) * time.Minute),
+		})
+		assert.Nil(t, err)
+	}
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+	listExclusions := func(query string, token string) (int, []string, string) {
+		w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/exclusions?" + query, ""}, token)
+		var body struct {
+			Data       []svc.Exclusion `json:"data"`
+			NextCursor string          `json:"next_cursor"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		subjectIDs := []string{}
+		for _, e := range body.Data {
+			subjectIDs = append(subjectIDs, e.SubjectID)
+		}
+		return w.Code, subjectIDs, body.NextCursor
+	}
+
+	// Paged, latest first unless sorted otherwise
+	code, subjectIDs, next := listExclusions("limit=2&instID="+instID, token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"third", "second"}, subjectIDs)
+	_, subjectIDs, next = listExclusions("limit=2&instID="+instID+"&cursor="+next, token)
+	assert.Equal(t, []string{"first"}, subjectIDs)
+	assert.Empty(t, next)
+	_, subjectIDs, _ = listExclusions("sort=created_at&instID="+instID, token)
+	assert.Equal(t, []string{"first", "second", "third"}, subjectIDs)
+	code, _, _ = listExclusions("sort=subject_id&instID="+instID, token)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Admins only see those of their own Institution, even without "instID"
+	code, subjectIDs, _ = listExclusions("", token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"third", "second", "first"}, subjectIDs)
+	code, subjectIDs, _ = listExclusions("", getTestToken(controllers.SessionRoleAdmin, primitive.NewObjectID().Hex()))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, subjectIDs)
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"cloudminds.com/harix/cc-server/controllers"
 	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -55,7 +56,7 @@ var familyFormFamilyTest = svc.FamilyRegForm{
 func initTestFamilyCC(t *testing.T) {
 	// Create Institution for Tag-CC Test
 	instToCreate := instFormFamilyTest
-	iRes, err := svc.CreateInst(instToCreate)
+	iRes, err := testCCServer.Stores.Insts.CreateInst(context.TODO(), instToCreate)
 	if err != nil {
 		t.Logf("Error when creating Testing Institution - %v\n", err)
 	}
@@ -70,7 +71,7 @@ func initTestFamilyCC(t *testing.T) {
 		wardsToCreate = append(wardsToCreate, newWard)
 	}
 
-	fRes, err := svc.CreateFamily(familyFormFamilyTest, guardianFormFamilyTest, wardsToCreate, []svc.Vehicle{})
+	fRes, err := testCCServer.Stores.Families.CreateFamily(context.TODO(), familyFormFamilyTest, guardianFormFamilyTest, wardsToCreate, []svc.Vehicle{})
 	if err != nil {
 		t.Logf("Error when creating Testing Family - %v\n", err)
 	}
@@ -89,7 +90,7 @@ func initTestFamilyCC(t *testing.T) {
 		FirstName:  guardianFormFamilyTest.FirstName,
 		LastName:   guardianFormFamilyTest.LastName,
 	}
-	mRes, err := svc.CreateMember(mRegForm)
+	mRes, err := testCCServer.Stores.Members.CreateMember(context.TODO(), mRegForm)
 
 	if err != nil {
 		t.Logf("Error when creating Testing Member - %v\n", err)
@@ -98,7 +99,7 @@ func initTestFamilyCC(t *testing.T) {
 	// Set Contact Member ID
 	insertedMemberID := mRes.InsertedID.(primitive.ObjectID).Hex()
 
-	_, err = svc.SetFamilyContactMemberID(insertedFamilyID, insertedMemberID)
+	_, err = testCCServer.Stores.Families.SetFamilyContactMemberID(context.TODO(), insertedFamilyID, insertedMemberID)
 	if err != nil {
 		t.Logf("Error when Setting ContactID to testing Family - %v\n", err)
 	}
@@ -109,20 +110,21 @@ func TestFamilyCCScan(t *testing.T) {
 
 	// Get Institution
 	var inst svc.Institution
-	if err := svc.GetInstByName(instName).Decode(&inst); err != nil {
+	if err := testCCServer.Stores.Insts.GetInstByName(context.TODO(), instName).Decode(&inst); err != nil {
 		if err == mongo.ErrNoDocuments {
 			initTestFamilyCC(t)
-			svc.GetInstByName(instName).Decode(&inst)
+			testCCServer.Stores.Insts.GetInstByName(context.TODO(), instName).Decode(&inst)
 		} else {
 			panic(err)
 		}
 	}
 
 	instID := inst.ID.Hex()
+	useTestDevice(instID)
 
 	var fParams svc.GetFamilyParams
 	fParams.InstID = instID
-	cursor, _ := svc.GetManyFamilies(&fParams)
+	cursor, _ := testCCServer.Stores.Families.GetManyFamilies(context.TODO(), &fParams)
 
 	var families []svc.Family
 	if err := cursor.All(context.TODO(), &families); err != nil {
@@ -142,7 +144,7 @@ func TestFamilyCCScan(t *testing.T) {
 	postCCScanTestCase(t, data, getExpectedResponseCaseTempNormal(stage))
 	checkCCRecordFamily(t, getExpectedRecordList(family, []string{wardID}, svc.CCrCheckInComplete))
 	// Mock Schedule Check-out
-	postScheduleCheckOut(t, getScheduleCheckOutRequest([]string{wardID}))
+	postScheduleCheckOut(t, instID, getScheduleCheckOutRequest([]string{wardID}))
 	checkCCRecordFamily(t, getExpectedRecordList(family, []string{wardID}, svc.CCrScheduleComplete))
 	// Test Scan-2 - <guardianID>|<wardID>|checkout|single|<timestamp>
 	stage = "checkout"
@@ -160,7 +162,7 @@ func TestFamilyCCScan(t *testing.T) {
 	postCCScanTestCase(t, data, getExpectedResponseCaseTempNormal(stage))
 	checkCCRecordFamily(t, getExpectedRecordList(family, wardIDList, svc.CCrCheckInComplete))
 	// Mock Schedule Check-out
-	postScheduleCheckOut(t, getScheduleCheckOutRequest(wardIDList))
+	postScheduleCheckOut(t, instID, getScheduleCheckOutRequest(wardIDList))
 	checkCCRecordFamily(t, getExpectedRecordList(family, wardIDList, svc.CCrScheduleComplete))
 	// Test Scan-4 - <guardianID>|<wardID>|checkout|all|<timestamp>
 	stage = "checkout"
@@ -197,7 +199,7 @@ func checkCCRecordFamily(t *testing.T, expectedRecordList []svc.CCRecord) {
 			Status:    -1, // set Status to "-1" to disable status filter
 			GetLatest: true,
 		}
-		if err := svc.GetCCRecord(&ccParams).Decode(&ccRecord); err != nil {
+		if err := testCCServer.Stores.CCRecords.GetCCRecord(context.TODO(), &ccParams).Decode(&ccRecord); err != nil {
 			panic(err)
 		}
 		assert.Equal(t, expectedRecord.Status, ccRecord.Status)
@@ -228,11 +230,11 @@ func checkCCRecordFamily(t *testing.T, expectedRecordList []svc.CCRecord) {
 
 }
 
-func postScheduleCheckOut(t *testing.T, scheduleRequest svc.SchedulePostingForm) {
+func postScheduleCheckOut(t *testing.T, instID string, scheduleRequest svc.SchedulePostingForm) {
 
 	postRequestString, _ := json.Marshal(scheduleRequest)
 	req, _ := http.NewRequest("POST", "/api/cc-record/schedule", strings.NewReader(string(postRequestString)))
-	req.Header.Add("Authorization", "Bearer "+testCCServer.Config.DebugTokenL.Mobile)
+	req.Header.Add("Authorization", "Bearer "+getTestToken(controllers.SessionRoleMobile, instID))
 	w := httptest.NewRecorder()
 	testRouter.ServeHTTP(w, req)
 
@@ -283,12 +285,12 @@ func getExpectedRecordList(family svc.Family, wardIDList []string, status svc.CC
 
 func getFamilyUniqueIDSingle(guardianID string, wardID string, stage string) string {
 	timestamp := time.Now().Unix() * 1000
-	return strings.Join([]string{guardianID, wardID, stage, "single", strconv.FormatInt(timestamp, 10)}, "|")
+	return signTestScanPayload(guardianID, strings.Join([]string{guardianID, wardID, stage, "single", strconv.FormatInt(timestamp, 10)}, "|"))
 }
 
 func getFamilyUniqueIDAll(guardianID string, stage string) string {
 	timestamp := time.Now().Unix() * 1000
-	return strings.Join([]string{guardianID, stage, "all", strconv.FormatInt(timestamp, 10)}, "|")
+	return signTestScanPayload(guardianID, strings.Join([]string{guardianID, stage, "all", strconv.FormatInt(timestamp, 10)}, "|"))
 }
 
 func getSyncRequestFamily(instID string, wardIDs []string) svc.CCSyncPostingForm {
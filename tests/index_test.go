@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIndexRegistry(t *testing.T) {
+	names := map[string]bool{}
+	for _, spec := range svc.Indexes {
+		name := spec.Collection + "." + spec.Name
+		assert.False(t, names[name], "%v declared twice", name)
+		assert.NotEmpty(t, spec.Keys, "%v has no keys", name)
+		names[name] = true
+	}
+	for _, name := range []string{"members.phone_num_unique", "tags.institution_id_tag_string_unique",
+		"institutions.identifier_unique", "admins.fras_username_unique"} {
+		assert.True(t, names[name], "%v not declared", name)
+	}
+}
+
+func TestUniqueIndexes(t *testing.T) {
+	stores := svc.NewMemStores()
+	ctx := context.TODO()
+	instID := primitive.NewObjectID().Hex()
+	otherInstID := primitive.NewObjectID().Hex()
+
+	// Phone #s of Members
+	res, err := stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: "5550000001"})
+	assert.Nil(t, err)
+	memberID := res.InsertedID.(primitive.ObjectID).Hex()
+	_, err = stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: otherInstID, PhoneNum: "5550000001"})
+	assert.True(t, svc.IsDuplicateKeyErr(err))
+	_, err = stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: "5550000002"})
+	assert.Nil(t, err)
+	_, err = stores.Members.UpdateMemberByID(ctx, svc.MemberEditForm{PhoneNum: "5550000002"}, memberID)
+	assert.True(t, svc.IsDuplicateKeyErr(err))
+	_, err = stores.Members.UpdateMemberByID(ctx, svc.MemberEditForm{PhoneNum: "5550000001", FirstName: "Same"}, memberID)
+	assert.Nil(t, err)
+
+	// TagStrings are unique within an Institution only
+	_, err = stores.Tags.CreateTag(ctx, svc.TagRegForm{InstID: instID, TagString: "1234"})
+	assert.Nil(t, err)
+	_, err = stores.Tags.CreateTag(ctx, svc.TagRegForm{InstID: instID, TagString: "1234"})
+	assert.True(t, svc.IsDuplicateKeyErr(err))
+	_, err = stores.Tags.CreateTag(ctx, svc.TagRegForm{InstID: otherInstID, TagString: "1234"})
+	assert.Nil(t, err)
+
+	// Identifiers of Institutions, when given
+	_, err = stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "No Identifier"})
+	assert.Nil(t, err)
+	_, err = stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "No Identifier Either"})
+	assert.Nil(t, err)
+	_, err = stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "Tagged", Identifier: "IDXT"})
+	assert.Nil(t, err)
+	_, err = stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "Tagged Again", Identifier: "IDXT"})
+	assert.True(t, svc.IsDuplicateKeyErr(err))
+
+	// FRAS usernames of Admins
+	_, err = stores.Admins.CreateAdmin(ctx, svc.AdminRegForm{InstID: instID, FrasUsername: "index_test"})
+	assert.Nil(t, err)
+	_, err = stores.Admins.CreateAdmin(ctx, svc.AdminRegForm{InstID: otherInstID, FrasUsername: "index_test"})
+	assert.True(t, svc.IsDuplicateKeyErr(err))
+}
+
+func TestDuplicateRegistrationRejected(t *testing.T) {
+	res, err := testCCServer.Stores.Insts.CreateInst(context.TODO(), svc.InstitutionForm{Name: "Index Test"})
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	superAdminToken := getTestToken(controllers.SessionRoleSuperAdmin, "")
+
+	body := `{"fras_username":"index_test_admin","institution_id":"` + instID + `"}`
+	assert.Equal(t, http.StatusCreated, sendWithToken(scopeTestCase{"POST", "/api/admin/register", body}, superAdminToken))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", "/api/admin/register", body}, superAdminToken))
+}
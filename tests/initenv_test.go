@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,8 +15,8 @@ func TestMain(m *testing.M) {
 
 	testCCServer = controllers.InitServer()
 	testCCServer.InitConfig(appName, true)
-	// Database
-	testCCServer.Connect()
+	// Database - kept in memory, so that tests need no running MongoDB
+	testCCServer.Stores = svc.NewMemStores()
 	testCCServer.ReloadConfigFromDB()
 
 	testCCServer.InitValidator()
@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Data is created fresh on every run, with unique Phone #s
+var instFormScopeTest = svc.InstitutionForm{
+	Type:         string(svc.InstTypeSchool),
+	MemberType:   string(svc.MemberTypeGuardian),
+	WorkflowType: string(svc.WorkflowTypeCC),
+	Name:         "INST_SCOPE_TEST",
+	Address:      "001 Test Drive",
+	State:        "AZ",
+	ZipCode:      "09999",
+}
+
+// scopeTestData - IDs of entities owned by the Institution under attack
+type scopeTestData struct {
+	InstID       string
+	MemberID     string
+	FamilyID     string
+	WardID       string
+	VehicleID    string
+	TagID        string
+	CCRecordID   string
+	RegCodeID    string
+	FrasUsername string
+}
+
+type scopeTestCase struct {
+	Method string
+	URL    string
+	Body   string
+}
+
+func initTestInstScope(t *testing.T) scopeTestData {
+	var data scopeTestData
+
+	iRes, err := testCCServer.Stores.Insts.CreateInst(context.TODO(), instFormScopeTest)
+	if err != nil {
+		panic(err)
+	}
+	data.InstID = iRes.InsertedID.(primitive.ObjectID).Hex()
+
+	ward := svc.GetNewWard(svc.WardForm{FirstName: "Scope", LastName: "Ward"})
+	vehicle := svc.GetNewVehicle(svc.VehicleForm{PlateNum: "SCOPE01"})
+	fRegForm := svc.FamilyRegForm{InstID: data.InstID}
+	cMember := svc.MemberInFamilyRegForm{PhoneNum: getUniquePhoneNum(), FirstName: "Scope", LastName: "Guardian"}
+	fRes, err := testCCServer.Stores.Families.CreateFamily(context.TODO(), fRegForm, cMember, []svc.Ward{ward}, []svc.Vehicle{vehicle})
+	if err != nil {
+		panic(err)
+	}
+	data.FamilyID = fRes.InsertedID.(primitive.ObjectID).Hex()
+	data.WardID = ward.ID.Hex()
+	data.VehicleID = vehicle.ID.Hex()
+
+	mRes, err := testCCServer.Stores.Members.CreateMember(context.TODO(), svc.MemberRegForm{
+		InstID:     data.InstID,
+		FamilyInfo: &svc.FamilyInfo{ID: data.FamilyID, Relation: "Mother"},
+		PhoneNum:   cMember.PhoneNum,
+		FirstName:  cMember.FirstName,
+		LastName:   cMember.LastName,
+	})
+	if err != nil {
+		panic(err)
+	}
+	data.MemberID = mRes.InsertedID.(primitive.ObjectID).Hex()
+
+	tRes, err := testCCServer.Stores.Tags.CreateTag(context.TODO(), svc.TagRegForm{InstID: data.InstID, TagString: "SCOPE01"})
+	if err != nil {
+		panic(err)
+	}
+	data.TagID = tRes.InsertedID.(primitive.ObjectID).Hex()
+
+	cRes, err := testCCServer.Stores.CCRecords.CreateCCRecord(context.TODO(), data.InstID, svc.CreateCCRecordData{Ward: &ward})
+	if err != nil {
+		panic(err)
+	}
+	data.CCRecordID = cRes.InsertedID.(primitive.ObjectID).Hex()
+
+	rRes, err := testCCServer.Stores.RegCodes.CreateRegCodeByMemberID(context.TODO(), data.MemberID, time.Hour)
+	if err != nil {
+		panic(err)
+	}
+	data.RegCodeID = rRes.InsertedID.(primitive.ObjectID).Hex()
+
+	data.FrasUsername = "inst_scope_test_admin_" + data.InstID
+	if _, err := testCCServer.Stores.Admins.CreateAdmin(context.TODO(), svc.AdminRegForm{FrasUsername: data.FrasUsername, InstID: data.InstID}); err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestInstScopeRejectsCrossInstAccess(t *testing.T) {
+	d := initTestInstScope(t)
+	// Admin of another Institution
+	token := getTestToken(controllers.SessionRoleAdmin, primitive.NewObjectID().Hex())
+	mobileToken := getTestToken(controllers.SessionRoleMobile, primitive.NewObjectID().Hex())
+
+	adminCases := map[string][]scopeTestCase{
+		"Admin": {
+			{"GET", "/api/admin?frasUsername=" + d.FrasUsername, ""},
+		},
+		"CC-Records": {
+			{"GET", "/api/cc-records?instID=" + d.InstID, ""},
+			{"DELETE", "/api/cc-record/" + d.CCRecordID, ""},
+		},
+		"Tag": {
+			{"GET", "/api/tags?instID=" + d.InstID, ""},
+			{"GET", "/api/tag?instID=" + d.InstID + "&tagString=SCOPE01", ""},
+			{"POST", "/api/tag", `{"institution_id":"` + d.InstID + `","tag_string":"SCOPE02"}`},
+			{"PUT", "/api/tag/" + d.TagID, `{"first_name":"Hacked"}`},
+			{"DELETE", "/api/tag/" + d.TagID, ""},
+		},
+		"Member": {
+			{"GET", "/api/members?instID=" + d.InstID, ""},
+			{"POST", "/api/member", `{"institution_id":"` + d.InstID + `","phone_num":"` + getUniquePhoneNum() + `"}`},
+			{"PUT", "/api/member/" + d.MemberID, `{"phone_num":"` + getUniquePhoneNum() + `"}`},
+			{"DELETE", "/api/member/" + d.MemberID, ""},
+		},
+		"Family": {
+			{"GET", "/api/families?instID=" + d.InstID, ""},
+			{"POST", "/api/family", `{"institution_id":"` + d.InstID + `"}`},
+			{"DELETE", "/api/family/" + d.FamilyID, ""},
+			{"GET", "/api/family?memberID=" + d.MemberID, ""},
+			{"GET", "/api/family?wardID=" + d.WardID, ""},
+			{"GET", "/api/family-with-members?wardID=" + d.WardID, ""},
+			{"GET", "/api/family-with-members/" + d.FamilyID, ""},
+		},
+		"Ward": {
+			{"POST", "/api/ward/add-new?familyID=" + d.FamilyID, `{"first_name":"Hacked"}`},
+			{"PUT", "/api/ward/" + d.WardID, `{"first_name":"Hacked"}`},
+			{"DELETE", "/api/ward/" + d.WardID, ""},
+		},
+		"Vehicle": {
+			{"POST", "/api/vehicle/add-new?familyID=" + d.FamilyID, `{"plate_num":"HACKED"}`},
+			{"PUT", "/api/vehicle/" + d.VehicleID, `{"plate_num":"HACKED"}`},
+			{"DELETE", "/api/vehicle/" + d.VehicleID, ""},
+		},
+		"RegCode": {
+			{"GET", "/api/reg-code?memberID=" + d.MemberID, ""},
+			{"POST", "/api/reg-code/email", `{"id":"` + d.RegCodeID + `"}`},
+			{"POST", "/api/reg-code/sms", `{"id":"` + d.RegCodeID + `"}`},
+			{"POST", "/api/reg-code/regenerate", `{"member_id":"` + d.MemberID + `"}`},
+		},
+		"Survey": {
+			{"GET", "/api/surveys?instID=" + d.InstID, ""},
+		},
+		"Export": {
+			{"GET", "/api/export/cc-records?instID=" + d.InstID, ""},
+			{"GET", "/api/export/members?instID=" + d.InstID, ""},
+			{"GET", "/api/export/families?instID=" + d.InstID, ""},
+			{"GET", "/api/export/wards?instID=" + d.InstID, ""},
+			{"GET", "/api/export/surveys?instID=" + d.InstID, ""},
+		},
+		"Device": {
+			{"GET", "/api/devices?instID=" + d.InstID, ""},
+			{"POST", "/api/device", `{"device_id":"SCOPE_DEVICE","institution_id":"` + d.InstID + `"}`},
+		},
+		"Import": {
+			{"POST", "/api/import/tags?instID=" + d.InstID, ""},
+			{"POST", "/api/import/members?instID=" + d.InstID, ""},
+		},
+		// Super Admin only groups
+		"Institution": {
+			{"GET", "/api/institutions", ""},
+			{"PUT", "/api/institution/" + d.InstID, `{}`},
+			{"DELETE", "/api/institution/" + d.InstID, ""},
+		},
+		"Admin Management": {
+			{"GET", "/api/admins?instID=" + d.InstID, ""},
+			{"POST", "/api/admin/register", `{"institution_id":"` + d.InstID + `"}`},
+		},
+		"Config": {
+			{"GET", "/api/configs", ""},
+			{"POST", "/api/config/reload", ""},
+		},
+	}
+	for group, cases := range adminCases {
+		for _, tc := range cases {
+			assert.Equal(t, http.StatusForbidden, sendWithToken(tc, token), "%v - %v %v", group, tc.Method, tc.URL)
+		}
+	}
+
+	mobileCases := []scopeTestCase{
+		{"POST", "/api/cc-record/sync", `{"institution_id":"` + d.InstID + `","ward_ids":["` + d.WardID + `"]}`},
+		{"POST", "/api/cc-record/schedule", `{"ward_ids":["` + d.WardID + `"],"timestamp":0}`},
+	}
+	for _, tc := range mobileCases {
+		assert.Equal(t, http.StatusForbidden, sendWithToken(tc, mobileToken), "Mobile - %v %v", tc.Method, tc.URL)
+	}
+
+	// Same requests are let through for the Admin of the Institution
+	ownToken := getTestToken(controllers.SessionRoleAdmin, d.InstID)
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"GET", "/api/members?instID=" + d.InstID, ""}, ownToken))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"GET", "/api/family-with-members/" + d.FamilyID, ""}, ownToken))
+}
+
+func getUniquePhoneNum() string {
+	n := time.Now().UnixNano() % 10000000000
+	return fmt.Sprintf("%03d-%03d-%04d", n/10000000, n/10000%1000, n%10000)
+}
+
+func sendWithToken(tc scopeTestCase, token string) int {
+	return sendWithTokenRecorded(tc, token).Code
+}
+
+func sendWithTokenRecorded(tc scopeTestCase, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(tc.Method, tc.URL, strings.NewReader(tc.Body))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type listTagsResponse struct {
+	Data       []svc.Tag `json:"data"`
+	NextCursor string    `json:"next_cursor"`
+}
+
+func TestListPagination(t *testing.T) {
+	ctx := context.TODO()
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "List Test"})
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+
+	for index, name := range []string{"Ann", "Bob", "Abe", "Cal", "Amy"} {
+		group := "Day"
+		if index%2 == 1 {
+			group = "Night"
+		}
+		_, err = testCCServer.Stores.Tags.CreateTag(ctx, svc.TagRegForm{
+			InstID: instID, TagString: fmt.Sprintf("LT%02d", index), FirstName: name, Group: group,
+		})
+		assert.Nil(t, err)
+	}
+
+	listTags := func(query url.Values) (int, listTagsResponse) {
+		query.Set("instID", instID)
+		w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/tags?" + query.Encode(), ""}, token)
+		var resp listTagsResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// Without page parameters, a page of "page_config.default_limit" is listed
+	code, resp := listTags(url.Values{})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Data, 5)
+	assert.Empty(t, resp.NextCursor)
+	pageConf := testCCServer.Config.PageConf
+	testCCServer.Config.PageConf = controllers.PageConfig{DefaultLimit: 3, MaxLimit: 4}
+	code, resp = listTags(url.Values{})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Data, 3)
+	assert.NotEmpty(t, resp.NextCursor)
+	// ... or of "page_config.max_limit", when there is no default
+	testCCServer.Config.PageConf = controllers.PageConfig{MaxLimit: 4}
+	code, resp = listTags(url.Values{})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Data, 4)
+	testCCServer.Config.PageConf = pageConf
+
+	// Paging by 2, sorted by name descending, lists each Tag once in order
+	names := []string{}
+	query := url.Values{"limit": {"2"}, "sort": {"-first_name"}}
+	for pages := 0; pages < 5; pages++ {
+		code, resp = listTags(query)
+		assert.Equal(t, http.StatusOK, code)
+		assert.LessOrEqual(t, len(resp.Data), 2)
+		for _, tag := range resp.Data {
+			names = append(names, tag.FirstName)
+		}
+		if len(resp.NextCursor) == 0 {
+			break
+		}
+		query.Set("cursor", resp.NextCursor)
+	}
+	assert.Equal(t, []string{"Cal", "Bob", "Ann", "Amy", "Abe"}, names)
+
+	// A page is not shifted by inserts before it
+	code, resp = listTags(url.Values{"limit": {"2"}, "sort": {"tag_string"}})
+	assert.Equal(t, http.StatusOK, code)
+	cursor := resp.NextCursor
+	_, err = testCCServer.Stores.Tags.CreateTag(ctx, svc.TagRegForm{InstID: instID, TagString: "LT00A"})
+	assert.Nil(t, err)
+	code, resp = listTags(url.Values{"limit": {"2"}, "sort": {"tag_string"}, "cursor": {cursor}})
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, resp.Data, 2) {
+		assert.Equal(t, "LT02", resp.Data[0].TagString)
+	}
+
+	// Filters
+	code, resp = listTags(url.Values{"group": {"Night"}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Data, 2)
+	code, resp = listTags(url.Values{"name": {"a"}, "group": {"Day"}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Data, 3)
+
+	// Bad page parameters
+	for _, bad := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"many"}},
+		{"sort": {"phone_num"}},
+		{"cursor": {"not-a-cursor"}},
+		{"sort": {"first_name"}, "cursor": {cursor}},
+		{"startDate": {"yesterday"}},
+	} {
+		code, _ = listTags(bad)
+		assert.Equal(t, http.StatusBadRequest, code, "%v", bad)
+	}
+}
+
+func TestListMemberFilters(t *testing.T) {
+	ctx := context.TODO()
+	stores := svc.NewMemStores()
+	instID := primitive.NewObjectID().Hex()
+	for index, name := range []string{"Dee", "Don", "Eve"} {
+		_, err := stores.Members.CreateMember(ctx, svc.MemberRegForm{
+			InstID: instID, PhoneNum: fmt.Sprintf("555000010%v", index), LastName: name, Group: "Staff",
+		})
+		assert.Nil(t, err)
+	}
+
+	listMembers := func(params svc.GetMemberParams) ([]svc.Member, string) {
+		params.InstID = instID
+		cursor, err := stores.Members.GetManyMembers(ctx, &params)
+		assert.Nil(t, err)
+		members := []svc.Member{}
+		next, err := svc.DecodeList(ctx, cursor, &members)
+		assert.Nil(t, err)
+		return members, next
+	}
+
+	members, _ := listMembers(svc.GetMemberParams{NamePrefix: "d"})
+	assert.Len(t, members, 2)
+	assigned := svc.MemberStatus(svc.MAssigned)
+	members, _ = listMembers(svc.GetMemberParams{Status: &assigned, Group: "Staff"})
+	assert.Len(t, members, 3)
+	activated := svc.MemberStatus(svc.MActivated)
+	members, _ = listMembers(svc.GetMemberParams{Status: &activated})
+	assert.Len(t, members, 0)
+
+	members, next := listMembers(svc.GetMemberParams{List: svc.ListParams{Limit: 2, Sort: "last_name"}})
+	assert.Len(t, members, 2)
+	assert.NotEmpty(t, next)
+	members, next = listMembers(svc.GetMemberParams{List: svc.ListParams{Limit: 2, Sort: "last_name", Cursor: next}})
+	if assert.Len(t, members, 1) {
+		assert.Equal(t, "Eve", members[0].LastName)
+	}
+	assert.Empty(t, next)
+}
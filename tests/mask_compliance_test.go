@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMaskPolicyScan(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "Mask Policy Test"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	useTestDevice(instID)
+	newMember := func(group string) string {
+		res, err := testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Mask", Group: group})
+		assert.Nil(t, err)
+		memberID := res.InsertedID.(primitive.ObjectID).Hex()
+		postCCSync(t, getSyncRequestMember(instID, memberID))
+		return memberID
+	}
+	getStatus := func(memberID string) svc.CCRecordStatus {
+		ccRecord := svc.CCRecord{}
+		params := svc.GetCCRecordParams{MemberTagID: memberID, Status: -1}
+		assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecord(ctx, &params).Decode(&ccRecord))
+		return ccRecord.Status
+	}
+	var scanRes struct {
+		ScanResponse
+		Reasons  []svc.ScreeningReason `json:"reasons"`
+		Warnings []svc.ScreeningReason `json:"warnings"`
+	}
+	scan := func(uniqueID string, mask bool) {
+		data := makeGateKeeperPost(testTemperatureNormal, testDeviceIMEI, uniqueID)
+		if mask {
+			data.Set("mask", "true")
+		}
+		req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		scanRes.Reasons, scanRes.Warnings = nil, nil
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &scanRes))
+	}
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+	policyURL := "/api/screening-policy/" + instID
+
+	// Denied Check-Ins are answered with why, and not applied; the Member may scan again with a mask
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"PUT", policyURL, `{"threshold": 99.2, "unit": "F", "mask_policy": "deny"}`}, token))
+	memberA := newMember("A")
+	scan(getMemberUniqueID(memberA, "checkin"), false)
+	assert.False(t, scanRes.Success)
+	assert.Equal(t, []svc.ScreeningReason{svc.ScreeningReasonNoMask}, scanRes.Reasons)
+	assert.Equal(t, svc.CCrInit, getStatus(memberA))
+	scan(getMemberUniqueID(memberA, "checkin"), true)
+	assert.True(t, scanRes.Success)
+	assert.Equal(t, svc.CCrCheckInComplete, getStatus(memberA))
+	// Check-Outs are not screened for masks
+	scan(getMemberUniqueID(memberA, "checkout"), false)
+	assert.True(t, scanRes.Success)
+
+	// Warned Check-Ins are applied
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"PUT", policyURL, `{"threshold": 99.2, "unit": "F", "mask_policy": "warn"}`}, token))
+	memberB := newMember("B")
+	scan(getMemberUniqueID(memberB, "checkin"), false)
+	assert.True(t, scanRes.Success)
+	assert.Equal(t, []svc.ScreeningReason{svc.ScreeningReasonNoMask}, scanRes.Warnings)
+	assert.Equal(t, svc.CCrCheckInComplete, getStatus(memberB))
+
+	w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/scan-events?decision=denied&instID=" + instID, ""}, token)
+	var events struct {
+		Data []svc.ScanEvent `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &events))
+	if assert.Len(t, events.Data, 1) {
+		assert.Equal(t, "A", events.Data[0].Group)
+		assert.Empty(t, events.Data[0].Records)
+	}
+
+	// Denied & applied Check-Ins are reported; Check-Outs are not
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/reports/mask-compliance?instID=" + instID, ""}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report struct {
+		Data svc.MaskComplianceReport `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, svc.MaskComplianceRow{Key: "total", Scans: 3, Masked: 1, Rate: 1.0 / 3}, report.Data.Total)
+	assert.Equal(t, []svc.MaskComplianceRow{
+		{Key: "A", Scans: 2, Masked: 1, Rate: 0.5},
+		{Key: "B", Scans: 1, Masked: 0, Rate: 0},
+	}, report.Data.ByGroup)
+	assert.Equal(t, []svc.MaskComplianceRow{{Key: testDeviceIMEI, Scans: 3, Masked: 1, Rate: 1.0 / 3}}, report.Data.ByDevice)
+	assert.Equal(t, []svc.MaskComplianceRow{{Key: time.Now().UTC().Format("2006-01-02"), Scans: 3, Masked: 1, Rate: 1.0 / 3}}, report.Data.ByDay)
+
+	// Admins only get the report of their own Institution, even without "instID"
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/reports/mask-compliance", ""}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 3, report.Data.Total.Scans)
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/reports/mask-compliance", ""}, getTestToken(controllers.SessionRoleAdmin, primitive.NewObjectID().Hex()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	report.Data = svc.MaskComplianceReport{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 0, report.Data.Total.Scans)
+}
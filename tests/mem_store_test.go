@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMemStores(t *testing.T) {
+	stores := svc.NewMemStores()
+	instID := primitive.NewObjectID().Hex()
+
+	// Not found is reported the way MongoDB does
+	var tag svc.Tag
+	assert.Equal(t, mongo.ErrNoDocuments, stores.Tags.GetTagByID(context.TODO(), primitive.NewObjectID().Hex()).Decode(&tag))
+
+	res, err := stores.Tags.CreateTag(context.TODO(), svc.TagRegForm{InstID: instID, TagString: "1234", FirstName: "Mem"})
+	assert.Nil(t, err)
+	tagID := res.InsertedID.(primitive.ObjectID).Hex()
+	count, err := stores.Tags.CountTag(context.TODO(), svc.CountTagParams{InstID: instID, TagString: "1234"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Decoded Models are copies; changing one does not change what is stored
+	assert.Nil(t, stores.Tags.GetTagByID(context.TODO(), tagID).Decode(&tag))
+	tag.FirstName = "Changed"
+	assert.Nil(t, stores.Tags.GetTagByID(context.TODO(), tagID).Decode(&tag))
+	assert.Equal(t, "Mem", tag.FirstName)
+
+	updateRes, err := stores.Tags.UpdateTagByID(context.TODO(), svc.TagEditForm{FirstName: "Updated"}, tagID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), updateRes.MatchedCount)
+	assert.Equal(t, int64(1), updateRes.ModifiedCount)
+
+	cursor, err := stores.Tags.GetManyTags(context.TODO(), &svc.GetTagParams{InstID: instID})
+	assert.Nil(t, err)
+	var tags []svc.Tag
+	assert.Nil(t, cursor.All(context.TODO(), &tags))
+	assert.Equal(t, 1, len(tags))
+	assert.Equal(t, "Updated", tags[0].FirstName)
+
+	deleteRes, err := stores.Tags.DeleteTagByID(context.TODO(), tagID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), deleteRes.DeletedCount)
+	assert.Equal(t, mongo.ErrNoDocuments, stores.Tags.GetTagByID(context.TODO(), tagID).Decode(&tag))
+}
@@ -38,7 +38,7 @@ func initTestMemberCC() {
 	// Create Institution for Tag-CC Test
 	instToCreate := instFormMemberTest
 
-	res, err := svc.CreateInst(instToCreate)
+	res, err := testCCServer.Stores.Insts.CreateInst(context.TODO(), instToCreate)
 	if err != nil {
 		panic(err)
 	}
@@ -49,7 +49,7 @@ func initTestMemberCC() {
 	memberToCreate := memberFormMemberTest
 	memberToCreate.InstID = instID
 
-	_, err = svc.CreateMember(memberToCreate)
+	_, err = testCCServer.Stores.Members.CreateMember(context.TODO(), memberToCreate)
 	if err != nil {
 		panic(err)
 	}
@@ -60,21 +60,22 @@ func TestMemberCCScan(t *testing.T) {
 
 	// Get Institution
 	var inst svc.Institution
-	if err := svc.GetInstByName(instName).Decode(&inst); err != nil {
+	if err := testCCServer.Stores.Insts.GetInstByName(context.TODO(), instName).Decode(&inst); err != nil {
 		if err == mongo.ErrNoDocuments {
 			// Set-Up testing data if not already
 			initTestMemberCC()
-			svc.GetInstByName(instName).Decode(&inst)
+			testCCServer.Stores.Insts.GetInstByName(context.TODO(), instName).Decode(&inst)
 		} else {
 			panic(err)
 		}
 	}
 	instID := inst.ID.Hex()
+	useTestDevice(instID)
 
 	// Get Member
 	var mParams svc.GetMemberParams
 	mParams.InstID = instID
-	cursor, _ := svc.GetManyMembers(&mParams)
+	cursor, _ := testCCServer.Stores.Members.GetManyMembers(context.TODO(), &mParams)
 
 	var members []svc.Admin
 	if err := cursor.All(context.TODO(), &members); err != nil {
@@ -121,7 +122,7 @@ func checkCCRecordMember(t *testing.T, expectedRecord svc.CCRecord) {
 		Status:      -1, // set Status to "-1" to disable status filter
 		GetLatest:   true,
 	}
-	if err := svc.GetCCRecord(&ccParams).Decode(&ccRecord); err != nil {
+	if err := testCCServer.Stores.CCRecords.GetCCRecord(context.TODO(), &ccParams).Decode(&ccRecord); err != nil {
 		panic(err)
 	}
 
@@ -136,7 +137,7 @@ func checkCCRecordMember(t *testing.T, expectedRecord svc.CCRecord) {
 
 func getMemberUniqueID(memberID string, stage string) string {
 	timestamp := time.Now().Unix() * 1000
-	return strings.Join([]string{memberID, stage, strconv.FormatInt(timestamp, 10)}, "|")
+	return signTestScanPayload(memberID, strings.Join([]string{memberID, stage, strconv.FormatInt(timestamp, 10)}, "|"))
 }
 
 func getExpectedRecordMember(memberID string, status svc.CCRecordStatus) svc.CCRecord {
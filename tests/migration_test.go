@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"testing"
+
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationRegistry(t *testing.T) {
+	names := map[string]bool{}
+	for index, m := range svc.Migrations {
+		// Versions are numbered from 1 with no gaps, so that their order is that of the list
+		assert.Equal(t, index+1, m.Version)
+		assert.NotEmpty(t, m.Name)
+		assert.False(t, names[m.Name], "%v declared twice", m.Name)
+		names[m.Name] = true
+
+		assert.NotEmpty(t, m.Up, "%v has no up steps", m.Name)
+		assert.NotEmpty(t, m.Down, "%v has no down steps", m.Name)
+		for _, step := range append(append([]svc.MigrationStep{}, m.Up...), m.Down...) {
+			assert.NotEmpty(t, step.Collection, "%v has a step without collection", m.Name)
+			assert.NotEmpty(t, step.Filter, "%v has a step without filter", m.Name)
+			assert.NotEmpty(t, step.Update, "%v has a step without update", m.Name)
+		}
+	}
+}
+
+func TestMigrateCommandUsage(t *testing.T) {
+	// Bad arguments are rejected before connecting to the database
+	for _, args := range [][]string{{}, {"sideways"}, {"up", "down"}, {"up", "--bogus"}} {
+		assert.Equal(t, 2, testCCServer.MigrateCommand(args), "%v", args)
+	}
+}
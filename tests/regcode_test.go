@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRegCodeActivation(t *testing.T) {
+	phoneNum := getUniquePhoneNum()
+	iRes, err := testCCServer.Stores.Insts.CreateInst(context.TODO(), instFormScopeTest)
+	assert.Nil(t, err)
+	instID := iRes.InsertedID.(primitive.ObjectID).Hex()
+	mRes, err := testCCServer.Stores.Members.CreateMember(context.TODO(), svc.MemberRegForm{InstID: instID, PhoneNum: phoneNum, FirstName: "RegCode", LastName: "Test"})
+	assert.Nil(t, err)
+	memberID := mRes.InsertedID.(primitive.ObjectID).Hex()
+
+	// Expired RegCode is rejected even when it matches
+	rRes, err := testCCServer.Stores.RegCodes.CreateRegCodeByMemberID(context.TODO(), memberID, -time.Minute)
+	assert.Nil(t, err)
+	var expired svc.RegCode
+	testCCServer.Stores.RegCodes.GetRegCodeByID(context.TODO(), rRes.InsertedID.(primitive.ObjectID).Hex()).Decode(&expired)
+	assert.Equal(t, http.StatusForbidden, postActivate(phoneNum, expired.RegCode))
+
+	// Regenerating invalidates the old RegCode
+	superAdminToken := getTestToken(controllers.SessionRoleSuperAdmin, "")
+	tc := scopeTestCase{"POST", "/api/reg-code/regenerate", `{"member_id":"` + memberID + `"}`}
+	assert.Equal(t, http.StatusCreated, sendWithToken(tc, superAdminToken))
+	testCCServer.Stores.RegCodes.GetRegCodeByID(context.TODO(), expired.ID.Hex()).Decode(&expired)
+	assert.NotNil(t, expired.InvalidatedAt)
+	var regCode svc.RegCode
+	assert.Nil(t, testCCServer.Stores.RegCodes.GetRegCodeByMemberID(context.TODO(), memberID).Decode(&regCode))
+	assert.NotEqual(t, expired.ID, regCode.ID)
+	assert.Len(t, regCode.RegCode, 6)
+
+	// Phone # gets locked out after too many failed attempts, even for the right RegCode
+	wrongCode := "000000"
+	if regCode.RegCode == wrongCode {
+		wrongCode = "111111"
+	}
+	for i := 0; i < testCCServer.Config.RegCodeConf.MaxFailedAttempts; i++ {
+		assert.Equal(t, http.StatusForbidden, postActivate(phoneNum, wrongCode))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, postActivate(phoneNum, regCode.RegCode))
+
+	// Regenerating lifts the lockout
+	assert.Equal(t, http.StatusCreated, sendWithToken(tc, superAdminToken))
+	assert.Nil(t, testCCServer.Stores.RegCodes.GetRegCodeByMemberID(context.TODO(), memberID).Decode(&regCode))
+	assert.Equal(t, http.StatusOK, postActivate(phoneNum, regCode.RegCode))
+
+	// RegCode records when it was used, and cannot be used again
+	testCCServer.Stores.RegCodes.GetRegCodeByID(context.TODO(), regCode.ID.Hex()).Decode(&regCode)
+	assert.NotNil(t, regCode.UsedAt)
+	assert.False(t, regCode.CreatedAt.IsZero())
+	assert.Equal(t, http.StatusForbidden, postActivate(phoneNum, regCode.RegCode))
+}
+
+func postActivate(phoneNum string, regCode string) int {
+	body := `{"phone_num":"` + phoneNum + `","reg_code":"` + regCode + `"}`
+	req, _ := http.NewRequest("POST", "/api/member/activate", strings.NewReader(body))
+	req.Header.Add("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	return w.Code
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestScanEvents(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "Scan Event Test"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	res, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Scan"})
+	assert.Nil(t, err)
+	memberID := res.InsertedID.(primitive.ObjectID).Hex()
+	useTestDevice(instID)
+	postCCSync(t, getSyncRequestMember(instID, memberID))
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+
+	// Every Scan is recorded, whether it is applied or not; the check-out fails screening
+	checkIn := getMemberUniqueID(memberID, "checkin")
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureNormal, checkIn))
+	assert.Equal(t, http.StatusBadRequest, postGateKeeperScan(testTemperatureNormal, "not|a|scan|payload|at|all"))
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureHigh, getMemberUniqueID(memberID, "checkout")))
+
+	getEvents := func(query string) []svc.ScanEvent {
+		w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/scan-events?instID=" + instID + query, ""}, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Data []svc.ScanEvent `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Data
+	}
+	events := getEvents("")
+	assert.Len(t, events, 3)
+	assert.Equal(t, checkIn, events[0].RawPayload)
+	assert.Equal(t, svc.ScanDecisionAccepted, events[0].Decision)
+	assert.True(t, events[0].ParseResult.Valid)
+	assert.Equal(t, memberID, events[0].ParseResult.MemberTagID)
+	assert.Equal(t, svc.ScanDecisionRejected, events[1].Decision)
+	assert.False(t, events[1].ParseResult.Valid)
+	assert.Equal(t, "Unrecognized Scan Payload", events[1].Reason)
+	assert.Empty(t, events[1].Records)
+	assert.Len(t, getEvents("&decision=rejected"), 1)
+
+	// Paged, oldest first unless sorted otherwise
+	var page struct {
+		Data       []svc.ScanEvent `json:"data"`
+		NextCursor string          `json:"next_cursor"`
+	}
+	w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/scan-events?limit=2&instID=" + instID, ""}, token)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Data, 2)
+	assert.NotEmpty(t, page.NextCursor)
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/scan-events?limit=2&instID=" + instID + "&cursor=" + page.NextCursor, ""}, token)
+	page.NextCursor = ""
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+	if assert.Len(t, page.Data, 1) {
+		assert.Equal(t, events[2].ID, page.Data[0].ID)
+	}
+	assert.Empty(t, page.NextCursor)
+	newest := getEvents("&sort=-received_at")
+	if assert.Len(t, newest, 3) {
+		assert.Equal(t, events[2].ID, newest[0].ID)
+	}
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/scan-events?sort=device_id&instID=" + instID, ""}, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Admins of other Institutions do not see them, even without "instID"
+	otherToken := getTestToken(controllers.SessionRoleAdmin, primitive.NewObjectID().Hex())
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/scan-events", ""}, otherToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), memberID)
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/scan-events", ""}, getTestToken(controllers.SessionRoleSuperAdmin, ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	ccRecord := svc.CCRecord{}
+	params := svc.GetCCRecordParams{MemberTagID: memberID, Status: -1}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecord(ctx, &params).Decode(&ccRecord))
+	assert.Equal(t, svc.CCrFailed, ccRecord.Status)
+	assert.Equal(t, testTemperatureHigh, ccRecord.Temperature)
+	applied := getEvents("&ccRecordID=" + ccRecord.ID.Hex())
+	assert.Len(t, applied, 2)
+	assert.Equal(t, []svc.ScanEventRecord{{CCRecordID: ccRecord.ID.Hex(), Version: 2}}, applied[1].Records)
+
+	// The Record is rebuilt from its events, whatever it was overwritten with
+	tampered := ccRecord
+	tampered.Version++
+	tampered.Status = svc.CCrCheckOutComplete
+	tampered.Temperature = 0
+	tampered.MT = &svc.MT{Info: ccRecord.MT.Info}
+	_, err = testCCServer.Stores.CCRecords.ReplaceCCRecord(ctx, ccRecord, tampered)
+	assert.Nil(t, err)
+
+	rebuildURL := "/api/cc-record/rebuild/" + ccRecord.ID.Hex()
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", rebuildURL + "?dry_run=true", ""}, token))
+	stored := svc.CCRecord{}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecordByID(ctx, ccRecord.ID.Hex()).Decode(&stored))
+	assert.Equal(t, svc.CCrCheckOutComplete, stored.Status)
+
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", rebuildURL, ""}, token))
+	rebuilt := svc.CCRecord{}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecordByID(ctx, ccRecord.ID.Hex()).Decode(&rebuilt))
+	assert.Equal(t, tampered.Version+1, rebuilt.Version)
+	rebuilt.Version = ccRecord.Version
+	assert.Equal(t, ccRecord, rebuilt)
+
+	// Records updated before Scan Events were recorded cannot be rebuilt
+	res, err = testCCServer.Stores.CCRecords.CreateCCRecord(ctx, instID, svc.CreateCCRecordData{Member: &svc.Member{ID: primitive.NewObjectID()}})
+	assert.Nil(t, err)
+	legacy := svc.CCRecord{}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecordByID(ctx, res.InsertedID.(primitive.ObjectID).Hex()).Decode(&legacy))
+	_, err = testCCServer.Stores.CCRecords.UpdateCCRecordWithEvent(ctx, legacy, svc.NewEventData{
+		MemberTagEvent: &svc.MemberTagEvent{DeviceID: testDeviceIMEI},
+		Stage:          "checkin",
+		MemberType:     svc.MemberTypeStandard,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", "/api/cc-record/rebuild/" + legacy.ID.Hex(), ""}, token))
+}
+
+func TestProjectCCRecordSchedule(t *testing.T) {
+	id := primitive.NewObjectID()
+	ccr := svc.CCRecord{ID: id, Status: svc.CCrCheckOutComplete, Version: 5, GW: &svc.GW{WardInfo: svc.WardInfo{ID: "ward"}}}
+	scheduledAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	applied := func(version int64) []svc.ScanEventRecord {
+		return []svc.ScanEventRecord{{CCRecordID: id.Hex(), Version: version}}
+	}
+	// Out of order, as the Records they were applied to give their order
+	events := []svc.ScanEvent{
+		{Records: applied(3), Stage: "checkout", MemberType: svc.MemberTypeGuardian, GuardianEvent: &svc.GuardianEvent{Temperature: 97.5}},
+		{Records: applied(1), Stage: "checkin", MemberType: svc.MemberTypeGuardian, GuardianEvent: &svc.GuardianEvent{Temperature: 98.1}},
+		{Records: applied(2), Source: svc.ScanEventSourceSchedule, Stage: "schedule", ScheduledAt: &scheduledAt},
+		{Records: []svc.ScanEventRecord{{CCRecordID: "other", Version: 4}}, Stage: "checkin", MemberType: svc.MemberTypeGuardian},
+	}
+	projected, err := svc.ProjectCCRecord(ccr, events)
+	assert.Nil(t, err)
+	assert.Equal(t, svc.CCrCheckOutComplete, projected.Status)
+	assert.Equal(t, scheduledAt, projected.CheckOutScheduledAt)
+	assert.Equal(t, float32(98.1), projected.Temperature)
+	assert.Equal(t, float32(97.5), projected.GW.CheckOutEvent.Temperature)
+	assert.Equal(t, "ward", projected.GW.WardInfo.ID)
+	assert.Equal(t, int64(6), projected.Version)
+}
@@ -0,0 +1,74 @@
+//go:build go1.18
+// +build go1.18
+
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+)
+
+// FuzzParseScanPayload - run with "go test ./tests -run ^$ -fuzz FuzzParseScanPayload" (Go 1.18 or later)
+func FuzzParseScanPayload(f *testing.F) {
+	id := "5f8f8c44b54764421b7156c9"
+	ward := "5f8f8c44b54764421b7156ca"
+	for _, seed := range []string{
+		"",
+		id + "|checkin|1600000000000",
+		id + "|" + ward + "|checkout|single|1600000000000",
+		id + "|checkin|all|1600000000000",
+		"TCCT|1230|checkin|1600000000000",
+		"v2:type=member;id=" + id + ";stage=checkin;ts=1600000000000",
+		"v2:type=guardian;id=" + id + ";ward=" + ward + ";stage=checkout;ts=1600000000000",
+		"v2:type=tag;inst=TCCT;tag=1230;ts=1600000000000",
+		"v3:type=member",
+		"v2:type=member;type=member",
+		"|||",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, payload string) {
+		p, err := controllers.ParseScanPayload(payload)
+		if err != nil {
+			pErr, ok := err.(*controllers.ScanPayloadError)
+			if !ok || len(pErr.Reason) == 0 || p != nil {
+				t.Fatalf("%q - got %v, %#v; want a nil Payload & a *ScanPayloadError", payload, p, err)
+			}
+			return
+		}
+		if p == nil {
+			t.Fatalf("%q - got neither a Payload nor an error", payload)
+		}
+		switch p.Type {
+		case controllers.ScanResultTagType:
+			if len(p.InstIdentifier) == 0 || len(p.MemberTagID) == 0 {
+				t.Fatalf("%q - Tag Payload without identifier or tag: %#v", payload, p)
+			}
+		case controllers.ScanResultMemberType, controllers.ScanResultGWType:
+			if p.Stage != "checkin" && p.Stage != "checkout" {
+				t.Fatalf("%q - Payload of stage %q", payload, p.Stage)
+			}
+			if p.IsSingleEvent != (len(p.WardID) > 0) || (p.IsSingleEvent && p.Type != controllers.ScanResultGWType) {
+				t.Fatalf("%q - Ward of a Payload not of a single Ward: %#v", payload, p)
+			}
+		default:
+			t.Fatalf("%q - Payload of type %v", payload, p.Type)
+		}
+
+		// Payloads formatted as v2 parse back the same, unless a Tag holds the field separator
+		if strings.Contains(p.InstIdentifier+p.MemberTagID, ";") {
+			return
+		}
+		reparsed, err := controllers.ParseScanPayload(controllers.FormatScanPayload(*p))
+		if err != nil {
+			t.Fatalf("%q - formatted as v2, failed to parse: %v", payload, err)
+		}
+		reparsed.Version = p.Version
+		if *reparsed != *p {
+			t.Fatalf("%q - formatted as v2, parsed into %#v; want %#v", payload, reparsed, p)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseScanPayload(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	ward := primitive.NewObjectID().Hex()
+	ts := time.Unix(1600000000, 0)
+
+	valid := []struct {
+		payload  string
+		expected controllers.ScanPayload
+	}{
+		// Legacy formats
+		{id + "|checkin|1600000000000", controllers.ScanPayload{Version: 1, Type: controllers.ScanResultMemberType, MemberTagID: id, Stage: "checkin", Time: ts}},
+		{id + "|" + ward + "|checkout|single|1600000000000", controllers.ScanPayload{Version: 1, Type: controllers.ScanResultGWType, MemberTagID: id, WardID: ward, Stage: "checkout", IsSingleEvent: true, Time: ts}},
+		{id + "|checkin|all|1600000000000", controllers.ScanPayload{Version: 1, Type: controllers.ScanResultGWType, MemberTagID: id, Stage: "checkin", Time: ts}},
+		{"TCCT|1230|checkin|1600000000000", controllers.ScanPayload{Version: 1, Type: controllers.ScanResultTagType, InstIdentifier: "TCCT", MemberTagID: "1230", Time: ts}},
+		// Identifiers of 24 characters or more are Tags too, unless they are ObjectIDs
+		{"AN-INSTITUTION-IDENTIFIER|1230|checkin|1600000000000", controllers.ScanPayload{Version: 1, Type: controllers.ScanResultTagType, InstIdentifier: "AN-INSTITUTION-IDENTIFIER", MemberTagID: "1230", Time: ts}},
+		// v2
+		{"v2:type=member;id=" + id + ";stage=checkin;ts=1600000000000", controllers.ScanPayload{Version: 2, Type: controllers.ScanResultMemberType, MemberTagID: id, Stage: "checkin", Time: ts}},
+		{"v2:ts=1600000000000;stage=checkout;ward=" + ward + ";id=" + id + ";type=guardian", controllers.ScanPayload{Version: 2, Type: controllers.ScanResultGWType, MemberTagID: id, WardID: ward, Stage: "checkout", IsSingleEvent: true, Time: ts}},
+		{"v2:type=guardian;id=" + id + ";stage=checkin;ts=1600000000000", controllers.ScanPayload{Version: 2, Type: controllers.ScanResultGWType, MemberTagID: id, Stage: "checkin", Time: ts}},
+		{"v2:type=tag;inst=TCCT;tag=12=30;ts=1600000000000", controllers.ScanPayload{Version: 2, Type: controllers.ScanResultTagType, InstIdentifier: "TCCT", MemberTagID: "12=30", Time: ts}},
+	}
+	for _, tc := range valid {
+		p, err := controllers.ParseScanPayload(tc.payload)
+		assert.Nil(t, err, tc.payload)
+		if assert.NotNil(t, p, tc.payload) {
+			assert.Equal(t, tc.expected, *p, tc.payload)
+		}
+		if p != nil && p.Version == 2 {
+			// The formatted Payload parses back the same
+			reparsed, err := controllers.ParseScanPayload(controllers.FormatScanPayload(*p))
+			assert.Nil(t, err, tc.payload)
+			assert.Equal(t, p, reparsed, tc.payload)
+		}
+	}
+
+	invalid := []struct {
+		payload string
+		reason  controllers.ScanPayloadReason
+	}{
+		{"", controllers.ScanPayloadEmpty},
+		{"just-a-string", controllers.ScanPayloadUnknownFormat},
+		{id + "|checkin|all", controllers.ScanPayloadBadTimestamp},
+		{id + "|" + ward + "|checkin|both|1600000000000", controllers.ScanPayloadUnknownFormat},
+		{id + "|checkin|yesterday", controllers.ScanPayloadBadTimestamp},
+		{id + "|checkin|-1", controllers.ScanPayloadBadTimestamp},
+		{id + "|schedule|1600000000000", controllers.ScanPayloadBadStage},
+		{"not-an-id|checkin|1600000000000", controllers.ScanPayloadBadID},
+		{id + "|not-a-ward|checkin|single|1600000000000", controllers.ScanPayloadBadID},
+		{"|1230|checkin|1600000000000", controllers.ScanPayloadMissingField},
+		{"v3:type=member", controllers.ScanPayloadUnknownVersion},
+		{"v2:", controllers.ScanPayloadMalformedField},
+		{"v2:type=member;id", controllers.ScanPayloadMalformedField},
+		{"v2:type=member;id=" + id + ";;ts=1", controllers.ScanPayloadMalformedField},
+		{"v2:type=member;type=tag", controllers.ScanPayloadDuplicateField},
+		{"v2:type=visitor;id=" + id, controllers.ScanPayloadBadType},
+		{"v2:id=" + id + ";stage=checkin;ts=1", controllers.ScanPayloadBadType},
+		{"v2:type=member;id=" + id + ";ward=" + ward + ";stage=checkin;ts=1", controllers.ScanPayloadUnknownField},
+		{"v2:type=member;id=" + id + ";ts=1", controllers.ScanPayloadMissingField},
+		{"v2:type=tag;inst=TCCT;ts=1", controllers.ScanPayloadMissingField},
+		{"v2:type=member;id=1230;stage=checkin;ts=1", controllers.ScanPayloadBadID},
+		{"v2:type=guardian;id=" + id + ";ward=1230;stage=checkin;ts=1", controllers.ScanPayloadBadID},
+		{"v2:type=member;id=" + id + ";stage=checkedin;ts=1", controllers.ScanPayloadBadStage},
+		{"v2:type=member;id=" + id + ";stage=checkin;ts=now", controllers.ScanPayloadBadTimestamp},
+	}
+	for _, tc := range invalid {
+		p, err := controllers.ParseScanPayload(tc.payload)
+		assert.Nil(t, p, tc.payload)
+		pErr, ok := err.(*controllers.ScanPayloadError)
+		if assert.True(t, ok, tc.payload) {
+			assert.Equal(t, tc.reason, pErr.Reason, tc.payload)
+		}
+	}
+}
+
+func TestScanPayloadRejected(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "Scan Payload Test"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	res, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Payload"})
+	assert.Nil(t, err)
+	memberID := res.InsertedID.(primitive.ObjectID).Hex()
+	useTestDevice(instID)
+	postCCSync(t, getSyncRequestMember(instID, memberID))
+
+	scan := func(uniqueID string) (int, string) {
+		data := makeGateKeeperPost(testTemperatureNormal, testDeviceIMEI, uniqueID)
+		req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		var body struct {
+			ReasonCode string `json:"reason_code"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body.ReasonCode
+	}
+
+	code, reason := scan("v2:type=member;id=" + memberID + ";stage=checkin")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, string(controllers.ScanPayloadMissingField), reason)
+	code, reason = scan("")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, string(controllers.ScanPayloadEmpty), reason)
+
+	// Signed v2 Payloads are scanned as legacy ones are
+	payload := controllers.FormatScanPayload(controllers.ScanPayload{
+		Type: controllers.ScanResultMemberType, MemberTagID: memberID, Stage: "checkin", Time: time.Now()})
+	code, _ = scan(signTestScanPayload(memberID, payload))
+	assert.Equal(t, http.StatusOK, code)
+	ccRecord := svc.CCRecord{}
+	params := svc.GetCCRecordParams{MemberTagID: memberID, Status: -1}
+	assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecord(ctx, &params).Decode(&ccRecord))
+	assert.Equal(t, svc.CCrCheckInComplete, ccRecord.Status)
+}
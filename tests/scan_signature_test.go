@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestScanPayloadSignature(t *testing.T) {
+	d := initTestInstScope(t)
+	useTestDevice(d.InstID)
+	now := time.Now().Unix() * 1000
+	payload := strings.Join([]string{d.MemberID, "checkin", strconv.FormatInt(now, 10)}, "|")
+
+	// Unsigned
+	assert.Equal(t, http.StatusForbidden, postScanPayload(payload))
+
+	// Forged, e.g. signed with another key or altered after signing
+	signed := signTestScanPayload(d.MemberID, payload)
+	assert.Equal(t, http.StatusForbidden, postScanPayload(signed[:len(signed)-2]+"00"))
+	assert.Equal(t, http.StatusForbidden, postScanPayload(strings.Replace(signed, "checkin", "checkout", 1)))
+
+	// Signed by another Member of the Institution
+	mRes, err := testCCServer.Stores.Members.CreateMember(context.TODO(), svc.MemberRegForm{InstID: d.InstID, PhoneNum: getUniquePhoneNum(), FirstName: "Other"})
+	assert.Nil(t, err)
+	otherMemberID := mRes.InsertedID.(primitive.ObjectID).Hex()
+	otherPayload := strings.Join([]string{otherMemberID, "checkin", strconv.FormatInt(now, 10)}, "|")
+	assert.Equal(t, http.StatusForbidden, postScanPayload(signTestScanPayload(d.MemberID, otherPayload)))
+
+	// Signed by a Guardian, for a Ward of another Family
+	otherWard := svc.Ward{ID: primitive.NewObjectID(), FirstName: "Other"}
+	_, err = testCCServer.Stores.Families.CreateFamily(context.TODO(), svc.FamilyRegForm{InstID: d.InstID},
+		svc.MemberInFamilyRegForm{FirstName: "Other", PhoneNum: getUniquePhoneNum()}, []svc.Ward{otherWard}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, postScanPayload(getFamilyUniqueIDSingle(d.MemberID, otherWard.ID.Hex(), "checkin")))
+	assert.NotEqual(t, http.StatusForbidden, postScanPayload(getFamilyUniqueIDSingle(d.MemberID, d.WardID, "checkin")))
+
+	// Replayed
+	stale := now - int64(testCCServer.Config.ScanConf.FreshnessWindowSeconds+60)*1000
+	stalePayload := strings.Join([]string{d.MemberID, "checkin", strconv.FormatInt(stale, 10)}, "|")
+	assert.Equal(t, http.StatusForbidden, postScanPayload(signTestScanPayload(d.MemberID, stalePayload)))
+
+	// Unrecognized
+	assert.Equal(t, http.StatusBadRequest, postScanPayload("not-a-payload"))
+
+	// Unsigned legacy payloads are let through when allowed, unless replayed
+	testCCServer.Config.ScanConf.AllowUnsignedPayloads = true
+	assert.NotEqual(t, http.StatusForbidden, postScanPayload(payload))
+	assert.Equal(t, http.StatusForbidden, postScanPayload(stalePayload))
+	testCCServer.Config.ScanConf.AllowUnsignedPayloads = false
+}
+
+func postScanPayload(payload string) int {
+	data := makeGateKeeperPost(testTemperatureNormal, testDeviceIMEI, payload)
+	req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	return w.Code
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestScreeningCase(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "Screening Case Test"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	useTestDevice(instID)
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+	nurseToken := getTestToken(controllers.SessionRoleNurse, instID)
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"PUT", "/api/screening-policy/" + instID, `{"threshold": 99.2, "unit": "F", "exclude_until_cleared": true}`}, token))
+	res, err = testCCServer.Stores.Admins.CreateAdmin(ctx, svc.AdminRegForm{InstID: instID, FrasUsername: "screening_case_nurse", Role: svc.AdminRoleNurse})
+	assert.Nil(t, err)
+	nurseID := res.InsertedID.(primitive.ObjectID).Hex()
+
+	failMember := func() string {
+		res, err := testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Case"})
+		assert.Nil(t, err)
+		memberID := res.InsertedID.(primitive.ObjectID).Hex()
+		postCCSync(t, getSyncRequestMember(instID, memberID))
+		assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureHigh, getMemberUniqueID(memberID, "checkin")))
+		return memberID
+	}
+	getCase := func(memberID string) svc.ScreeningCase {
+		w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/screening-cases?subjectID=" + memberID + "&instID=" + instID, ""}, nurseToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Data []svc.ScreeningCase `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+		if !assert.Len(t, body.Data, 1) {
+			return svc.ScreeningCase{}
+		}
+		return body.Data[0]
+	}
+	checkIn := func(memberID string) (bool, svc.ScreeningCaseStatus) {
+		data := makeGateKeeperPost(testTemperatureNormal, testDeviceIMEI, getMemberUniqueID(memberID, "checkin"))
+		req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var scanRes struct {
+			ScanResponse
+			CaseStatus svc.ScreeningCaseStatus `json:"case_status"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &scanRes))
+		return scanRes.Success, scanRes.CaseStatus
+	}
+
+	// Failed Records get an open case, and their subjects are held out while it is
+	memberID := failMember()
+	screeningCase := getCase(memberID)
+	caseID := screeningCase.ID.Hex()
+	assert.Equal(t, svc.ScreeningCaseOpen, screeningCase.Status)
+	assert.Equal(t, testTemperatureHigh, screeningCase.Temperature)
+	assert.NotEmpty(t, screeningCase.ExclusionID)
+	if assert.Len(t, screeningCase.Timeline, 1) {
+		assert.Equal(t, svc.ScreeningCaseEntryFlagged, screeningCase.Timeline[0].Type)
+	}
+	success, caseStatus := checkIn(memberID)
+	assert.False(t, success)
+	assert.Equal(t, svc.ScreeningCaseOpen, caseStatus)
+
+	// Nurses assign, note & retest cases; Front Desk does not
+	assignURL := "/api/screening-case/assign/" + caseID
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", assignURL, `{"assignee_id": "` + nurseID + `"}`}, getTestToken(controllers.SessionRoleFrontDesk, instID)))
+	assert.Equal(t, http.StatusBadRequest, sendWithToken(scopeTestCase{"POST", assignURL, `{"assignee_id": "000000000000000000000000"}`}, nurseToken))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", assignURL, `{"assignee_id": "` + nurseID + `"}`}, nurseToken))
+	assert.Equal(t, http.StatusBadRequest, sendWithToken(scopeTestCase{"POST", "/api/screening-case/note/" + caseID, `{}`}, nurseToken))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", "/api/screening-case/note/" + caseID, `{"note": "Feels fine"}`}, nurseToken))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", "/api/screening-case/retest/" + caseID, `{"temperature": 36.8, "unit": "C"}`}, nurseToken))
+	screeningCase = getCase(memberID)
+	assert.Equal(t, svc.ScreeningCaseRetested, screeningCase.Status)
+	assert.Equal(t, nurseID, screeningCase.AssigneeID)
+	if assert.NotNil(t, screeningCase.Retest) {
+		assert.True(t, screeningCase.Retest.Passed)
+		assert.Equal(t, svc.ToCanonicalTemperature(36.8, svc.TemperatureUnitC), screeningCase.Retest.Temperature)
+	}
+	var types []svc.ScreeningCaseEntryType
+	for _, entry := range screeningCase.Timeline {
+		types = append(types, entry.Type)
+	}
+	assert.Equal(t, []svc.ScreeningCaseEntryType{
+		svc.ScreeningCaseEntryFlagged, svc.ScreeningCaseEntryAssigned, svc.ScreeningCaseEntryNote, svc.ScreeningCaseEntryRetest,
+	}, types)
+
+	// Clearing closes the case & its Exclusion, and lets the Member check in again
+	decideURL := "/api/screening-case/decide/" + caseID
+	assert.Equal(t, http.StatusBadRequest, sendWithToken(scopeTestCase{"POST", decideURL, `{"decision": "open"}`}, nurseToken))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", decideURL, `{"decision": "cleared", "note": "Retest passed"}`}, nurseToken))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", decideURL, `{"decision": "sent_home"}`}, nurseToken))
+	screeningCase = getCase(memberID)
+	assert.Equal(t, svc.ScreeningCaseCleared, screeningCase.Status)
+	assert.NotNil(t, screeningCase.ClosedAt)
+	exclusion := svc.Exclusion{}
+	assert.Nil(t, testCCServer.Stores.Exclusions.GetExclusionByID(ctx, screeningCase.ExclusionID).Decode(&exclusion))
+	assert.NotNil(t, exclusion.ClearedAt)
+	postCCSync(t, getSyncRequestMember(instID, memberID))
+	success, _ = checkIn(memberID)
+	assert.True(t, success)
+
+	// Those sent home stay excluded
+	memberID = failMember()
+	screeningCase = getCase(memberID)
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", "/api/screening-case/decide/" + screeningCase.ID.Hex(), `{"decision": "sent_home"}`}, nurseToken))
+	success, caseStatus = checkIn(memberID)
+	assert.False(t, success)
+	assert.Equal(t, svc.ScreeningCaseSentHome, caseStatus)
+}
+
+func TestScreeningCaseList(t *testing.T) {
+	ctx := context.TODO()
+	newInst := func(name string) string {
+		res, err := testCCServer.Stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: name})
+		assert.Nil(t, err)
+		return res.InsertedID.(primitive.ObjectID).Hex()
+	}
+	instID := newInst("Screening Case List Test")
+	now := time.Now().Truncate(time.Millisecond)
+	for index, subjectID := range []string{"first", "second", "third"} {
+		_, err := testCCServer.Stores.ScreeningCases.CreateScreeningCase(ctx, svc.ScreeningCase{
+			InstID: instID, SubjectID: subjectID, Status: svc.ScreeningCaseOpen, CreatedAt: now.Add(time.Duration(index) * time.Minute),
+		})
+		assert.Nil(t, err)
+	}
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+	listCases := func(query string, token string) (int, []string, string) {
+		w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/screening-cases?" + query, ""}, token)
+		var body struct {
+			Data       []svc.ScreeningCase `json:"data"`
+			NextCursor string              `json:"next_cursor"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		subjectIDs := []string{}
+		for _, sc := range body.Data {
+			subjectIDs = append(subjectIDs, sc.SubjectID)
+		}
+		return w.Code, subjectIDs, body.NextCursor
+	}
+
+	// Paged, latest first unless sorted otherwise
+	code, subjectIDs, next := listCases("limit=2&instID="+instID, token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"third", "second"}, subjectIDs)
+	_, subjectIDs, next = listCases("limit=2&instID="+instID+"&cursor="+next, token)
+	assert.Equal(t, []string{"first"}, subjectIDs)
+	assert.Empty(t, next)
+	_, subjectIDs, _ = listCases("sort=created_at&instID="+instID, token)
+	assert.Equal(t, []string{"first", "second", "third"}, subjectIDs)
+	code, _, _ = listCases("sort=subject_id&instID="+instID, token)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Admins only see those of their own Institution, even without "instID"
+	code, subjectIDs, _ = listCases("", token)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"third", "second", "first"}, subjectIDs)
+	code, subjectIDs, _ = listCases("", getTestToken(controllers.SessionRoleAdmin, newInst("Screening Case List Other")))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, subjectIDs)
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestScreeningPolicyScreen(t *testing.T) {
+	// Policies are in their own unit; readings are screened as canonical ones
+	policy := svc.ScreeningPolicy{Threshold: 37.5, Unit: svc.TemperatureUnitC, MaskPolicy: svc.MaskPolicyWarn, MinTemperature: 30, MaxTemperature: 45}
+	assert.Nil(t, policy.Validate())
+
+	assert.True(t, policy.Screen(svc.CCStageCheckIn, 97.9, true).Passed)
+	assert.Equal(t, []svc.ScreeningReason{svc.ScreeningReasonFever}, policy.Screen(svc.CCStageCheckIn, 99.5, true).Reasons)
+	assert.Equal(t, []svc.ScreeningReason{svc.ScreeningReasonNoMask}, policy.Screen(svc.CCStageCheckIn, 97.9, false).Warnings)
+	assert.True(t, policy.Screen(svc.CCStageCheckIn, 97.9, false).Passed)
+	// Denied Scans pass, but are not to be applied; one that fails is applied however
+	policy.MaskPolicy = svc.MaskPolicyDeny
+	assert.Equal(t, svc.Screening{Passed: true, Denied: true, Reasons: []svc.ScreeningReason{svc.ScreeningReasonNoMask}}, policy.Screen(svc.CCStageCheckIn, 97.9, false))
+	assert.Equal(t, svc.Screening{Reasons: []svc.ScreeningReason{svc.ScreeningReasonFever, svc.ScreeningReasonNoMask}}, policy.Screen(svc.CCStageCheckIn, 100.4, false))
+	// Check-Outs need neither a reading nor a mask, unless a reading is required
+	assert.True(t, policy.Screen(svc.CCStageCheckOut, 100.4, false).Passed)
+	assert.Nil(t, policy.CheckReading(svc.CCStageCheckOut, 0))
+	policy.RequireCheckOutTemp = true
+	assert.False(t, policy.Screen(svc.CCStageCheckOut, 100.4, false).Passed)
+	assert.Equal(t, svc.ErrTemperatureOutOfRange, policy.CheckReading(svc.CCStageCheckOut, 0))
+	assert.Equal(t, svc.ErrTemperatureOutOfRange, policy.CheckReading(svc.CCStageCheckIn, 37))
+	assert.Nil(t, policy.CheckReading(svc.CCStageCheckIn, 113))
+
+	// A reading right at the Threshold fails, at Check-In & Check-Out alike
+	policy = svc.ScreeningPolicy{Threshold: 99.2, Unit: svc.TemperatureUnitF, RequireCheckOutTemp: true}
+	assert.False(t, policy.Screen(svc.CCStageCheckIn, 99.2, true).Passed)
+	assert.False(t, policy.Screen(svc.CCStageCheckOut, 99.2, true).Passed)
+	assert.True(t, policy.Screen(svc.CCStageCheckIn, 99.1, true).Passed)
+	assert.True(t, policy.Screen(svc.CCStageCheckOut, 99.1, true).Passed)
+
+	for _, bad := range []svc.ScreeningPolicy{
+		{Threshold: 99.2},
+		{Threshold: 99.2, Unit: "K"},
+		{Threshold: 99.2, Unit: svc.TemperatureUnitF, MaskPolicy: "require"},
+		{Threshold: 0, Unit: svc.TemperatureUnitF},
+		{Threshold: 99.2, Unit: svc.TemperatureUnitF, MinTemperature: 100, MaxTemperature: 90},
+		{Threshold: 99.2, Unit: svc.TemperatureUnitF, MinTemperature: 90, MaxTemperature: 95},
+	} {
+		assert.NotNil(t, bad.Validate(), bad)
+	}
+}
+
+func TestScreeningPolicyScan(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "Screening Policy Test"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	useTestDevice(instID)
+	newMember := func() string {
+		res, err := testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Screening"})
+		assert.Nil(t, err)
+		memberID := res.InsertedID.(primitive.ObjectID).Hex()
+		postCCSync(t, getSyncRequestMember(instID, memberID))
+		return memberID
+	}
+	getStatus := func(memberID string) svc.CCRecordStatus {
+		ccRecord := svc.CCRecord{}
+		params := svc.GetCCRecordParams{MemberTagID: memberID, Status: -1}
+		assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecord(ctx, &params).Decode(&ccRecord))
+		return ccRecord.Status
+	}
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+	policyURL := "/api/screening-policy/" + instID
+
+	// Institutions without a policy are screened by the Config
+	w := sendWithTokenRecorded(scopeTestCase{"GET", policyURL, ""}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data      svc.ScreeningPolicy `json:"data"`
+		IsDefault bool                `json:"is_default"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.True(t, body.IsDefault)
+	assert.Equal(t, testCCServer.Config.TempThrd, body.Data.Threshold)
+	memberID := newMember()
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureHigh, getMemberUniqueID(memberID, "checkin")))
+	assert.Equal(t, svc.CCrFailed, getStatus(memberID))
+
+	// Only Admins who manage screening edit the policy, and only valid ones
+	policy := `{"threshold": 101, "unit": "F", "require_check_out_temperature": true, "min_temperature": 90, "max_temperature": 110}`
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"PUT", policyURL, policy}, getTestToken(controllers.SessionRoleFrontDesk, instID)))
+	assert.Equal(t, http.StatusBadRequest, sendWithToken(scopeTestCase{"PUT", policyURL, `{"threshold": 101, "unit": "K"}`}, token))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"PUT", policyURL, policy}, token))
+	w = sendWithTokenRecorded(scopeTestCase{"GET", policyURL, ""}, token)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.False(t, body.IsDefault)
+	assert.Equal(t, float32(101), body.Data.Threshold)
+
+	// The same reading passes by the Institution's own Threshold
+	memberID = newMember()
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureHigh, getMemberUniqueID(memberID, "checkin")))
+	assert.Equal(t, svc.CCrCheckInComplete, getStatus(memberID))
+	// ... and one right at it fails the Record
+	thresholdMember := newMember()
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(101, getMemberUniqueID(thresholdMember, "checkin")))
+	assert.Equal(t, svc.CCrFailed, getStatus(thresholdMember))
+
+	// Readings outside of the allowed range are rejected, and the Record is left as is
+	assert.Equal(t, http.StatusBadRequest, postGateKeeperScan(0, getMemberUniqueID(memberID, "checkout")))
+	assert.Equal(t, svc.CCrCheckInComplete, getStatus(memberID))
+	assert.Equal(t, http.StatusOK, postGateKeeperScan(testTemperatureNormal, getMemberUniqueID(memberID, "checkout")))
+	assert.Equal(t, svc.CCrCheckOutComplete, getStatus(memberID))
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSearchHighlight(t *testing.T) {
+	q := svc.NewSearchQuery("ann")
+	res, ok := q.SearchMember(svc.Member{FirstName: "Joanna", LastName: "Smith"})
+	assert.True(t, ok)
+	assert.Equal(t, svc.SearchHighlight{Field: "first_name", Value: "Joanna", Start: 2, End: 5}, res.Highlight)
+	_, ok = q.SearchMember(svc.Member{FirstName: "Bob"})
+	assert.False(t, ok)
+
+	// Phone #s match digits, whatever the separators
+	res, ok = svc.NewSearchQuery("555 0101").SearchTag(svc.Tag{TagString: "T1", PhoneNum: "(555) 010-1234"})
+	assert.True(t, ok)
+	assert.Equal(t, svc.SearchHighlight{Field: "phone_num", Value: "(555) 010-1234", Start: 1, End: 11}, res.Highlight)
+
+	// Case is ignored, even beyond ASCII; Start & End count characters
+	res, ok = svc.NewSearchQuery("ËL").SearchMember(svc.Member{LastName: "Noël"})
+	assert.True(t, ok)
+	assert.Equal(t, 2, res.Highlight.Start)
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.TODO()
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "Search Test"})
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	otherRes, err := testCCServer.Stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "Search Test Other"})
+	assert.Nil(t, err)
+	otherInstID := otherRes.InsertedID.(primitive.ObjectID).Hex()
+
+	_, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Quincy", LastName: "Adams"})
+	assert.Nil(t, err)
+	_, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: otherInstID, PhoneNum: getUniquePhoneNum(), FirstName: "Quinn"})
+	assert.Nil(t, err)
+	_, err = testCCServer.Stores.Tags.CreateTag(ctx, svc.TagRegForm{InstID: instID, TagString: "QU1NT", FirstName: "Tag"})
+	assert.Nil(t, err)
+	_, err = testCCServer.Stores.Families.CreateFamily(ctx, svc.FamilyRegForm{InstID: instID},
+		svc.MemberInFamilyRegForm{FirstName: "Pat", LastName: "Quist", PhoneNum: getUniquePhoneNum()},
+		[]svc.Ward{{ID: primitive.NewObjectID(), FirstName: "Quentin", LastName: "Quist"}, {ID: primitive.NewObjectID(), FirstName: "Ada"}},
+		[]svc.Vehicle{{ID: primitive.NewObjectID(), PlateNum: "7QU-123"}})
+	assert.Nil(t, err)
+
+	token := getTestToken(controllers.SessionRoleFrontDesk, instID)
+	search := func(q string) (int, []svc.SearchResult) {
+		query := url.Values{"instID": {instID}, "q": {q}}
+		w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/search?" + query.Encode(), ""}, token)
+		var resp struct {
+			Data []svc.SearchResult `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	code, results := search("qu")
+	assert.Equal(t, http.StatusOK, code)
+	types := map[svc.SearchResultType]int{}
+	for _, r := range results {
+		types[r.Type]++
+		assert.Equal(t, "qu", strings.ToLower(string([]rune(r.Highlight.Value)[r.Highlight.Start:r.Highlight.End])))
+	}
+	// Quinn is of another Institution, and Ada holds no "qu"
+	assert.Equal(t, map[svc.SearchResultType]int{
+		svc.SearchResultMember: 1, svc.SearchResultTag: 1, svc.SearchResultFamily: 1, svc.SearchResultWard: 1, svc.SearchResultVehicle: 1,
+	}, types)
+
+	code, results = search("7qu")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, svc.SearchResultVehicle, results[0].Type)
+		assert.NotEmpty(t, results[0].FamilyID)
+		assert.Equal(t, "plate_num", results[0].Highlight.Field)
+	}
+
+	code, _ = search("q")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Other Institutions are out of scope
+	query := url.Values{"instID": {otherInstID}, "q": {"quinn"}}
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"GET", "/api/search?" + query.Encode(), ""}, token))
+}
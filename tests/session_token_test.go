@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionTokenAuth(t *testing.T) {
+	adminToken := getTestToken(controllers.SessionRoleAdmin, "")
+
+	// Missing Token
+	assert.Equal(t, http.StatusUnauthorized, getWithToken("/api/configs", ""))
+
+	// Tampered Signature
+	tamperedToken := adminToken[:len(adminToken)-2] + "xx"
+	assert.Equal(t, http.StatusUnauthorized, getWithToken("/api/configs", tamperedToken))
+
+	// Expired Token
+	ttlHours := testCCServer.Config.AuthConf.TokenTTLHours
+	testCCServer.Config.AuthConf.TokenTTLHours = -1
+	expiredToken := getTestToken(controllers.SessionRoleAdmin, "")
+	testCCServer.Config.AuthConf.TokenTTLHours = ttlHours
+	assert.Equal(t, http.StatusUnauthorized, getWithToken("/api/configs", expiredToken))
+
+	// Role not allowed on the Route
+	mobileToken := getTestToken(controllers.SessionRoleMobile, "")
+	assert.Equal(t, http.StatusForbidden, getWithToken("/api/configs", mobileToken))
+	assert.Equal(t, http.StatusForbidden, getWithToken("/api/institutions", adminToken))
+}
+
+func getWithToken(url string, token string) int {
+	req, _ := http.NewRequest("GET", url, nil)
+	if len(token) > 0 {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	return w.Code
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// found - whether a document was found
+func found(res svc.SingleResult) bool {
+	doc := bson.M{}
+	return res.Decode(&doc) == nil
+}
+
+func TestSoftDeleteMember(t *testing.T) {
+	ctx := context.TODO()
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "Soft Delete Test", MemberType: string(svc.MemberTypeStandard)})
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+
+	phoneNum := getUniquePhoneNum()
+	res, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: phoneNum, FirstName: "Dora"})
+	assert.Nil(t, err)
+	memberID := res.InsertedID.(primitive.ObjectID).Hex()
+	_, err = testCCServer.Stores.RegCodes.CreateRegCodeByMemberID(ctx, memberID, time.Hour)
+	assert.Nil(t, err)
+	member := svc.Member{}
+	assert.Nil(t, testCCServer.Stores.Members.GetMemberByID(ctx, memberID).Decode(&member))
+	_, err = testCCServer.Stores.CCRecords.CreateCCRecord(ctx, instID, svc.CreateCCRecordData{Member: &member})
+	assert.Nil(t, err)
+
+	listMembers := func(deleted bool) []svc.Member {
+		query := url.Values{"instID": {instID}}
+		if deleted {
+			query.Set("deleted", "true")
+		}
+		w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/members?" + query.Encode(), ""}, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data []svc.Member `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	hasCCRecord := func() bool {
+		params := svc.GetCCRecordParams{InstID: instID, MemberTagID: memberID, Status: -1}
+		return found(testCCServer.Stores.CCRecords.GetCCRecord(ctx, &params))
+	}
+
+	// Restoring a Member not deleted fails
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", "/api/member/restore/" + memberID, ""}, token))
+
+	// Deleted Members are left out, along with their CC-Records
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"DELETE", "/api/member/" + memberID, ""}, token))
+	assert.Len(t, listMembers(false), 0)
+	if deleted := listMembers(true); assert.Len(t, deleted, 1) {
+		assert.NotNil(t, deleted[0].DeletedAt)
+	}
+	assert.False(t, found(testCCServer.Stores.Members.GetMemberByID(ctx, memberID)))
+	assert.False(t, hasCCRecord())
+
+	// Deleted Members are not updated, and stay out of reach of other Institutions
+	editBody := `{"phone_num":"` + phoneNum + `","first_name":"Renamed"}`
+	assert.Equal(t, http.StatusNotFound, sendWithToken(scopeTestCase{"PUT", "/api/member/" + memberID, editBody}, token))
+	otherToken := getTestToken(controllers.SessionRoleAdmin, primitive.NewObjectID().Hex())
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"PUT", "/api/member/" + memberID, editBody}, otherToken))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", "/api/member/restore/" + memberID, ""}, otherToken))
+	deletedMember := svc.Member{}
+	assert.Nil(t, testCCServer.Stores.Members.GetDeletedMemberByID(ctx, memberID).Decode(&deletedMember))
+	assert.Equal(t, "Dora", deletedMember.FirstName)
+
+	// Deleting again, or an unknown Member, fails
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"DELETE", "/api/member/" + memberID, ""}, token))
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"DELETE", "/api/member/000000000000000000000000", ""}, token))
+
+	// The Phone # is freed; restoring the Member while it is taken again conflicts
+	res, err = testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: phoneNum})
+	assert.Nil(t, err)
+	count, err := testCCServer.Stores.Members.CountMembersByPhoneNum(ctx, phoneNum)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, http.StatusConflict, sendWithToken(scopeTestCase{"POST", "/api/member/restore/" + memberID, ""}, token))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"DELETE", "/api/member/" + res.InsertedID.(primitive.ObjectID).Hex(), ""}, token))
+
+	// Restored Members keep their RegCode
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", "/api/member/restore/" + memberID, ""}, token))
+	assert.True(t, found(testCCServer.Stores.RegCodes.GetRegCodeByMemberID(ctx, memberID)))
+	if restored := listMembers(false); assert.Len(t, restored, 1) {
+		assert.Nil(t, restored[0].DeletedAt)
+	}
+	assert.True(t, hasCCRecord())
+}
+
+func TestSoftDeleteTagAndWard(t *testing.T) {
+	ctx := context.TODO()
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "Soft Delete Test"})
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+
+	// Tag
+	res, err = testCCServer.Stores.Tags.CreateTag(ctx, svc.TagRegForm{InstID: instID, TagString: "SD01"})
+	assert.Nil(t, err)
+	tagID := res.InsertedID.(primitive.ObjectID).Hex()
+	tag := svc.Tag{}
+	assert.Nil(t, testCCServer.Stores.Tags.GetTagByID(ctx, tagID).Decode(&tag))
+	_, err = testCCServer.Stores.CCRecords.CreateCCRecord(ctx, instID, svc.CreateCCRecordData{Tag: &tag})
+	assert.Nil(t, err)
+	// CC-Records of Tags go by their TagString
+	tagParams := svc.GetCCRecordParams{InstID: instID, MemberTagID: "SD01", Status: -1}
+
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"DELETE", "/api/tag/" + tagID, ""}, token))
+	assert.Equal(t, http.StatusNotFound, sendWithToken(scopeTestCase{"PUT", "/api/tag/" + tagID, `{"first_name":"Renamed"}`}, token))
+	otherToken := getTestToken(controllers.SessionRoleAdmin, primitive.NewObjectID().Hex())
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"PUT", "/api/tag/" + tagID, `{"first_name":"Renamed"}`}, otherToken))
+	assert.False(t, found(testCCServer.Stores.Tags.GetTag(ctx, &svc.GetTagParams{InstID: instID, TagString: "SD01"})))
+	assert.False(t, found(testCCServer.Stores.CCRecords.GetCCRecord(ctx, &tagParams)))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", "/api/tag/restore/" + tagID, ""}, token))
+	assert.True(t, found(testCCServer.Stores.Tags.GetTagByID(ctx, tagID)))
+	assert.True(t, found(testCCServer.Stores.CCRecords.GetCCRecord(ctx, &tagParams)))
+
+	// Ward
+	ward := svc.Ward{ID: primitive.NewObjectID(), FirstName: "Wendy"}
+	wardID := ward.ID.Hex()
+	_, err = testCCServer.Stores.Families.CreateFamily(ctx, svc.FamilyRegForm{InstID: instID},
+		svc.MemberInFamilyRegForm{FirstName: "Gus", PhoneNum: getUniquePhoneNum()}, []svc.Ward{ward}, nil)
+	assert.Nil(t, err)
+	_, err = testCCServer.Stores.CCRecords.CreateCCRecord(ctx, instID, svc.CreateCCRecordData{Ward: &ward})
+	assert.Nil(t, err)
+	wardParams := svc.GetCCRecordParams{InstID: instID, WardID: wardID, Status: -1}
+
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"DELETE", "/api/ward/" + wardID, ""}, token))
+	assert.False(t, found(testCCServer.Stores.Families.GetFamilyByWardID(ctx, wardID)))
+	assert.False(t, found(testCCServer.Stores.CCRecords.GetCCRecord(ctx, &wardParams)))
+	assert.Equal(t, http.StatusOK, sendWithToken(scopeTestCase{"POST", "/api/ward/restore/" + wardID, ""}, token))
+	family := svc.Family{}
+	assert.Nil(t, testCCServer.Stores.Families.GetFamilyByWardID(ctx, wardID).Decode(&family))
+	assert.Len(t, family.Wards, 1)
+	assert.Len(t, family.DeletedWards, 0)
+	assert.True(t, found(testCCServer.Stores.CCRecords.GetCCRecord(ctx, &wardParams)))
+
+	// Once restored, there is no deleted Ward to restore
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", "/api/ward/restore/" + wardID, ""}, token))
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"testing"
@@ -36,19 +37,19 @@ func initTestTagCC() {
 	// Create Institution for Tag-CC Test
 	instToCreate := instFormTagTest
 
-	res, err := svc.CreateInst(instToCreate)
+	res, err := testCCServer.Stores.Insts.CreateInst(context.TODO(), instToCreate)
 	if err != nil {
 		panic(err)
 	}
 
 	// var inst svc.Institution
-	// err = svc.GetInstByID(res.InsertedID.(primitive.ObjectID).Hex()).Decode(&inst)
+	// err = testCCServer.Stores.Insts.GetInstByID(context.TODO(), res.InsertedID.(primitive.ObjectID).Hex()).Decode(&inst)
 	instID := res.InsertedID.(primitive.ObjectID).Hex()
 	// Create Member for Tag-CC Test
 	tagToCreate := tagFormTagTest
 	tagToCreate.InstID = instID
 
-	_, err = svc.CreateTag(tagToCreate)
+	_, err = testCCServer.Stores.Tags.CreateTag(context.TODO(), tagToCreate)
 	if err != nil {
 		panic(err)
 	}
@@ -59,9 +60,14 @@ func TestTagCCScan(t *testing.T) {
 	identifier := instFormTagTest.Identifier
 
 	// Set-Up testing data if not already
-	if count, _ := svc.CountInstByName(instName); count == 0 {
+	if count, _ := testCCServer.Stores.Insts.CountInstByName(context.TODO(), instName); count == 0 {
 		initTestTagCC()
 	}
+	var inst svc.Institution
+	if err := testCCServer.Stores.Insts.GetInstByIdentifier(context.TODO(), identifier).Decode(&inst); err != nil {
+		panic(err)
+	}
+	useTestDevice(inst.ID.Hex())
 
 	var tagString string
 	var stage string
@@ -116,7 +122,7 @@ func checkCCRecordTag(t *testing.T, expectedRecord svc.CCRecord) {
 		Status:      -1, // set Status to "-1" to disable status filter
 		GetLatest:   true,
 	}
-	if err := svc.GetCCRecord(&ccParams).Decode(&ccRecord); err != nil {
+	if err := testCCServer.Stores.CCRecords.GetCCRecord(context.TODO(), &ccParams).Decode(&ccRecord); err != nil {
 		panic(err)
 	}
 	// t.Logf("checkCCRecord - ccRecord found: %v\n", ccRecord)
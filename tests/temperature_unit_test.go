@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTemperatureConversion(t *testing.T) {
+	assert.Equal(t, float32(98.6), svc.ToCanonicalTemperature(37, svc.TemperatureUnitC))
+	assert.Equal(t, float32(37), svc.FromCanonicalTemperature(98.6, svc.TemperatureUnitC))
+	assert.Equal(t, float32(36.6), svc.FromCanonicalTemperature(svc.ToCanonicalTemperature(36.6, svc.TemperatureUnitC), svc.TemperatureUnitC))
+	assert.Equal(t, testTemperatureHigh, svc.ToCanonicalTemperature(testTemperatureHigh, svc.TemperatureUnitF))
+	assert.Equal(t, testTemperatureHigh, svc.ToCanonicalTemperature(testTemperatureHigh, ""))
+}
+
+func TestCelsiusScan(t *testing.T) {
+	ctx := context.TODO()
+	instForm := instFormMemberTest
+	instForm.Name = "Celsius Test"
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, instForm)
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	_, err = testCCServer.Stores.Insts.UpdateInstScreeningPolicy(ctx, instID, svc.ScreeningPolicy{Threshold: 37.5, Unit: svc.TemperatureUnitC})
+	assert.Nil(t, err)
+	useTestDevice(instID)
+	device := svc.Device{}
+	assert.Nil(t, testCCServer.Stores.Devices.GetDeviceByDeviceID(ctx, testDeviceIMEI).Decode(&device))
+	_, err = testCCServer.Stores.Devices.UpdateDeviceByID(ctx, svc.DeviceEditForm{Enabled: true, TemperatureUnit: svc.TemperatureUnitC}, device.ID.Hex())
+	assert.Nil(t, err)
+	newMember := func() string {
+		res, err := testCCServer.Stores.Members.CreateMember(ctx, svc.MemberRegForm{InstID: instID, PhoneNum: getUniquePhoneNum(), FirstName: "Celsius"})
+		assert.Nil(t, err)
+		memberID := res.InsertedID.(primitive.ObjectID).Hex()
+		postCCSync(t, getSyncRequestMember(instID, memberID))
+		return memberID
+	}
+	getRecord := func(memberID string) svc.CCRecord {
+		ccRecord := svc.CCRecord{}
+		params := svc.GetCCRecordParams{MemberTagID: memberID, Status: -1}
+		assert.Nil(t, testCCServer.Stores.CCRecords.GetCCRecord(ctx, &params).Decode(&ccRecord))
+		return ccRecord
+	}
+	scan := func(temperature float32, unit string, uniqueID string) int {
+		data := makeGateKeeperPost(temperature, testDeviceIMEI, uniqueID)
+		if len(unit) > 0 {
+			data.Set("temperature_unit", unit)
+		}
+		req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
+		w := httptest.NewRecorder()
+		testRouter.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Readings of the Device are in its unit, stored canonical with the reading as shown
+	normal := newMember()
+	assert.Equal(t, http.StatusOK, scan(36.6, "", getMemberUniqueID(normal, "checkin")))
+	ccRecord := getRecord(normal)
+	assert.Equal(t, svc.CCrCheckInComplete, ccRecord.Status)
+	assert.Equal(t, svc.ToCanonicalTemperature(36.6, svc.TemperatureUnitC), ccRecord.Temperature)
+	assert.Equal(t, float32(36.6), ccRecord.MT.CheckInEvent.ReportedTemperature)
+	assert.Equal(t, svc.TemperatureUnitC, ccRecord.MT.CheckInEvent.ReportedUnit)
+
+	// Scans may declare their own unit; 100.1°F is above 37.5°C
+	high := newMember()
+	assert.Equal(t, http.StatusOK, scan(testTemperatureHigh, "F", getMemberUniqueID(high, "checkin")))
+	ccRecord = getRecord(high)
+	assert.Equal(t, svc.CCrFailed, ccRecord.Status)
+	assert.Equal(t, testTemperatureHigh, ccRecord.Temperature)
+	assert.Equal(t, svc.TemperatureUnitF, ccRecord.MT.CheckInEvent.ReportedUnit)
+	assert.Equal(t, http.StatusBadRequest, scan(36.6, "K", getMemberUniqueID(newMember(), "checkin")))
+
+	// Records are rendered, and filtered, in the Institution's unit
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+	w := sendWithTokenRecorded(scopeTestCase{"GET", "/api/cc-records?tempThrd=37&instID=" + instID, ""}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data            []svc.CCRecord      `json:"data"`
+		TemperatureUnit svc.TemperatureUnit `json:"temperature_unit"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, svc.TemperatureUnitC, body.TemperatureUnit)
+	if assert.Len(t, body.Data, 1) {
+		assert.Equal(t, float32(37.83), body.Data[0].Temperature)
+		assert.Equal(t, float32(37.83), body.Data[0].MT.CheckInEvent.Temperature)
+		assert.Equal(t, testTemperatureHigh, body.Data[0].MT.CheckInEvent.ReportedTemperature)
+	}
+	w = sendWithTokenRecorded(scopeTestCase{"GET", "/api/export/cc-records?instID=" + instID, ""}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Temperature (°C)")
+	assert.Contains(t, w.Body.String(), ",36.6,")
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -22,6 +23,7 @@ var testCCServer controllers.CCServer
 var testTemperatureNormal float32 = 98.1
 var testTemperatureHigh float32 = 100.1
 var testDeviceIMEI = "1111222233334444"
+var testDeviceKey string
 
 type ScanResponse struct {
 	Data    string `json:"data"`
@@ -37,6 +39,7 @@ func postCCScanTestCase(t *testing.T, data url.Values, expectedResponse ScanResp
 	log.Printf("Data for TestCase Scan-1-1: %v\n", data.Encode())
 	req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
 	w := httptest.NewRecorder()
 	testRouter.ServeHTTP(w, req)
 
@@ -58,7 +61,7 @@ func postCCSync(t *testing.T, syncRequest svc.CCSyncPostingForm) {
 
 	syncRequestString, _ := json.Marshal(syncRequest)
 	req, _ := http.NewRequest("POST", "/api/cc-record/sync", strings.NewReader(string(syncRequestString)))
-	req.Header.Add("Authorization", "Bearer "+testCCServer.Config.DebugTokenL.Mobile)
+	req.Header.Add("Authorization", "Bearer "+getTestToken(controllers.SessionRoleMobile, syncRequest.InstID))
 	w := httptest.NewRecorder()
 	testRouter.ServeHTTP(w, req)
 
@@ -72,6 +75,18 @@ func postCCSync(t *testing.T, syncRequest svc.CCSyncPostingForm) {
 	assert.Equal(t, 200, w.Code)
 }
 
+func getTestToken(role controllers.SessionRole, instID string) string {
+	token, err := testCCServer.SignSessionToken(controllers.SessionClaims{
+		Subject: "000000000000000000000000",
+		Role:    role,
+		InstID:  instID,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
 func getExpectedResponseCaseTempNormal(stage string) ScanResponse {
 	return ScanResponse{
 		Success: true,
@@ -96,3 +111,40 @@ func makeGateKeeperPost(temperature float32, imei string, uniqueID string) url.V
 
 	return data
 }
+
+// postGateKeeperScan - post a Scan from the test Gatekeeper, returning the response code
+func postGateKeeperScan(temperature float32, uniqueID string) int {
+	data := makeGateKeeperPost(temperature, testDeviceIMEI, uniqueID)
+	req, _ := http.NewRequest("POST", "/api/cc-record/scan", strings.NewReader(data.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add(controllers.DeviceKeyHeader, testDeviceKey)
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	return w.Code
+}
+
+// signTestScanPayload - sign as the Mobile App of the Member does
+func signTestScanPayload(memberID string, payload string) string {
+	member := svc.Member{}
+	if err := testCCServer.Stores.Members.GetMemberByID(context.TODO(), memberID).Decode(&member); err != nil {
+		panic(err)
+	}
+	key, err := testCCServer.Stores.Insts.GetInstQRSigningKey(context.TODO(), member.InstID)
+	if err != nil {
+		panic(err)
+	}
+	return controllers.SignScanPayload(controllers.MemberQRSigningKey(key, memberID), payload)
+}
+
+// useTestDevice - (re-)register the test Gatekeeper to the Institution under test
+func useTestDevice(instID string) {
+	device := svc.Device{}
+	if err := testCCServer.Stores.Devices.GetDeviceByDeviceID(context.TODO(), testDeviceIMEI).Decode(&device); err == nil {
+		testCCServer.Stores.Devices.DeleteDeviceByID(context.TODO(), device.ID.Hex())
+	}
+	_, apiKey, err := testCCServer.Stores.Devices.CreateDevice(context.TODO(), svc.DeviceRegForm{DeviceID: testDeviceIMEI, InstID: instID})
+	if err != nil {
+		panic(err)
+	}
+	testDeviceKey = apiKey
+}
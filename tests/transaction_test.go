@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"cloudminds.com/harix/cc-server/controllers"
+	svc "cloudminds.com/harix/cc-server/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errInjected = errors.New("injected failure")
+
+// failingMemberStore - a Member Store failing to create a Member of a given Phone #, and keeping
+// the IDs of those it did create
+type failingMemberStore struct {
+	svc.MemberStore
+	failPhoneNum string
+	created      *[]string
+}
+
+func (s failingMemberStore) CreateMember(ctx context.Context, m svc.MemberRegForm) (*mongo.InsertOneResult, error) {
+	if m.PhoneNum == s.failPhoneNum {
+		return nil, errInjected
+	}
+	res, err := s.MemberStore.CreateMember(ctx, m)
+	if err == nil {
+		*s.created = append(*s.created, res.InsertedID.(primitive.ObjectID).Hex())
+	}
+	return res, err
+}
+
+// failingFamilyStore - a Family Store failing to set the Contact Member of a Family
+type failingFamilyStore struct {
+	svc.FamilyStore
+}
+
+func (s failingFamilyStore) SetFamilyContactMemberID(ctx context.Context, id string, cMemberID string) (*mongo.UpdateResult, error) {
+	return nil, errInjected
+}
+
+func TestTxRollback(t *testing.T) {
+	undone := []int{}
+	tx := svc.NewMemTransactor()
+	err := tx.RunInTx(context.TODO(), func(ctx context.Context, tx *svc.Tx) error {
+		for index := 0; index < 3; index++ {
+			index := index
+			tx.OnRollback(func(ctx context.Context) error {
+				undone = append(undone, index)
+				return nil
+			})
+		}
+		return errInjected
+	})
+	assert.Equal(t, errInjected, err)
+	// Latest write is undone first
+	assert.Equal(t, []int{2, 1, 0}, undone)
+
+	undone = []int{}
+	err = tx.RunInTx(context.TODO(), func(ctx context.Context, tx *svc.Tx) error {
+		tx.OnRollback(func(ctx context.Context) error {
+			undone = append(undone, 0)
+			return nil
+		})
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, undone)
+}
+
+func TestCreateFamilyRollback(t *testing.T) {
+	ctx := context.TODO()
+	res, err := testCCServer.Stores.Insts.CreateInst(ctx, svc.InstitutionForm{Name: "Tx Test"})
+	assert.Nil(t, err)
+	instID := res.InsertedID.(primitive.ObjectID).Hex()
+	token := getTestToken(controllers.SessionRoleAdmin, instID)
+
+	members := testCCServer.Stores.Members
+	families := testCCServer.Stores.Families
+	defer func() {
+		testCCServer.Stores.Members = members
+		testCCServer.Stores.Families = families
+	}()
+
+	createFamily := func(phoneNums ...string) int {
+		fRegForm := svc.FamilyRegForm{InstID: instID, Wards: []svc.WardForm{{FirstName: "Tx"}}}
+		for _, phoneNum := range phoneNums {
+			fRegForm.Members = append(fRegForm.Members, svc.MemberInFamilyRegForm{PhoneNum: phoneNum, FirstName: "Tx"})
+		}
+		body, _ := json.Marshal(fRegForm)
+		return sendWithToken(scopeTestCase{"POST", "/api/family", string(body)}, token)
+	}
+	// nothingLeft - neither the Family, nor any of the Members created before the failure
+	nothingLeft := func(created []string, phoneNums ...string) {
+		cursor, err := families.GetManyFamilies(ctx, &svc.GetFamilyParams{InstID: instID})
+		assert.Nil(t, err)
+		left := []svc.Family{}
+		_, err = svc.DecodeList(ctx, cursor, &left)
+		assert.Nil(t, err)
+		assert.Empty(t, left)
+		for _, phoneNum := range phoneNums {
+			count, err := members.CountMembersByPhoneNum(ctx, phoneNum)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(0), count, phoneNum)
+		}
+		for _, memberID := range created {
+			assert.False(t, found(testCCServer.Stores.RegCodes.GetRegCodeByMemberID(ctx, memberID)), memberID)
+		}
+	}
+
+	// Second Member fails; the Family & the first Member are undone
+	created := []string{}
+	first, second := getUniquePhoneNum(), getUniquePhoneNum()
+	testCCServer.Stores.Members = failingMemberStore{MemberStore: members, failPhoneNum: second, created: &created}
+	assert.Equal(t, http.StatusInternalServerError, createFamily(first, second))
+	assert.Len(t, created, 1)
+	nothingLeft(created, first, second)
+
+	// Setting the Contact Member fails, after every Member has been created
+	created = []string{}
+	testCCServer.Stores.Members = failingMemberStore{MemberStore: members, created: &created}
+	testCCServer.Stores.Families = failingFamilyStore{FamilyStore: families}
+	assert.Equal(t, http.StatusInternalServerError, createFamily(first, second))
+	assert.Len(t, created, 2)
+	nothingLeft(created, first, second)
+
+	// Without failures, everything is created
+	testCCServer.Stores.Members = members
+	testCCServer.Stores.Families = families
+	assert.Equal(t, http.StatusCreated, createFamily(first, second))
+	family := svc.Family{}
+	member := svc.Member{}
+	assert.Nil(t, members.GetMemberByPhoneNum(ctx, first).Decode(&member))
+	assert.Nil(t, families.GetFamilyByID(ctx, member.FamilyInfo.ID).Decode(&family))
+	assert.Equal(t, member.ID.Hex(), family.ContactGuardianInfo.ID)
+	assert.True(t, found(testCCServer.Stores.RegCodes.GetRegCodeByMemberID(ctx, member.ID.Hex())))
+
+	// A Phone # already used fails on the unique index; the Family & the Members before it are undone
+	third := getUniquePhoneNum()
+	assert.Equal(t, http.StatusForbidden, createFamily(third, first))
+	count, err := members.CountMembersByPhoneNum(ctx, third)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+	cursor, err := families.GetManyFamilies(ctx, &svc.GetFamilyParams{InstID: instID})
+	assert.Nil(t, err)
+	left := []svc.Family{}
+	_, err = svc.DecodeList(ctx, cursor, &left)
+	assert.Nil(t, err)
+	assert.Len(t, left, 1)
+}
+
+// racingFamilyStore - a Family Store adding a Ward right after the last of "reads" of a Family,
+// as another request would in between
+type racingFamilyStore struct {
+	svc.FamilyStore
+	reads *int
+}
+
+func (s racingFamilyStore) GetFamilyByID(ctx context.Context, id string) svc.SingleResult {
+	res := s.FamilyStore.GetFamilyByID(ctx, id)
+	if *s.reads--; *s.reads == 0 {
+		s.FamilyStore.AddFamilyWard(ctx, id, svc.GetNewWard(svc.WardForm{FirstName: "Racer"}))
+	}
+	return res
+}
+
+func TestAddWardRace(t *testing.T) {
+	d := initTestInstScope(t)
+	token := getTestToken(controllers.SessionRoleAdmin, d.InstID)
+	families := testCCServer.Stores.Families
+	defer func() { testCCServer.Stores.Families = families }()
+	before := svc.Family{}
+	assert.Nil(t, families.GetFamilyByID(context.TODO(), d.FamilyID).Decode(&before))
+
+	// A Ward added while the Family is being added to is kept
+	// The Family is read for the scope of the request, then by "AddWard"
+	reads := 2
+	testCCServer.Stores.Families = racingFamilyStore{FamilyStore: families, reads: &reads}
+	assert.Equal(t, http.StatusCreated, sendWithToken(scopeTestCase{"POST", "/api/ward/add-new?familyID=" + d.FamilyID, `{"first_name":"Twin"}`}, token))
+	testCCServer.Stores.Families = families
+	after := svc.Family{}
+	assert.Nil(t, families.GetFamilyByID(context.TODO(), d.FamilyID).Decode(&after))
+	names := []string{}
+	for _, w := range after.Wards {
+		names = append(names, w.FirstName)
+	}
+	assert.Len(t, after.Wards, len(before.Wards)+2)
+	assert.Contains(t, names, "Racer")
+	assert.Contains(t, names, "Twin")
+
+	assert.Equal(t, http.StatusForbidden, sendWithToken(scopeTestCase{"POST", "/api/ward/add-new?familyID=" + primitive.NewObjectID().Hex(), `{"first_name":"Twin"}`}, token))
+}